@@ -12,7 +12,9 @@ import (
 	"vibe-backend/internal/cache"
 	"vibe-backend/internal/config"
 	"vibe-backend/internal/database"
+	"vibe-backend/internal/jobs"
 	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
 	"vibe-backend/internal/router"
 
 	"go.uber.org/zap"
@@ -55,7 +57,15 @@ func main() {
 			// Auto-migrate models
 			if err := db.DB.AutoMigrate(
 				&models.User{},
+				&models.APIKey{},
+				&models.Session{},
+				&models.RefreshToken{},
+				&models.UserToken{},
+				&models.AuthEvent{},
+				&models.AccountExport{},
+				&models.GoogleAccount{},
 				&models.Pomodoro{},
+				&models.Analysis{},
 				&models.VideoAnalysis{},
 				&models.Chapter{},
 				&models.Transcription{},
@@ -63,8 +73,16 @@ func main() {
 				&models.Insight{},
 				&models.Highlight{},
 				&models.ChatMessage{},
+				&models.ShareLink{},
+				&models.ShareView{},
 				&models.Translation{},
 				&models.DualSubtitle{},
+				&models.Job{},
+				&models.LLMUsage{},
+				&models.TranscriptChunk{},
+				&models.ChatSummary{},
+				&models.Workspace{},
+				&models.WorkspaceMember{},
 			); err != nil {
 				log.Error("Failed to auto-migrate database", zap.Error(err))
 				db.Close()
@@ -133,8 +151,27 @@ func main() {
 	}
 
 
-	// Initialize router
-	r := router.New(cfg, db, redisCache, log)
+	// Initialize durable job queue (requires database)
+	var queue *jobs.Queue
+	if db != nil {
+		queue = jobs.NewQueue(repository.NewJobRepository(db.DB), jobs.Options{
+			Workers:            cfg.JobWorkers,
+			MaxAttempts:        cfg.JobMaxAttempts,
+			LeaseDuration:      cfg.JobLeaseTimeout,
+			BaseBackoff:        cfg.JobBaseBackoff,
+			MaxInFlightPerUser: cfg.JobMaxInFlightPerUser,
+		}, log)
+	}
+
+	// Initialize router (registers job handlers on the queue)
+	r := router.New(cfg, db, redisCache, queue, log)
+
+	// Start job workers; stale leases from a previous run are recovered first
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	if queue != nil {
+		queue.Start(workerCtx)
+	}
 
 
 	// Create HTTP server
@@ -170,6 +207,13 @@ func main() {
 		log.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Stop job workers; in-flight jobs are released back to the queue
+	stopWorkers()
+	if queue != nil {
+		queue.Wait()
+		log.Info("Job workers stopped")
+	}
+
 	log.Info("Server stopped")
 }
 
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 )
 
@@ -24,15 +26,101 @@ type Config struct {
 
 	// OpenRouter API configuration
 	OpenRouterAPIKey string `env:"OPENROUTER_API_KEY" envDefault:""`
-	// Gemini model configuration
+
+	// LLM provider configuration: openrouter, openai (any OpenAI-compatible endpoint) or fake
+	LLMProvider   string        `env:"LLM_PROVIDER" envDefault:"openrouter"`
+	LLMBaseURL    string        `env:"LLM_BASE_URL" envDefault:""`
+	LLMAPIKey     string        `env:"LLM_API_KEY" envDefault:""` // falls back to OPENROUTER_API_KEY
+	LLMTimeout    time.Duration `env:"LLM_TIMEOUT" envDefault:"120s"`
+	LLMMaxRetries int           `env:"LLM_MAX_RETRIES" envDefault:"2"`
+	// Per-user monthly LLM budgets (0 = unlimited) and model prices in USD per 1M tokens,
+	// e.g. "anthropic/claude-3-5-sonnet=3/15,*=0.5/3"
+	LLMMonthlyTokenBudget   int64   `env:"LLM_MONTHLY_TOKEN_BUDGET" envDefault:"0"`
+	LLMMonthlyCostBudgetUSD float64 `env:"LLM_MONTHLY_COST_BUDGET_USD" envDefault:"0"`
+	LLMPrices               string  `env:"LLM_PRICES" envDefault:""`
+
+	// Gemini model configuration (default model for all AI features)
 	GeminiModel string `env:"GEMINI_MODEL" envDefault:"google/gemini-3-flash-preview"`
+	// Per-feature model overrides (fall back to GeminiModel when empty)
+	ChatModel        string `env:"CHAT_MODEL" envDefault:""`
+	TranslationModel string `env:"TRANSLATION_MODEL" envDefault:""`
+	SummaryModel     string `env:"SUMMARY_MODEL" envDefault:""`
+	// Retrieval-augmented chat: embedding model (empty = local BM25 ranking) and passages per turn
+	EmbeddingModel string `env:"EMBEDDING_MODEL" envDefault:""`
+	ChatRAGTopK    int    `env:"CHAT_RAG_TOP_K" envDefault:"6"`
+	// Long chats: unsummarized history (in tokens) above which older turns are summarized
+	ChatMemoryThresholdTokens int `env:"CHAT_MEMORY_THRESHOLD_TOKENS" envDefault:"3000"`
 	// YouTube Data API v3 configuration
 	YouTubeAPIKey string `env:"YOUTUBE_API_KEY" envDefault:""`
 
+	// Twitter/X threads: syndication (default, public embed endpoints) or fake
+	// (posts from the JSON fixtures in TWITTER_FIXTURES_DIR), and the maximum
+	// number of posts unrolled per thread
+	TwitterBackend        string        `env:"TWITTER_BACKEND" envDefault:"syndication"`
+	TwitterSyndicationURL string        `env:"TWITTER_SYNDICATION_URL" envDefault:""`
+	TwitterFixturesDir    string        `env:"TWITTER_FIXTURES_DIR" envDefault:""`
+	TwitterTimeout        time.Duration `env:"TWITTER_TIMEOUT" envDefault:"15s"`
+	TwitterMaxThreadPosts int           `env:"TWITTER_MAX_THREAD_POSTS" envDefault:"50"`
+
+	// Background job queue configuration
+	JobWorkers      int           `env:"JOB_WORKERS" envDefault:"4"`
+	JobMaxAttempts  int           `env:"JOB_MAX_ATTEMPTS" envDefault:"3"`
+	JobLeaseTimeout time.Duration `env:"JOB_LEASE_TIMEOUT" envDefault:"2m"`
+	JobBaseBackoff  time.Duration `env:"JOB_BASE_BACKOFF" envDefault:"15s"`
+	// Maximum queued + running AI jobs per user (0 disables the cap)
+	JobMaxInFlightPerUser int `env:"JOB_MAX_INFLIGHT_PER_USER" envDefault:"3"`
+
 	// Google OAuth 2.0 configuration
 	GoogleClientID     string `env:"GOOGLE_CLIENT_ID" envDefault:""`
 	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET" envDefault:""`
 	GoogleRedirectURL  string `env:"GOOGLE_REDIRECT_URL" envDefault:"http://localhost:3000/auth/google/callback"`
+	// Passphrase for encrypting stored Google tokens (random per process when empty)
+	GoogleTokenEncryptionKey string `env:"GOOGLE_TOKEN_ENCRYPTION_KEY" envDefault:""`
+
+	// Login sessions: HMAC secret for access tokens (random per process when empty),
+	// access token lifetime and refresh token lifetime (renewed on every refresh)
+	SessionSecret   string        `env:"SESSION_SECRET" envDefault:""`
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
+	// Account emails: frontend URL used in links, lifetimes of verification and
+	// reset links, and whether unverified accounts may log in
+	FrontendURL              string        `env:"FRONTEND_URL" envDefault:"http://localhost:3000"`
+	EmailVerificationTTL     time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"48h"`
+	PasswordResetTTL         time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	RequireEmailVerification bool          `env:"REQUIRE_EMAIL_VERIFICATION" envDefault:"false"`
+
+	// Brute-force protection of logins and share passwords: backoff doubles from
+	// LOGIN_BACKOFF_BASE per failure after a few free attempts; LOGIN_MAX_FAILURES
+	// per account (LOGIN_IP_MAX_FAILURES per IP) lock out for LOGIN_LOCKOUT
+	LoginMaxFailures   int           `env:"LOGIN_MAX_FAILURES" envDefault:"10"`
+	LoginIPMaxFailures int           `env:"LOGIN_IP_MAX_FAILURES" envDefault:"50"`
+	LoginBackoffBase   time.Duration `env:"LOGIN_BACKOFF_BASE" envDefault:"1s"`
+	LoginBackoffMax    time.Duration `env:"LOGIN_BACKOFF_MAX" envDefault:"5m"`
+	LoginLockout       time.Duration `env:"LOGIN_LOCKOUT" envDefault:"15m"`
+	AuthEventRetention time.Duration `env:"AUTH_EVENT_RETENTION" envDefault:"2160h"` // 90 days, 0 keeps events forever
+
+	// Account data: exports are written to EXPORT_DIR and downloadable for
+	// EXPORT_TTL; deleted accounts are purged after ACCOUNT_DELETION_GRACE
+	ExportDir            string        `env:"EXPORT_DIR" envDefault:"./tmp/exports"`
+	ExportTTL            time.Duration `env:"EXPORT_TTL" envDefault:"168h"`
+	AccountDeletionGrace time.Duration `env:"ACCOUNT_DELETION_GRACE" envDefault:"720h"`
+
+	// Share links: HMAC secret for anonymized viewer hashes in share stats and
+	// for the access tokens issued when a share password is entered (random per
+	// process when empty, so unlocked shares lock again after a restart), and the
+	// lifetime of those access tokens
+	ShareSecret    string        `env:"SHARE_SECRET" envDefault:""`
+	ShareAccessTTL time.Duration `env:"SHARE_ACCESS_TTL" envDefault:"1h"`
+
+	// Mail delivery: log (default, development), file (writes .eml files to MAIL_DIR) or smtp
+	MailDriver   string `env:"MAIL_DRIVER" envDefault:"log"`
+	MailFrom     string `env:"MAIL_FROM" envDefault:""`
+	MailDir      string `env:"MAIL_DIR" envDefault:"./tmp/mail"`
+	SMTPHost     string `env:"SMTP_HOST" envDefault:""`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME" envDefault:""`
+	SMTPPassword string `env:"SMTP_PASSWORD" envDefault:""`
 }
 
 // Load parses environment variables and returns a Config struct.
@@ -44,6 +132,22 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// ModelOrDefault returns model, or GeminiModel when model is empty.
+func (c *Config) ModelOrDefault(model string) string {
+	if model != "" {
+		return model
+	}
+	return c.GeminiModel
+}
+
+// LLMKey returns the API key for the configured LLM provider.
+func (c *Config) LLMKey() string {
+	if c.LLMAPIKey != "" {
+		return c.LLMAPIKey
+	}
+	return c.OpenRouterAPIKey
+}
+
 // IsDevelopment returns true if running in development environment.
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+	"vibe-backend/internal/services"
+)
+
+// AccountHandler handles data export and deletion of the current account.
+type AccountHandler struct {
+	userRepo *repository.UserRepository
+	exports  *services.ExportService
+	deletion *services.AccountDeletionService
+	accounts *services.AccountService
+	logins   *services.AttemptLimiter
+	audit    *services.AuthAuditService
+	queue    JobQueue
+	log      *zap.Logger
+}
+
+// NewAccountHandler creates a new AccountHandler. Wrong passwords given to
+// confirm a deletion are counted by logins.
+func NewAccountHandler(userRepo *repository.UserRepository, exports *services.ExportService, deletion *services.AccountDeletionService, accounts *services.AccountService, logins *services.AttemptLimiter, audit *services.AuthAuditService, queue JobQueue, log *zap.Logger) *AccountHandler {
+	return &AccountHandler{
+		userRepo: userRepo,
+		exports:  exports,
+		deletion: deletion,
+		accounts: accounts,
+		logins:   logins,
+		audit:    audit,
+		queue:    queue,
+		log:      log,
+	}
+}
+
+// RequestExport handles POST /api/v1/account/exports - start building a ZIP
+// archive of everything the current user owns.
+func (h *AccountHandler) RequestExport(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	// Without a queue the export would never be built
+	if h.queue == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:      "SERVICE_UNAVAILABLE",
+			Message:   "Job queue is unavailable.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	// Reject before creating the record if the user already has too much work in flight
+	if err := h.queue.CheckUserLimit(c.Request.Context(), userID); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.log.Error("Failed to check job limit", zap.Error(err))
+	}
+
+	export, err := h.exports.Request(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to request export.")
+		return
+	}
+
+	job, err := h.queue.EnqueueForUser(c.Request.Context(), userID, models.JobTypeAccountExport, models.AccountExportJobPayload{
+		ExportID: export.ID,
+	})
+	if err != nil {
+		if discardErr := h.exports.Discard(c.Request.Context(), export.ID); discardErr != nil {
+			h.log.Error("Failed to discard export", zap.Uint("export_id", export.ID), zap.Error(discardErr))
+		}
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.respondError(c, err, "Failed to request export.")
+		return
+	}
+
+	h.log.Info("Account export enqueued",
+		zap.String("request_id", requestID),
+		zap.Uint("user_id", userID),
+		zap.Uint("export_id", export.ID),
+		zap.Uint("job_id", job.ID),
+	)
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetExport handles GET /api/v1/account/exports/:id - the status of an export.
+func (h *AccountHandler) GetExport(c *gin.Context) {
+	id, ok := h.exportID(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exports.Get(c.Request.Context(), middleware.MustGetUserID(c), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to get export.")
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadExport handles GET /api/v1/account/exports/:id/download - the ZIP
+// archive of a completed export.
+func (h *AccountHandler) DownloadExport(c *gin.Context) {
+	id, ok := h.exportID(c)
+	if !ok {
+		return
+	}
+
+	path, name, err := h.exports.Archive(c.Request.Context(), middleware.MustGetUserID(c), id)
+	if err != nil {
+		h.respondError(c, err, "Failed to download export.")
+		return
+	}
+	c.FileAttachment(path, name)
+}
+
+// DeleteAccount handles DELETE /api/v1/account - delete the current account.
+// The account is disabled at once and purged after the grace period; the
+// email sent to the user contains a link that cancels the deletion.
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	ctx := c.Request.Context()
+	userID := middleware.MustGetUserID(c)
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to delete account.")
+		return
+	}
+
+	// The body is optional for accounts without a password
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	// A stolen access token must not allow guessing the password
+	ip := c.ClientIP()
+	if wait := checkAttempts(ctx, h.logins, user.Email, ip, h.log); wait > 0 {
+		writeRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Code:      models.ErrTooManyAttempts.Code,
+			Message:   models.ErrTooManyAttempts.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	purgeAt, err := h.deletion.Delete(ctx, user, req.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCurrentPassword) {
+			recordFailedAttempt(ctx, h.logins, user.Email, ip, h.log)
+		}
+		h.respondError(c, err, "Failed to delete account.")
+		return
+	}
+	h.audit.Record(ctx, newAuthEvent(c, models.AuthEventAccountDeleted, user.ID))
+
+	// If the purge job cannot be scheduled, the startup sweep purges the account
+	if h.queue != nil {
+		if _, err := h.queue.EnqueueWithOptions(ctx, models.JobTypeAccountPurge, models.AccountPurgeJobPayload{
+			UserID: user.ID,
+		}, jobs.EnqueueOptions{RunAt: purgeAt}); err != nil {
+			h.log.Error("Failed to schedule account purge",
+				zap.String("request_id", requestID),
+				zap.Uint("user_id", user.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Account deleted. Your data will be permanently removed after the grace period; check your inbox to cancel.",
+		"purge_at": purgeAt,
+	})
+}
+
+// RestoreAccount handles POST /api/v1/auth/restore-account - cancel the
+// deletion of an account with the token from the deletion email.
+func (h *AccountHandler) RestoreAccount(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.RestoreAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	user, err := h.accounts.RestoreAccount(c.Request.Context(), req.Token)
+	if err != nil {
+		h.respondError(c, err, "Failed to restore account.")
+		return
+	}
+	h.audit.Record(c.Request.Context(), newAuthEvent(c, models.AuthEventAccountRestored, user.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account restored. Please log in again; share links and API keys need to be recreated.",
+		"user":    user.ToResponse(),
+	})
+}
+
+// exportID parses the :id parameter, writing a 400 response if it is invalid.
+func (h *AccountHandler) exportID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid export ID.",
+			RequestID: c.GetString("request_id"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondQueueLimit writes the 429 response used by AccountHandler when the user is over the job cap.
+func (h *AccountHandler) respondQueueLimit(c *gin.Context, limitErr *jobs.LimitError) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":           models.ErrRateLimitExceeded,
+		"message":        "Too many jobs in progress. Please wait for them to finish.",
+		"in_flight":      limitErr.InFlight,
+		"limit":          limitErr.Limit,
+		"queue_position": limitErr.QueuePosition,
+		"retry_after":    queueRetryAfterSeconds,
+		"request_id":     c.GetString("request_id"),
+	})
+}
+
+// respondError writes an account error with its status and any other error as 500.
+func (h *AccountHandler) respondError(c *gin.Context, err error, message string) {
+	requestID := c.GetString("request_id")
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		status := http.StatusBadRequest
+		switch apiErr.Code {
+		case models.ErrorExportNotFound:
+			status = http.StatusNotFound
+		case models.ErrorExportNotReady:
+			status = http.StatusConflict
+		case models.ErrorInvalidCurrentPassword:
+			status = http.StatusForbidden
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.log.Error(message,
+		zap.String("request_id", requestID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:      "INTERNAL_SERVER_ERROR",
+		Message:   message,
+		RequestID: requestID,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 )
@@ -51,7 +52,7 @@ func (h *AnalysisHandler) Get(c *gin.Context) {
 	}
 
 	// Get analysis from database
-	analysis, err := h.repo.GetByID(c.Request.Context(), uint(analysisID))
+	analysis, err := h.repo.GetByIDForUser(c.Request.Context(), uint(analysisID), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Log error with required fields: error code, analysis_id, request_id, timestamp
@@ -94,7 +95,7 @@ func (h *AnalysisHandler) Get(c *gin.Context) {
 	})
 }
 
-// List retrieves all analysis records with pagination.
+// List retrieves the current user's analysis records with pagination.
 // GET /api/analysis
 func (h *AnalysisHandler) List(c *gin.Context) {
 	requestID := c.GetString("request_id")
@@ -115,7 +116,7 @@ func (h *AnalysisHandler) List(c *gin.Context) {
 		}
 	}
 
-	analyses, total, err := h.repo.List(c.Request.Context(), limit, offset)
+	analyses, total, err := h.repo.ListByUser(c.Request.Context(), middleware.MustGetUserID(c), limit, offset)
 	if err != nil {
 		h.log.Error("Failed to retrieve analysis list",
 			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
@@ -163,7 +164,8 @@ func (h *AnalysisHandler) Create(c *gin.Context) {
 	}
 
 	analysis := &models.Analysis{
-		Data: req.Data,
+		UserID: middleware.MustGetUserID(c),
+		Data:   req.Data,
 	}
 
 	if err := h.repo.Create(c.Request.Context(), analysis); err != nil {
@@ -229,7 +231,7 @@ func (h *AnalysisHandler) Update(c *gin.Context) {
 		return
 	}
 
-	analysis, err := h.repo.GetByID(c.Request.Context(), uint(analysisID))
+	analysis, err := h.repo.GetByIDForUser(c.Request.Context(), uint(analysisID), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			h.log.Error("Analysis record not found",
@@ -309,7 +311,7 @@ func (h *AnalysisHandler) Delete(c *gin.Context) {
 	}
 
 	// Check if record exists
-	_, err = h.repo.GetByID(c.Request.Context(), uint(analysisID))
+	_, err = h.repo.GetByIDForUser(c.Request.Context(), uint(analysisID), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			h.log.Error("Analysis record not found",
@@ -341,7 +343,7 @@ func (h *AnalysisHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(c.Request.Context(), uint(analysisID)); err != nil {
+	if err := h.repo.DeleteForUser(c.Request.Context(), uint(analysisID), middleware.MustGetUserID(c)); err != nil {
 		h.log.Error("Failed to delete analysis record",
 			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
 			zap.Uint64("analysis_id", analysisID),
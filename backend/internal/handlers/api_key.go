@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+// APIKeyHandler handles API key management HTTP requests.
+type APIKeyHandler struct {
+	apiKeys *services.APIKeyService
+	log     *zap.Logger
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeys *services.APIKeyService, log *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+		log:     log,
+	}
+}
+
+// List handles GET /api/v1/auth/api-keys - list the current user's API keys, including revoked ones.
+func (h *APIKeyHandler) List(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	keys, err := h.apiKeys.List(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to list API keys",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to list API keys.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// Create handles POST /api/v1/auth/api-keys - create an API key.
+// The key is only returned in this response.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format: " + err.Error(),
+			RequestID: requestID,
+		})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, plaintext, err := h.apiKeys.Create(c.Request.Context(), userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:      models.ErrInvalidScope.Code,
+				Message:   models.ErrInvalidScope.Message,
+				RequestID: requestID,
+			})
+			return
+		}
+		h.log.Error("Failed to create API key",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to create API key.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{
+		APIKey: key.ToResponse(),
+		Key:    plaintext,
+	})
+}
+
+// Revoke handles DELETE /api/v1/auth/api-keys/:id - revoke an API key.
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_ID",
+			Message:   "Invalid API key ID format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	if err := h.apiKeys.Revoke(c.Request.Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Code:      models.ErrAPIKeyNotFound.Code,
+				Message:   models.ErrAPIKeyNotFound.Message,
+				RequestID: requestID,
+			})
+			return
+		}
+		h.log.Error("Failed to revoke API key",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to revoke API key.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked."})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+// maxAuthEventUserAgent is the length of the auth_events.user_agent column.
+const maxAuthEventUserAgent = 255
+
+// newAuthEvent returns an audit event of the request. userID 0 means the
+// event is not tied to an account.
+func newAuthEvent(c *gin.Context, eventType string, userID uint) *models.AuthEvent {
+	event := &models.AuthEvent{
+		Type:      eventType,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if len(event.UserAgent) > maxAuthEventUserAgent {
+		event.UserAgent = event.UserAgent[:maxAuthEventUserAgent]
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	return event
+}
+
+// checkAttempts returns how long the client must wait before trying subject
+// again. Limiter errors are logged and let the attempt through, so an outage
+// of the counter store does not lock everybody out.
+func checkAttempts(ctx context.Context, limiter *services.AttemptLimiter, subject, ip string, log *zap.Logger) time.Duration {
+	wait, err := limiter.Check(ctx, subject, ip)
+	if err != nil {
+		log.Error("Failed to check failed attempts", zap.String("ip", ip), zap.Error(err))
+		return 0
+	}
+	return wait
+}
+
+// recordFailedAttempt counts a failed attempt, logging limiter errors.
+func recordFailedAttempt(ctx context.Context, limiter *services.AttemptLimiter, subject, ip string, log *zap.Logger) time.Duration {
+	wait, err := limiter.Fail(ctx, subject, ip)
+	if err != nil {
+		log.Error("Failed to record failed attempt", zap.String("ip", ip), zap.Error(err))
+		return 0
+	}
+	return wait
+}
+
+// retryAfterSeconds rounds wait up to whole seconds for the Retry-After header.
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// writeRetryAfter sets the Retry-After header for a client that must wait.
+func writeRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/services"
 
@@ -14,6 +18,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// Chat history page size for GET /api/v1/insights/:id/chat.
+const (
+	defaultChatHistoryLimit = 50
+	maxChatHistoryLimit     = 200
+)
+
 // ChatHandler handles chat-related HTTP requests.
 type ChatHandler struct {
 	chatService *services.ChatService
@@ -28,13 +38,87 @@ func NewChatHandler(chatService *services.ChatService, log *zap.Logger) *ChatHan
 	}
 }
 
-// Chat handles POST /api/v1/insights/:id/chat - streaming chat
-func (h *ChatHandler) Chat(c *gin.Context) {
+// llmContext returns the request context with LLM usage attributed to the authenticated user.
+func llmContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if userID, ok := middleware.GetUserID(c); ok {
+		ctx = llm.WithUserID(ctx, userID)
+	}
+	return ctx
+}
+
+// chatErrorStatus maps chat errors defined in models to HTTP statuses.
+var chatErrorStatus = map[models.ErrorCode]int{
+	models.ErrorInsightNotFound:     http.StatusNotFound,
+	models.ErrorInsightForbidden:    http.StatusForbidden,
+	models.ErrorHighlightNotFound:   http.StatusNotFound,
+	models.ErrorNothingToRegenerate: http.StatusConflict,
+	models.ErrorLLMBudgetExceeded:   http.StatusPaymentRequired,
+}
+
+// respondChatError writes the error response for a failed chat operation.
+func (h *ChatHandler) respondChatError(c *gin.Context, err error, action string) {
 	requestID := c.GetString("request_id")
-	
-	// Parse insight ID
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		if status, ok := chatErrorStatus[apiErr.Code]; ok {
+			c.JSON(status, models.ErrorResponse{
+				Code:      apiErr.Code,
+				Message:   apiErr.Message,
+				RequestID: requestID,
+			})
+			return
+		}
+	}
+
+	// Check if it's an LLM provider authentication/configuration error
+	if kind := llm.KindOf(err); kind == llm.ErrorKindAuth || kind == llm.ErrorKindNotConfigured || strings.Contains(err.Error(), "User not found") {
+		h.log.Error("OpenRouter API authentication failed",
+			zap.String("error_code", "OPENROUTER_AUTH_FAILED"),
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:      "OPENROUTER_AUTH_FAILED",
+			Message:   "AI service authentication failed. Please contact administrator.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	// Other AI service failures
+	if llm.KindOf(err) != "" {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Code:      models.ErrChatFailed.Code,
+			Message:   models.ErrChatFailed.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.log.Error("Chat request failed",
+		zap.String("error_code", "INTERNAL_SERVER_ERROR"),
+		zap.String("action", action),
+		zap.String("request_id", requestID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:      "INTERNAL_SERVER_ERROR",
+		Message:   "Failed to " + action + ".",
+		RequestID: requestID,
+	})
+}
+
+// loadInsight parses the insight ID and loads the insight if the current user
+// owns it or is a workspace member allowed by allow, and the role of the user.
+// Viewers read the conversation, editors take part in it and owners clear it.
+// On failure the error response has been written.
+func (h *ChatHandler) loadInsight(c *gin.Context, action string, allow func(models.WorkspaceRole) bool) (*models.Insight, uint, models.WorkspaceRole, bool) {
+	requestID := c.GetString("request_id")
+
 	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
+	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		h.log.Warn("Invalid insight ID format",
 			zap.String("insight_id", idStr),
@@ -46,6 +130,35 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 			Message:   "Invalid insight ID format.",
 			RequestID: requestID,
 		})
+		return nil, 0, "", false
+	}
+
+	userID := middleware.MustGetUserID(c)
+	insight, role, err := h.chatService.GetInsightForUser(c.Request.Context(), uint(id), userID)
+	if err != nil {
+		h.respondChatError(c, err, action)
+		return nil, 0, "", false
+	}
+	if allow != nil && !allow(role) {
+		h.respondChatError(c, models.ErrInsightForbidden, action)
+		return nil, 0, "", false
+	}
+	return insight, userID, role, true
+}
+
+// wantsStream reports whether the reply should be streamed as SSE.
+func wantsStream(c *gin.Context, requested bool) bool {
+	return requested || strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+// Chat handles POST /api/v1/insights/:id/chat - send a message.
+// The reply is streamed as SSE when requested ("stream": true or Accept: text/event-stream),
+// otherwise returned as JSON once complete.
+func (h *ChatHandler) Chat(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	insight, userID, _, ok := h.loadInsight(c, "send message", models.WorkspaceRole.CanEdit)
+	if !ok {
 		return
 	}
 
@@ -58,44 +171,79 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 		)
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:      "INVALID_REQUEST",
-			Message:   fmt.Sprintf("Invalid request format: %v", err),
+			Message:   "Invalid request format: " + err.Error(),
 			RequestID: requestID,
 		})
 		return
 	}
 
-	// Start streaming
-	stream, err := h.chatService.ChatStream(c.Request.Context(), uint(id), req.Message, req.HighlightID)
+	ctx := llmContext(c)
+	if wantsStream(c, req.Stream) {
+		stream, err := h.chatService.ChatStream(ctx, insight, userID, req.Message, req.HighlightID)
+		if err != nil {
+			h.respondChatError(c, err, "start chat stream")
+			return
+		}
+		h.stream(c, stream)
+		return
+	}
+
+	reply, err := h.chatService.Chat(ctx, insight, userID, req.Message, req.HighlightID)
 	if err != nil {
-		// Check if it's a "not found" error
-		if strings.Contains(err.Error(), "not found") {
-			h.log.Error("Insight not found",
-				zap.String("error_code", "INSIGHT_NOT_FOUND"),
-				zap.Uint64("insight_id", id),
-				zap.String("request_id", requestID),
-			)
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Code:      "INSIGHT_NOT_FOUND",
-				Message:   "Insight not found.",
-				RequestID: requestID,
+		h.respondChatError(c, err, "generate reply")
+		return
+	}
+	c.JSON(http.StatusCreated, reply)
+}
+
+// Regenerate handles POST /api/v1/insights/:id/chat/regenerate - replace the latest reply.
+// Only the author of the question or the insight's owner may regenerate it.
+// Streaming works as for Chat.
+func (h *ChatHandler) Regenerate(c *gin.Context) {
+	insight, userID, role, ok := h.loadInsight(c, "regenerate reply", models.WorkspaceRole.CanEdit)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req models.RegenerateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:      "INVALID_REQUEST",
+				Message:   "Invalid request format: " + err.Error(),
+				RequestID: c.GetString("request_id"),
 			})
 			return
 		}
+	}
 
-		h.log.Error("Failed to start chat stream",
-			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
-			zap.Uint64("insight_id", id),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:      "INTERNAL_SERVER_ERROR",
-			Message:   "Failed to start chat stream.",
-			RequestID: requestID,
-		})
+	ctx := llmContext(c)
+	if wantsStream(c, req.Stream) {
+		stream, err := h.chatService.RegenerateStream(ctx, insight, userID, role)
+		if err != nil {
+			h.respondChatError(c, err, "start chat stream")
+			return
+		}
+		h.stream(c, stream)
 		return
 	}
 
+	reply, err := h.chatService.Regenerate(ctx, insight, userID, role)
+	if err != nil {
+		h.respondChatError(c, err, "regenerate reply")
+		return
+	}
+	c.JSON(http.StatusCreated, reply)
+}
+
+// stream writes chat events as SSE until the final event.
+func (h *ChatHandler) stream(c *gin.Context, stream <-chan models.ChatStreamEvent) {
+	// The reply may outlive the server WriteTimeout, so clear the deadline for this response
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Warn("Failed to clear write deadline for SSE", zap.Error(err))
+	}
+
 	// Set SSE headers
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -114,32 +262,30 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 	})
 }
 
-// GetHistory handles GET /api/v1/insights/:id/chat - get chat history
+// GetHistory handles GET /api/v1/insights/:id/chat - get chat history.
+// Returns the latest ?limit messages; older ones are paged with ?before=<message id>.
 func (h *ChatHandler) GetHistory(c *gin.Context) {
 	requestID := c.GetString("request_id")
-	
-	// Parse insight ID
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Invalid insight ID format",
-			zap.String("insight_id", idStr),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Code:      "INVALID_ID",
-			Message:   "Invalid insight ID format.",
-			RequestID: requestID,
-		})
+
+	insight, _, _, ok := h.loadInsight(c, "get chat history", nil)
+	if !ok {
 		return
 	}
 
-	history, err := h.chatService.GetChatHistory(c.Request.Context(), uint(id))
+	limit := defaultChatHistoryLimit
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = min(v, maxChatHistoryLimit)
+	}
+	var beforeID uint
+	if v, err := strconv.ParseUint(c.Query("before"), 10, 32); err == nil {
+		beforeID = uint(v)
+	}
+
+	history, err := h.chatService.GetChatHistory(c.Request.Context(), insight.ID, beforeID, limit)
 	if err != nil {
 		h.log.Error("Failed to get chat history",
 			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
-			zap.Uint64("insight_id", id),
+			zap.Uint("insight_id", insight.ID),
 			zap.String("request_id", requestID),
 			zap.Error(err),
 		)
@@ -154,74 +300,31 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, history)
 }
 
+// ClearHistory handles DELETE /api/v1/insights/:id/chat - clear chat history
+func (h *ChatHandler) ClearHistory(c *gin.Context) {
+	insight, _, _, ok := h.loadInsight(c, "clear chat history", models.WorkspaceRole.CanManage)
+	if !ok {
+		return
+	}
+
+	if err := h.chatService.ClearHistory(c.Request.Context(), insight.ID); err != nil {
+		h.respondChatError(c, err, "clear chat history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "对话历史已清空"})
+}
+
 // AnalyzeEntities handles POST /api/v1/insights/:id/analyze-entities
 func (h *ChatHandler) AnalyzeEntities(c *gin.Context) {
-	requestID := c.GetString("request_id")
-	
-	// Parse insight ID
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Invalid insight ID format",
-			zap.String("insight_id", idStr),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Code:      "INVALID_ID",
-			Message:   "Invalid insight ID format.",
-			RequestID: requestID,
-		})
+	insight, _, _, ok := h.loadInsight(c, "analyze entities", models.WorkspaceRole.CanEdit)
+	if !ok {
 		return
 	}
 
-	result, err := h.chatService.AnalyzeEntities(c.Request.Context(), uint(id))
+	result, err := h.chatService.AnalyzeEntities(llmContext(c), insight)
 	if err != nil {
-		errMsg := err.Error()
-		
-		// Check if it's a "insight not found" error (more precise check)
-		if strings.Contains(errMsg, "insight not found") || strings.Contains(errMsg, "record not found") {
-			h.log.Error("Insight not found",
-				zap.String("error_code", "INSIGHT_NOT_FOUND"),
-				zap.Uint64("insight_id", id),
-				zap.String("request_id", requestID),
-			)
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Code:      "INSIGHT_NOT_FOUND",
-				Message:   "Insight not found.",
-				RequestID: requestID,
-			})
-			return
-		}
-		
-		// Check if it's an OpenRouter API authentication error
-		if strings.Contains(errMsg, "OpenRouter API 认证失败") || strings.Contains(errMsg, "User not found") {
-			h.log.Error("OpenRouter API authentication failed",
-				zap.String("error_code", "OPENROUTER_AUTH_FAILED"),
-				zap.Uint64("insight_id", id),
-				zap.String("request_id", requestID),
-				zap.Error(err),
-			)
-			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
-				Code:      "OPENROUTER_AUTH_FAILED",
-				Message:   "AI service authentication failed. Please contact administrator.",
-				RequestID: requestID,
-			})
-			return
-		}
-
-		// Other errors (AI service, parsing, etc.)
-		h.log.Error("Failed to analyze entities",
-			zap.String("error_code", "INTERNAL_SERVER_ERROR"),
-			zap.Uint64("insight_id", id),
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:      "INTERNAL_SERVER_ERROR",
-			Message:   "Failed to analyze entities.",
-			RequestID: requestID,
-		})
+		h.respondChatError(c, err, "analyze entities")
 		return
 	}
 
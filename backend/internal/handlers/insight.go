@@ -2,10 +2,9 @@ package handlers
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -13,35 +12,88 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"vibe-backend/internal/jobs"
 	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
+	"vibe-backend/internal/services"
 )
 
-// InsightProcessor defines the interface for async insight processing.
-type InsightProcessor interface {
-	ProcessInsightAsync(ctx context.Context, insightID uint)
+// JobQueue defines the interface for enqueueing durable background jobs.
+type JobQueue interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error)
+	EnqueueForUser(ctx context.Context, userID uint, jobType string, payload interface{}) (*models.Job, error)
+	EnqueueWithOptions(ctx context.Context, jobType string, payload interface{}, opts jobs.EnqueueOptions) (*models.Job, error)
+	CheckUserLimit(ctx context.Context, userID uint) error
 }
 
+// queueRetryAfterSeconds is the Retry-After hint sent with 429 responses when a user is over the job cap.
+const queueRetryAfterSeconds = 30
+
+// writeQueueLimitHeaders sets the Retry-After header if err is a per-user job limit error.
+// Returns the limit error so callers can add the queue position to their own response format.
+func writeQueueLimitHeaders(c *gin.Context, err error) (*jobs.LimitError, bool) {
+	var limitErr *jobs.LimitError
+	if !errors.As(err, &limitErr) {
+		return nil, false
+	}
+	c.Header("Retry-After", strconv.Itoa(queueRetryAfterSeconds))
+	return limitErr, true
+}
+
+// respondQueueLimit writes the 429 response used by InsightHandler when the user is over the job cap.
+func (h *InsightHandler) respondQueueLimit(c *gin.Context, limitErr *jobs.LimitError) {
+	h.log.Info("User over in-flight job limit",
+		zap.Int64("in_flight", limitErr.InFlight),
+		zap.Int("limit", limitErr.Limit),
+		zap.Int64("queue_position", limitErr.QueuePosition),
+	)
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":          fmt.Sprintf("处理中的任务过多（%d/%d），请等待当前任务完成后再试", limitErr.InFlight, limitErr.Limit),
+		"in_flight":      limitErr.InFlight,
+		"limit":          limitErr.Limit,
+		"queue_position": limitErr.QueuePosition,
+		"retry_after":    queueRetryAfterSeconds,
+		"request_id":     c.GetString("request_id"),
+	})
+}
+
+// sseHeartbeatInterval is how often a comment line is sent to keep idle SSE connections open.
+const sseHeartbeatInterval = 15 * time.Second
+
 // InsightHandler handles InsightFlow HTTP requests.
 type InsightHandler struct {
-	repo      *repository.InsightRepository
-	processor InsightProcessor
-	log       *zap.Logger
+	repo     *repository.InsightRepository
+	shares   *services.ShareService
+	queue    JobQueue
+	progress *services.ProgressBroker
+	log      *zap.Logger
+
+	// Failed share password checks, counted per share link and IP (optional)
+	shareAttempts *services.AttemptLimiter
+	audit         *services.AuthAuditService
 }
 
 // NewInsightHandler creates a new InsightHandler.
-func NewInsightHandler(repo *repository.InsightRepository, processor InsightProcessor, log *zap.Logger) *InsightHandler {
+func NewInsightHandler(repo *repository.InsightRepository, shares *services.ShareService, queue JobQueue, progress *services.ProgressBroker, log *zap.Logger) *InsightHandler {
 	return &InsightHandler{
-		repo:      repo,
-		processor: processor,
-		log:       log,
+		repo:     repo,
+		shares:   shares,
+		queue:    queue,
+		progress: progress,
+		log:      log,
 	}
 }
 
+// SetShareProtection sets the limiter of share password checks and the audit
+// trail failed checks are recorded in.
+func (h *InsightHandler) SetShareProtection(attempts *services.AttemptLimiter, audit *services.AuthAuditService) {
+	h.shareAttempts = attempts
+	h.audit = audit
+}
+
 // List returns a list of insights grouped by date for the current user.
 // GET /api/v1/insights
 func (h *InsightHandler) List(c *gin.Context) {
@@ -64,9 +116,12 @@ func (h *InsightHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": result})
 }
 
-// Get returns a single insight by ID with all related data.
-// GET /api/v1/insights/:id
-func (h *InsightHandler) Get(c *gin.Context) {
+// loadInsight parses the insight ID and loads the insight if the current user
+// owns it or is a member of its workspace, and returns the role of the user on it.
+// Insights the user cannot see are reported as not found; allow (nil for any
+// member) rejects roles that may not perform the action.
+// On failure the error response has been written.
+func (h *InsightHandler) loadInsight(c *gin.Context, withHighlights bool, allow func(models.WorkspaceRole) bool) (*models.Insight, models.WorkspaceRole, bool) {
 	userID := middleware.MustGetUserID(c)
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -75,37 +130,96 @@ func (h *InsightHandler) Get(c *gin.Context) {
 			"error":      "无效的 Insight ID",
 			"request_id": c.GetString("request_id"),
 		})
-		return
+		return nil, "", false
 	}
 
-	insight, err := h.repo.GetByIDWithRelations(c.Request.Context(), uint(id))
+	insight, role, err := h.repo.GetByIDForMember(c.Request.Context(), uint(id), userID, withHighlights)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":      "Insight 不存在",
 				"request_id": c.GetString("request_id"),
 			})
-			return
+			return nil, "", false
 		}
 		h.log.Error("Failed to get insight", zap.Error(err), zap.Uint64("id", id))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":      "获取 Insight 失败",
 			"request_id": c.GetString("request_id"),
 		})
-		return
+		return nil, "", false
+	}
+	if allow != nil && !allow(role) {
+		h.respondForbidden(c)
+		return nil, "", false
+	}
+	return insight, role, true
+}
+
+// respondForbidden writes the 403 response for workspace members whose role does not allow an action.
+func (h *InsightHandler) respondForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "没有执行该操作的权限",
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// loadHighlight loads the highlight :highlightId of the insight :id for changing it.
+// Editors change their own highlights; owners change any highlight.
+// On failure the error response has been written.
+func (h *InsightHandler) loadHighlight(c *gin.Context) (*models.Highlight, bool) {
+	insight, role, ok := h.loadInsight(c, false, models.WorkspaceRole.CanEdit)
+	if !ok {
+		return nil, false
+	}
+
+	highlightIDStr := c.Param("highlightId")
+	highlightID, err := strconv.ParseUint(highlightIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "无效的 Highlight ID",
+			"request_id": c.GetString("request_id"),
+		})
+		return nil, false
 	}
 
-	// Verify user ownership
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限访问此 Insight",
+	highlight, err := h.repo.GetHighlightForInsight(c.Request.Context(), insight.ID, uint(highlightID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":      "高亮不存在",
+				"request_id": c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		h.log.Error("Failed to get highlight", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "获取高亮失败",
 			"request_id": c.GetString("request_id"),
 		})
+		return nil, false
+	}
+	if highlight.UserID != middleware.MustGetUserID(c) && !role.CanManage() {
+		h.respondForbidden(c)
+		return nil, false
+	}
+	return highlight, true
+}
+
+// Get returns a single insight by ID with all related data.
+// GET /api/v1/insights/:id
+func (h *InsightHandler) Get(c *gin.Context) {
+	insight, role, ok := h.loadInsight(c, true, nil)
+	if !ok {
 		return
 	}
+	if err := h.repo.AttachHighlightAuthors(c.Request.Context(), insight.Highlights); err != nil {
+		h.log.Warn("Failed to load highlight authors", zap.Error(err))
+	}
 
 	// Convert to response format
 	response := h.convertToDetailResponse(insight)
+	response.Role = role
 	c.JSON(http.StatusOK, response)
 }
 
@@ -184,6 +298,17 @@ func (h *InsightHandler) Create(c *gin.Context) {
 		)
 	}
 
+	// Reject before creating the record if the user already has too much work in flight
+	if h.queue != nil {
+		if err := h.queue.CheckUserLimit(c.Request.Context(), userID); err != nil {
+			if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+				h.respondQueueLimit(c, limitErr)
+				return
+			}
+			h.log.Error("Failed to check job limit", zap.Error(err))
+		}
+	}
+
 	insight := &models.Insight{
 		UserID:     userID,
 		SourceURL:  req.SourceURL,
@@ -201,11 +326,22 @@ func (h *InsightHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Trigger async processing if processor is available
-	if h.processor != nil {
-		// Use background context for async processing since request context may be cancelled
-		go h.processor.ProcessInsightAsync(context.Background(), insight.ID)
-		h.log.Info("Triggered async insight processing", zap.Uint("insight_id", insight.ID))
+	// Enqueue durable background processing
+	if err := h.enqueueProcessing(c.Request.Context(), userID, insight.ID); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			// Lost a race with a concurrent request; drop the record that will never be processed
+			if delErr := h.repo.Delete(c.Request.Context(), insight.ID); delErr != nil {
+				h.log.Error("Failed to delete unqueued insight", zap.Error(delErr))
+			}
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.log.Error("Failed to enqueue insight processing", zap.Error(err), zap.Uint("insight_id", insight.ID))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "提交处理任务失败",
+			"request_id": c.GetString("request_id"),
+		})
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -217,6 +353,23 @@ func (h *InsightHandler) Create(c *gin.Context) {
 	})
 }
 
+// enqueueProcessing enqueues an insight.process job for the given insight on behalf of userID.
+func (h *InsightHandler) enqueueProcessing(ctx context.Context, userID, insightID uint) error {
+	if h.queue == nil {
+		h.log.Warn("Job queue not configured, insight will stay pending", zap.Uint("insight_id", insightID))
+		return nil
+	}
+	job, err := h.queue.EnqueueForUser(ctx, userID, models.JobTypeInsightProcess, models.InsightJobPayload{InsightID: insightID})
+	if err != nil {
+		return err
+	}
+	h.log.Info("Enqueued insight processing",
+		zap.Uint("insight_id", insightID),
+		zap.Uint("job_id", job.ID),
+	)
+	return nil
+}
+
 // extractSourceID extracts the source ID from a URL (e.g., YouTube video ID)
 func extractSourceID(sourceURL string) string {
 	// YouTube URL patterns:
@@ -247,30 +400,8 @@ func extractSourceID(sourceURL string) string {
 // Update updates an existing insight.
 // PATCH /api/v1/insights/:id
 func (h *InsightHandler) Update(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanEdit)
+	if !ok {
 		return
 	}
 
@@ -309,45 +440,12 @@ func (h *InsightHandler) Update(c *gin.Context) {
 // Delete soft-deletes an insight.
 // DELETE /api/v1/insights/:id
 func (h *InsightHandler) Delete(c *gin.Context) {
-	userID := middleware.MustGetUserID(c)
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	// Verify insight exists and user owns it
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err), zap.Uint64("id", id))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
 		return
 	}
 
-	// Verify user ownership
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限删除此 Insight",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	if err := h.repo.Delete(c.Request.Context(), uint(id)); err != nil {
+	if err := h.repo.Delete(c.Request.Context(), insight.ID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":      "Insight 不存在",
@@ -371,13 +469,8 @@ func (h *InsightHandler) Delete(c *gin.Context) {
 // CreateHighlight creates a new highlight for an insight.
 // POST /api/v1/insights/:id/highlights
 func (h *InsightHandler) CreateHighlight(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanEdit)
+	if !ok {
 		return
 	}
 
@@ -390,43 +483,14 @@ func (h *InsightHandler) CreateHighlight(c *gin.Context) {
 		return
 	}
 
-	userID := middleware.MustGetUserID(c)
-
-	// Verify insight exists and user owns it
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(insightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	// Verify user ownership
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限操作此 Insight",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
 	color := req.Color
 	if color == "" {
 		color = "yellow"
 	}
 
 	highlight := &models.Highlight{
-		InsightID:   uint(insightID),
-		UserID:      userID,
+		InsightID:   insight.ID,
+		UserID:      middleware.MustGetUserID(c),
 		Text:        req.Text,
 		StartOffset: req.StartOffset,
 		EndOffset:   req.EndOffset,
@@ -449,17 +513,12 @@ func (h *InsightHandler) CreateHighlight(c *gin.Context) {
 // ListHighlights returns all highlights for an insight.
 // GET /api/v1/insights/:id/highlights
 func (h *InsightHandler) ListHighlights(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
+	insight, _, ok := h.loadInsight(c, false, nil)
+	if !ok {
 		return
 	}
 
-	highlights, err := h.repo.GetHighlightsByInsightID(c.Request.Context(), uint(insightID))
+	highlights, err := h.repo.GetHighlightsByInsightID(c.Request.Context(), insight.ID)
 	if err != nil {
 		h.log.Error("Failed to get highlights", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -468,6 +527,9 @@ func (h *InsightHandler) ListHighlights(c *gin.Context) {
 		})
 		return
 	}
+	if err := h.repo.AttachHighlightAuthors(c.Request.Context(), highlights); err != nil {
+		h.log.Warn("Failed to load highlight authors", zap.Error(err))
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": highlights})
 }
@@ -475,41 +537,8 @@ func (h *InsightHandler) ListHighlights(c *gin.Context) {
 // UpdateHighlight updates an existing highlight.
 // PATCH /api/v1/insights/:id/highlights/:highlightId
 func (h *InsightHandler) UpdateHighlight(c *gin.Context) {
-	highlightIDStr := c.Param("highlightId")
-	highlightID, err := strconv.ParseUint(highlightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Highlight ID",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	highlight, err := h.repo.GetHighlightByID(c.Request.Context(), uint(highlightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "高亮不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get highlight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取高亮失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	// Verify user ownership
-	userID := middleware.MustGetUserID(c)
-	insight, err := h.repo.GetByID(c.Request.Context(), highlight.InsightID)
-	if err != nil || insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限修改此高亮",
-			"request_id": c.GetString("request_id"),
-		})
+	highlight, ok := h.loadHighlight(c)
+	if !ok {
 		return
 	}
 
@@ -547,45 +576,12 @@ func (h *InsightHandler) UpdateHighlight(c *gin.Context) {
 // DeleteHighlight deletes a highlight.
 // DELETE /api/v1/insights/:id/highlights/:highlightId
 func (h *InsightHandler) DeleteHighlight(c *gin.Context) {
-	userID := middleware.MustGetUserID(c)
-	highlightIDStr := c.Param("highlightId")
-	highlightID, err := strconv.ParseUint(highlightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Highlight ID",
-			"request_id": c.GetString("request_id"),
-		})
+	highlight, ok := h.loadHighlight(c)
+	if !ok {
 		return
 	}
 
-	// Verify user ownership
-	highlight, err := h.repo.GetHighlightByID(c.Request.Context(), uint(highlightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "高亮不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get highlight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取高亮失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	insight, err := h.repo.GetByID(c.Request.Context(), highlight.InsightID)
-	if err != nil || insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限删除此高亮",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-
-	if err := h.repo.DeleteHighlight(c.Request.Context(), uint(highlightID)); err != nil {
+	if err := h.repo.DeleteHighlight(c.Request.Context(), highlight.ID); err != nil {
 		h.log.Error("Failed to delete highlight", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":      "删除高亮失败",
@@ -597,385 +593,426 @@ func (h *InsightHandler) DeleteHighlight(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "高亮已删除"})
 }
 
-// --- Chat endpoints ---
+// Process manually triggers reprocessing of an insight.
+// POST /api/v1/insights/:id/process
+func (h *InsightHandler) Process(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanEdit)
+	if !ok {
+		return
+	}
 
-// ListChatMessages returns all chat messages for an insight.
-// GET /api/v1/insights/:id/chat
-func (h *InsightHandler) ListChatMessages(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
+	userID := insight.UserID
+	if h.queue != nil {
+		if err := h.queue.CheckUserLimit(c.Request.Context(), userID); err != nil {
+			if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+				h.respondQueueLimit(c, limitErr)
+				return
+			}
+			h.log.Error("Failed to check job limit", zap.Error(err))
+		}
+	}
+
+	// Reset status to pending
+	insight.Status = models.InsightStatusPending
+	insight.ErrorMessage = ""
+	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
+		h.log.Error("Failed to update insight status", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "更新 Insight 状态失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "20")
-	offsetStr := c.DefaultQuery("offset", "0")
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-
-	messages, total, err := h.repo.GetChatMessagesByInsightIDPaginated(c.Request.Context(), uint(insightID), limit, offset)
-	if err != nil {
-		h.log.Error("Failed to get chat messages", zap.Error(err))
+	// Enqueue durable background reprocessing
+	if err := h.enqueueProcessing(c.Request.Context(), userID, insight.ID); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.log.Error("Failed to enqueue insight reprocessing", zap.Error(err), zap.Uint("insight_id", insight.ID))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取对话历史失败",
+			"error":      "提交处理任务失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":   messages,
-		"limit":  limit,
-		"offset": offset,
-		"total":  total,
+		"data": gin.H{
+			"id":      insight.ID,
+			"status":  insight.Status,
+			"message": "重新处理已启动",
+		},
 	})
 }
 
-// CreateChatMessage creates a new chat message (user message).
-// POST /api/v1/insights/:id/chat
-// Note: AI responses will be handled separately via streaming in a future issue
-func (h *InsightHandler) CreateChatMessage(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
+// Events streams the processing progress of an insight as Server-Sent Events.
+// GET /api/v1/insights/:id/events
+func (h *InsightHandler) Events(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, nil)
+	if !ok {
 		return
 	}
 
-	var req models.ChatRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      err.Error(),
-			"request_id": c.GetString("request_id"),
-		})
-		return
+	// The stream outlives the server WriteTimeout, so clear the deadline for this response
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Warn("Failed to clear write deadline for SSE", zap.Error(err))
 	}
 
-	userID := middleware.MustGetUserID(c)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
 
-	// Verify insight exists and user owns it
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(insightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
+	send := func(event services.ProgressEvent) {
+		c.SSEvent(event.Type, event)
+		c.Writer.Flush()
+	}
+	statusEvent := func(insight *models.Insight) services.ProgressEvent {
+		return services.ProgressEvent{
+			InsightID: insight.ID,
+			Type:      services.ProgressEventStatus,
+			Status:    insight.Status,
+			Error:     insight.ErrorMessage,
+			Timestamp: insight.UpdatedAt,
 		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
 	}
 
-	// Verify user ownership
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限操作此 Insight",
-			"request_id": c.GetString("request_id"),
-		})
+	current := statusEvent(insight)
+	if current.IsFinal() || h.progress == nil {
+		send(current)
 		return
 	}
 
-	message := &models.ChatMessage{
-		InsightID:   uint(insightID),
-		UserID:      userID,
-		Role:        "user",
-		Content:     req.Message,
-		HighlightID: req.HighlightID,
-	}
+	events, last, unsubscribe := h.progress.Subscribe(insight.ID)
+	defer unsubscribe()
 
-	if err := h.repo.CreateChatMessage(c.Request.Context(), message); err != nil {
-		h.log.Error("Failed to create chat message", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "创建消息失败",
-			"request_id": c.GetString("request_id"),
-		})
+	// Re-read after subscribing so a completion in between is not missed
+	if refreshed, err := h.repo.GetByID(c.Request.Context(), insight.ID); err == nil {
+		current = statusEvent(refreshed)
+	}
+	send(current)
+	if current.IsFinal() {
 		return
 	}
+	if last != nil {
+		send(*last)
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": models.ChatResponse{
-			ID:      message.ID,
-			Role:    message.Role,
-			Content: message.Content,
-		},
-	})
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			send(event)
+			if event.IsFinal() {
+				return
+			}
+		case <-heartbeat.C:
+			// Fall back to the stored status in case the final event was missed
+			if refreshed, err := h.repo.GetByID(c.Request.Context(), insight.ID); err == nil {
+				if current := statusEvent(refreshed); current.IsFinal() {
+					send(current)
+					return
+				}
+			}
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
 }
 
-// ClearChatHistory clears all chat messages for an insight.
-// DELETE /api/v1/insights/:id/chat
-func (h *InsightHandler) ClearChatHistory(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
+// ShareInsight creates a new share link for an insight. Existing links keep working.
+// POST /api/v1/insights/:id/share
+func (h *InsightHandler) ShareInsight(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
 		return
 	}
 
-	if err := h.repo.DeleteChatMessagesByInsightID(c.Request.Context(), uint(insightID)); err != nil {
-		h.log.Error("Failed to clear chat history", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "清空对话历史失败",
+	var req models.ShareInsightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "对话历史已清空"})
-}
-
-// Process manually triggers reprocessing of an insight.
-// POST /api/v1/insights/:id/process
-func (h *InsightHandler) Process(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
+			"error":      "过期时间必须晚于当前时间",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	// Get the insight
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	link, err := h.shares.Create(c.Request.Context(), insight, middleware.MustGetUserID(c), &req)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
+		h.respondShareError(c, err, "生成分享链接失败")
 		return
 	}
 
-	// Reset status to pending
-	insight.Status = models.InsightStatusPending
-	insight.ErrorMessage = ""
-	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
-		h.log.Error("Failed to update insight status", zap.Error(err))
+	c.JSON(http.StatusCreated, gin.H{"data": h.shares.LinkResponse(link)})
+}
+
+// ListShares returns the share links of an insight with their view counts, including revoked ones.
+// GET /api/v1/insights/:id/shares
+func (h *InsightHandler) ListShares(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
+		return
+	}
+
+	links, err := h.shares.List(c.Request.Context(), insight.ID)
+	if err != nil {
+		h.log.Error("Failed to list share links", zap.Error(err), zap.Uint("insight_id", insight.ID))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "更新 Insight 状态失败",
+			"error":      "获取分享链接失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	// Trigger async reprocessing if processor is available
-	if h.processor != nil {
-		// Use background context for async processing since request context may be cancelled
-		go h.processor.ProcessInsightAsync(context.Background(), insight.ID)
-		h.log.Info("Triggered manual insight reprocessing", zap.Uint("insight_id", insight.ID))
+	response := make([]models.ShareInsightResponse, len(links))
+	for i := range links {
+		response[i] = h.shares.LinkResponse(&links[i])
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"id":      insight.ID,
-			"status":  insight.Status,
-			"message": "重新处理已启动",
-		},
-	})
+	c.JSON(http.StatusOK, gin.H{"data": response})
 }
 
-// ShareInsight creates or updates a share configuration for an insight.
-// POST /api/v1/insights/:id/share
-func (h *InsightHandler) ShareInsight(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
+// RevokeShare revokes one share link of an insight. Other links keep working.
+// DELETE /api/v1/insights/:id/shares/:shareId
+func (h *InsightHandler) RevokeShare(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
+		return
+	}
+
+	shareID, err := strconv.ParseUint(c.Param("shareId"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
+			"error":      "无效的分享链接 ID",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	var req models.ShareInsightRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      err.Error(),
-			"request_id": c.GetString("request_id"),
-		})
+	if err := h.shares.Revoke(c.Request.Context(), insight.ID, uint(shareID)); err != nil {
+		h.respondShareError(c, err, "撤销分享链接失败")
 		return
 	}
 
-	userID := middleware.MustGetUserID(c)
+	c.JSON(http.StatusOK, gin.H{"message": "分享链接已撤销"})
+}
 
-	// Verify insight exists and user owns it
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(insightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
+// DeleteShare revokes every share link of an insight.
+// DELETE /api/v1/insights/:id/share
+func (h *InsightHandler) DeleteShare(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
 		return
 	}
 
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限分享此 Insight",
+	if _, err := h.shares.RevokeAll(c.Request.Context(), insight.ID); err != nil {
+		h.log.Error("Failed to revoke share links", zap.Error(err), zap.Uint("insight_id", insight.ID))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "删除分享配置失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	// Generate share token if not exists
-	if insight.ShareToken == nil || *insight.ShareToken == "" {
-		token, err := h.generateShareToken()
-		if err != nil {
-			h.log.Error("Failed to generate share token", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "生成分享链接失败",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		insight.ShareToken = &token
+	c.JSON(http.StatusOK, gin.H{"message": "分享已取消"})
+}
+
+// ShareStats returns the view analytics of an insight's share links.
+// GET /api/v1/insights/:id/share/stats?days=30
+func (h *InsightHandler) ShareStats(c *gin.Context) {
+	insight, _, ok := h.loadInsight(c, false, models.WorkspaceRole.CanManage)
+	if !ok {
+		return
 	}
 
-	// Hash password if provided
-	if req.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			h.log.Error("Failed to hash password", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "密码加密失败",
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "无效的统计天数",
 				"request_id": c.GetString("request_id"),
 			})
 			return
 		}
-		insight.SharePassword = string(hashedPassword)
-	} else {
-		insight.SharePassword = ""
-	}
-
-	// Set share configuration
-	shareConfig := models.ShareConfigData{
-		IncludeSummary:    req.IncludeSummary,
-		IncludeKeyPoints:  req.IncludeKeyPoints,
-		IncludeHighlights: req.IncludeHighlights,
-		IncludeChat:       req.IncludeChat,
+		days = parsed
 	}
 
-	shareConfigJSON, err := json.Marshal(shareConfig)
+	stats, err := h.shares.Stats(c.Request.Context(), insight.ID, days)
 	if err != nil {
-		h.log.Error("Failed to marshal share config", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "保存分享配置失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
-	}
-	insight.ShareConfig = shareConfigJSON
-	insight.IsPublic = req.IsPublic
-	now := time.Now()
-	insight.SharedAt = &now
-
-	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
-		h.log.Error("Failed to update insight share config", zap.Error(err))
+		h.log.Error("Failed to get share stats", zap.Error(err), zap.Uint("insight_id", insight.ID))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "更新分享配置失败",
+			"error":      "获取分享统计失败",
 			"request_id": c.GetString("request_id"),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": models.ShareInsightResponse{
-			ShareToken: *insight.ShareToken,
-			ShareURL:   "/api/v1/shared/" + *insight.ShareToken,
-		},
-	})
+	c.JSON(http.StatusOK, gin.H{"data": stats})
 }
 
-// DeleteShare removes the share configuration for an insight.
-// DELETE /api/v1/insights/:id/share
-func (h *InsightHandler) DeleteShare(c *gin.Context) {
-	insightIDStr := c.Param("id")
-	insightID, err := strconv.ParseUint(insightIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "无效的 Insight ID",
-			"request_id": c.GetString("request_id"),
-		})
-		return
+// shareVisitor describes the client of a share link request for view analytics.
+func shareVisitor(c *gin.Context) models.ShareVisitor {
+	return models.ShareVisitor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Referrer:  c.Request.Referer(),
 	}
+}
 
-	userID := middleware.MustGetUserID(c)
+// shareErrorStatus maps share link errors defined in models to HTTP statuses.
+var shareErrorStatus = map[models.ErrorCode]int{
+	models.ErrorShareNotFound:    http.StatusNotFound,
+	models.ErrorShareExpired:     http.StatusGone,
+	models.ErrorShareExhausted:   http.StatusGone,
+	models.ErrorInvalidShareClip: http.StatusBadRequest,
+}
 
-	insight, err := h.repo.GetByID(c.Request.Context(), uint(insightID))
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Insight 不存在",
+// respondShareError writes the error response for a failed share link operation.
+func (h *InsightHandler) respondShareError(c *gin.Context, err error, message string) {
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		if status, ok := shareErrorStatus[apiErr.Code]; ok {
+			c.JSON(status, gin.H{
+				"error":      apiErr.Message,
+				"code":       apiErr.Code,
 				"request_id": c.GetString("request_id"),
 			})
 			return
 		}
-		h.log.Error("Failed to get insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取 Insight 失败",
-			"request_id": c.GetString("request_id"),
-		})
-		return
 	}
 
-	if insight.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "无权限操作此 Insight",
-			"request_id": c.GetString("request_id"),
-		})
-		return
+	h.log.Error(message, zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":      message,
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// sharePasswordResult is the outcome of a share password check.
+type sharePasswordResult int
+
+const (
+	sharePasswordOK        sharePasswordResult = iota
+	sharePasswordWrong                         // the password does not open the link
+	sharePasswordThrottled                     // too many failures, the client must wait
+)
+
+// verifySharePassword verifies the password of a protected share link, counting
+// failures per link and IP. Sets the Retry-After header when the client has to
+// wait before trying again.
+func (h *InsightHandler) verifySharePassword(c *gin.Context, link *models.ShareLink, password string) (sharePasswordResult, time.Duration) {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	if h.shareAttempts != nil {
+		if wait := checkAttempts(ctx, h.shareAttempts, link.Token, ip, h.log); wait > 0 {
+			h.recordShareEvent(c, models.AuthEventShareThrottled, link)
+			writeRetryAfter(c, wait)
+			return sharePasswordThrottled, wait
+		}
 	}
 
-	// Clear share configuration
-	insight.ShareToken = nil
-	insight.SharePassword = ""
-	insight.ShareConfig = nil
-	insight.IsPublic = false
-	insight.SharedAt = nil
+	if !h.shares.CheckPassword(link, password) {
+		var wait time.Duration
+		if h.shareAttempts != nil {
+			if wait = recordFailedAttempt(ctx, h.shareAttempts, link.Token, ip, h.log); wait > 0 {
+				writeRetryAfter(c, wait)
+			}
+		}
+		h.recordShareEvent(c, models.AuthEventSharePasswordFailed, link)
+		h.shares.RecordFailedPassword(ctx, link, shareVisitor(c))
+		return sharePasswordWrong, wait
+	}
 
-	if err := h.repo.Update(c.Request.Context(), insight); err != nil {
-		h.log.Error("Failed to delete share config", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "删除分享配置失败",
+	if h.shareAttempts != nil {
+		if err := h.shareAttempts.Succeed(ctx, link.Token); err != nil {
+			h.log.Error("Failed to reset failed share password counter", zap.Uint("share_link_id", link.ID), zap.Error(err))
+		}
+	}
+	return sharePasswordOK, 0
+}
+
+// checkSharePassword verifies the password of a protected share link. Writes
+// the error response and returns false if the password is wrong or the client
+// must wait before trying again.
+func (h *InsightHandler) checkSharePassword(c *gin.Context, link *models.ShareLink, password string) bool {
+	switch result, wait := h.verifySharePassword(c, link, password); result {
+	case sharePasswordThrottled:
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "密码错误次数过多，请稍后再试",
+			"retry_after": retryAfterSeconds(wait),
+			"request_id":  c.GetString("request_id"),
+		})
+		return false
+	case sharePasswordWrong:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":      "密码错误",
 			"request_id": c.GetString("request_id"),
 		})
-		return
+		return false
 	}
+	return true
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "分享已取消"})
+const (
+	// shareAccessHeader carries the access token of a protected share link.
+	shareAccessHeader = "X-Share-Token"
+	// shareAccessCookie holds the access token of a protected share link in browsers.
+	shareAccessCookie = "share_access"
+)
+
+// hasShareAccess reports whether the request may open link: links without a
+// password are open, protected links need a valid access token.
+func (h *InsightHandler) hasShareAccess(c *gin.Context, link *models.ShareLink) bool {
+	if !link.HasPassword() {
+		return true
+	}
+	token := c.GetHeader(shareAccessHeader)
+	if token == "" {
+		token, _ = c.Cookie(shareAccessCookie)
+	}
+	return token != "" && h.shares.CheckAccess(link, token)
+}
+
+// setShareAccessCookie sets the access token of link as a cookie sent only to
+// the link's share page and API paths, so tokens of different links never mix.
+func setShareAccessCookie(c *gin.Context, link *models.ShareLink, access *models.ShareAccessResponse) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	for _, path := range []string{"/s/" + link.Token, "/api/v1/shared/" + link.Token} {
+		c.SetCookie(shareAccessCookie, access.AccessToken, access.ExpiresIn, path, "", isHTTPS(c), true)
+	}
 }
 
-// GetShared returns a publicly shared insight.
+// recordShareEvent adds a share access event to the audit trail of the user who created the link.
+func (h *InsightHandler) recordShareEvent(c *gin.Context, eventType string, link *models.ShareLink) {
+	if h.audit == nil {
+		return
+	}
+	event := newAuthEvent(c, eventType, link.UserID)
+	event.Subject = fmt.Sprintf("insight:%d", link.InsightID)
+	event.Detail = fmt.Sprintf("share_link:%d", link.ID)
+	h.audit.Record(c.Request.Context(), event)
+}
+
+// GetShared returns a publicly shared insight and counts the view. Password
+// protected links need an access token from UnlockShare, sent in the
+// X-Share-Token header or as the cookie set by UnlockShare.
 // GET /api/v1/shared/:token
 func (h *InsightHandler) GetShared(c *gin.Context) {
 	token := c.Param("token")
@@ -987,114 +1024,57 @@ func (h *InsightHandler) GetShared(c *gin.Context) {
 		return
 	}
 
-	// Check for password in request
-	password := c.Query("password")
-
-	insight, err := h.repo.GetByShareToken(c.Request.Context(), token)
+	link, err := h.shares.Resolve(c.Request.Context(), token)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "分享链接不存在或已过期",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-		h.log.Error("Failed to get shared insight", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "获取分享内容失败",
-			"request_id": c.GetString("request_id"),
-		})
+		h.respondShareError(c, err, "获取分享内容失败")
 		return
 	}
 
-	// Verify password if required
-	if insight.SharePassword != "" {
-		if password == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":         "此分享需要密码访问",
-				"requires_auth": true,
-				"request_id":    c.GetString("request_id"),
-			})
-			return
-		}
-
-		if err := bcrypt.CompareHashAndPassword([]byte(insight.SharePassword), []byte(password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":      "密码错误",
-				"request_id": c.GetString("request_id"),
-			})
-			return
-		}
-	}
-
-	// Parse share configuration
-	var shareConfig models.ShareConfigData
-	if len(insight.ShareConfig) > 0 {
-		if err := json.Unmarshal(insight.ShareConfig, &shareConfig); err != nil {
-			h.log.Warn("Failed to unmarshal share config, using defaults", zap.Error(err))
-			shareConfig = models.ShareConfigData{
-				IncludeSummary:    true,
-				IncludeKeyPoints:  true,
-				IncludeHighlights: false,
-				IncludeChat:       false,
-			}
-		}
-	} else {
-		// Default config if none set
-		shareConfig = models.ShareConfigData{
-			IncludeSummary:    true,
-			IncludeKeyPoints:  true,
-			IncludeHighlights: false,
-			IncludeChat:       false,
-		}
+	if !h.hasShareAccess(c, link) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":         "此分享需要密码访问",
+			"requires_auth": true,
+			"request_id":    c.GetString("request_id"),
+		})
+		return
 	}
 
-	// Build filtered response based on share configuration
-	response := models.SharedInsightResponse{
-		Title:        insight.Title,
-		Author:       insight.Author,
-		ThumbnailURL: insight.ThumbnailURL,
-		SharedBy:     "用户",
-		SharedAt:     insight.SharedAt,
-		SourceType:   insight.SourceType,
-		SourceURL:    insight.SourceURL,
-		Content:      models.SharedContent{},
+	response, err := h.shares.Open(c.Request.Context(), link, shareVisitor(c))
+	if err != nil {
+		h.respondShareError(c, err, "获取分享内容失败")
+		return
 	}
 
-	// Apply content filtering based on share config
-	if shareConfig.IncludeSummary {
-		response.Content.Summary = insight.Summary
-	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
 
-	if shareConfig.IncludeKeyPoints {
-		var keyPoints []string
-		if len(insight.KeyPoints) > 0 {
-			if err := json.Unmarshal(insight.KeyPoints, &keyPoints); err != nil {
-				h.log.Warn("Failed to unmarshal key_points", zap.Error(err))
-			} else {
-				response.Content.KeyPoints = keyPoints
-			}
-		}
+// UnlockShare verifies the password of a protected share link once and issues
+// a short-lived access token for the link, returned in the body and set as a
+// cookie scoped to the link's share page and API paths.
+// POST /api/v1/shared/:token/unlock
+func (h *InsightHandler) UnlockShare(c *gin.Context) {
+	var req models.UnlockShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "请输入分享密码",
+			"request_id": c.GetString("request_id"),
+		})
+		return
 	}
 
-	if shareConfig.IncludeHighlights && len(insight.Highlights) > 0 {
-		response.Content.Highlights = insight.Highlights
+	link, err := h.shares.Resolve(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.respondShareError(c, err, "验证分享密码失败")
+		return
 	}
 
-	if shareConfig.IncludeChat && len(insight.ChatMessages) > 0 {
-		response.Content.Chat = insight.ChatMessages
+	if link.HasPassword() && !h.checkSharePassword(c, link, req.Password) {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": response})
-}
-
-// generateShareToken generates a cryptographically secure random token.
-func (h *InsightHandler) generateShareToken() (string, error) {
-	bytes := make([]byte, 32) // 256 bits
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
+	access := h.shares.IssueAccess(link)
+	setShareAccessCookie(c, link, access)
+	c.JSON(http.StatusOK, gin.H{"data": access})
 }
 
 // convertToDetailResponse converts an Insight model to InsightDetailResponse.
@@ -1129,11 +1109,13 @@ func (h *InsightHandler) convertToDetailResponse(insight *models.Insight) *model
 		PublishedAt:  insight.PublishedAt,
 		Summary:      insight.Summary,
 		KeyPoints:    keyPoints,
+		SummaryModel: insight.SummaryModel,
 		RawContent:   insight.RawContent,
 		TransContent: insight.TransContent,
 		Transcripts:  transcripts,
 		Status:       insight.Status,
 		Highlights:   insight.Highlights,
+		WorkspaceID:  insight.WorkspaceID,
 		CreatedAt:    insight.CreatedAt,
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 
@@ -41,12 +42,8 @@ func (h *PomodoroHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// TODO: Get user ID from JWT token (for now, use a placeholder)
-	//
-	userID := uint(1)
-
 	pomodoro := &models.Pomodoro{
-		UserID:    userID,
+		UserID:    middleware.MustGetUserID(c),
 		Title:     req.Title,
 		Duration:  req.Duration,
 		StartTime: time.Now().UTC(),
@@ -66,8 +63,7 @@ func (h *PomodoroHandler) Create(c *gin.Context) {
 // List returns all pomodoros for the current user.
 // GET /api/pomodoros
 func (h *PomodoroHandler) List(c *gin.Context) {
-	// TODO: Get user ID from JWT token
-	userID := uint(1)
+	userID := middleware.MustGetUserID(c)
 
 	// Parse pagination params
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -111,7 +107,7 @@ func (h *PomodoroHandler) Get(c *gin.Context) {
 		return
 	}
 
-	pomodoro, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	pomodoro, err := h.repo.GetByIDForUser(c.Request.Context(), uint(id), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -151,7 +147,7 @@ func (h *PomodoroHandler) Update(c *gin.Context) {
 		return
 	}
 
-	pomodoro, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	pomodoro, err := h.repo.GetByIDForUser(c.Request.Context(), uint(id), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -202,7 +198,14 @@ func (h *PomodoroHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(c.Request.Context(), uint(id)); err != nil {
+	if err := h.repo.DeleteForUser(c.Request.Context(), uint(id), middleware.MustGetUserID(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:     "Pomodoro not found",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:     "Failed to delete pomodoro",
 			RequestID: c.GetString("request_id"),
@@ -225,7 +228,7 @@ func (h *PomodoroHandler) Complete(c *gin.Context) {
 		return
 	}
 
-	pomodoro, err := h.repo.GetByID(c.Request.Context(), uint(id))
+	pomodoro, err := h.repo.GetByIDForUser(c.Request.Context(), uint(id), middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
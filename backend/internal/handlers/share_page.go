@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+const (
+	shareSiteName      = "InsightFlow"
+	shareExcerptLength = 200 // runes of the summary in link preview descriptions
+)
+
+//go:embed templates/share_page.html
+var sharePageSource string
+
+// sharePageTemplate renders the public HTML page of a share link.
+var sharePageTemplate = template.Must(template.New("share_page").
+	Funcs(template.FuncMap{"paragraphs": paragraphs}).
+	Parse(sharePageSource))
+
+// sharePageMeta is the link preview metadata of a share page, rendered as
+// Open Graph and Twitter card tags.
+type sharePageMeta struct {
+	Title       string
+	Description string
+	Author      string
+	Image       string
+	URL         string
+}
+
+// sharePageData is the data of the share page template. Exactly one of Share,
+// Preview, PasswordRequired and Error describes the body.
+type sharePageData struct {
+	SiteName         string
+	PageTitle        string
+	Meta             *sharePageMeta
+	Share            *models.SharedInsightResponse
+	Preview          bool // link preview crawler: metadata only, no view counted
+	PasswordRequired bool
+	Error            string
+}
+
+// SharePage renders a share link as a readable HTML page whose Open Graph and
+// Twitter card tags make the link unfurl in chats and social networks. Password
+// protected links show a form that posts the password back to the same URL,
+// which unlocks the link with the same access cookie as UnlockShare.
+// Link preview crawlers get the metadata without using up a view.
+// GET /s/:token
+// POST /s/:token
+func (h *InsightHandler) SharePage(c *gin.Context) {
+	ctx := c.Request.Context()
+	token := c.Param("token")
+	page := &sharePageData{
+		SiteName: shareSiteName,
+		Meta: &sharePageMeta{
+			Title:       "分享内容 - " + shareSiteName,
+			Description: "查看朋友分享的内容分析和笔记标注",
+			URL:         sharePageURL(c, token),
+		},
+	}
+
+	link, err := h.shares.Resolve(ctx, token)
+	if err != nil {
+		h.renderShareErrorPage(c, page, err)
+		return
+	}
+
+	if c.Request.Method == http.MethodPost {
+		h.unlockSharePage(c, link, page)
+		return
+	}
+
+	if !h.hasShareAccess(c, link) {
+		page.PasswordRequired = true
+		page.Meta.Description = "此分享需要密码访问"
+		h.renderSharePage(c, http.StatusUnauthorized, page)
+		return
+	}
+
+	var share *models.SharedInsightResponse
+	if services.ClassifyUserAgent(c.Request.UserAgent()) == models.UserAgentClassBot {
+		share, err = h.shares.Preview(ctx, link)
+		page.Preview = true
+	} else {
+		share, err = h.shares.Open(ctx, link, shareVisitor(c))
+		page.Share = share
+	}
+	if err != nil {
+		h.renderShareErrorPage(c, page, err)
+		return
+	}
+
+	page.Meta = sharePreviewMeta(share, page.Meta.URL)
+	page.PageTitle = share.Title + " - " + shareSiteName + " 分享"
+	h.renderSharePage(c, http.StatusOK, page)
+}
+
+// unlockSharePage verifies the password posted by the form of a protected
+// share page. On success it sets the access cookie and redirects back to the
+// page, so reloading it does not post the password again.
+func (h *InsightHandler) unlockSharePage(c *gin.Context, link *models.ShareLink, page *sharePageData) {
+	page.PasswordRequired = true
+	page.Meta.Description = "此分享需要密码访问"
+
+	if link.HasPassword() {
+		password := c.PostForm("password")
+		if password == "" {
+			h.renderSharePage(c, http.StatusUnauthorized, page)
+			return
+		}
+
+		switch result, wait := h.verifySharePassword(c, link, password); result {
+		case sharePasswordThrottled:
+			page.Error = fmt.Sprintf("密码错误次数过多，请 %d 秒后再试", retryAfterSeconds(wait))
+			h.renderSharePage(c, http.StatusTooManyRequests, page)
+			return
+		case sharePasswordWrong:
+			page.Error = "密码错误"
+			h.renderSharePage(c, http.StatusUnauthorized, page)
+			return
+		}
+		setShareAccessCookie(c, link, h.shares.IssueAccess(link))
+	}
+
+	c.Redirect(http.StatusSeeOther, "/s/"+link.Token)
+}
+
+// renderShareErrorPage renders the page of a share link that cannot be viewed.
+func (h *InsightHandler) renderShareErrorPage(c *gin.Context, page *sharePageData, err error) {
+	page.PasswordRequired = false
+	page.Preview = false
+	page.Share = nil
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		if status, ok := shareErrorStatus[apiErr.Code]; ok {
+			page.Error = apiErr.Message
+			page.Meta.Description = apiErr.Message
+			h.renderSharePage(c, status, page)
+			return
+		}
+	}
+
+	h.log.Error("Failed to get share page", zap.Error(err))
+	page.Error = "获取分享内容失败"
+	h.renderSharePage(c, http.StatusInternalServerError, page)
+}
+
+// renderSharePage writes the share page. Share pages are never cached or
+// indexed, and do not leak their token to the sites they link to.
+func (h *InsightHandler) renderSharePage(c *gin.Context, status int, page *sharePageData) {
+	if page.PageTitle == "" {
+		page.PageTitle = page.Meta.Title
+	}
+
+	var buf bytes.Buffer
+	if err := sharePageTemplate.Execute(&buf, page); err != nil {
+		h.log.Error("Failed to render share page", zap.Error(err))
+		c.String(http.StatusInternalServerError, "获取分享内容失败")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// isHTTPS reports whether the client reached the server over HTTPS, directly or through a proxy.
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// sharePageURL returns the absolute URL of the share page of token as requested by the client.
+func sharePageURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if isHTTPS(c) {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/s/" + token
+}
+
+// sharePreviewMeta builds the link preview metadata of a shared insight. The
+// description is the comment of a shared clip, or an excerpt of the summary,
+// the key points or the clip's transcript, whichever is shared first.
+func sharePreviewMeta(share *models.SharedInsightResponse, url string) *sharePageMeta {
+	title := share.Title
+	var description string
+	if clip := share.Content.Clip; clip != nil {
+		title = fmt.Sprintf("%s (%s – %s)", share.Title, clip.StartTimestamp, clip.EndTimestamp)
+		description = excerpt(clip.Comment, shareExcerptLength)
+	}
+	if description == "" {
+		description = excerpt(share.Content.Summary, shareExcerptLength)
+	}
+	if description == "" {
+		description = excerpt(strings.Join(share.Content.KeyPoints, "；"), shareExcerptLength)
+	}
+	if description == "" && share.Content.Clip != nil {
+		lines := make([]string, len(share.Content.Clip.Lines))
+		for i, line := range share.Content.Clip.Lines {
+			lines[i] = line.Text
+		}
+		description = excerpt(strings.Join(lines, " "), shareExcerptLength)
+	}
+	if description == "" {
+		description = fmt.Sprintf("查看 %s 的内容分析和笔记标注", share.Author)
+	}
+
+	return &sharePageMeta{
+		Title:       title,
+		Description: description,
+		Author:      share.Author,
+		Image:       share.ThumbnailURL,
+		URL:         url,
+	}
+}
+
+// markdownMarkers strips the markdown emphasis and heading markers of LLM summaries.
+var markdownMarkers = strings.NewReplacer("**", "", "__", "", "`", "", "#", "")
+
+// excerpt returns text as a single line without markdown markers, cut to at
+// most limit runes.
+func excerpt(text string, limit int) string {
+	text = strings.Join(strings.Fields(markdownMarkers.Replace(text)), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// paragraphs splits text into its non-empty paragraphs, separated by blank
+// lines, without markdown markers.
+func paragraphs(text string) []string {
+	var result []string
+	text = markdownMarkers.Replace(strings.ReplaceAll(text, "\r\n", "\n"))
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			result = append(result, paragraph)
+		}
+	}
+	return result
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
@@ -17,6 +23,7 @@ type TranslationHandler struct {
 	translationRepo   *repository.TranslationRepository
 	translationSvc    *services.TranslationService
 	transcriptSvc     *services.TranscriptService
+	queue             JobQueue
 	log               *zap.Logger
 }
 
@@ -25,17 +32,19 @@ func NewTranslationHandler(
 	translationRepo *repository.TranslationRepository,
 	translationSvc *services.TranslationService,
 	transcriptSvc *services.TranscriptService,
+	queue JobQueue,
 	log *zap.Logger,
 ) *TranslationHandler {
 	return &TranslationHandler{
 		translationRepo: translationRepo,
 		translationSvc:  translationSvc,
 		transcriptSvc:   transcriptSvc,
+		queue:           queue,
 		log:             log,
 	}
 }
 
-// Translate handles translation requests.
+// Translate accepts a translation request and enqueues it for background processing.
 // POST /api/translate
 func (h *TranslationHandler) Translate(c *gin.Context) {
 	var req models.TranslateRequest
@@ -60,85 +69,180 @@ func (h *TranslationHandler) Translate(c *gin.Context) {
 		return
 	}
 
-	// Process translation
-	translation, err := h.translationSvc.ProcessTranslation(c.Request.Context(), &req, h.transcriptSvc)
-	if err != nil {
-		h.log.Error("Translation processing failed",
-			zap.Error(err),
-			zap.String("youtube_url", req.YoutubeURL),
-			zap.String("target_language", req.TargetLanguage),
-		)
-
-		// Save failed translation
-		if translation != nil {
-			if saveErr := h.translationRepo.Create(c.Request.Context(), translation); saveErr != nil {
-				h.log.Error("Failed to save failed translation record",
-					zap.Error(saveErr),
-				)
-			}
+	userID := middleware.MustGetUserID(c)
+	translation := &models.Translation{
+		UserID:         userID,
+		SourceText:     req.SourceText,
+		YoutubeURL:     req.YoutubeURL,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		EnableDualSubs: req.EnableDualSubs,
+		Status:         "processing",
+	}
+	if req.YoutubeURL != "" {
+		videoID, err := services.ExtractVideoID(req.YoutubeURL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.TranslateResponse{
+				Status:  "error",
+				Message: "无效的 YouTube URL",
+			})
+			return
 		}
+		translation.VideoID = videoID
+	}
 
-		c.JSON(http.StatusBadRequest, models.TranslateResponse{
+	// Without a queue the record would never be processed
+	if h.queue == nil {
+		c.JSON(http.StatusServiceUnavailable, models.TranslateResponse{
 			Status:  "error",
-			Message: err.Error(),
+			Message: "任务队列不可用",
 		})
 		return
 	}
 
-	// Save translation to database
+	// Reject before creating the record if the user already has too much work in flight
+	if err := h.queue.CheckUserLimit(c.Request.Context(), userID); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.log.Error("Failed to check job limit", zap.Error(err))
+	}
+
 	if err := h.translationRepo.Create(c.Request.Context(), translation); err != nil {
 		h.log.Error("Failed to save translation",
 			zap.Error(err),
 		)
-		// Continue anyway, return the result
+		c.JSON(http.StatusInternalServerError, models.TranslateResponse{
+			Status:  "error",
+			Message: "创建翻译任务失败",
+		})
+		return
 	}
 
-	// Save dual subtitles if enabled
-	if req.EnableDualSubs && len(translation.DualSubtitles) > 0 {
-		for i := range translation.DualSubtitles {
-			translation.DualSubtitles[i].TranslationID = translation.ID
-		}
-		if err := h.translationRepo.CreateDualSubtitles(c.Request.Context(), translation.DualSubtitles); err != nil {
-			h.log.Error("Failed to save dual subtitles",
-				zap.Error(err),
-			)
+	job, err := h.queue.EnqueueForUser(c.Request.Context(), userID, models.JobTypeTranslationProcess, models.TranslationJobPayload{
+		TranslationID: translation.ID,
+	})
+	if err != nil {
+		h.log.Error("Failed to enqueue translation",
+			zap.Error(err),
+			zap.Uint("translation_id", translation.ID),
+		)
+		translation.Status = "failed"
+		translation.ErrorMessage = err.Error()
+		if updateErr := h.translationRepo.Update(c.Request.Context(), translation); updateErr != nil {
+			h.log.Error("Failed to mark translation as failed", zap.Error(updateErr))
 		}
+		c.JSON(http.StatusInternalServerError, models.TranslateResponse{
+			ID:      translation.ID,
+			Status:  "error",
+			Message: "创建翻译任务失败",
+		})
+		return
 	}
 
-	// Build response
-	response := models.TranslateResponse{
-		Status: "success",
+	h.log.Info("Translation enqueued",
+		zap.Uint("translation_id", translation.ID),
+		zap.Uint("job_id", job.ID),
+		zap.String("target_language", req.TargetLanguage),
+		zap.Bool("dual_subtitles", req.EnableDualSubs),
+	)
+
+	c.JSON(http.StatusAccepted, models.TranslateResponse{
+		ID:     translation.ID,
+		Status: "processing",
+	})
+}
+
+// respondQueueLimit writes the 429 response used by TranslationHandler when the user is over the job cap.
+func (h *TranslationHandler) respondQueueLimit(c *gin.Context, limitErr *jobs.LimitError) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"status":         "error",
+		"message":        fmt.Sprintf("翻译中的任务过多（%d/%d），请等待当前任务完成后再试", limitErr.InFlight, limitErr.Limit),
+		"in_flight":      limitErr.InFlight,
+		"limit":          limitErr.Limit,
+		"queue_position": limitErr.QueuePosition,
+		"retry_after":    queueRetryAfterSeconds,
+	})
+}
+
+// HandleTranslationJob runs a translation.process job.
+func (h *TranslationHandler) HandleTranslationJob(ctx context.Context, job *models.Job) error {
+	var payload models.TranslationJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
 	}
 
-	// Add source language if detected
-	if translation.SourceLanguage != "" {
-		response.SourceLanguage = &translation.SourceLanguage
+	translation, err := h.translationRepo.GetByID(ctx, payload.TranslationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("translation %d not found", payload.TranslationID))
+		}
+		return err
+	}
+	if translation.Status == "completed" {
+		return nil
 	}
 
-	if req.EnableDualSubs {
-		// Return dual subtitles
-		dualSubs := make([]models.DualSubtitleResponse, len(translation.DualSubtitles))
-		for i, sub := range translation.DualSubtitles {
-			dualSubs[i] = models.DualSubtitleResponse{
-				Original:   sub.Original,
-				Translated: sub.Translated,
-				StartTime:  sub.StartTime,
-				EndTime:    sub.EndTime,
-			}
+	req := &models.TranslateRequest{
+		SourceText:     translation.SourceText,
+		YoutubeURL:     translation.YoutubeURL,
+		SourceLanguage: translation.SourceLanguage,
+		TargetLanguage: translation.TargetLanguage,
+		EnableDualSubs: translation.EnableDualSubs,
+	}
+
+	// Attribute LLM usage to the owner
+	ctx = llm.WithUserID(ctx, translation.UserID)
+	result, err := h.translationSvc.ProcessTranslation(ctx, req, h.transcriptSvc)
+	if err != nil {
+		if result == nil {
+			// Invalid input, retrying will not help
+			return jobs.Permanent(err)
 		}
-		response.DualSubtitles = dualSubs
-	} else {
-		// Return translated text
-		response.TranslatedText = &translation.TranslatedText
+		translation.ErrorMessage = err.Error()
+		if updateErr := h.translationRepo.Update(ctx, translation); updateErr != nil {
+			h.log.Error("Failed to record translation error", zap.Error(updateErr))
+		}
+		return err
+	}
+
+	translation.SourceLanguage = result.SourceLanguage
+	translation.TranslatedText = result.TranslatedText
+	translation.DualSubtitles = result.DualSubtitles
+	translation.Status = "completed"
+	translation.ErrorMessage = ""
+
+	if err := h.translationRepo.SaveResult(ctx, translation); err != nil {
+		return fmt.Errorf("failed to save translation result: %w", err)
 	}
 
 	h.log.Info("Translation completed successfully",
 		zap.Uint("translation_id", translation.ID),
-		zap.String("target_language", req.TargetLanguage),
-		zap.Bool("dual_subtitles", req.EnableDualSubs),
+		zap.String("target_language", translation.TargetLanguage),
+		zap.Bool("dual_subtitles", translation.EnableDualSubs),
 	)
+	return nil
+}
 
-	c.JSON(http.StatusOK, response)
+// HandleDeadTranslationJob marks the translation as failed once its job is dead-lettered.
+func (h *TranslationHandler) HandleDeadTranslationJob(ctx context.Context, job *models.Job, jobErr error) {
+	var payload models.TranslationJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return
+	}
+
+	translation, err := h.translationRepo.GetByID(ctx, payload.TranslationID)
+	if err != nil {
+		h.log.Error("Failed to load dead-lettered translation", zap.Error(err))
+		return
+	}
+	translation.Status = "failed"
+	translation.ErrorMessage = jobErr.Error()
+	translation.DualSubtitles = nil
+	if err := h.translationRepo.Update(ctx, translation); err != nil {
+		h.log.Error("Failed to mark translation as failed", zap.Error(err))
+	}
 }
 
 // GetTranslation retrieves a translation by ID.
@@ -162,7 +266,7 @@ func (h *TranslationHandler) GetTranslation(c *gin.Context) {
 		return
 	}
 
-	translation, err := h.translationRepo.GetByID(c.Request.Context(), translationID)
+	translation, err := h.translationRepo.GetByIDForUser(c.Request.Context(), translationID, middleware.MustGetUserID(c))
 	if err != nil {
 		h.log.Error("Failed to get translation",
 			zap.Error(err),
@@ -175,8 +279,25 @@ func (h *TranslationHandler) GetTranslation(c *gin.Context) {
 		return
 	}
 
+	switch translation.Status {
+	case "pending", "processing":
+		c.JSON(http.StatusOK, models.TranslateResponse{
+			ID:     translation.ID,
+			Status: "processing",
+		})
+		return
+	case "failed":
+		c.JSON(http.StatusOK, models.TranslateResponse{
+			ID:      translation.ID,
+			Status:  "error",
+			Message: translation.ErrorMessage,
+		})
+		return
+	}
+
 	// Build response
 	response := models.TranslateResponse{
+		ID:     translation.ID,
 		Status: "success",
 	}
 
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+// UsageHandler handles LLM usage HTTP requests.
+type UsageHandler struct {
+	usageService *services.UsageService
+	log          *zap.Logger
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(usageService *services.UsageService, log *zap.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+		log:          log,
+	}
+}
+
+// Get handles GET /api/v1/usage - LLM usage and budget of the current user.
+// The optional ?month=YYYY-MM query selects a past month (default: current month).
+func (h *UsageHandler) Get(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	month := time.Now()
+	if monthStr := c.Query("month"); monthStr != "" {
+		parsed, err := time.Parse("2006-01", monthStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:      "INVALID_REQUEST",
+				Message:   "Invalid month format, expected YYYY-MM.",
+				RequestID: requestID,
+			})
+			return
+		}
+		month = parsed
+	}
+
+	usage, err := h.usageService.GetMonthlyUsage(c.Request.Context(), userID, month)
+	if err != nil {
+		h.log.Error("Failed to get LLM usage",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to get usage.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
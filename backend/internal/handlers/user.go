@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -10,19 +13,38 @@ import (
 	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
+	"vibe-backend/internal/services"
 )
 
+// maxAuthEventsPage is the most authentication events returned at once.
+const maxAuthEventsPage = 100
+
 // UserHandler handles user-related HTTP requests.
 type UserHandler struct {
 	userRepo *repository.UserRepository
+	apiKeys  *services.APIKeyService
+	sessions *services.SessionService
+	accounts *services.AccountService
+	logins   *services.AttemptLimiter
+	audit    *services.AuthAuditService
 	log      *zap.Logger
+
+	// requireVerifiedEmail rejects logins of users who have not verified their email
+	requireVerifiedEmail bool
 }
 
 // NewUserHandler creates a new UserHandler.
-func NewUserHandler(userRepo *repository.UserRepository, log *zap.Logger) *UserHandler {
+// Failed logins and password changes are counted by logins.
+func NewUserHandler(userRepo *repository.UserRepository, apiKeys *services.APIKeyService, sessions *services.SessionService, accounts *services.AccountService, logins *services.AttemptLimiter, audit *services.AuthAuditService, requireVerifiedEmail bool, log *zap.Logger) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
-		log:      log,
+		userRepo:             userRepo,
+		apiKeys:              apiKeys,
+		sessions:             sessions,
+		accounts:             accounts,
+		logins:               logins,
+		audit:                audit,
+		log:                  log,
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
@@ -46,7 +68,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	existingUser, err := h.userRepo.GetByEmail(c.Request.Context(), req.Email)
+	existingUser, err := h.userRepo.GetByEmailWithDeleted(c.Request.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
 	if err != nil && err != gorm.ErrRecordNotFound {
 		h.log.Error("Failed to check existing user",
 			zap.String("request_id", requestID),
@@ -61,6 +83,15 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if existingUser != nil && existingUser.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:      "ACCOUNT_PENDING_DELETION",
+			Message:   "The account with this email is being deleted. Use the link in the deletion email to restore it.",
+			RequestID: requestID,
+		})
+		return
+	}
+
 	if existingUser != nil {
 		h.log.Warn("User already exists",
 			zap.String("request_id", requestID),
@@ -95,21 +126,54 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	h.audit.Record(c.Request.Context(), newAuthEvent(c, models.AuthEventRegister, user.ID))
+
+	// The account works without verification unless REQUIRE_EMAIL_VERIFICATION is set
+	if err := h.accounts.SendVerification(c.Request.Context(), user); err != nil {
+		h.log.Error("Failed to send verification email",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+
+	if h.requireVerifiedEmail {
+		h.log.Info("User registered, awaiting email verification",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", user.ID),
+		)
+		c.JSON(http.StatusCreated, gin.H{
+			"user":    user.ToResponse(),
+			"message": "Registration complete. Check your inbox to verify your email address.",
+		})
+		return
+	}
+
+	tokens, err := h.sessions.Start(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		h.log.Error("Failed to start session",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to register user.",
+			RequestID: requestID,
+		})
+		return
+	}
+
 	h.log.Info("User registered successfully",
 		zap.String("request_id", requestID),
 		zap.Uint("user_id", user.ID),
 		zap.String("email", user.Email),
 	)
 
-	// Return user info and API key
+	// Return user info and session tokens
 	c.JSON(http.StatusCreated, models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-		},
-		APIKey: user.APIKey,
+		User:          user.ToResponse(),
+		SessionTokens: *tokens,
 	})
 }
 
@@ -132,13 +196,46 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Get user by email
-	user, err := h.userRepo.GetByEmail(c.Request.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
-	if err != nil {
-		h.log.Warn("User not found or invalid credentials",
+	ctx := c.Request.Context()
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	ip := c.ClientIP()
+
+	// Reject without checking the password while the account or IP is backing off
+	if wait := checkAttempts(ctx, h.logins, email, ip, h.log); wait > 0 {
+		h.log.Warn("Login throttled",
 			zap.String("request_id", requestID),
-			zap.String("email", req.Email),
+			zap.String("email", email),
+			zap.String("ip", ip),
+			zap.Duration("retry_after", wait),
 		)
+		event := newAuthEvent(c, models.AuthEventLoginThrottled, 0)
+		event.Email = email
+		h.audit.Record(ctx, event)
+		h.respondTooManyAttempts(c, wait)
+		return
+	}
+
+	// Unknown emails and wrong passwords are counted alike, so the counters
+	// do not reveal which emails have accounts
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if err != nil || !h.userRepo.VerifyPassword(user, req.Password) {
+		var userID uint
+		if user != nil {
+			userID = user.ID
+		}
+		wait := recordFailedAttempt(ctx, h.logins, email, ip, h.log)
+		h.log.Warn("Invalid login credentials",
+			zap.String("request_id", requestID),
+			zap.String("email", email),
+			zap.String("ip", ip),
+			zap.Duration("retry_after", wait),
+		)
+		event := newAuthEvent(c, models.AuthEventLoginFailed, userID)
+		event.Email = email
+		h.audit.Record(ctx, event)
+		if wait > 0 {
+			writeRetryAfter(c, wait)
+		}
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Code:      "INVALID_CREDENTIALS",
 			Message:   "Invalid email or password.",
@@ -147,35 +244,49 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Verify password
-	if !h.userRepo.VerifyPassword(user, req.Password) {
-		h.log.Warn("Invalid password",
+	if err := h.logins.Succeed(ctx, email); err != nil {
+		h.log.Error("Failed to reset failed login counter",
 			zap.String("request_id", requestID),
-			zap.String("email", req.Email),
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
 		)
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Code:      "INVALID_CREDENTIALS",
-			Message:   "Invalid email or password.",
+	}
+
+	if h.requireVerifiedEmail && !user.EmailVerified() {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Code:      models.ErrEmailNotVerified.Code,
+			Message:   models.ErrEmailNotVerified.Message,
 			RequestID: requestID,
 		})
 		return
 	}
 
+	tokens, err := h.sessions.Start(ctx, user.ID, c.Request.UserAgent(), ip)
+	if err != nil {
+		h.log.Error("Failed to start session",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to log in.",
+			RequestID: requestID,
+		})
+		return
+	}
+	h.audit.Record(ctx, newAuthEvent(c, models.AuthEventLoginSucceeded, user.ID))
+
 	h.log.Info("User logged in successfully",
 		zap.String("request_id", requestID),
 		zap.Uint("user_id", user.ID),
 		zap.String("email", user.Email),
 	)
 
-	// Return user info and API key
+	// Return user info and session tokens
 	c.JSON(http.StatusOK, models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-		},
-		APIKey: user.APIKey,
+		User:          user.ToResponse(),
+		SessionTokens: *tokens,
 	})
 }
 
@@ -199,20 +310,26 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	})
+	c.JSON(http.StatusOK, user.ToResponse())
 }
 
-// RegenerateAPIKey handles POST /api/v1/auth/regenerate-key - regenerate API key
+// RegenerateAPIKey handles POST /api/v1/auth/regenerate-key - replace the API key
+// used for the request with a new one with the same name, scopes and lifetime.
 func (h *UserHandler) RegenerateAPIKey(c *gin.Context) {
 	requestID := c.GetString("request_id")
 	userID := middleware.MustGetUserID(c)
 
-	apiKey, err := h.userRepo.RegenerateAPIKey(c.Request.Context(), userID)
+	current, ok := middleware.GetAPIKey(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      models.ErrAPIKeyRequired.Code,
+			Message:   models.ErrAPIKeyRequired.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	_, apiKey, err := h.apiKeys.Rotate(c.Request.Context(), userID, current)
 	if err != nil {
 		h.log.Error("Failed to regenerate API key",
 			zap.String("request_id", requestID),
@@ -236,3 +353,339 @@ func (h *UserHandler) RegenerateAPIKey(c *gin.Context) {
 		"api_key": apiKey,
 	})
 }
+
+// Refresh handles POST /api/v1/auth/refresh - exchange a refresh token for a new
+// access token and refresh token. Each refresh token can be used once.
+func (h *UserHandler) Refresh(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.respondSessionError(c, err, "Failed to refresh session.")
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handles POST /api/v1/auth/logout - end the session of a refresh token.
+func (h *UserHandler) Logout(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	if err := h.sessions.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		h.respondSessionError(c, err, "Failed to log out.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out."})
+}
+
+// LogoutAll handles POST /api/v1/auth/logout-all - end every session of the
+// current user. API keys keep working.
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	count, err := h.sessions.LogoutAll(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to end sessions",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to log out.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.audit.Record(c.Request.Context(), newAuthEvent(c, models.AuthEventLogoutAll, userID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Logged out everywhere.",
+		"sessions_revoked": count,
+	})
+}
+
+// VerifyEmail handles POST /api/v1/auth/verify-email - confirm the email address
+// with the token from the verification email.
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	user, err := h.accounts.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		h.respondAccountError(c, err, "Failed to verify email.")
+		return
+	}
+	h.audit.Record(c.Request.Context(), newAuthEvent(c, models.AuthEventEmailVerified, user.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified.",
+		"user":    user.ToResponse(),
+	})
+}
+
+// ResendVerification handles POST /api/v1/auth/verify-email/resend - send a new
+// verification email to the current user. Earlier links stop working.
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c, "Failed to send verification email.")
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.SendVerification(c.Request.Context(), user); err != nil {
+		h.respondAccountError(c, err, "Failed to send verification email.")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent."})
+}
+
+// ForgotPassword handles POST /api/v1/auth/forgot-password - email a password
+// reset link. The response is the same whether or not the address has an account.
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	if err := h.accounts.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		h.respondAccountError(c, err, "Failed to request password reset.")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent.",
+	})
+}
+
+// ResetPassword handles POST /api/v1/auth/reset-password - set a new password with
+// the token from the reset email. Every session of the user is ended.
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format. Passwords need at least 8 characters.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	user, err := h.accounts.ResetPassword(c.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		h.respondAccountError(c, err, "Failed to reset password.")
+		return
+	}
+	h.audit.Record(c.Request.Context(), newAuthEvent(c, models.AuthEventPasswordReset, user.ID))
+
+	// The new password starts with a clean slate
+	if err := h.logins.Succeed(c.Request.Context(), user.Email); err != nil {
+		h.log.Error("Failed to reset failed login counter",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in with your new password."})
+}
+
+// ChangePassword handles POST /api/v1/auth/change-password - change the password
+// of the current user. Other sessions are ended; the current one stays valid.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	user, ok := h.loadCurrentUser(c, "Failed to change password.")
+	if !ok {
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_REQUEST",
+			Message:   "Invalid request format. Passwords need at least 8 characters.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	// A stolen access token must not allow guessing the current password
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+	if wait := checkAttempts(ctx, h.logins, user.Email, ip, h.log); wait > 0 {
+		h.respondTooManyAttempts(c, wait)
+		return
+	}
+
+	sessionID, _ := middleware.GetSessionID(c)
+	if err := h.accounts.ChangePassword(ctx, user, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, models.ErrInvalidCurrentPassword) {
+			recordFailedAttempt(ctx, h.logins, user.Email, ip, h.log)
+			h.audit.Record(ctx, newAuthEvent(c, models.AuthEventPasswordChangeFailed, user.ID))
+		}
+		h.respondAccountError(c, err, "Failed to change password.")
+		return
+	}
+	h.audit.Record(ctx, newAuthEvent(c, models.AuthEventPasswordChanged, user.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed."})
+}
+
+// ListAuthEvents handles GET /api/v1/auth/events - the recent authentication
+// events of the current user, newest first.
+func (h *UserHandler) ListAuthEvents(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > maxAuthEventsPage {
+		limit = maxAuthEventsPage
+	}
+
+	events, err := h.audit.ListForUser(c.Request.Context(), userID, limit)
+	if err != nil {
+		h.log.Error("Failed to list auth events",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "Failed to list authentication events.",
+			RequestID: requestID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// loadCurrentUser reads the authenticated user from the database, writing a 500
+// response with message on failure.
+func (h *UserHandler) loadCurrentUser(c *gin.Context, message string) (*models.User, bool) {
+	userID := middleware.MustGetUserID(c)
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("Failed to get current user",
+			zap.String("request_id", c.GetString("request_id")),
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   message,
+			RequestID: c.GetString("request_id"),
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// respondAccountError writes an account error with its status and any other error as 500.
+func (h *UserHandler) respondAccountError(c *gin.Context, err error, message string) {
+	requestID := c.GetString("request_id")
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		status := http.StatusBadRequest
+		switch apiErr.Code {
+		case models.ErrorInvalidCurrentPassword:
+			status = http.StatusForbidden
+		case models.ErrorEmailAlreadyVerified:
+			status = http.StatusConflict
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.log.Error(message,
+		zap.String("request_id", requestID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:      "INTERNAL_SERVER_ERROR",
+		Message:   message,
+		RequestID: requestID,
+	})
+}
+
+// respondTooManyAttempts writes a 429 for a client that must wait before trying again.
+func (h *UserHandler) respondTooManyAttempts(c *gin.Context, wait time.Duration) {
+	writeRetryAfter(c, wait)
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Code:      models.ErrTooManyAttempts.Code,
+		Message:   models.ErrTooManyAttempts.Message,
+		RequestID: c.GetString("request_id"),
+	})
+}
+
+// respondSessionError writes a refresh token error as 401 and any other error as 500.
+func (h *UserHandler) respondSessionError(c *gin.Context, err error, message string) {
+	requestID := c.GetString("request_id")
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.log.Error(message,
+		zap.String("request_id", requestID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:      "INTERNAL_SERVER_ERROR",
+		Message:   message,
+		RequestID: requestID,
+	})
+}
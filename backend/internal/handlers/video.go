@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +14,9 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
@@ -24,14 +26,16 @@ import (
 type VideoHandler struct {
 	repo           *repository.VideoRepository
 	youtubeService *services.YouTubeService
+	queue          JobQueue
 	log            *zap.Logger
 }
 
 // NewVideoHandler creates a new VideoHandler.
-func NewVideoHandler(repo *repository.VideoRepository, youtubeService *services.YouTubeService, log *zap.Logger) *VideoHandler {
+func NewVideoHandler(repo *repository.VideoRepository, youtubeService *services.YouTubeService, queue JobQueue, log *zap.Logger) *VideoHandler {
 	return &VideoHandler{
 		repo:           repo,
 		youtubeService: youtubeService,
+		queue:          queue,
 		log:            log,
 	}
 }
@@ -84,7 +88,7 @@ func (h *VideoHandler) GetMetadata(c *gin.Context) {
 			zap.String("video_id", videoID),
 		)
 		// Check if error is due to missing API key
-		if strings.Contains(err.Error(), "OPENROUTER_API_KEY") {
+		if llm.KindOf(err) == llm.ErrorKindNotConfigured {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    "API_KEY_MISSING",
 				"message": "OPENROUTER_API_KEY 环境变量未配置，请设置该环境变量以使用视频分析功能",
@@ -157,8 +161,25 @@ func (h *VideoHandler) AnalyzeVideo(c *gin.Context) {
 	// Generate job ID for frontend compatibility
 	jobID := uuid.New().String()
 
-	// TODO: Get user ID from JWT token
-	userID := uint(1)
+	userID := middleware.MustGetUserID(c)
+
+	// Without a queue the record would never be processed
+	if h.queue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "QUEUE_UNAVAILABLE",
+			"message": "任务队列不可用",
+		})
+		return
+	}
+
+	// Reject before creating the record if the user already has too much work in flight
+	if err := h.queue.CheckUserLimit(c.Request.Context(), userID); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			h.respondQueueLimit(c, limitErr)
+			return
+		}
+		h.log.Error("Failed to check job limit", zap.Error(err))
+	}
 
 	// Create analysis record
 	analysis := &models.VideoAnalysis{
@@ -181,127 +202,33 @@ func (h *VideoHandler) AnalyzeVideo(c *gin.Context) {
 		return
 	}
 
-	// Call Gemini directly and save result (even if error, save raw response)
-	go func() {
-		ctx := context.Background()
-		response, err := h.youtubeService.CallGeminiDirect(ctx, videoURL)
-
-		// Get analysis record
-		analysisRecord, err2 := h.repo.GetAnalysisByJobID(ctx, jobID)
-		if err2 != nil {
-			h.log.Error("Failed to get analysis record", zap.Error(err2))
-			return
-		}
-
-		// Even if Gemini returns error, save the response as completed
-		// User said: "大模型如果返回错误，也是正确的"
-		if err != nil {
-			h.log.Warn("Gemini returned error, but saving as completed",
-				zap.Error(err),
-				zap.String("video_url", videoURL),
-				zap.String("job_id", jobID),
-			)
-			// Save error message as transcription
-			analysisRecord.Status = "completed"
-			analysisRecord.Summary = err.Error()
-			if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-				h.log.Error("Failed to update analysis", zap.Error(err))
-				return
-			}
-			// Save error as a single transcription entry
-			transcriptions := []models.Transcription{
-				{
-					AnalysisID: analysisRecord.ID,
-					Text:       fmt.Sprintf("Error: %v", err),
-					Timestamp:  "00:00",
-					Seconds:    0,
-					OrderIndex: 0,
-				},
+	// Enqueue durable background analysis
+	if _, err := h.queue.EnqueueForUser(c.Request.Context(), userID, models.JobTypeVideoAnalyze, models.VideoAnalyzeJobPayload{
+		AnalysisID: analysis.ID,
+		VideoURL:   videoURL,
+	}); err != nil {
+		if limitErr, ok := writeQueueLimitHeaders(c, err); ok {
+			// Lost a race with a concurrent request; drop the record that will never be processed
+			if delErr := h.repo.DeleteAnalysis(c.Request.Context(), analysis.ID); delErr != nil {
+				h.log.Error("Failed to delete unqueued analysis", zap.Error(delErr))
 			}
-			h.repo.CreateTranscriptions(ctx, transcriptions)
+			h.respondQueueLimit(c, limitErr)
 			return
 		}
-
-		// Parse response to extract transcription
-		var result struct {
-			Transcription []struct {
-				Text      string `json:"text"`
-				Timestamp string `json:"timestamp"`
-				Seconds   int    `json:"seconds"`
-			} `json:"transcription"`
-		}
-
-		// Clean response before parsing
-		cleanedResponse := strings.TrimSpace(response)
-		if strings.HasPrefix(cleanedResponse, "```json") {
-			cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		} else if strings.HasPrefix(cleanedResponse, "```") {
-			cleanedResponse = strings.TrimPrefix(cleanedResponse, "```")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		}
-		if strings.HasSuffix(cleanedResponse, "```") {
-			cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
-			cleanedResponse = strings.TrimSpace(cleanedResponse)
-		}
-		if !strings.HasPrefix(cleanedResponse, "{") {
-			startIdx := strings.Index(cleanedResponse, "{")
-			endIdx := strings.LastIndex(cleanedResponse, "}")
-			if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
-				cleanedResponse = cleanedResponse[startIdx : endIdx+1]
-			}
-		}
-
-		// Even if parsing fails, save raw response as completed
-		if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
-			h.log.Warn("Failed to parse Gemini response, but saving raw response as completed",
-				zap.Error(err),
-				zap.String("job_id", jobID),
-				zap.String("raw_response", response),
-			)
-			// Save raw response as transcription
-			analysisRecord.Status = "completed"
-			analysisRecord.Summary = response // Save raw response
-			if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-				h.log.Error("Failed to update analysis", zap.Error(err))
-				return
-			}
-			// Save raw response as a single transcription entry
-			transcriptions := []models.Transcription{
-				{
-					AnalysisID: analysisRecord.ID,
-					Text:       response,
-					Timestamp:  "00:00",
-					Seconds:    0,
-					OrderIndex: 0,
-				},
-			}
-			h.repo.CreateTranscriptions(ctx, transcriptions)
-			return
-		}
-
-		// Update analysis status to completed
-		analysisRecord.Status = "completed"
-		if err := h.repo.UpdateAnalysis(ctx, analysisRecord); err != nil {
-			h.log.Error("Failed to update analysis", zap.Error(err))
-			return
-		}
-
-		// Save transcriptions
-		transcriptions := make([]models.Transcription, len(result.Transcription))
-		for i, tr := range result.Transcription {
-			transcriptions[i] = models.Transcription{
-				AnalysisID: analysisRecord.ID,
-				Text:       tr.Text,
-				Timestamp:  tr.Timestamp,
-				Seconds:    tr.Seconds,
-				OrderIndex: i,
-			}
-		}
-		if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
-			h.log.Error("Failed to save transcriptions", zap.Error(err))
+		h.log.Error("Failed to enqueue video analysis",
+			zap.Error(err),
+			zap.String("job_id", jobID),
+		)
+		analysis.Status = "failed"
+		if updateErr := h.repo.UpdateAnalysis(c.Request.Context(), analysis); updateErr != nil {
+			h.log.Error("Failed to update analysis", zap.Error(updateErr))
 		}
-	}()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "ANALYSIS_FAILED",
+			"message": "无法创建解析任务",
+		})
+		return
+	}
 
 	// Return jobId immediately for frontend compatibility
 	// #region agent log
@@ -338,6 +265,129 @@ func (h *VideoHandler) AnalyzeVideo(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// respondQueueLimit writes the 429 response used by VideoHandler when the user is over the job cap.
+func (h *VideoHandler) respondQueueLimit(c *gin.Context, limitErr *jobs.LimitError) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":           "TOO_MANY_JOBS",
+		"message":        fmt.Sprintf("解析中的任务过多（%d/%d），请等待当前任务完成后再试", limitErr.InFlight, limitErr.Limit),
+		"in_flight":      limitErr.InFlight,
+		"limit":          limitErr.Limit,
+		"queue_position": limitErr.QueuePosition,
+		"retry_after":    queueRetryAfterSeconds,
+	})
+}
+
+// HandleAnalysisJob runs a video.analyze job: calls Gemini and saves the transcription.
+func (h *VideoHandler) HandleAnalysisJob(ctx context.Context, job *models.Job) error {
+	var payload models.VideoAnalyzeJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	analysisRecord, err := h.repo.GetAnalysisByID(ctx, payload.AnalysisID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("analysis %d not found", payload.AnalysisID))
+		}
+		return err
+	}
+	if analysisRecord.Status == "completed" {
+		return nil
+	}
+
+	// Attribute LLM usage to the owner
+	ctx = llm.WithUserID(ctx, analysisRecord.UserID)
+	response, err := h.youtubeService.CallGeminiDirect(ctx, payload.VideoURL)
+	if err != nil {
+		// Let the queue retry; the error is saved once retries are exhausted
+		return err
+	}
+
+	// Parse response to extract transcription
+	var result struct {
+		Transcription []struct {
+			Text      string `json:"text"`
+			Timestamp string `json:"timestamp"`
+			Seconds   int    `json:"seconds"`
+		} `json:"transcription"`
+	}
+
+	// Even if parsing fails, save raw response as completed
+	if err := json.Unmarshal([]byte(llm.ExtractJSON(response)), &result); err != nil {
+		h.log.Warn("Failed to parse Gemini response, but saving raw response as completed",
+			zap.Error(err),
+			zap.String("job_id", analysisRecord.JobID),
+			zap.String("raw_response", response),
+		)
+		return h.completeAnalysis(ctx, analysisRecord, response, []models.Transcription{
+			{
+				AnalysisID: analysisRecord.ID,
+				Text:       response,
+				Timestamp:  "00:00",
+				Seconds:    0,
+				OrderIndex: 0,
+			},
+		})
+	}
+
+	transcriptions := make([]models.Transcription, len(result.Transcription))
+	for i, tr := range result.Transcription {
+		transcriptions[i] = models.Transcription{
+			AnalysisID: analysisRecord.ID,
+			Text:       tr.Text,
+			Timestamp:  tr.Timestamp,
+			Seconds:    tr.Seconds,
+			OrderIndex: i,
+		}
+	}
+	return h.completeAnalysis(ctx, analysisRecord, analysisRecord.Summary, transcriptions)
+}
+
+// HandleDeadAnalysisJob saves the last Gemini error once a video.analyze job is dead-lettered.
+// User said: "大模型如果返回错误，也是正确的" - so the error is stored as a completed result.
+func (h *VideoHandler) HandleDeadAnalysisJob(ctx context.Context, job *models.Job, jobErr error) {
+	var payload models.VideoAnalyzeJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return
+	}
+
+	analysisRecord, err := h.repo.GetAnalysisByID(ctx, payload.AnalysisID)
+	if err != nil {
+		h.log.Error("Failed to get analysis record", zap.Error(err))
+		return
+	}
+
+	h.log.Warn("Gemini returned error, but saving as completed",
+		zap.Error(jobErr),
+		zap.String("video_url", payload.VideoURL),
+		zap.String("job_id", analysisRecord.JobID),
+	)
+	if err := h.completeAnalysis(ctx, analysisRecord, jobErr.Error(), []models.Transcription{
+		{
+			AnalysisID: analysisRecord.ID,
+			Text:       fmt.Sprintf("Error: %v", jobErr),
+			Timestamp:  "00:00",
+			Seconds:    0,
+			OrderIndex: 0,
+		},
+	}); err != nil {
+		h.log.Error("Failed to save analysis error", zap.Error(err))
+	}
+}
+
+// completeAnalysis saves transcriptions and marks the analysis as completed.
+func (h *VideoHandler) completeAnalysis(ctx context.Context, analysis *models.VideoAnalysis, summary string, transcriptions []models.Transcription) error {
+	if err := h.repo.CreateTranscriptions(ctx, transcriptions); err != nil {
+		return fmt.Errorf("failed to save transcriptions: %w", err)
+	}
+	analysis.Status = "completed"
+	analysis.Summary = summary
+	if err := h.repo.UpdateAnalysis(ctx, analysis); err != nil {
+		return fmt.Errorf("failed to update analysis: %w", err)
+	}
+	return nil
+}
+
 // processAnalysis performs the actual video analysis asynchronously.
 func (h *VideoHandler) processAnalysis(ctx context.Context, analysisID uint, videoID, targetLanguage string) {
 	h.log.Info("Starting video analysis",
@@ -404,7 +454,7 @@ func (h *VideoHandler) GetResult(c *gin.Context) {
 	}
 
 	// Get analysis
-	analysis, err := h.repo.GetAnalysisByJobID(c.Request.Context(), jobID)
+	analysis, err := h.repo.GetAnalysisByJobID(c.Request.Context(), jobID, middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -468,8 +518,7 @@ func (h *VideoHandler) GetResult(c *gin.Context) {
 // GetHistory retrieves the user's analysis history.
 // GET /api/v1/history
 func (h *VideoHandler) GetHistory(c *gin.Context) {
-	// TODO: Get user ID from JWT token
-	userID := uint(1)
+	userID := middleware.MustGetUserID(c)
 
 	analyses, err := h.repo.GetHistoryByUserID(c.Request.Context(), userID, 20)
 	if err != nil {
@@ -508,8 +557,7 @@ func (h *VideoHandler) ExportVideo(c *gin.Context) {
 		return
 	}
 
-	// TODO: Get user ID from JWT token
-	userID := uint(1)
+	userID := middleware.MustGetUserID(c)
 
 	// Get latest analysis for this video
 	analysis, err := h.repo.GetAnalysisByVideoID(c.Request.Context(), req.VideoID, userID)
@@ -613,12 +661,8 @@ func (h *VideoHandler) DeleteAnalysis(c *gin.Context) {
 		return
 	}
 
-	// TODO: Get user ID from JWT token and verify ownership
-	// For now, we'll allow deletion without ownership check
-	// userID := uint(1)
-
-	// Get analysis to verify it exists
-	analysis, err := h.repo.GetAnalysisByID(c.Request.Context(), uri.ID)
+	// Get analysis to verify it exists and the user owns it
+	analysis, err := h.repo.GetAnalysisByIDForUser(c.Request.Context(), uri.ID, middleware.MustGetUserID(c))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -635,17 +679,8 @@ func (h *VideoHandler) DeleteAnalysis(c *gin.Context) {
 		return
 	}
 
-	// TODO: Verify user owns this analysis
-	// if analysis.UserID != userID {
-	// 	c.JSON(http.StatusForbidden, gin.H{
-	// 		"code":    "FORBIDDEN",
-	// 		"message": "无权删除此记录",
-	// 	})
-	// 	return
-	// }
-
 	// Delete the analysis and all related records
-	if err := h.repo.DeleteAnalysis(c.Request.Context(), uri.ID); err != nil {
+	if err := h.repo.DeleteAnalysis(c.Request.Context(), analysis.ID); err != nil {
 		h.log.Error("Failed to delete analysis",
 			zap.Error(err),
 			zap.Uint("analysis_id", uri.ID),
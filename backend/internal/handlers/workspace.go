@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/services"
+)
+
+// WorkspaceHandler handles workspaces, their members and moving insights between libraries.
+type WorkspaceHandler struct {
+	workspaces *services.WorkspaceService
+	log        *zap.Logger
+}
+
+// NewWorkspaceHandler creates a new WorkspaceHandler.
+func NewWorkspaceHandler(workspaces *services.WorkspaceService, log *zap.Logger) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaces: workspaces,
+		log:        log,
+	}
+}
+
+// workspaceErrorStatus maps workspace errors defined in models to HTTP statuses.
+var workspaceErrorStatus = map[models.ErrorCode]int{
+	models.ErrorWorkspaceNotFound:  http.StatusNotFound,
+	models.ErrorInsightNotFound:    http.StatusNotFound,
+	models.ErrorMemberNotFound:     http.StatusNotFound,
+	models.ErrorWorkspaceForbidden: http.StatusForbidden,
+	models.ErrorInsightForbidden:   http.StatusForbidden,
+	models.ErrorMemberExists:       http.StatusConflict,
+	models.ErrorOwnerMembership:    http.StatusConflict,
+}
+
+// respondError writes the error response for a failed workspace operation.
+func (h *WorkspaceHandler) respondError(c *gin.Context, err error, message string) {
+	requestID := c.GetString("request_id")
+
+	var apiErr *models.ErrorResponse
+	if errors.As(err, &apiErr) {
+		if status, ok := workspaceErrorStatus[apiErr.Code]; ok {
+			c.JSON(status, models.ErrorResponse{
+				Code:      apiErr.Code,
+				Message:   apiErr.Message,
+				RequestID: requestID,
+			})
+			return
+		}
+	}
+
+	h.log.Error(message,
+		zap.String("request_id", requestID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Code:      "INTERNAL_SERVER_ERROR",
+		Message:   message,
+		RequestID: requestID,
+	})
+}
+
+// respondInvalidRequest writes the 400 response for an unparsable request body.
+func respondInvalidRequest(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Code:      "INVALID_REQUEST",
+		Message:   "Invalid request format: " + err.Error(),
+		RequestID: c.GetString("request_id"),
+	})
+}
+
+// uintParam parses the path parameter name as an ID. On failure the error response has been written.
+func uintParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:      "INVALID_ID",
+			Message:   "Invalid " + name + " format.",
+			RequestID: c.GetString("request_id"),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// Create handles POST /api/v1/workspaces - create a workspace owned by the current user.
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	var req models.CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	workspace, err := h.workspaces.Create(c.Request.Context(), middleware.MustGetUserID(c), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create workspace.")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": workspace})
+}
+
+// List handles GET /api/v1/workspaces - list the workspaces of the current user.
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	workspaces, err := h.workspaces.List(c.Request.Context(), middleware.MustGetUserID(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to list workspaces.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspaces})
+}
+
+// Get handles GET /api/v1/workspaces/:id.
+func (h *WorkspaceHandler) Get(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaces.Get(c.Request.Context(), id, middleware.MustGetUserID(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to get workspace.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspace})
+}
+
+// Update handles PATCH /api/v1/workspaces/:id (owner only).
+func (h *WorkspaceHandler) Update(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	workspace, err := h.workspaces.Update(c.Request.Context(), id, middleware.MustGetUserID(c), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update workspace.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspace})
+}
+
+// Delete handles DELETE /api/v1/workspaces/:id (owner only). Insights of the
+// workspace return to the personal libraries of their creators.
+func (h *WorkspaceHandler) Delete(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.workspaces.Delete(c.Request.Context(), id, middleware.MustGetUserID(c)); err != nil {
+		h.respondError(c, err, "Failed to delete workspace.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "工作区已删除"})
+}
+
+// ListInsights handles GET /api/v1/workspaces/:id/insights - the insights of
+// a workspace grouped by date, like GET /api/v1/insights.
+func (h *WorkspaceHandler) ListInsights(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	result, err := h.workspaces.ListInsights(c.Request.Context(), id, middleware.MustGetUserID(c), c.Query("search"), limit)
+	if err != nil {
+		h.respondError(c, err, "Failed to list workspace insights.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ListMembers handles GET /api/v1/workspaces/:id/members.
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.workspaces.ListMembers(c.Request.Context(), id, middleware.MustGetUserID(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to list workspace members.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddMember handles POST /api/v1/workspaces/:id/members - add a registered
+// user by email (owner only).
+func (h *WorkspaceHandler) AddMember(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.AddWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	member, err := h.workspaces.AddMember(c.Request.Context(), id, middleware.MustGetUserID(c), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to add workspace member.")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": member})
+}
+
+// UpdateMember handles PATCH /api/v1/workspaces/:id/members/:userId - change
+// the role of a member (owner only).
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "userId")
+	if !ok {
+		return
+	}
+	var req models.UpdateWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	if err := h.workspaces.UpdateMember(c.Request.Context(), id, middleware.MustGetUserID(c), memberID, req.Role); err != nil {
+		h.respondError(c, err, "Failed to update workspace member.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "成员角色已更新"})
+}
+
+// RemoveMember handles DELETE /api/v1/workspaces/:id/members/:userId. The
+// owner removes members; members remove themselves to leave.
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.workspaces.RemoveMember(c.Request.Context(), id, middleware.MustGetUserID(c), memberID); err != nil {
+		h.respondError(c, err, "Failed to remove workspace member.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "成员已移除"})
+}
+
+// MoveInsight handles PUT /api/v1/insights/:id/workspace - move an insight
+// into a workspace ({"workspace_id": 3}) or back to the personal library
+// ({"workspace_id": null}).
+func (h *WorkspaceHandler) MoveInsight(c *gin.Context) {
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.MoveInsightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	insight, err := h.workspaces.MoveInsight(c.Request.Context(), id, middleware.MustGetUserID(c), req.WorkspaceID)
+	if err != nil {
+		h.respondError(c, err, "Failed to move insight.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"id":           insight.ID,
+		"workspace_id": insight.WorkspaceID,
+	}})
+}
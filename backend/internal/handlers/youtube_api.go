@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,33 +14,48 @@ import (
 	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 
+	"vibe-backend/internal/middleware"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
 )
 
+const (
+	// oauthBindingCookie binds a pending OAuth state to the browser that requested it.
+	oauthBindingCookie = "oauth_binding"
+
+	// oauthBindingCookieMaxAge matches the lifetime of OAuth states.
+	oauthBindingCookieMaxAge = 10 * time.Minute
+)
+
 // YouTubeAPIHandler handles YouTube API endpoints.
 type YouTubeAPIHandler struct {
 	youtubeAPI     *services.YouTubeAPIService
 	youtubeService *services.YouTubeService
 	oauthService   *services.OAuthService
 	userRepo       repository.UserRepository
+	sessions       *services.SessionService
+	googleAccounts *services.GoogleAccountService
 	log            *zap.Logger
 }
 
 // NewYouTubeAPIHandler creates a new YouTubeAPIHandler.
-func NewYouTubeAPIHandler(youtubeAPI *services.YouTubeAPIService, youtubeService *services.YouTubeService, oauthService *services.OAuthService, userRepo *repository.UserRepository, log *zap.Logger) *YouTubeAPIHandler {
+func NewYouTubeAPIHandler(youtubeAPI *services.YouTubeAPIService, youtubeService *services.YouTubeService, oauthService *services.OAuthService, userRepo *repository.UserRepository, sessions *services.SessionService, googleAccounts *services.GoogleAccountService, log *zap.Logger) *YouTubeAPIHandler {
 	return &YouTubeAPIHandler{
 		youtubeAPI:     youtubeAPI,
 		youtubeService: youtubeService,
 		oauthService:   oauthService,
 		userRepo:       *userRepo,
+		sessions:       sessions,
+		googleAccounts: googleAccounts,
 		log:            log,
 	}
 }
 
 // GetAuthURL generates Google OAuth authorization URL.
-// GET /api/v1/auth/google/url
+// GET /api/v1/auth/google/url[?intent=link]
+// The state is single-use and bound to this browser with a cookie. With
+// intent=link an authenticated user links Google to the current account.
 func (h *YouTubeAPIHandler) GetAuthURL(c *gin.Context) {
 	// Check if OAuth is configured
 	if h.oauthService == nil {
@@ -50,10 +66,32 @@ func (h *YouTubeAPIHandler) GetAuthURL(c *gin.Context) {
 		return
 	}
 
-	// Generate state token (in production, this should be stored and verified)
-	state := "random-state-token" // TODO: Generate secure random state
+	var linkUserID uint
+	if c.Query("intent") == "link" {
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Code:    models.ErrorUnauthorized,
+				Message: "关联 Google 账号需要先登录",
+			})
+			return
+		}
+		linkUserID = userID
+	}
+
+	authURL, binding, err := h.oauthService.StartAuth(c.Request.Context(), linkUserID)
+	if err != nil {
+		h.log.Error("Failed to start OAuth authorization",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    models.ErrorAuthFailed,
+			Message: "授权初始化失败，请重试",
+		})
+		return
+	}
 
-	authURL := h.oauthService.GetAuthURL(state)
+	setOAuthBindingCookie(c, binding, int(oauthBindingCookieMaxAge.Seconds()))
 
 	c.JSON(http.StatusOK, models.AuthURLResponse{
 		URL: authURL,
@@ -81,8 +119,30 @@ func (h *YouTubeAPIHandler) HandleCallback(c *gin.Context) {
 		return
 	}
 
+	// The state must be one we issued, unused, and presented by the browser it was issued to
+	binding, _ := c.Cookie(oauthBindingCookie)
+	setOAuthBindingCookie(c, "", -1)
+	oauthState, err := h.oauthService.CompleteAuth(c.Request.Context(), req.State, binding)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidOAuthState) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    models.ErrInvalidOAuthState.Code,
+				Message: models.ErrInvalidOAuthState.Message,
+			})
+			return
+		}
+		h.log.Error("Failed to verify OAuth state",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    models.ErrorAuthFailed,
+			Message: "授权失败，请重试",
+		})
+		return
+	}
+
 	// Exchange authorization code for access token
-	token, err := h.oauthService.ExchangeCode(c.Request.Context(), req.Code)
+	token, err := h.oauthService.ExchangeCode(c.Request.Context(), req.Code, oauthState.Verifier)
 	if err != nil {
 		h.log.Error("Failed to exchange authorization code",
 			zap.Error(err),
@@ -107,10 +167,21 @@ func (h *YouTubeAPIHandler) HandleCallback(c *gin.Context) {
 		return
 	}
 
-	// Check if user exists
-	user, err := h.userRepo.GetByEmail(c.Request.Context(), strings.ToLower(userInfo.Email))
-	if err != nil && err != gorm.ErrRecordNotFound {
-		h.log.Error("Failed to query user",
+	user, err := h.resolveGoogleUser(c, oauthState, userInfo)
+	if err != nil {
+		var apiErr *models.ErrorResponse
+		if errors.As(err, &apiErr) {
+			status := http.StatusConflict
+			if errors.Is(err, models.ErrGoogleEmailNotVerified) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, models.ErrorResponse{
+				Code:    apiErr.Code,
+				Message: apiErr.Message,
+			})
+			return
+		}
+		h.log.Error("Failed to resolve Google user",
 			zap.String("email", userInfo.Email),
 			zap.Error(err),
 		)
@@ -121,36 +192,31 @@ func (h *YouTubeAPIHandler) HandleCallback(c *gin.Context) {
 		return
 	}
 
-	// If user doesn't exist, create new user
-	if user == nil {
-		user = &models.User{
-			Email:    strings.ToLower(userInfo.Email),
-			Name:     userInfo.Name,
-			Password: "google-oauth-user", // Placeholder password for OAuth users
-		}
-
-		if err := h.userRepo.Create(c.Request.Context(), user); err != nil {
-			h.log.Error("Failed to create user",
-				zap.String("email", userInfo.Email),
-				zap.Error(err),
-			)
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Code:    models.ErrorAuthFailed,
-				Message: "用户创建失败",
+	// Store the Google token server-side (encrypted) for YouTube API access
+	account, err := h.googleAccounts.Link(c.Request.Context(), user.ID, userInfo, token)
+	if err != nil {
+		if errors.Is(err, models.ErrGoogleAccountInUse) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Code:    models.ErrGoogleAccountInUse.Code,
+				Message: models.ErrGoogleAccountInUse.Message,
 			})
 			return
 		}
-
-		h.log.Info("New user created via Google OAuth",
+		h.log.Error("Failed to link Google account",
 			zap.Uint("user_id", user.ID),
-			zap.String("email", user.Email),
+			zap.Error(err),
 		)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    models.ErrorAuthFailed,
+			Message: "授权失败，请重试",
+		})
+		return
 	}
 
-	// Convert token to JSON for storage (for YouTube API access)
-	tokenJSON, err := h.oauthService.TokenToJSON(token)
+	session, err := h.sessions.Start(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		h.log.Error("Failed to serialize token",
+		h.log.Error("Failed to start session",
+			zap.Uint("user_id", user.ID),
 			zap.Error(err),
 		)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -160,72 +226,172 @@ func (h *YouTubeAPIHandler) HandleCallback(c *gin.Context) {
 		return
 	}
 
-	// Return both system API key and Google OAuth token
+	// Return system session tokens; the Google token stays on the server
+	userResponse := user.ToResponse()
 	c.JSON(http.StatusOK, models.OAuthCallbackResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		TokenType:    token.TokenType,
-		Expiry:       token.Expiry,
-		TokenJSON:    tokenJSON,
-		User: &models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
+		User:    &userResponse,
+		Session: session,
+		Google: &models.GoogleAccountResponse{
+			Linked:   true,
+			Email:    account.Email,
+			LinkedAt: &account.UpdatedAt,
 		},
-		APIKey: user.APIKey,
 	})
 }
 
-// RefreshToken refreshes an expired OAuth token.
-// POST /api/v1/auth/google/refresh
-func (h *YouTubeAPIHandler) RefreshToken(c *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refreshToken" binding:"required"`
+// resolveGoogleUser returns the user a Google sign-in belongs to:
+//   - the user who started an explicit link,
+//   - else the user the Google account is already linked to,
+//   - else the user with the Google email, if that account was created through
+//     Google; password accounts must link explicitly (models.ErrAccountLinkRequired),
+//   - else a new user.
+func (h *YouTubeAPIHandler) resolveGoogleUser(c *gin.Context, oauthState *services.OAuthState, userInfo *services.GoogleUserInfo) (*models.User, error) {
+	ctx := c.Request.Context()
+
+	if oauthState.LinkUserID != 0 {
+		return h.userRepo.GetByID(ctx, oauthState.LinkUserID)
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Code:    models.ErrorInvalidInput,
-			Message: "Refresh token 不能为空",
-		})
-		return
+	linkedTo, err := h.googleAccounts.LinkedUserID(ctx, userInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linkedTo != 0 {
+		return h.userRepo.GetByID(ctx, linkedTo)
 	}
 
-	// Refresh the token using OAuth service
-	token, err := h.oauthService.RefreshToken(c.Request.Context(), req.RefreshToken)
-	if err != nil {
-		h.log.Error("Failed to refresh token",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Code:    models.ErrorAuthFailed,
-			Message: "Token 刷新失败，请重新登录",
-		})
-		return
+	// Matching by email is only safe for addresses Google has verified
+	if !userInfo.VerifiedEmail {
+		return nil, models.ErrGoogleEmailNotVerified
+	}
+
+	email := strings.ToLower(userInfo.Email)
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if user != nil {
+		if user.HasPassword() {
+			h.log.Info("Google sign-in matches a password account, explicit linking required",
+				zap.Uint("user_id", user.ID),
+			)
+			return nil, models.ErrAccountLinkRequired
+		}
+		return user, nil
 	}
 
-	// Convert token to JSON for storage
-	tokenJSON, err := h.oauthService.TokenToJSON(token)
+	// Google-only accounts have no password until the user sets one; Google has verified the email
+	now := time.Now()
+	user = &models.User{
+		Email:           email,
+		Name:            userInfo.Name,
+		EmailVerifiedAt: &now,
+	}
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	h.log.Info("New user created via Google OAuth",
+		zap.Uint("user_id", user.ID),
+		zap.String("email", user.Email),
+	)
+	return user, nil
+}
+
+// setOAuthBindingCookie sets (maxAge > 0) or clears (maxAge < 0) the cookie
+// binding an OAuth state to the browser. Over HTTPS the frontend and API may
+// be on different sites, which requires SameSite=None.
+func setOAuthBindingCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	if secure {
+		c.SetSameSite(http.SameSiteNoneMode)
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(oauthBindingCookie, value, maxAge, "/api/v1/auth/google", "", secure, true)
+}
+
+// GetGoogleAccount returns the Google account linked to the current user.
+// GET /api/v1/auth/google
+func (h *YouTubeAPIHandler) GetGoogleAccount(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	status, err := h.googleAccounts.Status(c.Request.Context(), userID)
 	if err != nil {
-		h.log.Error("Failed to serialize refreshed token",
+		h.log.Error("Failed to get Google account",
+			zap.String("request_id", requestID),
+			zap.Uint("user_id", userID),
 			zap.Error(err),
 		)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:    models.ErrorAuthFailed,
-			Message: "Token 刷新失败，请重新登录",
+			Code:      "INTERNAL_SERVER_ERROR",
+			Message:   "获取 Google 账号失败",
+			RequestID: requestID,
 		})
 		return
 	}
 
-	// Return new token to client
-	c.JSON(http.StatusOK, models.OAuthCallbackResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		TokenType:    token.TokenType,
-		Expiry:       token.Expiry,
-		TokenJSON:    tokenJSON,
-	})
+	c.JSON(http.StatusOK, status)
+}
+
+// UnlinkGoogleAccount revokes the Google grant of the current user and removes the link.
+// DELETE /api/v1/auth/google
+func (h *YouTubeAPIHandler) UnlinkGoogleAccount(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	userID := middleware.MustGetUserID(c)
+
+	if err := h.googleAccounts.Unlink(c.Request.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrGoogleNotLinked):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Code:      models.ErrGoogleNotLinked.Code,
+				Message:   models.ErrGoogleNotLinked.Message,
+				RequestID: requestID,
+			})
+		case errors.Is(err, models.ErrGoogleRevokeFailed):
+			c.JSON(http.StatusBadGateway, models.ErrorResponse{
+				Code:      models.ErrGoogleRevokeFailed.Code,
+				Message:   models.ErrGoogleRevokeFailed.Message,
+				RequestID: requestID,
+			})
+		default:
+			h.log.Error("Failed to unlink Google account",
+				zap.String("request_id", requestID),
+				zap.Uint("user_id", userID),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:      "INTERNAL_SERVER_ERROR",
+				Message:   "解除 Google 账号关联失败",
+				RequestID: requestID,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除 Google 账号关联"})
+}
+
+// googleTokenSource returns the token source of the current user's linked
+// Google account, or nil if the request is anonymous or no usable account is linked.
+func (h *YouTubeAPIHandler) googleTokenSource(c *gin.Context) oauth2.TokenSource {
+	userID, ok := middleware.GetUserID(c)
+	if !ok || h.googleAccounts == nil {
+		return nil
+	}
+
+	tokenSource, err := h.googleAccounts.TokenSource(c.Request.Context(), userID)
+	if err != nil {
+		if !errors.Is(err, models.ErrGoogleNotLinked) {
+			h.log.Warn("Google account unavailable",
+				zap.Uint("user_id", userID),
+				zap.Error(err),
+			)
+		}
+		return nil
+	}
+	return tokenSource
 }
 
 // GetVideoMetadata fetches video metadata.
@@ -286,17 +452,10 @@ func (h *YouTubeAPIHandler) GetPlaylist(c *gin.Context) {
 		return
 	}
 
-	// Get OAuth token from Authorization header for private playlists
-	var token *oauth2.Token
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" && len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		accessToken := authHeader[7:]
-		token = &oauth2.Token{
-			AccessToken: accessToken,
-		}
-	}
+	// Use the linked Google account of the user for private playlists
+	tokenSource := h.googleTokenSource(c)
 
-	response, err := h.youtubeAPI.GetPlaylist(c.Request.Context(), playlistID, token)
+	response, err := h.youtubeAPI.GetPlaylist(c.Request.Context(), playlistID, tokenSource)
 	if err != nil {
 		h.log.Error("Failed to get playlist",
 			zap.Error(err),
@@ -363,34 +522,21 @@ func (h *YouTubeAPIHandler) GetCaptions(c *gin.Context) {
 		return
 	}
 
-	// Get OAuth token from Authorization header
-	var token *oauth2.Token
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" && len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		accessToken := authHeader[7:]
-		token = &oauth2.Token{
-			AccessToken: accessToken,
-		}
-	}
+	// Use the linked Google account of the user, refreshed server-side
+	tokenSource := h.googleTokenSource(c)
 
 	// #region agent log
 	logDebug("youtube_api.go:293", "Before GetCaptions API call", map[string]interface{}{
 		"videoId": videoID,
-		"hasToken": token != nil,
-		"tokenLength": func() int {
-			if token != nil {
-				return len(token.AccessToken)
-			}
-			return 0
-		}(),
+		"hasToken": tokenSource != nil,
 	}, "B,E")
 	// #endregion
 
 	// Try YouTube Data API v3 first (only if we have a token)
 	var response *models.YouTubeCaptionsResponse
 	var err error
-	if token != nil && token.AccessToken != "" {
-		response, err = h.youtubeAPI.GetCaptions(c.Request.Context(), videoID, token)
+	if tokenSource != nil {
+		response, err = h.youtubeAPI.GetCaptions(c.Request.Context(), videoID, tokenSource)
 		if err != nil {
 			// #region agent log
 			logDebug("youtube_api.go:296", "GetCaptions API error", map[string]interface{}{
@@ -0,0 +1,511 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// Handler processes a single leased job. Returning an error schedules a retry
+// (or buries the job once attempts are exhausted); wrap the error with
+// Permanent to skip the remaining retries.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// DeadLetterFunc is called after a job has been moved to the dead-letter state.
+type DeadLetterFunc func(ctx context.Context, job *models.Job, err error)
+
+// Options configures the queue workers.
+type Options struct {
+	// Workers is the number of concurrent workers.
+	Workers int
+	// PollInterval is how long an idle worker waits before polling again.
+	PollInterval time.Duration
+	// LeaseDuration is how long a lease is valid without a heartbeat.
+	LeaseDuration time.Duration
+	// MaxAttempts is the default number of attempts for new jobs.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the retry delay.
+	MaxBackoff time.Duration
+	// JobTimeout bounds the execution time of a single attempt.
+	JobTimeout time.Duration
+	// MaxInFlightPerUser caps the number of queued or running jobs per user (0 disables the cap).
+	MaxInFlightPerUser int
+}
+
+// DefaultOptions returns the default queue options.
+func DefaultOptions() Options {
+	return Options{
+		Workers:       4,
+		PollInterval:  2 * time.Second,
+		LeaseDuration: 2 * time.Minute,
+		MaxAttempts:   3,
+		BaseBackoff:   15 * time.Second,
+		MaxBackoff:    10 * time.Minute,
+		JobTimeout:    30 * time.Minute,
+	}
+}
+
+// permanentError marks an error as non-retryable.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the job is moved to the dead-letter state without retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// LimitError is returned when a user already has the maximum number of jobs in flight.
+type LimitError struct {
+	InFlight int64
+	Limit    int
+	// QueuePosition is the position of the user's next queued job in the global
+	// queue (0 when all of the user's jobs are already running).
+	QueuePosition int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("user has %d jobs in flight (limit %d)", e.InFlight, e.Limit)
+}
+
+// jobStore persists the jobs of a queue; implemented by repository.JobRepository.
+type jobStore interface {
+	Create(ctx context.Context, job *models.Job) error
+	CreateWithUserLimit(ctx context.Context, job *models.Job, limit int) (int64, error)
+	CountInFlightByUser(ctx context.Context, userID uint) (int64, error)
+	QueuePosition(ctx context.Context, userID uint) (int64, error)
+	Lease(ctx context.Context, types []string, owner string, leaseFor time.Duration) (*models.Job, error)
+	Heartbeat(ctx context.Context, id uint, owner string, leaseFor time.Duration) error
+	Complete(ctx context.Context, id uint, owner string) error
+	Retry(ctx context.Context, id uint, owner string, runAt time.Time, errMsg string) error
+	Bury(ctx context.Context, id uint, owner string, errMsg string) error
+	Release(ctx context.Context, id uint, owner string) error
+	RecoverStale(ctx context.Context) (requeued int64, buried []models.Job, err error)
+}
+
+// Queue is a durable, Postgres-backed job queue with a pool of workers.
+type Queue struct {
+	repo        jobStore
+	opts        Options
+	owner       string
+	handlers    map[string]Handler
+	deadLetters map[string]DeadLetterFunc
+	log         *zap.Logger
+
+	mu      sync.RWMutex
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewQueue creates a new Queue.
+func NewQueue(repo *repository.JobRepository, opts Options, log *zap.Logger) *Queue {
+	defaults := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaults.PollInterval
+	}
+	if opts.LeaseDuration <= 0 {
+		opts.LeaseDuration = defaults.LeaseDuration
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaults.BaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = defaults.JobTimeout
+	}
+
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+
+	return &Queue{
+		repo:        repo,
+		opts:        opts,
+		owner:       owner,
+		handlers:    make(map[string]Handler),
+		deadLetters: make(map[string]DeadLetterFunc),
+		log:         log,
+	}
+}
+
+// Register registers the handler for a job type. Must be called before Start.
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// OnDeadLetter registers a callback invoked when a job of the given type is buried.
+func (q *Queue) OnDeadLetter(jobType string, fn DeadLetterFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deadLetters[jobType] = fn
+}
+
+// EnqueueOptions customizes a single enqueued job.
+type EnqueueOptions struct {
+	// RunAt delays the job until the given time (zero means now).
+	RunAt time.Time
+	// MaxAttempts overrides the queue default.
+	MaxAttempts int
+	// UserID attributes the job to a user and enforces the per-user in-flight cap.
+	UserID uint
+}
+
+// Enqueue persists a new job of the given type with a JSON-encoded payload.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error) {
+	return q.EnqueueWithOptions(ctx, jobType, payload, EnqueueOptions{})
+}
+
+// EnqueueForUser persists a new job owned by userID, failing with *LimitError when
+// the user is over the in-flight cap.
+func (q *Queue) EnqueueForUser(ctx context.Context, userID uint, jobType string, payload interface{}) (*models.Job, error) {
+	return q.EnqueueWithOptions(ctx, jobType, payload, EnqueueOptions{UserID: userID})
+}
+
+// CheckUserLimit returns *LimitError when userID cannot enqueue another job right now.
+// Handlers call it before creating the records a job will operate on.
+func (q *Queue) CheckUserLimit(ctx context.Context, userID uint) error {
+	if q.opts.MaxInFlightPerUser <= 0 || userID == 0 {
+		return nil
+	}
+	inFlight, err := q.repo.CountInFlightByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count in-flight jobs: %w", err)
+	}
+	if inFlight < int64(q.opts.MaxInFlightPerUser) {
+		return nil
+	}
+	return q.limitError(ctx, userID, inFlight)
+}
+
+// limitError builds a LimitError including the user's queue position.
+func (q *Queue) limitError(ctx context.Context, userID uint, inFlight int64) error {
+	position, err := q.repo.QueuePosition(ctx, userID)
+	if err != nil {
+		q.log.Warn("Failed to compute queue position", zap.Uint("user_id", userID), zap.Error(err))
+	}
+	return &LimitError{
+		InFlight:      inFlight,
+		Limit:         q.opts.MaxInFlightPerUser,
+		QueuePosition: position,
+	}
+}
+
+// EnqueueWithOptions persists a new job with custom scheduling options.
+func (q *Queue) EnqueueWithOptions(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.opts.MaxAttempts
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      models.JobStatusQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	}
+	if opts.UserID != 0 {
+		userID := opts.UserID
+		job.UserID = &userID
+	}
+
+	if job.UserID != nil && q.opts.MaxInFlightPerUser > 0 {
+		inFlight, err := q.repo.CreateWithUserLimit(ctx, job, q.opts.MaxInFlightPerUser)
+		if errors.Is(err, repository.ErrUserJobLimit) {
+			return nil, q.limitError(ctx, opts.UserID, inFlight)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to enqueue job: %w", err)
+		}
+	} else if err := q.repo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	q.log.Info("Job enqueued",
+		zap.Uint("job_id", job.ID),
+		zap.String("type", jobType),
+		zap.Time("run_at", runAt),
+	)
+	return job, nil
+}
+
+// Start recovers stale jobs and launches the workers. Workers stop when ctx is cancelled.
+func (q *Queue) Start(ctx context.Context) {
+	q.mu.Lock()
+	if q.started {
+		q.mu.Unlock()
+		return
+	}
+	q.started = true
+	q.mu.Unlock()
+
+	q.recoverStale(ctx)
+
+	q.log.Info("Starting job workers",
+		zap.Int("workers", q.opts.Workers),
+		zap.String("owner", q.owner),
+	)
+
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(ctx, i)
+	}
+
+	// Periodically recover jobs whose worker died without releasing them
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.opts.LeaseDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.recoverStale(ctx)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all workers have stopped.
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// recoverStale re-queues jobs with expired leases. Jobs whose worker died
+// during their final attempt are buried and handed to their dead-letter handler.
+func (q *Queue) recoverStale(ctx context.Context) {
+	requeued, buried, err := q.repo.RecoverStale(ctx)
+	if err != nil {
+		q.log.Error("Failed to recover stale jobs", zap.Error(err))
+		return
+	}
+	if requeued > 0 || len(buried) > 0 {
+		q.log.Warn("Recovered jobs with stale leases",
+			zap.Int64("requeued", requeued),
+			zap.Int("buried", len(buried)),
+		)
+	}
+
+	for i := range buried {
+		job := &buried[i]
+		q.log.Error("Job moved to dead letter",
+			zap.Uint("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Int("attempt", job.Attempts),
+			zap.String("error", job.LastError),
+		)
+		q.deadLetter(ctx, job, errors.New(job.LastError))
+	}
+}
+
+// deadLetter runs the dead-letter handler registered for the type of a buried job.
+func (q *Queue) deadLetter(ctx context.Context, job *models.Job, err error) {
+	q.mu.RLock()
+	deadLetter := q.deadLetters[job.Type]
+	q.mu.RUnlock()
+	if deadLetter != nil {
+		deadLetter(ctx, job, err)
+	}
+}
+
+// types returns the job types this queue has handlers for.
+func (q *Queue) types() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	return types
+}
+
+// worker polls for jobs until ctx is cancelled.
+func (q *Queue) worker(ctx context.Context, index int) {
+	defer q.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := q.repo.Lease(ctx, q.types(), q.owner, q.opts.LeaseDuration)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+				q.log.Error("Failed to lease job", zap.Int("worker", index), zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+
+		q.run(ctx, job)
+	}
+}
+
+// run executes a leased job while keeping its lease alive.
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	q.mu.RLock()
+	handler := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	log := q.log.With(
+		zap.Uint("job_id", job.ID),
+		zap.String("type", job.Type),
+		zap.Int("attempt", job.Attempts),
+	)
+	log.Info("Job started")
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.opts.JobTimeout)
+	defer cancel()
+
+	// Heartbeat until the handler returns
+	heartbeatDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(q.opts.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatDone:
+				return
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if err := q.repo.Heartbeat(jobCtx, job.ID, q.owner, q.opts.LeaseDuration); err != nil {
+					log.Warn("Job heartbeat failed, cancelling", zap.Error(err))
+					if errors.Is(err, repository.ErrLeaseLost) {
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var err error
+	if handler == nil {
+		err = Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	} else {
+		err = q.safeCall(jobCtx, handler, job)
+	}
+	close(heartbeatDone)
+
+	// Use a fresh context for bookkeeping so shutdown does not lose the outcome
+	bookCtx, bookCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer bookCancel()
+
+	switch {
+	case err == nil:
+		if err := q.repo.Complete(bookCtx, job.ID, q.owner); err != nil {
+			log.Error("Failed to mark job as succeeded", zap.Error(err))
+			return
+		}
+		log.Info("Job succeeded")
+
+	case ctx.Err() != nil:
+		// Worker is shutting down: hand the job back without consuming the attempt
+		if err := q.repo.Release(bookCtx, job.ID, q.owner); err != nil {
+			log.Error("Failed to release job on shutdown", zap.Error(err))
+			return
+		}
+		log.Info("Job released on shutdown")
+
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		if buryErr := q.repo.Bury(bookCtx, job.ID, q.owner, err.Error()); buryErr != nil {
+			log.Error("Failed to bury job", zap.Error(buryErr))
+			return
+		}
+		log.Error("Job moved to dead letter", zap.Error(err))
+		q.deadLetter(bookCtx, job, err)
+
+	default:
+		runAt := time.Now().Add(q.backoff(job.Attempts))
+		if retryErr := q.repo.Retry(bookCtx, job.ID, q.owner, runAt, err.Error()); retryErr != nil {
+			log.Error("Failed to schedule job retry", zap.Error(retryErr))
+			return
+		}
+		log.Warn("Job failed, retry scheduled",
+			zap.Error(err),
+			zap.Time("retry_at", runAt),
+		)
+	}
+}
+
+// safeCall runs the handler and converts panics into errors.
+func (q *Queue) safeCall(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns the exponential retry delay (with jitter) for the given attempt.
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := 1; i < attempt && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		delay = q.opts.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// DecodePayload unmarshals a job payload into v, marking decode errors as permanent.
+func DecodePayload(job *models.Job, v interface{}) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid payload for job %d: %w", job.ID, err))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+)
+
+func newTestQueue(opts Options) *Queue {
+	return NewQueue(nil, opts, zap.NewNop())
+}
+
+func TestBackoffDoublesPerAttemptWithJitter(t *testing.T) {
+	q := newTestQueue(Options{BaseBackoff: time.Second, MaxBackoff: time.Hour})
+
+	for attempt, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		5: 16 * time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			got := q.backoff(attempt)
+			if got < want || got > want+want/5 {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempt, got, want, want+want/5)
+			}
+		}
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	q := newTestQueue(Options{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	for _, attempt := range []int{5, 10, 100} {
+		got := q.backoff(attempt)
+		if got < 10*time.Second || got > 12*time.Second {
+			t.Fatalf("backoff(%d) = %v, want capped at 10s plus jitter", attempt, got)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Fatal("Permanent(nil) should be nil")
+	}
+
+	base := errors.New("bad payload")
+	err := Permanent(base)
+	if !IsPermanent(err) {
+		t.Fatal("Permanent error not detected")
+	}
+	if !errors.Is(err, base) {
+		t.Fatal("Permanent should unwrap to the original error")
+	}
+	if err.Error() != base.Error() {
+		t.Fatalf("Error() = %q, want %q", err.Error(), base.Error())
+	}
+
+	if !IsPermanent(fmt.Errorf("wrapped: %w", err)) {
+		t.Fatal("wrapped Permanent error not detected")
+	}
+	if IsPermanent(base) {
+		t.Fatal("plain error reported as permanent")
+	}
+}
+
+func TestDecodePayloadErrorsArePermanent(t *testing.T) {
+	var payload models.TranslationJobPayload
+	err := DecodePayload(&models.Job{ID: 7, Payload: []byte("{not json")}, &payload)
+	if !IsPermanent(err) {
+		t.Fatalf("DecodePayload error = %v, want permanent", err)
+	}
+
+	err = DecodePayload(&models.Job{Payload: []byte(`{"translation_id": 3}`)}, &payload)
+	if err != nil || payload.TranslationID != 3 {
+		t.Fatalf("DecodePayload = %v, payload %+v", err, payload)
+	}
+}
+
+func TestSafeCallRecoversPanics(t *testing.T) {
+	q := newTestQueue(Options{})
+	job := &models.Job{ID: 1}
+
+	err := q.safeCall(context.Background(), func(ctx context.Context, job *models.Job) error {
+		panic("boom")
+	}, job)
+	if err == nil || IsPermanent(err) {
+		t.Fatalf("safeCall error = %v, want retryable panic error", err)
+	}
+
+	want := errors.New("handler failed")
+	err = q.safeCall(context.Background(), func(ctx context.Context, job *models.Job) error {
+		return want
+	}, job)
+	if !errors.Is(err, want) {
+		t.Fatalf("safeCall error = %v, want %v", err, want)
+	}
+}
+
+func TestCheckUserLimitDisabled(t *testing.T) {
+	q := newTestQueue(Options{})
+	if err := q.CheckUserLimit(context.Background(), 1); err != nil {
+		t.Fatalf("CheckUserLimit with no cap = %v", err)
+	}
+
+	q = newTestQueue(Options{MaxInFlightPerUser: 1})
+	if err := q.CheckUserLimit(context.Background(), 0); err != nil {
+		t.Fatalf("CheckUserLimit for anonymous jobs = %v", err)
+	}
+}
+
+// staleStore is a jobStore whose only stale job died during its final attempt.
+type staleStore struct {
+	jobStore
+	buried []models.Job
+}
+
+func (s *staleStore) RecoverStale(ctx context.Context) (int64, []models.Job, error) {
+	buried := s.buried
+	s.buried = nil
+	return 0, buried, nil
+}
+
+func TestRecoverStaleRunsDeadLetterHandlers(t *testing.T) {
+	q := newTestQueue(Options{})
+	q.repo = &staleStore{buried: []models.Job{{
+		ID:          9,
+		Type:        models.JobTypeInsightProcess,
+		Payload:     []byte(`{"insight_id": 42}`),
+		Status:      models.JobStatusDead,
+		Attempts:    3,
+		MaxAttempts: 3,
+		LastError:   "lease expired after final attempt",
+	}}}
+
+	// Mirrors InsightProcessor.HandleDeadJob, which marks the insight failed
+	statuses := map[uint]models.InsightStatus{42: models.InsightStatusProcessing}
+	q.OnDeadLetter(models.JobTypeInsightProcess, func(ctx context.Context, job *models.Job, err error) {
+		var payload models.InsightJobPayload
+		if decodeErr := DecodePayload(job, &payload); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		if err == nil || err.Error() != job.LastError {
+			t.Fatalf("dead-letter error = %v, want %q", err, job.LastError)
+		}
+		statuses[payload.InsightID] = models.InsightStatusFailed
+	})
+
+	q.recoverStale(context.Background())
+	if statuses[42] != models.InsightStatusFailed {
+		t.Fatalf("insight status = %s, want failed", statuses[42])
+	}
+}
@@ -0,0 +1,317 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Provider names accepted by NewProvider.
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	ProviderFake       = "fake"
+)
+
+// ProviderConfig selects and configures a Provider.
+type ProviderConfig struct {
+	Name    string // openrouter (default), openai, fake
+	APIKey  string
+	BaseURL string // required for openai
+}
+
+// NewProvider creates the provider described by cfg.
+func NewProvider(cfg ProviderConfig, log *zap.Logger) (Provider, error) {
+	switch strings.ToLower(cfg.Name) {
+	case "", ProviderOpenRouter:
+		return NewOpenRouter(cfg.APIKey, log), nil
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("LLM_BASE_URL is required for the %q provider", cfg.Name)
+		}
+		return NewOpenAICompatible(cfg.BaseURL, cfg.APIKey, log), nil
+	case ProviderFake:
+		log.Warn("⚠️  使用 fake LLM provider，AI 输出为确定性假数据")
+		return NewFake(nil), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Name)
+	}
+}
+
+// UsageRecorder receives the token usage of every successful call.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, feature, model string, usage Usage)
+}
+
+// Options configures a Client.
+type Options struct {
+	// Timeout bounds a single attempt (for streams: the whole stream).
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt for retryable errors.
+	MaxRetries int
+	// BaseBackoff is the delay before the first retry; it doubles on every retry.
+	BaseBackoff time.Duration
+}
+
+// DefaultOptions returns the default client options.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:     120 * time.Second,
+		MaxRetries:  2,
+		BaseBackoff: time.Second,
+	}
+}
+
+// UsageTotals is the token usage accumulated by a Client since start.
+type UsageTotals struct {
+	Calls            int64 `json:"calls"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// Client wraps a Provider with timeouts, retries and token accounting.
+type Client struct {
+	provider Provider
+	opts     Options
+	log      *zap.Logger
+
+	mu       sync.Mutex
+	recorder UsageRecorder
+	totals   map[string]*UsageTotals // keyed by feature
+}
+
+// NewClient creates a new Client.
+func NewClient(provider Provider, opts Options, log *zap.Logger) *Client {
+	defaults := DefaultOptions()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaults.BaseBackoff
+	}
+	return &Client{
+		provider: provider,
+		opts:     opts,
+		log:      log,
+		totals:   make(map[string]*UsageTotals),
+	}
+}
+
+// SetUsageRecorder sets the recorder that receives per-call usage (for dependency injection).
+func (c *Client) SetUsageRecorder(recorder UsageRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = recorder
+}
+
+// ProviderName returns the name of the underlying provider.
+func (c *Client) ProviderName() string {
+	return c.provider.Name()
+}
+
+// Totals returns a snapshot of the usage accumulated per feature.
+func (c *Client) Totals() map[string]UsageTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]UsageTotals, len(c.totals))
+	for feature, t := range c.totals {
+		out[feature] = *t
+	}
+	return out
+}
+
+// Complete performs a completion, retrying retryable failures with exponential backoff.
+func (c *Client) Complete(ctx context.Context, req Request) (*Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, attempt); err != nil {
+				return nil, lastErr
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+		start := time.Now()
+		resp, err := c.provider.Complete(attemptCtx, req)
+		cancel()
+
+		if err == nil {
+			c.account(ctx, req, resp.Model, resp.Usage)
+			c.log.Debug("LLM call completed",
+				zap.String("provider", c.provider.Name()),
+				zap.String("feature", req.Feature),
+				zap.String("model", resp.Model),
+				zap.Int("total_tokens", resp.Usage.TotalTokens),
+				zap.Duration("duration", time.Since(start)),
+			)
+			return resp, nil
+		}
+
+		lastErr = err
+		if !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+		c.log.Warn("LLM call failed, retrying",
+			zap.String("provider", c.provider.Name()),
+			zap.String("feature", req.Feature),
+			zap.String("model", req.Model),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+	}
+	return nil, lastErr
+}
+
+// CompleteText performs a completion and returns only the content.
+func (c *Client) CompleteText(ctx context.Context, req Request) (string, error) {
+	resp, err := c.Complete(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// CompleteJSON performs a JSON-mode completion and unmarshals the result into v.
+// Markdown code fences and surrounding prose are stripped before decoding.
+func (c *Client) CompleteJSON(ctx context.Context, req Request, v interface{}) (*Response, error) {
+	req.JSON = true
+	resp, err := c.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(ExtractJSON(resp.Content)), v); err != nil {
+		return resp, &Error{
+			Kind:     ErrorKindBadResponse,
+			Provider: c.provider.Name(),
+			Message:  "model did not return valid JSON",
+			Err:      err,
+		}
+	}
+	return resp, nil
+}
+
+// Stream performs a streaming completion. Establishing the stream is retried
+// for retryable failures; once events start flowing errors are delivered on the channel.
+// Streams that end without usage, because they failed or ctx was cancelled,
+// are accounted with the estimated usage of the prompt and the content
+// streamed so far, so disconnecting early does not bypass usage budgets.
+func (c *Client) Stream(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, attempt); err != nil {
+				return nil, lastErr
+			}
+		}
+
+		streamCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+		events, err := c.provider.Stream(streamCtx, req)
+		if err == nil {
+			out := make(chan StreamEvent, 100)
+			go func() {
+				defer cancel()
+				defer close(out)
+
+				var content strings.Builder
+				accounted := false
+				defer func() {
+					if !accounted {
+						c.account(context.WithoutCancel(ctx), req, req.Model, estimateUsage(req, content.String()))
+					}
+				}()
+
+				for event := range events {
+					content.WriteString(event.Delta)
+					if event.Done && event.Usage != nil {
+						c.account(context.WithoutCancel(ctx), req, req.Model, *event.Usage)
+						accounted = true
+					}
+					select {
+					case out <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out, nil
+		}
+		cancel()
+
+		lastErr = err
+		if !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+		c.log.Warn("LLM stream failed to start, retrying",
+			zap.String("provider", c.provider.Name()),
+			zap.String("feature", req.Feature),
+			zap.String("model", req.Model),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+	}
+	return nil, lastErr
+}
+
+// sleep waits for the backoff of the given retry attempt.
+func (c *Client) sleep(ctx context.Context, attempt int) error {
+	delay := c.opts.BaseBackoff << (attempt - 1)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// account adds usage to the running totals and forwards it to the recorder.
+func (c *Client) account(ctx context.Context, req Request, model string, usage Usage) {
+	feature := req.Feature
+	if feature == "" {
+		feature = "unknown"
+	}
+	if model == "" {
+		model = req.Model
+	}
+
+	c.mu.Lock()
+	t := c.totals[feature]
+	if t == nil {
+		t = &UsageTotals{}
+		c.totals[feature] = t
+	}
+	t.Calls++
+	t.PromptTokens += int64(usage.PromptTokens)
+	t.CompletionTokens += int64(usage.CompletionTokens)
+	recorder := c.recorder
+	c.mu.Unlock()
+
+	if recorder != nil {
+		recorder.RecordUsage(ctx, feature, model, usage)
+	}
+}
+
+// ExtractJSON strips Markdown code fences and surrounding text from a model response.
+func ExtractJSON(response string) string {
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+	cleaned = strings.TrimSpace(cleaned)
+
+	if !strings.HasPrefix(cleaned, "{") && !strings.HasPrefix(cleaned, "[") {
+		startIdx := strings.IndexAny(cleaned, "{[")
+		endIdx := strings.LastIndexAny(cleaned, "}]")
+		if startIdx != -1 && endIdx > startIdx {
+			cleaned = cleaned[startIdx : endIdx+1]
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,221 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// usageSink records the usage forwarded by a Client.
+type usageSink struct {
+	mu       sync.Mutex
+	features []string
+	tokens   int
+}
+
+func (s *usageSink) RecordUsage(ctx context.Context, feature, model string, usage Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.features = append(s.features, feature)
+	s.tokens += usage.TotalTokens
+}
+
+func newTestClient(provider Provider, maxRetries int) *Client {
+	return NewClient(provider, Options{
+		Timeout:     time.Second,
+		MaxRetries:  maxRetries,
+		BaseBackoff: time.Millisecond,
+	}, zap.NewNop())
+}
+
+// failingResponder fails the first n calls with err, then answers with content.
+func failingResponder(n int, err error, content string) FakeResponder {
+	calls := 0
+	return func(req Request) (string, error) {
+		calls++
+		if calls <= n {
+			return "", err
+		}
+		return content, nil
+	}
+}
+
+func TestFakeIsDeterministic(t *testing.T) {
+	client := newTestClient(NewFake(nil), 0)
+	req := UserPrompt(FeatureChat, "test-model", "hello")
+
+	first, err := client.CompleteText(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := client.CompleteText(context.Background(), req)
+	if first != second || first != "[fake:test-model] hello" {
+		t.Fatalf("responses = %q, %q", first, second)
+	}
+}
+
+func TestCompleteRetriesRetryableErrors(t *testing.T) {
+	fake := NewFake(failingResponder(2, &Error{Kind: ErrorKindRateLimit, Provider: "Fake", StatusCode: 429}, "ok"))
+	client := newTestClient(fake, 2)
+
+	text, err := client.CompleteText(context.Background(), UserPrompt(FeatureChat, "m", "q"))
+	if err != nil || text != "ok" {
+		t.Fatalf("CompleteText = %q, %v", text, err)
+	}
+	if got := len(fake.Calls()); got != 3 {
+		t.Fatalf("provider calls = %d, want 3", got)
+	}
+}
+
+func TestCompleteGivesUpAfterMaxRetries(t *testing.T) {
+	fake := NewFake(failingResponder(10, &Error{Kind: ErrorKindServer, Provider: "Fake", StatusCode: 502}, "ok"))
+	client := newTestClient(fake, 1)
+
+	_, err := client.Complete(context.Background(), UserPrompt(FeatureChat, "m", "q"))
+	if KindOf(err) != ErrorKindServer {
+		t.Fatalf("error = %v, want server error", err)
+	}
+	if got := len(fake.Calls()); got != 2 {
+		t.Fatalf("provider calls = %d, want 2", got)
+	}
+}
+
+func TestCompleteDoesNotRetryPermanentErrors(t *testing.T) {
+	fake := NewFake(failingResponder(10, &Error{Kind: ErrorKindAuth, Provider: "Fake", StatusCode: 401}, "ok"))
+	client := newTestClient(fake, 3)
+
+	_, err := client.Complete(context.Background(), UserPrompt(FeatureChat, "m", "q"))
+	if KindOf(err) != ErrorKindAuth {
+		t.Fatalf("error = %v, want auth error", err)
+	}
+	if got := len(fake.Calls()); got != 1 {
+		t.Fatalf("provider calls = %d, want 1", got)
+	}
+}
+
+func TestCompleteJSON(t *testing.T) {
+	fake := NewFake(func(req Request) (string, error) {
+		if !req.JSON {
+			t.Error("CompleteJSON did not request JSON mode")
+		}
+		return "Here you go:\n```json\n{\"name\": \"vibe\"}\n```", nil
+	})
+	client := newTestClient(fake, 0)
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if _, err := client.CompleteJSON(context.Background(), UserPrompt(FeatureEntities, "m", "q"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "vibe" {
+		t.Fatalf("decoded = %+v", out)
+	}
+}
+
+func TestCompleteJSONInvalidResponse(t *testing.T) {
+	client := newTestClient(NewFake(func(req Request) (string, error) {
+		return "I cannot answer that.", nil
+	}), 0)
+
+	var out map[string]interface{}
+	resp, err := client.CompleteJSON(context.Background(), UserPrompt(FeatureEntities, "m", "q"), &out)
+	if KindOf(err) != ErrorKindBadResponse {
+		t.Fatalf("error = %v, want bad_response", err)
+	}
+	if resp == nil || resp.Content != "I cannot answer that." {
+		t.Fatalf("response = %+v, want the raw completion", resp)
+	}
+}
+
+func TestUsageIsRecorded(t *testing.T) {
+	sink := &usageSink{}
+	client := newTestClient(NewFake(nil), 0)
+	client.SetUsageRecorder(sink)
+
+	if _, err := client.Complete(context.Background(), UserPrompt(FeatureSummary, "m", "summarize this")); err != nil {
+		t.Fatal(err)
+	}
+	events, err := client.Stream(context.Background(), UserPrompt(FeatureChat, "m", "stream this please"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for event := range events {
+		text += event.Delta
+	}
+	if text != "[fake:m] stream this please" {
+		t.Fatalf("streamed text = %q", text)
+	}
+
+	if len(sink.features) != 2 || sink.features[0] != FeatureSummary || sink.features[1] != FeatureChat {
+		t.Fatalf("recorded features = %v", sink.features)
+	}
+	if sink.tokens == 0 {
+		t.Fatal("no tokens recorded")
+	}
+	if totals := client.Totals(); totals[FeatureSummary].Calls != 1 || totals[FeatureChat].Calls != 1 {
+		t.Fatalf("totals = %+v", totals)
+	}
+}
+
+func TestCompleteStopsOnCanceledContext(t *testing.T) {
+	client := newTestClient(NewFake(nil), 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.Complete(ctx, UserPrompt(FeatureChat, "m", "q"))
+	if KindOf(err) != ErrorKindCanceled {
+		t.Fatalf("error = %v, want canceled", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v should wrap context.Canceled", err)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                        `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":        `{"a": 1}`,
+		"```\n[1, 2]\n```":                `[1, 2]`,
+		"Sure! {\"a\": 1} Hope it helps.": `{"a": 1}`,
+		"no json here":                    "no json here",
+	}
+	for in, want := range tests {
+		if got := ExtractJSON(in); got != want {
+			t.Errorf("ExtractJSON(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCancelledStreamRecordsEstimatedUsage(t *testing.T) {
+	sink := &usageSink{}
+	long := strings.Repeat("word ", 500)
+	client := newTestClient(NewFake(func(req Request) (string, error) { return long, nil }), 0)
+	client.SetUsageRecorder(sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := client.Stream(ctx, UserPrompt(FeatureChat, "m", "q"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-events
+	cancel() // the client disconnects before the final usage event
+	for range events {
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.features) != 1 || sink.features[0] != FeatureChat {
+		t.Fatalf("recorded features = %v, want one chat call", sink.features)
+	}
+	full := estimateUsage(UserPrompt(FeatureChat, "m", "q"), long)
+	if sink.tokens == 0 || sink.tokens >= full.TotalTokens {
+		t.Fatalf("recorded %d tokens, want the partial stream (< %d)", sink.tokens, full.TotalTokens)
+	}
+}
@@ -0,0 +1,18 @@
+package llm
+
+import "context"
+
+type contextKey int
+
+const userIDKey contextKey = iota
+
+// WithUserID attributes LLM calls made with ctx to a user for usage accounting and budgets.
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the user LLM calls made with ctx are attributed to.
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(userIDKey).(uint)
+	return userID, ok && userID != 0
+}
@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// EmbeddingRequest describes an embeddings request.
+type EmbeddingRequest struct {
+	// Feature attributes the call for usage accounting (see Feature* constants).
+	Feature string
+	Model   string
+	Inputs  []string
+}
+
+// EmbeddingResponse is the result of an embeddings request. Vectors are in input order.
+type EmbeddingResponse struct {
+	Vectors [][]float32
+	Model   string
+	Usage   Usage
+}
+
+// Embedder is implemented by providers that can compute text embeddings.
+type Embedder interface {
+	Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// CanEmbed reports whether the provider implements Embedder.
+func (c *Client) CanEmbed() bool {
+	_, ok := c.provider.(Embedder)
+	return ok
+}
+
+// Embed computes embeddings, retrying retryable failures with exponential backoff.
+// Returns an ErrorKindUnsupported error if the provider cannot embed.
+func (c *Client) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	embedder, ok := c.provider.(Embedder)
+	if !ok {
+		return nil, &Error{Kind: ErrorKindUnsupported, Provider: c.provider.Name(), Message: "provider does not support embeddings"}
+	}
+	if req.Feature == "" {
+		req.Feature = FeatureEmbedding
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, attempt); err != nil {
+				return nil, lastErr
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+		resp, err := embedder.Embed(attemptCtx, req)
+		cancel()
+
+		if err == nil {
+			c.account(ctx, Request{Feature: req.Feature, Model: req.Model}, resp.Model, resp.Usage)
+			return resp, nil
+		}
+
+		lastErr = err
+		if !IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+		c.log.Warn("LLM embedding call failed, retrying",
+			zap.String("provider", c.provider.Name()),
+			zap.String("model", req.Model),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+	}
+	return nil, lastErr
+}
+
+// Embed implements Embedder using the OpenAI /embeddings endpoint.
+func (p *OpenAICompatible) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	start := time.Now()
+	resp, err := p.post(ctx, "/embeddings", map[string]interface{}{
+		"model": req.Model,
+		"input": req.Inputs,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, classifyTransportError(p.name, ctx, err)
+	}
+
+	var result struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage *wireUsage `json:"usage"`
+		Error *wireError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &Error{Kind: ErrorKindBadResponse, Provider: p.name, Message: "failed to parse embeddings response", Err: err}
+	}
+	if result.Error != nil {
+		return nil, &Error{Kind: ErrorKindServer, Provider: p.name, Message: result.Error.Message}
+	}
+	if len(result.Data) != len(req.Inputs) {
+		return nil, &Error{Kind: ErrorKindBadResponse, Provider: p.name, Message: "embeddings count does not match inputs"}
+	}
+
+	out := &EmbeddingResponse{
+		Vectors: make([][]float32, len(req.Inputs)),
+		Model:   result.Model,
+	}
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(out.Vectors) {
+			return nil, &Error{Kind: ErrorKindBadResponse, Provider: p.name, Message: "embedding index out of range"}
+		}
+		out.Vectors[d.Index] = d.Embedding
+	}
+	if out.Model == "" {
+		out.Model = req.Model
+	}
+	if usage := result.Usage.toUsage(); usage != nil {
+		out.Usage = *usage
+	} else {
+		out.Usage = estimateEmbeddingUsage(req.Model, req.Inputs)
+	}
+
+	p.log.Debug("Embeddings computed",
+		zap.String("provider", p.name),
+		zap.Int("inputs", len(req.Inputs)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return out, nil
+}
+
+// fakeEmbeddingDims is the dimensionality of Fake embeddings.
+const fakeEmbeddingDims = 64
+
+// Embed implements Embedder with deterministic hashed bag-of-words vectors,
+// so texts sharing words are close to each other.
+func (f *Fake) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, classifyTransportError(f.Name(), ctx, err)
+	}
+
+	out := &EmbeddingResponse{Vectors: make([][]float32, len(req.Inputs)), Model: req.Model}
+	for i, input := range req.Inputs {
+		vec := make([]float32, fakeEmbeddingDims)
+		for _, word := range strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vec[h.Sum32()%fakeEmbeddingDims]++
+		}
+		out.Vectors[i] = normalize(vec)
+	}
+	out.Usage = estimateEmbeddingUsage(req.Model, req.Inputs)
+	return out, nil
+}
+
+// estimateEmbeddingUsage builds an estimated Usage for embedding inputs.
+func estimateEmbeddingUsage(model string, inputs []string) Usage {
+	total := 0
+	for _, input := range inputs {
+		total += EstimateTokensForModel(model, input)
+	}
+	return Usage{PromptTokens: total, TotalTokens: total, Estimated: true}
+}
+
+// normalize scales v to unit length in place.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+// CosineSimilarity returns the cosine similarity of a and b (0 if their lengths differ).
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind classifies provider failures.
+type ErrorKind string
+
+const (
+	ErrorKindNotConfigured  ErrorKind = "not_configured"  // missing API key or base URL
+	ErrorKindAuth           ErrorKind = "auth"            // invalid API key (401/403)
+	ErrorKindRateLimit      ErrorKind = "rate_limit"      // 429
+	ErrorKindInvalidRequest ErrorKind = "invalid_request" // other 4xx
+	ErrorKindServer         ErrorKind = "server"          // 5xx
+	ErrorKindTimeout        ErrorKind = "timeout"         // deadline exceeded
+	ErrorKindNetwork        ErrorKind = "network"         // connection failures
+	ErrorKindBadResponse    ErrorKind = "bad_response"    // unparsable or empty response
+	ErrorKindCanceled       ErrorKind = "canceled"        // caller cancelled the context
+	ErrorKindUnsupported    ErrorKind = "unsupported"     // provider does not implement the operation
+)
+
+// Error is returned by providers and the Client.
+type Error struct {
+	Kind       ErrorKind
+	Provider   string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s API error (%s, status %d): %s", e.Provider, e.Kind, e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s API error (%s): %s", e.Provider, e.Kind, msg)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable reports whether the call may succeed if retried.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimit, ErrorKindServer, ErrorKindTimeout, ErrorKindNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// KindOf returns the ErrorKind of err, or "" if err is not an *Error.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ""
+}
+
+// IsRetryable reports whether err is a retryable *Error.
+func IsRetryable(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Retryable()
+}
+
+// classifyStatus maps an HTTP status code to an ErrorKind.
+func classifyStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindAuth
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimit
+	case status == http.StatusRequestTimeout:
+		return ErrorKindTimeout
+	case status >= 500:
+		return ErrorKindServer
+	default:
+		return ErrorKindInvalidRequest
+	}
+}
+
+// classifyTransportError wraps an error returned by the HTTP client.
+func classifyTransportError(provider string, ctx context.Context, err error) *Error {
+	kind := ErrorKindNetwork
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		kind = ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		kind = ErrorKindTimeout
+	}
+	return &Error{Kind: kind, Provider: provider, Err: err}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := map[int]ErrorKind{
+		http.StatusUnauthorized:        ErrorKindAuth,
+		http.StatusForbidden:           ErrorKindAuth,
+		http.StatusTooManyRequests:     ErrorKindRateLimit,
+		http.StatusRequestTimeout:      ErrorKindTimeout,
+		http.StatusBadRequest:          ErrorKindInvalidRequest,
+		http.StatusNotFound:            ErrorKindInvalidRequest,
+		http.StatusInternalServerError: ErrorKindServer,
+		http.StatusBadGateway:          ErrorKindServer,
+	}
+	for status, want := range tests {
+		if got := classifyStatus(status); got != want {
+			t.Errorf("classifyStatus(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	retryable := []ErrorKind{ErrorKindRateLimit, ErrorKindServer, ErrorKindTimeout, ErrorKindNetwork}
+	for _, kind := range retryable {
+		err := fmt.Errorf("wrapped: %w", &Error{Kind: kind})
+		if !IsRetryable(err) {
+			t.Errorf("%s should be retryable", kind)
+		}
+	}
+
+	permanent := []ErrorKind{ErrorKindNotConfigured, ErrorKindAuth, ErrorKindInvalidRequest, ErrorKindBadResponse, ErrorKindCanceled, ErrorKindUnsupported}
+	for _, kind := range permanent {
+		if IsRetryable(&Error{Kind: kind}) {
+			t.Errorf("%s should not be retryable", kind)
+		}
+	}
+
+	if IsRetryable(errors.New("plain")) {
+		t.Error("plain errors should not be retryable")
+	}
+	if KindOf(errors.New("plain")) != "" {
+		t.Error("KindOf(plain error) should be empty")
+	}
+}
+
+func TestClassifyTransportError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := classifyTransportError("p", canceled, context.Canceled); got.Kind != ErrorKindCanceled {
+		t.Errorf("canceled context classified as %s", got.Kind)
+	}
+
+	if got := classifyTransportError("p", context.Background(), context.DeadlineExceeded); got.Kind != ErrorKindTimeout {
+		t.Errorf("deadline classified as %s", got.Kind)
+	}
+
+	if got := classifyTransportError("p", context.Background(), errors.New("connection refused")); got.Kind != ErrorKindNetwork {
+		t.Errorf("connection error classified as %s", got.Kind)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &Error{Kind: ErrorKindRateLimit, Provider: "OpenRouter", StatusCode: 429, Message: "slow down"}
+	if got, want := err.Error(), "OpenRouter API error (rate_limit, status 429): slow down"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
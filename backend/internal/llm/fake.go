@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeResponder produces the completion for a request.
+type FakeResponder func(req Request) (string, error)
+
+// Fake is a deterministic Provider for local development and tests. By default
+// it echoes the last user message (so numbered translation prompts round-trip)
+// and answers JSON-mode requests with an empty object.
+type Fake struct {
+	mu        sync.Mutex
+	responder FakeResponder
+	calls     []Request
+}
+
+// NewFake creates a fake provider. A nil responder uses the default echo behaviour.
+func NewFake(responder FakeResponder) *Fake {
+	if responder == nil {
+		responder = defaultFakeResponder
+	}
+	return &Fake{responder: responder}
+}
+
+func defaultFakeResponder(req Request) (string, error) {
+	if req.JSON {
+		return "{}", nil
+	}
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			return fmt.Sprintf("[fake:%s] %s", req.Model, req.Messages[i].Content), nil
+		}
+	}
+	return fmt.Sprintf("[fake:%s]", req.Model), nil
+}
+
+// Name implements Provider.
+func (f *Fake) Name() string {
+	return "Fake"
+}
+
+// Calls returns the requests received so far.
+func (f *Fake) Calls() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.calls...)
+}
+
+func (f *Fake) respond(req Request) (string, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, req)
+	f.mu.Unlock()
+	return f.responder(req)
+}
+
+// Complete implements Provider.
+func (f *Fake) Complete(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, classifyTransportError(f.Name(), ctx, err)
+	}
+	content, err := f.respond(req)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Content:      content,
+		Model:        req.Model,
+		FinishReason: "stop",
+		Usage:        estimateUsage(req, content),
+	}, nil
+}
+
+// Stream implements Provider. The completion is emitted word by word.
+func (f *Fake) Stream(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, classifyTransportError(f.Name(), ctx, err)
+	}
+	content, err := f.respond(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent, 100)
+	go func() {
+		defer close(events)
+		words := strings.SplitAfter(content, " ")
+		for _, w := range words {
+			if w == "" {
+				continue
+			}
+			select {
+			case events <- StreamEvent{Delta: w}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		usage := estimateUsage(req, content)
+		events <- StreamEvent{Done: true, Usage: &usage}
+	}()
+	return events, nil
+}
@@ -0,0 +1,97 @@
+// Package llm provides a provider-agnostic client for chat-completion style LLM APIs.
+//
+// Services talk to a *Client, which wraps a Provider (OpenRouter, any
+// OpenAI-compatible endpoint, or a deterministic fake) with shared timeout,
+// retry, error classification and token accounting.
+package llm
+
+import "context"
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Features identify the product feature making a call. They are used to pick
+// per-feature models and to attribute token usage.
+const (
+	FeatureChat        = "chat"
+	FeatureEntities    = "entities"
+	FeatureTranslation = "translation"
+	FeatureSummary     = "summary"
+	FeatureVideo       = "video"
+	FeatureEmbedding   = "embedding"
+	FeatureChatMemory  = "chat_memory"
+)
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request describes a completion request.
+type Request struct {
+	// Feature attributes the call for usage accounting (see Feature* constants).
+	Feature  string
+	Model    string
+	Messages []Message
+	// Temperature is optional; nil leaves the provider default.
+	Temperature *float64
+	// MaxTokens is optional; 0 leaves the provider default.
+	MaxTokens int
+	// JSON asks the provider to return a single JSON object.
+	JSON bool
+}
+
+// Usage reports token consumption of a call.
+type Usage struct {
+	PromptTokens     int  `json:"prompt_tokens"`
+	CompletionTokens int  `json:"completion_tokens"`
+	TotalTokens      int  `json:"total_tokens"`
+	Estimated        bool `json:"estimated"` // true when the provider did not report usage
+}
+
+// Response is the result of a completion request.
+type Response struct {
+	Content      string
+	Model        string
+	FinishReason string
+	Usage        Usage
+}
+
+// StreamEvent is a single event of a streaming completion. The last event has
+// Done set and carries the usage, or carries Err if the stream failed.
+type StreamEvent struct {
+	Delta string
+	Done  bool
+	Usage *Usage
+	Err   error
+}
+
+// Provider is an LLM backend.
+type Provider interface {
+	// Name identifies the provider in logs and errors.
+	Name() string
+	// Complete performs a non-streaming completion.
+	Complete(ctx context.Context, req Request) (*Response, error)
+	// Stream performs a streaming completion. The returned channel is closed
+	// after the final event.
+	Stream(ctx context.Context, req Request) (<-chan StreamEvent, error)
+}
+
+// Float returns a pointer to f, for Request.Temperature.
+func Float(f float64) *float64 {
+	return &f
+}
+
+// UserPrompt builds a request with a single user message.
+func UserPrompt(feature, model, prompt string) Request {
+	return Request{
+		Feature:  feature,
+		Model:    model,
+		Messages: []Message{{Role: RoleUser, Content: prompt}},
+	}
+}
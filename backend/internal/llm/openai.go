@@ -0,0 +1,328 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const openRouterBaseURL = "https://openrouter.ai/api/v1"
+
+// OpenAICompatible is a Provider for any endpoint implementing the OpenAI
+// chat completions API. OpenRouter is configured through NewOpenRouter.
+type OpenAICompatible struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	keyEnv     string // environment variable holding the key, for error hints
+	headers    map[string]string
+	httpClient *http.Client
+	log        *zap.Logger
+}
+
+// NewOpenRouter creates a provider for the OpenRouter API.
+func NewOpenRouter(apiKey string, log *zap.Logger) *OpenAICompatible {
+	p := newOpenAICompatible("OpenRouter", openRouterBaseURL, apiKey, "OPENROUTER_API_KEY", log)
+	p.headers["HTTP-Referer"] = "https://github.com/your-repo/vibe-engineering-playbook"
+	p.headers["X-Title"] = "VIBE Engineering Playbook"
+	return p
+}
+
+// NewOpenAICompatible creates a provider for an OpenAI-compatible endpoint,
+// e.g. "https://api.openai.com/v1" or a self-hosted gateway.
+func NewOpenAICompatible(baseURL, apiKey string, log *zap.Logger) *OpenAICompatible {
+	return newOpenAICompatible("OpenAI-compatible", baseURL, apiKey, "LLM_API_KEY", log)
+}
+
+func newOpenAICompatible(name, baseURL, apiKey, keyEnv string, log *zap.Logger) *OpenAICompatible {
+	if apiKey == "" {
+		log.Warn("⚠️  LLM API 密钥未设置",
+			zap.String("provider", name),
+			zap.String("环境变量", keyEnv),
+			zap.String("影响功能", "聊天、翻译、摘要、视频分析等AI功能将无法使用"),
+		)
+	} else {
+		log.Info("✅ LLM provider 已初始化",
+			zap.String("provider", name),
+			zap.String("base_url", baseURL),
+			zap.String("api_key", maskKey(apiKey)),
+		)
+	}
+
+	return &OpenAICompatible{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		keyEnv:  keyEnv,
+		headers: map[string]string{},
+		// Timeouts are applied per call by Client through the request context
+		httpClient: &http.Client{},
+		log:        log,
+	}
+}
+
+// Name implements Provider.
+func (p *OpenAICompatible) Name() string {
+	return p.name
+}
+
+// chatRequest is the wire format of a chat completion request.
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []Message         `json:"messages"`
+	Temperature    *float64          `json:"temperature,omitempty"`
+	MaxTokens      int               `json:"max_tokens,omitempty"`
+	Stream         bool              `json:"stream,omitempty"`
+	StreamOptions  map[string]bool   `json:"stream_options,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+// wireUsage is the usage block of a chat completion response.
+type wireUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *wireUsage) toUsage() *Usage {
+	if u == nil || (u.PromptTokens == 0 && u.CompletionTokens == 0) {
+		return nil
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: total}
+}
+
+// wireError is the error block some providers return with a 200 status.
+type wireError struct {
+	Message string      `json:"message"`
+	Code    interface{} `json:"code"`
+}
+
+// Complete implements Provider.
+func (p *OpenAICompatible) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := p.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, classifyTransportError(p.name, ctx, err)
+	}
+
+	var result struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *wireUsage `json:"usage"`
+		Error *wireError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &Error{Kind: ErrorKindBadResponse, Provider: p.name, Message: "failed to parse response", Err: err}
+	}
+	if result.Error != nil {
+		return nil, &Error{Kind: ErrorKindServer, Provider: p.name, Message: fmt.Sprintf("%s (code: %v)", result.Error.Message, result.Error.Code)}
+	}
+	if len(result.Choices) == 0 {
+		return nil, &Error{Kind: ErrorKindBadResponse, Provider: p.name, Message: "no choices returned"}
+	}
+
+	out := &Response{
+		Content:      result.Choices[0].Message.Content,
+		Model:        result.Model,
+		FinishReason: result.Choices[0].FinishReason,
+	}
+	if out.Model == "" {
+		out.Model = req.Model
+	}
+	if usage := result.Usage.toUsage(); usage != nil {
+		out.Usage = *usage
+	} else {
+		out.Usage = estimateUsage(req, out.Content)
+	}
+	return out, nil
+}
+
+// Stream implements Provider.
+func (p *OpenAICompatible) Stream(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	resp, err := p.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent, 100)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		var content strings.Builder
+		var usage *Usage
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			// Skip empty lines and comments
+			if line == "" || strings.HasPrefix(line, ":") || !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+				Usage *wireUsage `json:"usage"`
+				Error *wireError `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != nil {
+				send(ctx, events, StreamEvent{Err: &Error{Kind: ErrorKindServer, Provider: p.name, Message: chunk.Error.Message}})
+				return
+			}
+			if u := chunk.Usage.toUsage(); u != nil {
+				usage = u
+			}
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				delta := chunk.Choices[0].Delta.Content
+				content.WriteString(delta)
+				if !send(ctx, events, StreamEvent{Delta: delta}) {
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(ctx, events, StreamEvent{Err: classifyTransportError(p.name, ctx, err)})
+			return
+		}
+
+		if usage == nil {
+			estimated := estimateUsage(req, content.String())
+			usage = &estimated
+		}
+		send(ctx, events, StreamEvent{Done: true, Usage: usage})
+	}()
+
+	return events, nil
+}
+
+// send delivers a stream event unless ctx is cancelled first, so an abandoned
+// stream does not block its goroutine. The client accounts the usage of
+// streams that end without a final event.
+func send(ctx context.Context, events chan<- StreamEvent, event StreamEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// do sends a chat completion request and returns the response if it has a 200 status.
+func (p *OpenAICompatible) do(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	body := chatRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if stream {
+		body.StreamOptions = map[string]bool{"include_usage": true}
+	}
+	if req.JSON {
+		body.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+	return p.post(ctx, "/chat/completions", body, stream)
+}
+
+// post sends a JSON request to path and returns the response if it has a 200 status.
+func (p *OpenAICompatible) post(ctx context.Context, path string, body interface{}, stream bool) (*http.Response, error) {
+	if p.apiKey == "" {
+		return nil, &Error{
+			Kind:     ErrorKindNotConfigured,
+			Provider: p.name,
+			Message:  fmt.Sprintf("%s environment variable is not set. Please configure it to use AI features", p.keyEnv),
+		}
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindInvalidRequest, Provider: p.name, Message: "failed to marshal request", Err: err}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindInvalidRequest, Provider: p.name, Message: "failed to create request", Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	for k, v := range p.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, classifyTransportError(p.name, ctx, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		kind := classifyStatus(resp.StatusCode)
+
+		message := string(errBody)
+		if kind == ErrorKindAuth {
+			p.log.Error("❌ LLM API 认证失败 - API密钥无效",
+				zap.String("provider", p.name),
+				zap.Int("status_code", resp.StatusCode),
+				zap.String("response_body", message),
+				zap.String("api_key_prefix", maskKey(p.apiKey)),
+				zap.String("error_type", "AUTHENTICATION_FAILED"),
+				zap.String("解决方案", fmt.Sprintf("请检查 %s 环境变量", p.keyEnv)),
+			)
+			message = fmt.Sprintf("认证失败（%d）: %s - 请检查 %s 是否有效", resp.StatusCode, message, p.keyEnv)
+		}
+		return nil, &Error{Kind: kind, Provider: p.name, StatusCode: resp.StatusCode, Message: message}
+	}
+
+	return resp, nil
+}
+
+// maskKey returns a masked version of an API key for logging.
+func maskKey(key string) string {
+	if key == "" {
+		return "<未设置>"
+	}
+	if len(key) <= 14 {
+		return "***"
+	}
+	return key[:10] + "..." + key[len(key)-4:]
+}
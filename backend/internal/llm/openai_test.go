@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestOpenAICompatibleRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"model": "m", "choices": [{"message": {"content": "hi"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(NewOpenAICompatible(server.URL, "test-key", zap.NewNop()), 2)
+	resp, err := client.Complete(context.Background(), UserPrompt(FeatureChat, "m", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "hi" || resp.Usage.TotalTokens != 4 || resp.Usage.Estimated {
+		t.Fatalf("response = %+v", resp)
+	}
+	if calls != 2 {
+		t.Fatalf("server calls = %d, want 2", calls)
+	}
+}
+
+func TestOpenAICompatibleClassifiesStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := newTestClient(NewOpenAICompatible(server.URL, "test-key", zap.NewNop()), 2)
+	_, err := client.Complete(context.Background(), UserPrompt(FeatureChat, "m", "hello"))
+	if KindOf(err) != ErrorKindAuth {
+		t.Fatalf("error = %v, want auth error", err)
+	}
+}
+
+func TestOpenAICompatibleWithoutKey(t *testing.T) {
+	client := newTestClient(NewOpenAICompatible("http://127.0.0.1:0", "", zap.NewNop()), 2)
+	_, err := client.Complete(context.Background(), UserPrompt(FeatureChat, "m", "hello"))
+	if KindOf(err) != ErrorKindNotConfigured {
+		t.Fatalf("error = %v, want not_configured", err)
+	}
+}
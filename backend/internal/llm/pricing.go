@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	PromptPerMTok     float64
+	CompletionPerMTok float64
+}
+
+// PriceTable maps model names to prices. The "*" entry applies to models
+// without an explicit price.
+type PriceTable map[string]Price
+
+// ParsePriceTable parses a comma-separated list of model=prompt/completion
+// prices in USD per million tokens, e.g.
+// "google/gemini-3-flash-preview=0.5/3,anthropic/claude-3-5-sonnet=3/15,*=1/4".
+func ParsePriceTable(spec string) (PriceTable, error) {
+	table := PriceTable{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eq := strings.LastIndex(entry, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid price entry %q: expected model=prompt/completion", entry)
+		}
+		model := strings.TrimSpace(entry[:eq])
+		parts := strings.Split(entry[eq+1:], "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid price entry %q: expected model=prompt/completion", entry)
+		}
+
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price in %q: %w", entry, err)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price in %q: %w", entry, err)
+		}
+		table[model] = Price{PromptPerMTok: prompt, CompletionPerMTok: completion}
+	}
+	return table, nil
+}
+
+// Cost returns the USD cost of usage on model (0 when the model has no price).
+func (t PriceTable) Cost(model string, usage Usage) float64 {
+	price, ok := t[model]
+	if !ok {
+		price, ok = t["*"]
+	}
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.PromptPerMTok +
+		float64(usage.CompletionTokens)*price.CompletionPerMTok) / 1_000_000
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenProfile describes how a model family's tokenizer splits text.
+type tokenProfile struct {
+	charsPerToken    float64 // non-CJK characters per token
+	cjkTokensPerChar float64 // tokens per CJK character
+}
+
+// defaultTokenProfile is used for unknown models: CJK characters count as one
+// token each, other text as one token per four characters.
+var defaultTokenProfile = tokenProfile{charsPerToken: 4, cjkTokensPerChar: 1}
+
+// modelTokenProfiles are rough tokenizer ratios per model family, matched by
+// substring of the lowercased model name in order.
+var modelTokenProfiles = []struct {
+	match   string
+	profile tokenProfile
+}{
+	{"claude", tokenProfile{charsPerToken: 3.5, cjkTokensPerChar: 1.2}},
+	{"gpt-4o", tokenProfile{charsPerToken: 4, cjkTokensPerChar: 0.8}},
+	{"gpt-4.1", tokenProfile{charsPerToken: 4, cjkTokensPerChar: 0.8}},
+	{"gpt-5", tokenProfile{charsPerToken: 4, cjkTokensPerChar: 0.8}},
+	{"gpt", tokenProfile{charsPerToken: 4, cjkTokensPerChar: 1.3}},
+	{"gemini", tokenProfile{charsPerToken: 4, cjkTokensPerChar: 0.7}},
+	{"deepseek", tokenProfile{charsPerToken: 3.8, cjkTokensPerChar: 0.6}},
+	{"qwen", tokenProfile{charsPerToken: 3.8, cjkTokensPerChar: 0.6}},
+}
+
+// profileFor returns the token profile of model.
+func profileFor(model string) tokenProfile {
+	model = strings.ToLower(model)
+	for _, p := range modelTokenProfiles {
+		if strings.Contains(model, p.match) {
+			return p.profile
+		}
+	}
+	return defaultTokenProfile
+}
+
+// EstimateTokens roughly estimates the number of tokens in text for an unknown
+// model. It is used when a provider does not report usage.
+func EstimateTokens(text string) int {
+	return defaultTokenProfile.estimate(text)
+}
+
+// EstimateTokensForModel estimates the number of tokens in text for model's tokenizer.
+func EstimateTokensForModel(model, text string) int {
+	return profileFor(model).estimate(text)
+}
+
+func (p tokenProfile) estimate(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	tokens := float64(cjk)*p.cjkTokensPerChar + float64(other)/p.charsPerToken
+	if tokens > 0 && tokens < 1 {
+		return 1
+	}
+	return int(tokens + 0.5)
+}
+
+// EstimateMessagesTokens estimates the prompt tokens of a message list,
+// including a small per-message overhead for role markers.
+func EstimateMessagesTokens(messages []Message) int {
+	return EstimateMessagesTokensForModel("", messages)
+}
+
+// EstimateMessagesTokensForModel estimates the prompt tokens of a message list for model.
+func EstimateMessagesTokensForModel(model string, messages []Message) int {
+	profile := profileFor(model)
+	total := 0
+	for _, m := range messages {
+		total += profile.estimate(m.Content) + 4
+	}
+	return total
+}
+
+// estimateUsage builds an estimated Usage for a request and its completion.
+func estimateUsage(req Request, completion string) Usage {
+	prompt := EstimateMessagesTokensForModel(req.Model, req.Messages)
+	out := EstimateTokensForModel(req.Model, completion)
+	return Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: out,
+		TotalTokens:      prompt + out,
+		Estimated:        true,
+	}
+}
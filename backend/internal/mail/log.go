@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer writes email to the log instead of sending it.
+type LogMailer struct {
+	log *zap.Logger
+}
+
+// NewLogMailer creates a new LogMailer.
+func NewLogMailer(log *zap.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+// Send implements Mailer.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.Info("📧 Email (not sent, MAIL_DRIVER=log)",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// unsafeFileChars matches characters not allowed in generated file names.
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// FileMailer writes every email as an .eml file into a directory.
+type FileMailer struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileMailer creates a new FileMailer writing into dir.
+func NewFileMailer(dir, from string) *FileMailer {
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	return &FileMailer{dir: dir, from: from}
+}
+
+// Send implements Mailer.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%03d-%s.eml", now.Format("20060102T150405"), m.seq%1000, unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	m.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, msg, now), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
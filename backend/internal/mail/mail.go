@@ -0,0 +1,64 @@
+// Package mail sends transactional email (account verification, password
+// reset) through a pluggable Mailer: SMTP in production, or the log and
+// file mailers for local development and tests.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Mailer drivers accepted by NewMailer.
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a Mailer.
+type Config struct {
+	Driver string // log (default), file, smtp
+	From   string
+	Dir    string // output directory for the file driver
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// NewMailer creates the mailer described by cfg.
+func NewMailer(cfg Config, log *zap.Logger) (Mailer, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", DriverLog:
+		log.Warn("⚠️  MAIL_DRIVER=log，邮件只会写入日志，不会真正发送")
+		return NewLogMailer(log), nil
+	case DriverFile:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("MAIL_DIR is required for the %q mail driver", cfg.Driver)
+		}
+		return NewFileMailer(cfg.Dir, cfg.From), nil
+	case DriverSMTP:
+		if cfg.SMTPHost == "" || cfg.From == "" {
+			return nil, fmt.Errorf("SMTP_HOST and MAIL_FROM are required for the %q mail driver", cfg.Driver)
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
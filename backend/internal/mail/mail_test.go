@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestFileMailerWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFileMailer(dir, "VIBE <no-reply@example.com>")
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "ada@example.com",
+		Subject: "验证邮箱",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want one .eml", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	content := string(data)
+	for _, want := range []string{
+		"From: VIBE <no-reply@example.com>\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("message missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestFormatMessageDate(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := string(formatMessage("a@example.com", Message{To: "b@example.com", Subject: "Hi"}, date))
+	if !strings.Contains(content, "Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n") {
+		t.Fatalf("unexpected message:\n%s", content)
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	log := zap.NewNop()
+	if m, err := NewMailer(Config{}, log); err != nil {
+		t.Fatal(err)
+	} else if _, ok := m.(*LogMailer); !ok {
+		t.Fatalf("default mailer = %T, want *LogMailer", m)
+	}
+
+	if _, err := NewMailer(Config{Driver: DriverFile}, log); err == nil {
+		t.Fatal("file driver without MAIL_DIR should fail")
+	}
+	if _, err := NewMailer(Config{Driver: DriverSMTP, From: "a@example.com"}, log); err == nil {
+		t.Fatal("smtp driver without host should fail")
+	}
+	if _, err := NewMailer(Config{Driver: "pigeon"}, log); err == nil {
+		t.Fatal("unknown driver should fail")
+	}
+	if m, err := NewMailer(Config{Driver: DriverSMTP, SMTPHost: "smtp.example.com", From: "a@example.com"}, log); err != nil {
+		t.Fatal(err)
+	} else if m.(*SMTPMailer).addr != "smtp.example.com:587" {
+		t.Fatalf("smtp addr = %s", m.(*SMTPMailer).addr)
+	}
+}
@@ -1,30 +1,65 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"vibe-backend/internal/models"
-	"vibe-backend/internal/repository"
 )
 
 const (
-	// AuthorizationHeader is the HTTP header key for API key.
+	// AuthorizationHeader is the HTTP header key for the access token or API key.
 	AuthorizationHeader = "Authorization"
 	// UserIDKey is the context key for user ID.
 	UserIDKey = "user_id"
 	// UserKey is the context key for full user object.
 	UserKey = "user"
+	// ScopesKey is the context key for the scopes granted to the request.
+	ScopesKey = "scopes"
+	// APIKeyKey is the context key for the API key used, if any.
+	APIKeyKey = "api_key"
+	// SessionIDKey is the context key for the session of the access token used, if any.
+	SessionIDKey = "session_id"
 )
 
-// Auth returns a Gin middleware that validates API key authentication.
-func Auth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc {
+// Authenticator resolves a bearer credential to the user and scopes it grants.
+// Errors of type *models.ErrorResponse are reported to the client as 401.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*models.AuthIdentity, error)
+}
+
+// bearerCredential extracts the credential of an "Authorization: Bearer <credential>" header.
+func bearerCredential(authHeader string) (string, bool) {
+	credential := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
+	if credential == "" || credential == authHeader {
+		return "", false
+	}
+	return credential, true
+}
+
+// setIdentity stores an authenticated identity in the Gin context.
+func setIdentity(c *gin.Context, identity *models.AuthIdentity) {
+	c.Set(UserIDKey, identity.User.ID)
+	c.Set(UserKey, identity.User)
+	c.Set(ScopesKey, identity.Scopes)
+	if identity.APIKey != nil {
+		c.Set(APIKeyKey, identity.APIKey)
+	}
+	if identity.SessionID != "" {
+		c.Set(SessionIDKey, identity.SessionID)
+	}
+}
+
+// Auth returns a Gin middleware that validates access token or API key authentication.
+func Auth(authenticator Authenticator, log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetString(RequestIDKey)
 
-		// Get API key from Authorization header
+		// Get credential from Authorization header
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
 			log.Warn("Missing authorization header",
@@ -40,33 +75,49 @@ func Auth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc
 			return
 		}
 
-		// Extract API key (format: "Bearer <api_key>")
-		apiKey := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
-		if apiKey == "" || apiKey == authHeader {
+		// Extract credential (format: "Bearer <access_token|api_key>")
+		credential, ok := bearerCredential(authHeader)
+		if !ok {
 			log.Warn("Invalid authorization header format",
 				zap.String("request_id", requestID),
 				zap.String("path", c.Request.URL.Path),
 			)
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Code:      "UNAUTHORIZED",
-				Message:   "Invalid authorization header format. Use: Bearer <api_key>",
+				Message:   "Invalid authorization header format. Use: Bearer <access_token|api_key>",
 				RequestID: requestID,
 			})
 			c.Abort()
 			return
 		}
 
-		// Validate API key
-		user, err := userRepo.GetByAPIKey(c.Request.Context(), apiKey)
+		// Validate credential
+		identity, err := authenticator.Authenticate(c.Request.Context(), credential)
 		if err != nil {
-			log.Warn("Invalid API key",
+			var apiErr *models.ErrorResponse
+			if !errors.As(err, &apiErr) {
+				log.Error("Failed to authenticate request",
+					zap.String("request_id", requestID),
+					zap.String("path", c.Request.URL.Path),
+					zap.Error(err),
+				)
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Code:      "INTERNAL_SERVER_ERROR",
+					Message:   "Failed to authenticate request.",
+					RequestID: requestID,
+				})
+				c.Abort()
+				return
+			}
+
+			log.Warn("Invalid credential",
 				zap.String("request_id", requestID),
 				zap.String("path", c.Request.URL.Path),
-				zap.Error(err),
+				zap.String("reason", string(apiErr.Code)),
 			)
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Code:      "UNAUTHORIZED",
-				Message:   "Invalid API key.",
+				Code:      apiErr.Code,
+				Message:   apiErr.Message,
 				RequestID: requestID,
 			})
 			c.Abort()
@@ -74,13 +125,13 @@ func Auth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc
 		}
 
 		// Set user information in context
-		c.Set(UserIDKey, user.ID)
-		c.Set(UserKey, user)
+		setIdentity(c, identity)
 
 		log.Debug("User authenticated",
 			zap.String("request_id", requestID),
-			zap.Uint("user_id", user.ID),
-			zap.String("email", user.Email),
+			zap.Uint("user_id", identity.User.ID),
+			zap.String("email", identity.User.Email),
+			zap.Strings("scopes", identity.Scopes),
 		)
 
 		c.Next()
@@ -89,7 +140,7 @@ func Auth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc
 
 // OptionalAuth returns a Gin middleware that optionally validates authentication.
 // If authentication is provided, it validates it. If not, the request continues without user context.
-func OptionalAuth(userRepo *repository.UserRepository, log *zap.Logger) gin.HandlerFunc {
+func OptionalAuth(authenticator Authenticator, log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
@@ -97,24 +148,74 @@ func OptionalAuth(userRepo *repository.UserRepository, log *zap.Logger) gin.Hand
 			return
 		}
 
-		// Extract API key
-		apiKey := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
-		if apiKey == "" || apiKey == authHeader {
+		// Extract credential
+		credential, ok := bearerCredential(authHeader)
+		if !ok {
 			c.Next()
 			return
 		}
 
-		// Validate API key (silently ignore errors)
-		user, err := userRepo.GetByAPIKey(c.Request.Context(), apiKey)
+		// Validate credential (silently ignore errors)
+		identity, err := authenticator.Authenticate(c.Request.Context(), credential)
 		if err == nil {
-			c.Set(UserIDKey, user.ID)
-			c.Set(UserKey, user)
+			setIdentity(c, identity)
 		}
 
 		c.Next()
 	}
 }
 
+// RequireScope returns a Gin middleware that rejects requests whose credential
+// lacks scope. Read-only requests (GET, HEAD) are also allowed with the read
+// scope. Must run after Auth.
+func RequireScope(scope string, log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes := GetScopes(c)
+		readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+		if models.HasScope(scopes, scope) || (readOnly && models.HasScope(scopes, models.ScopeRead)) {
+			c.Next()
+			return
+		}
+
+		requestID := c.GetString(RequestIDKey)
+		log.Warn("Insufficient API key scope",
+			zap.String("request_id", requestID),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("required_scope", scope),
+			zap.Strings("scopes", scopes),
+		)
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Code:      models.ErrorInsufficientScope,
+			Message:   "API key lacks the required scope: " + scope,
+			RequestID: requestID,
+		})
+		c.Abort()
+	}
+}
+
+// GetScopes returns the scopes granted to the request, nil if unauthenticated.
+func GetScopes(c *gin.Context) []string {
+	scopes, _ := c.Get(ScopesKey)
+	list, _ := scopes.([]string)
+	return list
+}
+
+// GetAPIKey returns the API key the request was authenticated with.
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, exists := c.Get(APIKeyKey)
+	if !exists {
+		return nil, false
+	}
+	k, ok := key.(*models.APIKey)
+	return k, ok
+}
+
+// GetSessionID returns the session the request was authenticated with, if it used an access token.
+func GetSessionID(c *gin.Context) (string, bool) {
+	sessionID := c.GetString(SessionIDKey)
+	return sessionID, sessionID != ""
+}
+
 // GetUserID extracts the user ID from the Gin context.
 func GetUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get(UserIDKey)
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/models"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name     string
+		scopes   []string
+		method   string
+		required string
+		want     int
+	}{
+		{"admin implies insights", []string{models.ScopeAdmin}, http.MethodPost, models.ScopeInsights, http.StatusNoContent},
+		{"admin implies chat", []string{models.ScopeAdmin}, http.MethodDelete, models.ScopeChat, http.StatusNoContent},
+		{"admin implies admin", []string{models.ScopeAdmin}, http.MethodPost, models.ScopeAdmin, http.StatusNoContent},
+		{"matching scope", []string{models.ScopeChat}, http.MethodPost, models.ScopeChat, http.StatusNoContent},
+		{"read allows GET", []string{models.ScopeRead}, http.MethodGet, models.ScopeInsights, http.StatusNoContent},
+		{"read allows HEAD", []string{models.ScopeRead}, http.MethodHead, models.ScopeChat, http.StatusNoContent},
+		{"read rejects POST", []string{models.ScopeRead}, http.MethodPost, models.ScopeInsights, http.StatusForbidden},
+		{"read rejects PATCH", []string{models.ScopeRead}, http.MethodPatch, models.ScopeInsights, http.StatusForbidden},
+		{"read rejects DELETE", []string{models.ScopeRead}, http.MethodDelete, models.ScopeInsights, http.StatusForbidden},
+		{"other scope rejects writes", []string{models.ScopeChat}, http.MethodPost, models.ScopeInsights, http.StatusForbidden},
+		{"other scope rejects reads", []string{models.ScopeChat}, http.MethodGet, models.ScopeInsights, http.StatusForbidden},
+		{"no scopes", nil, http.MethodGet, models.ScopeRead, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Handle(tc.method, "/", func(c *gin.Context) {
+				c.Set(ScopesKey, tc.scopes)
+			}, RequireScope(tc.required, zap.NewNop()), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, "/", nil))
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if tc.want == http.StatusForbidden && tc.method != http.MethodHead && !strings.Contains(w.Body.String(), string(models.ErrorInsufficientScope)) {
+				t.Fatalf("body = %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// AccountExportStatus represents the state of an account export.
+type AccountExportStatus string
+
+const (
+	AccountExportStatusPending    AccountExportStatus = "pending"
+	AccountExportStatusProcessing AccountExportStatus = "processing"
+	AccountExportStatusCompleted  AccountExportStatus = "completed"
+	AccountExportStatusFailed     AccountExportStatus = "failed"
+)
+
+// AccountExport is a requested ZIP archive of everything a user owns. The
+// archive is built by an account.export job and can be downloaded until ExpiresAt.
+type AccountExport struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	UserID       uint                `json:"user_id" gorm:"index;not null"`
+	Status       AccountExportStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	FilePath     string              `json:"-" gorm:"type:varchar(500)"` // archive on local disk
+	SizeBytes    int64               `json:"size_bytes"`
+	ErrorMessage string              `json:"error_message,omitempty" gorm:"type:text"`
+	CompletedAt  *time.Time          `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty" gorm:"index"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// TableName returns the table name for AccountExport model.
+func (AccountExport) TableName() string {
+	return "account_exports"
+}
+
+// AccountData is everything a user owns, as written to an export archive.
+type AccountData struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	User          UserResponse          `json:"user"`
+	Insights      []Insight             `json:"insights"` // with highlights and chat messages
+	Translations  []Translation         `json:"translations"`
+	Pomodoros     []Pomodoro            `json:"pomodoros"`
+	VideoAnalyses []VideoAnalysisExport `json:"video_analyses"`
+}
+
+// VideoAnalysisExport is a video analysis with its chapters, transcript and key points.
+type VideoAnalysisExport struct {
+	VideoAnalysis
+	Chapters       []Chapter       `json:"chapters"`
+	Transcriptions []Transcription `json:"transcriptions"`
+	KeyPoints      []KeyPoint      `json:"key_points"`
+}
+
+// DeleteAccountRequest represents the request body for deleting the current
+// account. Password is required when the user has a password.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// RestoreAccountRequest represents the request body for cancelling an account
+// deletion with the token from the deletion email.
+type RestoreAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Account data error codes
+const (
+	ErrorExportNotFound ErrorCode = "EXPORT_NOT_FOUND"
+	ErrorExportNotReady ErrorCode = "EXPORT_NOT_READY"
+)
+
+// Account data errors
+var (
+	ErrExportNotFound = &ErrorResponse{
+		Code:    ErrorExportNotFound,
+		Message: "Export not found or expired.",
+	}
+	ErrExportNotReady = &ErrorResponse{
+		Code:    ErrorExportNotReady,
+		Message: "Export is not ready yet.",
+	}
+)
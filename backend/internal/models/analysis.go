@@ -23,7 +23,8 @@ const (
 // This model is used for demonstrating proper error handling patterns.
 type Analysis struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	Data      string         `json:"data" gorm:"type:jsonb"` // Generic data field stored as JSONB
+	UserID    uint           `json:"user_id" gorm:"index;not null;default:0"` // owner; 0 for records created before ownership
+	Data      string         `json:"data" gorm:"type:jsonb"`                  // Generic data field stored as JSONB
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// API key scopes. Admin grants every scope; read grants GET access to all routes.
+const (
+	ScopeRead     = "read"     // read-only access to all data
+	ScopeInsights = "insights" // manage insights, highlights, shares, videos, translations, pomodoros and analyses
+	ScopeChat     = "chat"     // chat about insights
+	ScopeAdmin    = "admin"    // everything, including API key management
+)
+
+// AllScopes lists the valid API key scopes.
+var AllScopes = []string{ScopeRead, ScopeInsights, ScopeChat, ScopeAdmin}
+
+// APIKey is a hashed, scoped and revocable API key. The key itself is only
+// shown once at creation; Prefix identifies it in listings.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // hex SHA-256 of the key
+	Scopes     string     `json:"-" gorm:"type:varchar(255);not null"`            // comma-separated
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for APIKey model.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the scopes of the key.
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ToResponse converts an APIKey to APIKeyResponse.
+func (k *APIKey) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// HasScope reports whether scopes grant scope. Admin grants every scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidScope reports whether scope is a known API key scope.
+func ValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthIdentity is the result of authenticating a request credential.
+type AuthIdentity struct {
+	User      *User
+	Scopes    []string
+	APIKey    *APIKey // the key used, nil for other credential types
+	SessionID string  // the session of an access token, empty for other credential types
+}
+
+// APIKeyResponse represents an API key in API responses.
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // 0 = never expires
+}
+
+// CreateAPIKeyResponse is returned once when a key is created; Key is not retrievable later.
+type CreateAPIKeyResponse struct {
+	APIKey APIKeyResponse `json:"api_key"`
+	Key    string         `json:"key"`
+}
+
+// API key error codes
+const (
+	ErrorInvalidAPIKey     ErrorCode = "INVALID_API_KEY"
+	ErrorAPIKeyExpired     ErrorCode = "API_KEY_EXPIRED"
+	ErrorAPIKeyRevoked     ErrorCode = "API_KEY_REVOKED"
+	ErrorAPIKeyNotFound    ErrorCode = "API_KEY_NOT_FOUND"
+	ErrorInvalidScope      ErrorCode = "INVALID_SCOPE"
+	ErrorInsufficientScope ErrorCode = "INSUFFICIENT_SCOPE"
+)
+
+// API key errors
+var (
+	ErrInvalidAPIKey = &ErrorResponse{
+		Code:    ErrorInvalidAPIKey,
+		Message: "Invalid API key.",
+	}
+	ErrAPIKeyExpired = &ErrorResponse{
+		Code:    ErrorAPIKeyExpired,
+		Message: "API key has expired.",
+	}
+	ErrAPIKeyRevoked = &ErrorResponse{
+		Code:    ErrorAPIKeyRevoked,
+		Message: "API key has been revoked.",
+	}
+	ErrAPIKeyNotFound = &ErrorResponse{
+		Code:    ErrorAPIKeyNotFound,
+		Message: "API key not found.",
+	}
+	ErrInvalidScope = &ErrorResponse{
+		Code:    ErrorInvalidScope,
+		Message: "Invalid scope. Valid scopes: read, insights, chat, admin.",
+	}
+)
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Authentication event types recorded in the audit trail.
+const (
+	AuthEventRegister             = "register"
+	AuthEventLoginSucceeded       = "login_succeeded"
+	AuthEventLoginFailed          = "login_failed"
+	AuthEventLoginThrottled       = "login_throttled"
+	AuthEventLogoutAll            = "logout_all"
+	AuthEventPasswordChanged      = "password_changed"
+	AuthEventPasswordChangeFailed = "password_change_failed"
+	AuthEventPasswordReset        = "password_reset"
+	AuthEventEmailVerified        = "email_verified"
+	AuthEventAccountDeleted       = "account_deleted"
+	AuthEventAccountRestored      = "account_restored"
+	AuthEventSharePasswordFailed  = "share_password_failed"
+	AuthEventShareThrottled       = "share_throttled"
+)
+
+// AuthEvent is an entry of the authentication audit trail. UserID is nil when
+// the event cannot be tied to an account, e.g. a login with an unknown email.
+type AuthEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id,omitempty" gorm:"index"`
+	Type      string    `json:"type" gorm:"type:varchar(32);index;not null"`
+	Email     string    `json:"email,omitempty" gorm:"type:varchar(255)"`        // email given at login, for unknown accounts
+	Subject   string    `json:"subject,omitempty" gorm:"type:varchar(64);index"` // e.g. share token of share events
+	IPAddress string    `json:"ip_address" gorm:"type:varchar(64);index"`
+	UserAgent string    `json:"user_agent" gorm:"type:varchar(255)"`
+	Detail    string    `json:"detail,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName returns the table name for AuthEvent model.
+func (AuthEvent) TableName() string {
+	return "auth_events"
+}
+
+// Brute-force protection error codes
+const (
+	ErrorTooManyAttempts ErrorCode = "TOO_MANY_ATTEMPTS"
+)
+
+// Brute-force protection errors
+var (
+	ErrTooManyAttempts = &ErrorResponse{
+		Code:    ErrorTooManyAttempts,
+		Message: "Too many failed attempts. Please try again later.",
+	}
+)
@@ -20,15 +20,33 @@ type Suggestion struct {
 
 // ChatMessageResponse represents a single message in the response.
 type ChatMessageResponse struct {
-	ID        uint      `json:"id"`
-	Role      string    `json:"role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint          `json:"id"`
+	Role        string        `json:"role"`
+	Content     string        `json:"content"`
+	HighlightID *uint         `json:"highlight_id,omitempty"`
+	Highlight   *HighlightRef `json:"highlight,omitempty"`
+	Author      *AuthorRef    `json:"author,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
 }
 
-// ChatHistoryResponse represents the chat history response.
+// ChatHistoryResponse represents a window of the chat history, oldest first.
+// Older messages are fetched with ?before=<id of the first message>.
 type ChatHistoryResponse struct {
 	Messages []ChatMessageResponse `json:"messages"`
+	Total    int64                 `json:"total"`
+	HasMore  bool                  `json:"has_more"`
+}
+
+// ChatReplyResponse is the response of a non-streaming chat turn.
+type ChatReplyResponse struct {
+	Message *ChatMessageResponse `json:"message,omitempty"` // the user message (nil when regenerating)
+	Reply   ChatMessageResponse  `json:"reply"`
+	Sources []TranscriptPassage  `json:"sources,omitempty"`
+}
+
+// RegenerateRequest represents a request to regenerate the latest assistant reply.
+type RegenerateRequest struct {
+	Stream bool `json:"stream"`
 }
 
 // ChatStreamEvent represents a streaming chat event.
@@ -37,8 +55,40 @@ type ChatStreamEvent struct {
 	Content   string `json:"content"`
 	Done      bool   `json:"done"`
 	MessageID *uint  `json:"message_id,omitempty"`
+	// Sources are the transcript passages given to the model; answers cite them as [mm:ss]
+	Sources []TranscriptPassage `json:"sources,omitempty"`
+	// Error is set on the final event if the reply was interrupted
+	Error string `json:"error,omitempty"`
 }
 
+// Chat-specific error codes
+const (
+	ErrorHighlightNotFound   ErrorCode = "HIGHLIGHT_NOT_FOUND"
+	ErrorInsightNotFound     ErrorCode = "INSIGHT_NOT_FOUND"
+	ErrorNothingToRegenerate ErrorCode = "NOTHING_TO_REGENERATE"
+	ErrorChatFailed          ErrorCode = "CHAT_FAILED"
+)
+
+// Chat-specific errors
+var (
+	ErrHighlightNotFound = &ErrorResponse{
+		Code:    ErrorHighlightNotFound,
+		Message: "高亮不存在或不属于该 Insight",
+	}
+	ErrChatInsightNotFound = &ErrorResponse{
+		Code:    ErrorInsightNotFound,
+		Message: "Insight 不存在",
+	}
+	ErrNothingToRegenerate = &ErrorResponse{
+		Code:    ErrorNothingToRegenerate,
+		Message: "没有可以重新生成的回答",
+	}
+	ErrChatFailed = &ErrorResponse{
+		Code:    ErrorChatFailed,
+		Message: "AI 回复生成失败，请稍后重试",
+	}
+)
+
 // AnalyzeEntitiesResponse represents the entity analysis response.
 type AnalyzeEntitiesResponse struct {
 	Entities    []Entity     `json:"entities"`
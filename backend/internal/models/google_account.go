@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// GoogleAccount is the Google account linked to a user. The OAuth token is
+// stored encrypted and refreshed server-side; it is never returned to clients.
+type GoogleAccount struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	GoogleID       string     `json:"google_id" gorm:"type:varchar(64);index"`
+	Email          string     `json:"email" gorm:"type:varchar(255)"`
+	EncryptedToken string     `json:"-" gorm:"type:text;not null"` // AES-GCM encrypted oauth2.Token JSON
+	TokenExpiry    *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for GoogleAccount model.
+func (GoogleAccount) TableName() string {
+	return "google_accounts"
+}
+
+// GoogleAccountResponse describes the Google account link of the current user.
+type GoogleAccountResponse struct {
+	Linked   bool       `json:"linked"`
+	Email    string     `json:"email,omitempty"`
+	LinkedAt *time.Time `json:"linked_at,omitempty"`
+}
+
+// Google account error codes
+const (
+	ErrorGoogleNotLinked        ErrorCode = "GOOGLE_NOT_LINKED"
+	ErrorGoogleRelinkRequired   ErrorCode = "GOOGLE_RELINK_REQUIRED"
+	ErrorGoogleRevokeFailed     ErrorCode = "GOOGLE_REVOKE_FAILED"
+	ErrorGoogleAccountInUse     ErrorCode = "GOOGLE_ACCOUNT_IN_USE"
+	ErrorGoogleEmailNotVerified ErrorCode = "GOOGLE_EMAIL_NOT_VERIFIED"
+	ErrorAccountLinkRequired    ErrorCode = "ACCOUNT_LINK_REQUIRED"
+	ErrorInvalidOAuthState      ErrorCode = "INVALID_OAUTH_STATE"
+)
+
+// Google account errors
+var (
+	ErrGoogleNotLinked = &ErrorResponse{
+		Code:    ErrorGoogleNotLinked,
+		Message: "未关联 Google 账号",
+	}
+	ErrGoogleRelinkRequired = &ErrorResponse{
+		Code:    ErrorGoogleRelinkRequired,
+		Message: "Google 授权已失效，请重新授权",
+	}
+	ErrGoogleRevokeFailed = &ErrorResponse{
+		Code:    ErrorGoogleRevokeFailed,
+		Message: "撤销 Google 授权失败，请稍后重试",
+	}
+	ErrGoogleAccountInUse = &ErrorResponse{
+		Code:    ErrorGoogleAccountInUse,
+		Message: "该 Google 账号已关联其他用户",
+	}
+	ErrGoogleEmailNotVerified = &ErrorResponse{
+		Code:    ErrorGoogleEmailNotVerified,
+		Message: "Google 账号邮箱未验证",
+	}
+	ErrAccountLinkRequired = &ErrorResponse{
+		Code:    ErrorAccountLinkRequired,
+		Message: "该邮箱已注册，请先使用密码登录，再在账号设置中关联 Google 账号",
+	}
+	ErrInvalidOAuthState = &ErrorResponse{
+		Code:    ErrorInvalidOAuthState,
+		Message: "授权请求无效或已过期，请重新发起授权",
+	}
+)
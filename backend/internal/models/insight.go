@@ -28,8 +28,9 @@ const (
 
 // Insight represents a media content analysis record (video, tweet, podcast, etc.).
 type Insight struct {
-	ID     uint `json:"id" gorm:"primaryKey"`
-	UserID uint `json:"user_id" gorm:"index;not null"`
+	ID          uint  `json:"id" gorm:"primaryKey"`
+	UserID      uint  `json:"user_id" gorm:"index;not null"`
+	WorkspaceID *uint `json:"workspace_id,omitempty" gorm:"index"` // Shared with the members of this workspace
 
 	// Source information
 	SourceType SourceType `json:"source_type" gorm:"type:varchar(20);not null"`
@@ -48,6 +49,11 @@ type Insight struct {
 	KeyPoints  datatypes.JSON `json:"key_points" gorm:"type:jsonb"`                        // Key points as JSON array
 	TargetLang string         `json:"target_lang" gorm:"type:varchar(10);default:'zh'"`   // Target language for translation
 
+	// Provenance of the AI generated content
+	SummaryModel         string     `json:"summary_model,omitempty" gorm:"type:varchar(100)"`         // Model that produced summary/key points
+	SummaryPromptVersion string     `json:"summary_prompt_version,omitempty" gorm:"type:varchar(50)"` // Prompt template version
+	SummarizedAt         *time.Time `json:"summarized_at,omitempty"`
+
 	// Raw content
 	RawContent   string `json:"raw_content" gorm:"type:text"`   // Original transcription/content
 	TransContent string `json:"trans_content" gorm:"type:text"` // Translated content
@@ -59,13 +65,6 @@ type Insight struct {
 	Status       InsightStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	ErrorMessage string        `json:"error_message,omitempty" gorm:"type:text"`
 
-	// Sharing
-	ShareToken    *string    `json:"share_token,omitempty" gorm:"type:varchar(64);uniqueIndex"`
-	SharePassword string     `json:"-" gorm:"type:varchar(255)"`             // bcrypt hash, never exposed in JSON
-	ShareConfig   datatypes.JSON `json:"share_config,omitempty" gorm:"type:jsonb"` // What to include in share
-	IsPublic      bool       `json:"is_public" gorm:"default:false"`
-	SharedAt      *time.Time `json:"shared_at,omitempty"`
-
 	// Associations
 	Highlights   []Highlight   `json:"highlights,omitempty" gorm:"foreignKey:InsightID"`
 	ChatMessages []ChatMessage `json:"chat_messages,omitempty" gorm:"foreignKey:InsightID"`
@@ -82,10 +81,11 @@ func (Insight) TableName() string {
 
 // ShareConfigData represents the configuration for sharing an insight.
 type ShareConfigData struct {
-	IncludeSummary    bool `json:"include_summary"`
-	IncludeKeyPoints  bool `json:"include_key_points"`
-	IncludeHighlights bool `json:"include_highlights"`
-	IncludeChat       bool `json:"include_chat"`
+	IncludeSummary    bool       `json:"include_summary"`
+	IncludeKeyPoints  bool       `json:"include_key_points"`
+	IncludeHighlights bool       `json:"include_highlights"`
+	IncludeChat       bool       `json:"include_chat"`
+	Clip              *ShareClip `json:"clip,omitempty"` // Transcript excerpt; nil = no clip
 }
 
 // TranscriptItem represents a single transcript segment with timestamp.
@@ -108,6 +108,8 @@ type Highlight struct {
 	Color       string `json:"color" gorm:"type:varchar(20);default:'yellow'"`    // Highlight color
 	Note        string `json:"note,omitempty" gorm:"type:text"`                   // User's note on the highlight
 
+	Author *AuthorRef `json:"author,omitempty" gorm:"-"` // Loaded for workspace members
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -117,6 +119,29 @@ func (Highlight) TableName() string {
 	return "highlights"
 }
 
+// HighlightRef is the highlight a chat message is anchored to, returned with
+// chat history so the UI can thread replies under it.
+type HighlightRef struct {
+	ID          uint   `json:"id"`
+	Text        string `json:"text"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	Color       string `json:"color"`
+	Note        string `json:"note,omitempty"`
+}
+
+// Ref returns the reference to the highlight used in chat history.
+func (h *Highlight) Ref() *HighlightRef {
+	return &HighlightRef{
+		ID:          h.ID,
+		Text:        h.Text,
+		StartOffset: h.StartOffset,
+		EndOffset:   h.EndOffset,
+		Color:       h.Color,
+		Note:        h.Note,
+	}
+}
+
 // ChatMessage represents a message in AI conversation about an insight.
 type ChatMessage struct {
 	ID        uint `json:"id" gorm:"primaryKey"`
@@ -127,7 +152,9 @@ type ChatMessage struct {
 	Content string `json:"content" gorm:"type:text;not null"`
 
 	// Optional: link to a specific highlight
-	HighlightID *uint `json:"highlight_id,omitempty" gorm:"index"`
+	HighlightID *uint         `json:"highlight_id,omitempty" gorm:"index"`
+	Highlight   *HighlightRef `json:"highlight,omitempty" gorm:"-"` // Loaded for chat history threading
+	Author      *AuthorRef    `json:"author,omitempty" gorm:"-"`    // Loaded for chat history attribution
 
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -137,6 +164,27 @@ func (ChatMessage) TableName() string {
 	return "chat_messages"
 }
 
+// ChatSummary is the rolling summary of the older part of an insight's
+// conversation. Messages up to CoveredMessageID are folded into Content and
+// only later messages are sent to the model verbatim.
+type ChatSummary struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"uniqueIndex;not null"`
+
+	Content          string `json:"content" gorm:"type:text;not null"`
+	CoveredMessageID uint   `json:"covered_message_id" gorm:"not null"` // Last message folded into the summary
+	MessageCount     int    `json:"message_count"`                      // Number of messages folded so far
+	Model            string `json:"model" gorm:"type:varchar(100)"`      // Model that wrote the summary
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ChatSummary model.
+func (ChatSummary) TableName() string {
+	return "chat_summaries"
+}
+
 // Request/Response DTOs
 
 // CreateInsightRequest represents the request to create a new insight.
@@ -155,6 +203,8 @@ type CreateInsightResponse struct {
 // InsightListItem represents a single insight in list view.
 type InsightListItem struct {
 	ID           uint       `json:"id"`
+	UserID       uint       `json:"user_id"`
+	WorkspaceID  *uint      `json:"workspace_id,omitempty"`
 	SourceType   SourceType `json:"source_type"`
 	Title        string     `json:"title"`
 	Author       string     `json:"author"`
@@ -184,11 +234,14 @@ type InsightDetailResponse struct {
 	PublishedAt  *time.Time       `json:"published_at,omitempty"`
 	Summary      string           `json:"summary"`
 	KeyPoints    []string         `json:"key_points"`
+	SummaryModel string           `json:"summary_model,omitempty"`
 	RawContent   string           `json:"raw_content,omitempty"`
 	TransContent string           `json:"trans_content,omitempty"`
 	Transcripts  []TranscriptItem `json:"transcripts,omitempty"`
 	Status       InsightStatus    `json:"status"`
 	Highlights   []Highlight      `json:"highlights,omitempty"`
+	WorkspaceID  *uint            `json:"workspace_id,omitempty"`
+	Role         WorkspaceRole    `json:"role"` // Role of the current user; owner for personal insights
 	CreatedAt    time.Time        `json:"created_at"`
 }
 
@@ -205,30 +258,38 @@ type CreateHighlightRequest struct {
 type ChatRequest struct {
 	Message     string `json:"message" binding:"required"`
 	HighlightID *uint  `json:"highlight_id" binding:"omitempty"`
+	Stream      bool   `json:"stream"` // stream the reply as SSE (also enabled by Accept: text/event-stream)
 }
 
-// ChatResponse represents a chat message response (for non-streaming).
-type ChatResponse struct {
-	ID      uint   `json:"id"`
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// ShareInsightRequest represents the request to share an insight.
+// ShareInsightRequest represents the request to create a share link for an insight.
 type ShareInsightRequest struct {
-	IncludeSummary    bool   `json:"include_summary"`
-	IncludeKeyPoints  bool   `json:"include_key_points"`
-	IncludeHighlights bool   `json:"include_highlights"`
-	IncludeChat       bool   `json:"include_chat"`
-	IsPublic          bool   `json:"is_public"`
-	Password          string `json:"password,omitempty"`
+	Label             string     `json:"label" binding:"omitempty,max=100"`
+	IncludeSummary    bool       `json:"include_summary"`
+	IncludeKeyPoints  bool       `json:"include_key_points"`
+	IncludeHighlights bool       `json:"include_highlights"`
+	IncludeChat       bool       `json:"include_chat"`
+	Clip              *ShareClip `json:"clip,omitempty"`
+	Password          string     `json:"password,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`                // must be in the future; omit for no expiry
+	MaxViews          int        `json:"max_views" binding:"omitempty,min=0"` // 0 = unlimited
 }
 
-// ShareInsightResponse represents the response after sharing an insight.
+// ShareInsightResponse represents a share link of an insight.
 type ShareInsightResponse struct {
-	ShareToken string     `json:"share_token"`
-	ShareURL   string     `json:"share_url"`
-	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	ID           uint            `json:"id"`
+	Label        string          `json:"label,omitempty"`
+	ShareToken   string          `json:"share_token"`
+	ShareURL     string          `json:"share_url"`
+	PageURL      string          `json:"page_url"` // HTML page with link previews
+	Config       ShareConfigData `json:"config"`
+	HasPassword  bool            `json:"has_password"`
+	ExpiresAt    *time.Time      `json:"expires_at,omitempty"`
+	MaxViews     int             `json:"max_views"`
+	ViewCount    int             `json:"view_count"`
+	LastViewedAt *time.Time      `json:"last_viewed_at,omitempty"`
+	RevokedAt    *time.Time      `json:"revoked_at,omitempty"`
+	Active       bool            `json:"active"`
+	CreatedAt    time.Time       `json:"created_at"`
 }
 
 // SharedInsightResponse represents the public view of a shared insight.
@@ -249,4 +310,5 @@ type SharedContent struct {
 	KeyPoints  []string    `json:"key_points,omitempty"`
 	Highlights []Highlight `json:"highlights,omitempty"`
 	Chat       []ChatMessage `json:"chat,omitempty"`
+	Clip       *SharedClip   `json:"clip,omitempty"`
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// JobStatus represents the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"    // waiting to be leased (also used between retries)
+	JobStatusRunning   JobStatus = "running"   // leased by a worker
+	JobStatusSucceeded JobStatus = "succeeded" // finished successfully
+	JobStatusDead      JobStatus = "dead"      // exhausted retries or failed permanently (dead letter)
+)
+
+// Job types handled by the background workers.
+const (
+	JobTypeInsightProcess     = "insight.process"
+	JobTypeVideoAnalyze       = "video.analyze"
+	JobTypeTranslationProcess = "translation.process"
+	JobTypeAccountExport      = "account.export"
+	JobTypeAccountPurge       = "account.purge"
+)
+
+// Job represents a durable unit of background work.
+type Job struct {
+	ID      uint           `json:"id" gorm:"primaryKey"`
+	Type    string         `json:"type" gorm:"type:varchar(50);index;not null"`
+	Payload datatypes.JSON `json:"payload" gorm:"type:jsonb"`
+	Status  JobStatus      `json:"status" gorm:"type:varchar(20);index;default:'queued'"`
+	UserID  *uint          `json:"user_id,omitempty" gorm:"index"` // owner used for per-user in-flight caps
+
+	// Retry bookkeeping
+	Attempts    int       `json:"attempts" gorm:"default:0"`
+	MaxAttempts int       `json:"max_attempts" gorm:"default:3"`
+	RunAt       time.Time `json:"run_at" gorm:"index"` // earliest time the job may be leased
+	LastError   string    `json:"last_error,omitempty" gorm:"type:text"`
+
+	// Leasing
+	LeaseOwner     string     `json:"lease_owner,omitempty" gorm:"type:varchar(100)"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty" gorm:"index"`
+	HeartbeatAt    *time.Time `json:"heartbeat_at,omitempty"`
+
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Job model.
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// InsightJobPayload is the payload of an insight.process job.
+type InsightJobPayload struct {
+	InsightID uint `json:"insight_id"`
+}
+
+// VideoAnalyzeJobPayload is the payload of a video.analyze job.
+type VideoAnalyzeJobPayload struct {
+	AnalysisID uint   `json:"analysis_id"`
+	VideoURL   string `json:"video_url"`
+}
+
+// TranslationJobPayload is the payload of a translation.process job.
+type TranslationJobPayload struct {
+	TranslationID uint `json:"translation_id"`
+}
+
+// AccountExportJobPayload is the payload of an account.export job.
+type AccountExportJobPayload struct {
+	ExportID uint `json:"export_id"`
+}
+
+// AccountPurgeJobPayload is the payload of an account.purge job.
+type AccountPurgeJobPayload struct {
+	UserID uint `json:"user_id"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Session is a login session of a browser client. Access tokens carry the
+// session ID, so revoking the session also ends its outstanding access tokens.
+type Session struct {
+	ID            string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"index;not null"`
+	UserAgent     string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IPAddress     string     `json:"ip_address" gorm:"type:varchar(64)"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty" gorm:"type:varchar(32)"` // logout, logout_all, refresh_reuse, password_change, account_deleted
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for Session model.
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// RefreshToken is a single-use refresh token of a session. Refreshing marks
+// the token used and issues its successor; presenting a used token again
+// revokes the whole session.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	SessionID string     `json:"session_id" gorm:"type:varchar(36);index;not null"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // hex SHA-256 of the token
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for RefreshToken model.
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// SessionTokens is the token pair issued on login and refresh.
+type SessionTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"` // always "Bearer"
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+}
+
+// RefreshRequest represents the request body for refreshing or ending a session.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Session error codes
+const (
+	ErrorInvalidAccessToken  ErrorCode = "INVALID_ACCESS_TOKEN"
+	ErrorAccessTokenExpired  ErrorCode = "ACCESS_TOKEN_EXPIRED"
+	ErrorSessionRevoked      ErrorCode = "SESSION_REVOKED"
+	ErrorInvalidRefreshToken ErrorCode = "INVALID_REFRESH_TOKEN"
+	ErrorRefreshTokenReused  ErrorCode = "REFRESH_TOKEN_REUSED"
+	ErrorAPIKeyRequired      ErrorCode = "API_KEY_REQUIRED"
+)
+
+// Session errors
+var (
+	ErrInvalidAccessToken = &ErrorResponse{
+		Code:    ErrorInvalidAccessToken,
+		Message: "Invalid access token.",
+	}
+	ErrAccessTokenExpired = &ErrorResponse{
+		Code:    ErrorAccessTokenExpired,
+		Message: "Access token has expired. Refresh the session.",
+	}
+	ErrSessionRevoked = &ErrorResponse{
+		Code:    ErrorSessionRevoked,
+		Message: "Session has ended. Please log in again.",
+	}
+	ErrInvalidRefreshToken = &ErrorResponse{
+		Code:    ErrorInvalidRefreshToken,
+		Message: "Invalid or expired refresh token. Please log in again.",
+	}
+	ErrRefreshTokenReused = &ErrorResponse{
+		Code:    ErrorRefreshTokenReused,
+		Message: "Refresh token was already used. The session has been ended; please log in again.",
+	}
+	ErrAPIKeyRequired = &ErrorResponse{
+		Code:    ErrorAPIKeyRequired,
+		Message: "Authenticate with the API key to rotate it. Use /api/v1/auth/api-keys to create keys.",
+	}
+)
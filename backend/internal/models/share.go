@@ -0,0 +1,200 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ShareLink is a public link to an insight. An insight can have several links,
+// each with its own content selection, password, expiry and view limit.
+// Revoked links are kept as history.
+type ShareLink struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"index;not null"`
+	UserID    uint `json:"user_id" gorm:"index;not null"` // Who created the link
+
+	Token        string         `json:"token" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Label        string         `json:"label,omitempty" gorm:"type:varchar(100)"`
+	PasswordHash string         `json:"-" gorm:"type:varchar(255)"`        // bcrypt hash, never exposed in JSON
+	Config       datatypes.JSON `json:"config" gorm:"type:jsonb"`          // ShareConfigData: what to include
+	ExpiresAt    *time.Time     `json:"expires_at,omitempty" gorm:"index"` // nil = never expires
+	MaxViews     int            `json:"max_views"`                         // 0 = unlimited
+	ViewCount    int            `json:"view_count" gorm:"not null;default:0"`
+	LastViewedAt *time.Time     `json:"last_viewed_at,omitempty"`
+	RevokedAt    *time.Time     `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ShareLink model.
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// HasPassword reports whether the link is protected by a password.
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+// Expired reports whether the link has expired at now.
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Exhausted reports whether the link has been viewed MaxViews times.
+func (l *ShareLink) Exhausted() bool {
+	return l.MaxViews > 0 && l.ViewCount >= l.MaxViews
+}
+
+// Active reports whether the link can be viewed at now.
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && !l.Expired(now) && !l.Exhausted()
+}
+
+// Share link error codes
+const (
+	ErrorShareNotFound  ErrorCode = "SHARE_NOT_FOUND"
+	ErrorShareExpired   ErrorCode = "SHARE_EXPIRED"
+	ErrorShareExhausted ErrorCode = "SHARE_EXHAUSTED"
+)
+
+// Share link errors
+var (
+	ErrShareNotFound = &ErrorResponse{
+		Code:    ErrorShareNotFound,
+		Message: "分享链接不存在或已被撤销",
+	}
+	ErrShareExpired = &ErrorResponse{
+		Code:    ErrorShareExpired,
+		Message: "分享链接已过期",
+	}
+	ErrShareExhausted = &ErrorResponse{
+		Code:    ErrorShareExhausted,
+		Message: "分享链接的访问次数已用完",
+	}
+)
+
+// Share view event kinds
+const (
+	ShareViewKindView           = "view"            // the shared content was returned
+	ShareViewKindPasswordFailed = "password_failed" // a wrong password was given
+)
+
+// Share viewer user agent classes
+const (
+	UserAgentClassDesktop = "desktop"
+	UserAgentClassMobile  = "mobile"
+	UserAgentClassTablet  = "tablet"
+	UserAgentClassBot     = "bot" // crawlers and link preview fetchers
+	UserAgentClassOther   = "other"
+)
+
+// ShareView is an access event of a share link. Viewers are recorded without
+// personal data: the IP address only as a keyed hash that identifies repeat
+// visitors, the user agent as a coarse class and the referrer as a host name.
+type ShareView struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	ShareLinkID uint `json:"share_link_id" gorm:"index;not null"`
+	InsightID   uint `json:"insight_id" gorm:"index:idx_share_views_insight_time;not null"`
+
+	Kind           string `json:"kind" gorm:"type:varchar(20);not null"`
+	VisitorHash    string `json:"-" gorm:"type:varchar(64);not null"`
+	UserAgentClass string `json:"user_agent_class" gorm:"type:varchar(20)"`
+	ReferrerHost   string `json:"referrer_host,omitempty" gorm:"type:varchar(255)"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_share_views_insight_time"`
+}
+
+// TableName returns the table name for ShareView model.
+func (ShareView) TableName() string {
+	return "share_views"
+}
+
+// ShareVisitor describes the client opening a share link, before anonymization.
+type ShareVisitor struct {
+	IP        string
+	UserAgent string
+	Referrer  string
+}
+
+// ShareDailyStats is the number of views of an insight's share links on one day (UTC).
+type ShareDailyStats struct {
+	Date           string `json:"date"` // YYYY-MM-DD
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+}
+
+// ShareLinkStats is the number of views of one share link.
+type ShareLinkStats struct {
+	ShareLinkID    uint   `json:"share_link_id"`
+	Label          string `json:"label,omitempty"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+}
+
+// ShareCount is a number of views grouped by a key such as a referrer host.
+type ShareCount struct {
+	Key   string `json:"key"`
+	Views int64  `json:"views"`
+}
+
+// ShareStatsResponse represents the view analytics of an insight's share links.
+// Totals cover all time, the daily series the last Days days.
+type ShareStatsResponse struct {
+	TotalViews             int64             `json:"total_views"`
+	UniqueVisitors         int64             `json:"unique_visitors"`
+	FailedPasswordAttempts int64             `json:"failed_password_attempts"`
+	Days                   int               `json:"days"`
+	Daily                  []ShareDailyStats `json:"daily"`
+	Links                  []ShareLinkStats  `json:"links"`
+	UserAgentClasses       []ShareCount      `json:"user_agent_classes"`
+	Referrers              []ShareCount      `json:"referrers"`
+}
+
+// UnlockShareRequest represents the password entered for a protected share link.
+type UnlockShareRequest struct {
+	Password string `json:"password" binding:"required,max=128"`
+}
+
+// ShareAccessResponse is the access token that opens a protected share link
+// after its password has been entered. Clients send it in the X-Share-Token
+// header; browsers also receive it as a cookie scoped to the link.
+type ShareAccessResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"` // seconds
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MaxShareClipSeconds is the longest time range a shared clip may cover.
+const MaxShareClipSeconds = 30 * 60
+
+// ShareClip selects the transcript lines of an insight between two positions
+// of the video, in seconds, to share as a clip.
+type ShareClip struct {
+	StartSeconds int    `json:"start_seconds" binding:"min=0"`
+	EndSeconds   int    `json:"end_seconds" binding:"gtfield=StartSeconds"`
+	Comment      string `json:"comment,omitempty" binding:"max=1000"`
+}
+
+// SharedClip is the transcript excerpt of a shared clip with a link to the
+// start of the clip in the original video.
+type SharedClip struct {
+	StartSeconds   int              `json:"start_seconds"`
+	EndSeconds     int              `json:"end_seconds"`
+	StartTimestamp string           `json:"start_timestamp"` // e.g., "05:12"
+	EndTimestamp   string           `json:"end_timestamp"`
+	Comment        string           `json:"comment,omitempty"`
+	URL            string           `json:"url,omitempty"` // Original video at the start of the clip (YouTube only)
+	Lines          []TranscriptItem `json:"lines"`         // Original and translated lines
+}
+
+// Share clip error code
+const ErrorInvalidShareClip ErrorCode = "INVALID_SHARE_CLIP"
+
+// ErrInvalidShareClip is returned for clips that are too long or contain no transcript lines.
+var ErrInvalidShareClip = &ErrorResponse{
+	Code:    ErrorInvalidShareClip,
+	Message: "片段时长不能超过 30 分钟，且所选时间范围内必须有字幕",
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// TranscriptChunk is a window of consecutive transcript segments of an insight,
+// indexed for retrieval-augmented chat.
+type TranscriptChunk struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	InsightID uint `json:"insight_id" gorm:"uniqueIndex:idx_transcript_chunks_insight_position;not null"`
+	Position  int  `json:"position" gorm:"uniqueIndex:idx_transcript_chunks_insight_position;not null"` // Order within the transcript
+
+	StartSeconds   int    `json:"start_seconds"`
+	EndSeconds     int    `json:"end_seconds"`
+	Text           string `json:"text" gorm:"type:text;not null"` // Original transcript text
+	TranslatedText string `json:"translated_text,omitempty" gorm:"type:text"`
+
+	// Embedding of Text + TranslatedText as a JSON float array (empty when only BM25 is available)
+	Embedding      datatypes.JSON `json:"-" gorm:"type:jsonb"`
+	EmbeddingModel string         `json:"-" gorm:"type:varchar(100)"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for TranscriptChunk model.
+func (TranscriptChunk) TableName() string {
+	return "transcript_chunks"
+}
+
+// TranscriptPassage is a transcript chunk retrieved as context for a chat turn.
+type TranscriptPassage struct {
+	Timestamp    string  `json:"timestamp"` // e.g., "05:12", cited as [05:12] in answers
+	StartSeconds int     `json:"start_seconds"`
+	EndSeconds   int     `json:"end_seconds"`
+	Text         string  `json:"text"`
+	Score        float64 `json:"score"`
+}
@@ -9,6 +9,7 @@ import (
 // Translation represents a translation task.
 type Translation struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
+	UserID          uint           `json:"user_id" gorm:"index;not null;default:0"` // owner; 0 for translations created before ownership
 	SourceText      string         `json:"source_text,omitempty" gorm:"type:text"`
 	YoutubeURL      string         `json:"youtube_url,omitempty" gorm:"type:varchar(500)"`
 	VideoID         string         `json:"video_id,omitempty" gorm:"type:varchar(50);index"`
@@ -79,6 +80,7 @@ type DualSubtitleResponse struct {
 
 // TranslateResponse represents the translation API response.
 type TranslateResponse struct {
+	ID             uint                    `json:"id,omitempty"`
 	Status         string                  `json:"status"`
 	Message        string                  `json:"message,omitempty"`
 	TranslatedText *string                 `json:"translated_text,omitempty"`
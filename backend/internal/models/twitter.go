@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Twitter media types
+const (
+	TwitterMediaPhoto = "photo"
+	TwitterMediaVideo = "video"
+	TwitterMediaGIF   = "animated_gif"
+)
+
+// TwitterPost is a single post on Twitter/X.
+type TwitterPost struct {
+	ID              string         `json:"id"`
+	AuthorName      string         `json:"author_name"`
+	AuthorHandle    string         `json:"author_handle"` // without the leading @
+	AuthorAvatarURL string         `json:"author_avatar_url,omitempty"`
+	Text            string         `json:"text"` // links expanded, media links removed
+	CreatedAt       time.Time      `json:"created_at"`
+	InReplyToID     string         `json:"in_reply_to_id,omitempty"`
+	ReplyCount      int            `json:"reply_count,omitempty"`
+	Media           []TwitterMedia `json:"media,omitempty"`
+}
+
+// URL returns the canonical URL of the post.
+func (p *TwitterPost) URL() string {
+	return "https://x.com/" + p.AuthorHandle + "/status/" + p.ID
+}
+
+// TwitterMedia is a photo, video or GIF attached to a post.
+type TwitterMedia struct {
+	Type       string `json:"type"`                  // TwitterMedia* constant
+	URL        string `json:"url"`                   // image, or best quality MP4 for videos and GIFs
+	PreviewURL string `json:"preview_url,omitempty"` // still image of videos and GIFs
+}
+
+// TwitterThread is a thread of posts by one author, oldest first.
+type TwitterThread struct {
+	Posts []TwitterPost `json:"posts"`
+	// MayContinue is set when the last post has replies that the fetch
+	// backend cannot list, so later posts of the thread may be missing.
+	MayContinue bool `json:"may_continue,omitempty"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+)
+
+// LLMUsage is a single entry of the LLM usage ledger.
+type LLMUsage struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	UserID           *uint     `json:"user_id,omitempty" gorm:"index:idx_llm_usage_user_created"` // nil for calls not attributed to a user
+	Feature          string    `json:"feature" gorm:"type:varchar(50);index"`
+	Provider         string    `json:"provider" gorm:"type:varchar(50)"`
+	Model            string    `json:"model" gorm:"type:varchar(100)"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostUSD          float64   `json:"cost_usd" gorm:"type:numeric(12,6);default:0"`
+	Estimated        bool      `json:"estimated" gorm:"default:false"` // token counts estimated locally
+	CreatedAt        time.Time `json:"created_at" gorm:"index:idx_llm_usage_user_created"`
+}
+
+// TableName returns the table name for LLMUsage model.
+func (LLMUsage) TableName() string {
+	return "llm_usage"
+}
+
+// UsageTotals aggregates usage ledger entries.
+type UsageTotals struct {
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UsageBreakdown is the usage of a single feature or model.
+type UsageBreakdown struct {
+	Key string `json:"key"`
+	UsageTotals
+}
+
+// UsageBudget describes a user's monthly budget. Zero limits mean unlimited.
+type UsageBudget struct {
+	MonthlyTokens    int64    `json:"monthly_tokens"`
+	MonthlyCostUSD   float64  `json:"monthly_cost_usd"`
+	RemainingTokens  *int64   `json:"remaining_tokens,omitempty"`
+	RemainingCostUSD *float64 `json:"remaining_cost_usd,omitempty"`
+	Exhausted        bool     `json:"exhausted"`
+}
+
+// UsageResponse is the response of GET /api/v1/usage.
+type UsageResponse struct {
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Totals      UsageTotals      `json:"totals"`
+	Budget      UsageBudget      `json:"budget"`
+	ByFeature   []UsageBreakdown `json:"by_feature"`
+	ByModel     []UsageBreakdown `json:"by_model"`
+}
+
+// Usage-specific error codes
+const (
+	ErrorLLMBudgetExceeded ErrorCode = "LLM_BUDGET_EXCEEDED"
+)
+
+// Usage-specific errors
+var (
+	ErrLLMBudgetExceeded = &ErrorResponse{
+		Code:    ErrorLLMBudgetExceeded,
+		Message: "本月 AI 用量已达上限，请下月再试或联系管理员提高额度",
+	}
+)
@@ -10,9 +10,10 @@ import (
 type User struct {
 	ID       uint   `json:"id" gorm:"primaryKey"`
 	Email    string `json:"email" gorm:"type:varchar(255);uniqueIndex;not null"`
-	Password string `json:"-" gorm:"type:varchar(255);not null"` // bcrypt hash, never exposed in JSON
+	Password string `json:"-" gorm:"type:varchar(255);not null"` // bcrypt hash, never exposed in JSON; empty for Google-only accounts
 	Name     string `json:"name" gorm:"type:varchar(255)"`
-	APIKey   string `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // API key for authentication
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -24,12 +25,36 @@ func (User) TableName() string {
 	return "users"
 }
 
+// HasPassword reports whether the user can log in with a password.
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
+// EmailVerified reports whether the user has confirmed their email address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// ToResponse converts a User to UserResponse.
+func (u *User) ToResponse() UserResponse {
+	return UserResponse{
+		ID:            u.ID,
+		Email:         u.Email,
+		Name:          u.Name,
+		EmailVerified: u.EmailVerified(),
+		HasPassword:   u.HasPassword(),
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
 // UserResponse represents the user data returned in API responses.
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint      `json:"id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	EmailVerified bool      `json:"email_verified"`
+	HasPassword   bool      `json:"has_password"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // RegisterRequest represents the user registration request.
@@ -47,6 +72,6 @@ type LoginRequest struct {
 
 // AuthResponse represents the response after successful login/registration.
 type AuthResponse struct {
-	User   UserResponse `json:"user"`
-	APIKey string       `json:"api_key"`
+	User UserResponse `json:"user"`
+	SessionTokens
 }
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// User token purposes.
+const (
+	UserTokenEmailVerification = "email_verification"
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenAccountRestore    = "account_restore"
+)
+
+// UserToken is a single-use, expiring token sent to a user by email to verify
+// their address, reset their password or cancel the deletion of their
+// account. Only the SHA-256 hash is stored.
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32);not null"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null"` // address the token was sent to
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for UserToken model.
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// VerifyEmailRequest represents the request body for confirming an email address.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest represents the request body for requesting a password reset email.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request body for setting a new password with a reset token.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// ChangePasswordRequest represents the request body for changing the password of
+// the current user. CurrentPassword is required when the user has a password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// Account error codes
+const (
+	ErrorInvalidUserToken       ErrorCode = "INVALID_TOKEN"
+	ErrorEmailNotVerified       ErrorCode = "EMAIL_NOT_VERIFIED"
+	ErrorEmailAlreadyVerified   ErrorCode = "EMAIL_ALREADY_VERIFIED"
+	ErrorInvalidCurrentPassword ErrorCode = "INVALID_CURRENT_PASSWORD"
+)
+
+// Account errors
+var (
+	ErrInvalidUserToken = &ErrorResponse{
+		Code:    ErrorInvalidUserToken,
+		Message: "Invalid or expired link. Please request a new one.",
+	}
+	ErrEmailNotVerified = &ErrorResponse{
+		Code:    ErrorEmailNotVerified,
+		Message: "Please verify your email address before logging in.",
+	}
+	ErrEmailAlreadyVerified = &ErrorResponse{
+		Code:    ErrorEmailAlreadyVerified,
+		Message: "Email address is already verified.",
+	}
+	ErrInvalidCurrentPassword = &ErrorResponse{
+		Code:    ErrorInvalidCurrentPassword,
+		Message: "Current password is incorrect.",
+	}
+)
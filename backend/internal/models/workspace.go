@@ -0,0 +1,157 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkspaceRole is the role of a member in a workspace.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"  // manages the workspace, its members and all insights in it
+	WorkspaceRoleEditor WorkspaceRole = "editor" // adds insights, highlights and chat messages
+	WorkspaceRoleViewer WorkspaceRole = "viewer" // reads insights, highlights and chats
+)
+
+// CanEdit reports whether the role may add content to insights.
+func (r WorkspaceRole) CanEdit() bool {
+	return r == WorkspaceRoleOwner || r == WorkspaceRoleEditor
+}
+
+// CanManage reports whether the role may delete and share insights and manage members.
+func (r WorkspaceRole) CanManage() bool {
+	return r == WorkspaceRoleOwner
+}
+
+// Workspace is a library of insights shared by its members.
+type Workspace struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"type:varchar(255);not null"`
+	Description string `json:"description,omitempty" gorm:"type:text"`
+	OwnerID     uint   `json:"owner_id" gorm:"index;not null"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName returns the table name for Workspace model.
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+// WorkspaceMember is the membership of a user in a workspace. The owner is a
+// member with the owner role.
+type WorkspaceMember struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint          `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_member;not null"`
+	UserID      uint          `json:"user_id" gorm:"uniqueIndex:idx_workspace_member;index;not null"`
+	Role        WorkspaceRole `json:"role" gorm:"type:varchar(20);not null"`
+
+	Workspace *Workspace `json:"-" gorm:"foreignKey:WorkspaceID"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for WorkspaceMember model.
+func (WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// AuthorRef identifies the member who wrote a highlight or chat message.
+type AuthorRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// Request/Response DTOs
+
+// CreateWorkspaceRequest represents the request to create a workspace.
+type CreateWorkspaceRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description" binding:"omitempty,max=2000"`
+}
+
+// UpdateWorkspaceRequest represents the request to rename or describe a workspace.
+type UpdateWorkspaceRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+}
+
+// AddWorkspaceMemberRequest represents the request to add a registered user to a workspace.
+type AddWorkspaceMemberRequest struct {
+	Email string        `json:"email" binding:"required,email"`
+	Role  WorkspaceRole `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// UpdateWorkspaceMemberRequest represents the request to change the role of a member.
+type UpdateWorkspaceMemberRequest struct {
+	Role WorkspaceRole `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// MoveInsightRequest represents the request to move an insight into a
+// workspace, or back to the personal library when WorkspaceID is nil.
+type MoveInsightRequest struct {
+	WorkspaceID *uint `json:"workspace_id"`
+}
+
+// WorkspaceResponse represents a workspace together with the role of the current user.
+type WorkspaceResponse struct {
+	ID          uint          `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	OwnerID     uint          `json:"owner_id"`
+	Role        WorkspaceRole `json:"role"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// WorkspaceMemberResponse represents a member in the member list of a workspace.
+type WorkspaceMemberResponse struct {
+	UserID   uint          `json:"user_id"`
+	Name     string        `json:"name"`
+	Email    string        `json:"email"`
+	Role     WorkspaceRole `json:"role"`
+	JoinedAt time.Time     `json:"joined_at"`
+}
+
+// Workspace error codes
+const (
+	ErrorWorkspaceNotFound  ErrorCode = "WORKSPACE_NOT_FOUND"
+	ErrorWorkspaceForbidden ErrorCode = "WORKSPACE_FORBIDDEN"
+	ErrorMemberNotFound     ErrorCode = "MEMBER_NOT_FOUND"
+	ErrorMemberExists       ErrorCode = "MEMBER_EXISTS"
+	ErrorOwnerMembership    ErrorCode = "OWNER_MEMBERSHIP"
+	ErrorInsightForbidden   ErrorCode = "INSIGHT_FORBIDDEN"
+)
+
+// Workspace errors
+var (
+	ErrWorkspaceNotFound = &ErrorResponse{
+		Code:    ErrorWorkspaceNotFound,
+		Message: "工作区不存在",
+	}
+	ErrWorkspaceForbidden = &ErrorResponse{
+		Code:    ErrorWorkspaceForbidden,
+		Message: "在该工作区中没有执行该操作的权限",
+	}
+	ErrMemberNotFound = &ErrorResponse{
+		Code:    ErrorMemberNotFound,
+		Message: "用户不存在或不是工作区成员",
+	}
+	ErrMemberExists = &ErrorResponse{
+		Code:    ErrorMemberExists,
+		Message: "该用户已是工作区成员",
+	}
+	ErrOwnerMembership = &ErrorResponse{
+		Code:    ErrorOwnerMembership,
+		Message: "不能移除工作区所有者或修改其角色",
+	}
+	ErrInsightForbidden = &ErrorResponse{
+		Code:    ErrorInsightForbidden,
+		Message: "没有执行该操作的权限",
+	}
+)
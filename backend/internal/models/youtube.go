@@ -1,7 +1,5 @@
 package models
 
-import "time"
-
 // YouTubeVideoRequest represents the request for video metadata.
 type YouTubeVideoRequest struct {
 	Input string `json:"input" binding:"required"` // Can be URL or video ID
@@ -127,14 +125,11 @@ type OAuthCallbackRequest struct {
 }
 
 // OAuthCallbackResponse represents the OAuth callback response.
+// The Google token itself is stored server-side and never returned.
 type OAuthCallbackResponse struct {
-	AccessToken  string        `json:"accessToken"`
-	RefreshToken string        `json:"refreshToken"`
-	TokenType    string        `json:"tokenType"`
-	Expiry       time.Time     `json:"expiry"`
-	TokenJSON    string        `json:"tokenJSON"`
-	User         *UserResponse `json:"user,omitempty"`  // System user info
-	APIKey       string        `json:"apiKey,omitempty"` // System API key for authentication
+	User    *UserResponse          `json:"user,omitempty"`    // System user info
+	Session *SessionTokens         `json:"session,omitempty"` // System session tokens for authentication
+	Google  *GoogleAccountResponse `json:"google,omitempty"`  // Linked Google account
 }
 
 const (
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// AccountRepository handles database operations that span everything a user
+// owns: data exports, revoking shares and purging deleted accounts.
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountRepository creates a new AccountRepository.
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// --- Export operations ---
+
+// CreateExport creates a new export record.
+func (r *AccountRepository) CreateExport(ctx context.Context, export *models.AccountExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+// GetExport returns an export by ID.
+func (r *AccountRepository) GetExport(ctx context.Context, id uint) (*models.AccountExport, error) {
+	var export models.AccountExport
+	err := r.db.WithContext(ctx).First(&export, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetExportForUser returns an export by ID if it belongs to userID.
+// Exports of other users are reported as gorm.ErrRecordNotFound.
+func (r *AccountRepository) GetExportForUser(ctx context.Context, id, userID uint) (*models.AccountExport, error) {
+	var export models.AccountExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&export, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// UpdateExport saves an export record.
+func (r *AccountRepository) UpdateExport(ctx context.Context, export *models.AccountExport) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
+
+// ListExportsByUser returns every export of a user.
+func (r *AccountRepository) ListExportsByUser(ctx context.Context, userID uint) ([]models.AccountExport, error) {
+	var exports []models.AccountExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&exports).Error
+	return exports, err
+}
+
+// ListExpiredExports returns exports that expired before cutoff.
+func (r *AccountRepository) ListExpiredExports(ctx context.Context, cutoff time.Time) ([]models.AccountExport, error) {
+	var exports []models.AccountExport
+	err := r.db.WithContext(ctx).Where("expires_at < ?", cutoff).Find(&exports).Error
+	return exports, err
+}
+
+// DeleteExport deletes an export record.
+func (r *AccountRepository) DeleteExport(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.AccountExport{}, id).Error
+}
+
+// --- Account data operations ---
+
+// LoadData returns everything userID owns, for an export.
+func (r *AccountRepository) LoadData(ctx context.Context, userID uint) (*models.AccountData, error) {
+	db := r.db.WithContext(ctx)
+	data := &models.AccountData{}
+
+	err := db.Where("user_id = ?", userID).
+		Preload("Highlights", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_offset ASC")
+		}).
+		Preload("ChatMessages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Order("created_at ASC").
+		Find(&data.Insights).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Where("user_id = ?", userID).
+		Preload("DualSubtitles", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Order("created_at ASC").
+		Find(&data.Translations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("start_time ASC").Find(&data.Pomodoros).Error; err != nil {
+		return nil, err
+	}
+
+	var analyses []models.VideoAnalysis
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&analyses).Error; err != nil {
+		return nil, err
+	}
+	data.VideoAnalyses = make([]models.VideoAnalysisExport, len(analyses))
+	for i, analysis := range analyses {
+		export := &data.VideoAnalyses[i]
+		export.VideoAnalysis = analysis
+		if err := db.Where("analysis_id = ?", analysis.ID).Order("order_index ASC").Find(&export.Chapters).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("analysis_id = ?", analysis.ID).Order("order_index ASC").Find(&export.Transcriptions).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("analysis_id = ?", analysis.ID).Order("order_index ASC").Find(&export.KeyPoints).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// RevokeShares revokes every active share link of the insights of a user and
+// every link the user created.
+func (r *AccountRepository) RevokeShares(ctx context.Context, userID uint) (int64, error) {
+	insightIDs := r.db.WithContext(ctx).Model(&models.Insight{}).Select("id").Where("user_id = ?", userID)
+	result := r.db.WithContext(ctx).
+		Model(&models.ShareLink{}).
+		Where("(insight_id IN (?) OR user_id = ?) AND revoked_at IS NULL", insightIDs, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// Purge permanently deletes a user and every record they own, including
+// soft-deleted ones.
+func (r *AccountRepository) Purge(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		insightIDs := tx.Model(&models.Insight{}).Select("id").Where("user_id = ?", userID)
+		analysisIDs := tx.Model(&models.VideoAnalysis{}).Select("id").Where("user_id = ?", userID)
+		translationIDs := tx.Model(&models.Translation{}).Select("id").Where("user_id = ?", userID)
+		sessionIDs := tx.Model(&models.Session{}).Select("id").Where("user_id = ?", userID)
+		workspaceIDs := tx.Model(&models.Workspace{}).Select("id").Where("owner_id = ?", userID)
+		shareLinkIDs := tx.Model(&models.ShareLink{}).Select("id").Where("user_id = ?", userID)
+
+		// Insights of other members in the user's workspaces return to their creators
+		if err := tx.Model(&models.Insight{}).
+			Where("workspace_id IN (?) AND user_id <> ?", workspaceIDs, userID).
+			Update("workspace_id", nil).Error; err != nil {
+			return err
+		}
+
+		// Children before their parents
+		steps := []struct {
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{&models.Highlight{}, "user_id = ?", userID},
+			{&models.Highlight{}, "insight_id IN (?)", insightIDs},
+			{&models.ChatMessage{}, "user_id = ?", userID},
+			{&models.ChatMessage{}, "insight_id IN (?)", insightIDs},
+			{&models.ChatSummary{}, "insight_id IN (?)", insightIDs},
+			{&models.ShareView{}, "insight_id IN (?)", insightIDs},
+			{&models.ShareView{}, "share_link_id IN (?)", shareLinkIDs},
+			{&models.ShareLink{}, "insight_id IN (?)", insightIDs},
+			{&models.ShareLink{}, "user_id = ?", userID},
+			{&models.TranscriptChunk{}, "insight_id IN (?)", insightIDs},
+			{&models.Insight{}, "user_id = ?", userID},
+			{&models.WorkspaceMember{}, "user_id = ?", userID},
+			{&models.WorkspaceMember{}, "workspace_id IN (?)", workspaceIDs},
+			{&models.Workspace{}, "owner_id = ?", userID},
+			{&models.Chapter{}, "analysis_id IN (?)", analysisIDs},
+			{&models.Transcription{}, "analysis_id IN (?)", analysisIDs},
+			{&models.KeyPoint{}, "analysis_id IN (?)", analysisIDs},
+			{&models.VideoAnalysis{}, "user_id = ?", userID},
+			{&models.DualSubtitle{}, "translation_id IN (?)", translationIDs},
+			{&models.Translation{}, "user_id = ?", userID},
+			{&models.Pomodoro{}, "user_id = ?", userID},
+			{&models.Analysis{}, "user_id = ?", userID},
+			{&models.RefreshToken{}, "session_id IN (?)", sessionIDs},
+			{&models.Session{}, "user_id = ?", userID},
+			{&models.APIKey{}, "user_id = ?", userID},
+			{&models.UserToken{}, "user_id = ?", userID},
+			{&models.GoogleAccount{}, "user_id = ?", userID},
+			{&models.AccountExport{}, "user_id = ?", userID},
+			{&models.LLMUsage{}, "user_id = ?", userID},
+			{&models.Job{}, "user_id = ?", userID},
+			{&models.AuthEvent{}, "user_id = ?", userID},
+		}
+		for _, step := range steps {
+			if err := tx.Where(step.query, step.arg).Delete(step.model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.User{}, userID).Error
+	})
+}
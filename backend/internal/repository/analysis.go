@@ -17,10 +17,11 @@ func NewAnalysisRepository(db *gorm.DB) *AnalysisRepository {
 	return &AnalysisRepository{db: db}
 }
 
-// GetByID retrieves an analysis record by ID.
-func (r *AnalysisRepository) GetByID(ctx context.Context, id uint) (*models.Analysis, error) {
+// GetByIDForUser retrieves an analysis record by ID if it belongs to userID.
+// Records of other users are reported as gorm.ErrRecordNotFound.
+func (r *AnalysisRepository) GetByIDForUser(ctx context.Context, id, userID uint) (*models.Analysis, error) {
 	var analysis models.Analysis
-	err := r.db.WithContext(ctx).First(&analysis, id).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&analysis, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -37,23 +38,32 @@ func (r *AnalysisRepository) Update(ctx context.Context, analysis *models.Analys
 	return r.db.WithContext(ctx).Save(analysis).Error
 }
 
-// Delete soft deletes an analysis record.
-func (r *AnalysisRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Analysis{}, id).Error
+// DeleteForUser soft deletes an analysis record of userID.
+// Returns gorm.ErrRecordNotFound if the user has no such record.
+func (r *AnalysisRepository) DeleteForUser(ctx context.Context, id, userID uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Analysis{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-// List retrieves all analysis records with pagination.
-func (r *AnalysisRepository) List(ctx context.Context, limit, offset int) ([]models.Analysis, int64, error) {
+// ListByUser retrieves the analysis records of userID with pagination.
+func (r *AnalysisRepository) ListByUser(ctx context.Context, userID uint, limit, offset int) ([]models.Analysis, int64, error) {
 	var analyses []models.Analysis
 	var total int64
 
 	// Get total count
-	if err := r.db.WithContext(ctx).Model(&models.Analysis{}).Count(&total).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.Analysis{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
 	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
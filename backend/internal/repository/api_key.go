@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// APIKeyRepository handles database operations for API keys.
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create creates a new API key record.
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByHash returns an API key by the hash of the key.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByIDForUser returns an API key of userID by ID.
+// Keys of other users are reported as gorm.ErrRecordNotFound.
+func (r *APIKeyRepository) GetByIDForUser(ctx context.Context, id, userID uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUserID returns the API keys of a user, newest first. Revoked keys are included.
+func (r *APIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revokes an API key of userID. Revoking a revoked key is a no-op.
+// Returns gorm.ErrRecordNotFound if the user has no such key.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID uint, at time.Time) error {
+	if _, err := r.GetByIDForUser(ctx, id, userID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// RevokeAllForUser revokes every active API key of a user.
+func (r *APIKeyRepository) RevokeAllForUser(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// TouchLastUsed records that a key was used at. To avoid a write per request,
+// last_used_at is only updated when it is older than at minus resolution.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time, resolution time.Duration) error {
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-resolution)).
+		Update("last_used_at", at).Error
+}
+
+// MigrateLegacyKeys moves the plaintext keys of the former users.api_key column
+// into api_keys (hashed with hash, labelled with prefix), then drops the column.
+// Returns the number of keys migrated; 0 once the column is gone.
+func (r *APIKeyRepository) MigrateLegacyKeys(ctx context.Context, hash func(key string) string, prefix func(key string) string) (int, error) {
+	migrator := r.db.WithContext(ctx).Migrator()
+	if !migrator.HasColumn("users", "api_key") {
+		return 0, nil
+	}
+
+	var legacy []struct {
+		ID     uint
+		APIKey string
+	}
+	if err := r.db.WithContext(ctx).Table("users").
+		Select("id, api_key").
+		Where("api_key IS NOT NULL AND api_key <> ''").
+		Find(&legacy).Error; err != nil {
+		return 0, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, user := range legacy {
+			key := &models.APIKey{
+				UserID:  user.ID,
+				Name:    "Legacy key",
+				Prefix:  prefix(user.APIKey),
+				KeyHash: hash(user.APIKey),
+				Scopes:  models.ScopeAdmin,
+			}
+			if err := tx.Create(key).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropColumn("users", "api_key")
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(legacy), nil
+}
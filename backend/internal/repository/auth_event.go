@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// AuthEventRepository handles database operations for the authentication audit trail.
+type AuthEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthEventRepository creates a new AuthEventRepository.
+func NewAuthEventRepository(db *gorm.DB) *AuthEventRepository {
+	return &AuthEventRepository{db: db}
+}
+
+// Create records an event.
+func (r *AuthEventRepository) Create(ctx context.Context, event *models.AuthEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// ListByUser returns the most recent events of a user, newest first.
+func (r *AuthEventRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error) {
+	var events []models.AuthEvent
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// DeleteBefore deletes events recorded before cutoff.
+func (r *AuthEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("created_at < ?", cutoff).
+		Delete(&models.AuthEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"vibe-backend/internal/models"
 )
 
@@ -22,17 +23,6 @@ func (r *ChatRepository) CreateMessage(ctx context.Context, message *models.Chat
 	return r.db.WithContext(ctx).Create(message).Error
 }
 
-// GetMessagesByAnalysisID returns all chat messages for an analysis.
-// Note: This method now uses InsightID instead of AnalysisID for compatibility with the InsightFlow system.
-func (r *ChatRepository) GetMessagesByAnalysisID(ctx context.Context, analysisID uint) ([]models.ChatMessage, error) {
-	var messages []models.ChatMessage
-	err := r.db.WithContext(ctx).
-		Where("insight_id = ?", analysisID).
-		Order("created_at ASC").
-		Find(&messages).Error
-	return messages, err
-}
-
 // GetMessageByID returns a chat message by ID.
 func (r *ChatRepository) GetMessageByID(ctx context.Context, id uint) (*models.ChatMessage, error) {
 	var message models.ChatMessage
@@ -43,10 +33,86 @@ func (r *ChatRepository) GetMessageByID(ctx context.Context, id uint) (*models.C
 	return &message, nil
 }
 
-// DeleteMessagesByAnalysisID deletes all chat messages for an analysis.
-// Note: This method now uses InsightID instead of AnalysisID for compatibility with the InsightFlow system.
-func (r *ChatRepository) DeleteMessagesByAnalysisID(ctx context.Context, analysisID uint) error {
-	return r.db.WithContext(ctx).
-		Where("insight_id = ?", analysisID).
-		Delete(&models.ChatMessage{}).Error
+// GetMessagesBefore returns up to limit messages of an insight's conversation with
+// an ID lower than beforeID (0 = from the latest message), oldest first.
+func (r *ChatRepository) GetMessagesBefore(ctx context.Context, insightID uint, beforeID uint, limit int) ([]models.ChatMessage, error) {
+	return r.GetMessagesBetween(ctx, insightID, 0, beforeID, limit)
+}
+
+// GetMessagesBetween returns up to limit of the latest messages of an insight's
+// conversation with afterID < ID < beforeID (0 = unbounded), oldest first.
+func (r *ChatRepository) GetMessagesBetween(ctx context.Context, insightID uint, afterID, beforeID uint, limit int) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	query := r.db.WithContext(ctx).Where("insight_id = ?", insightID)
+	if afterID > 0 {
+		query = query.Where("id > ?", afterID)
+	}
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	// Reverse into chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// CountMessages returns the number of messages in an insight's conversation.
+func (r *ChatRepository) CountMessages(ctx context.Context, insightID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.ChatMessage{}).Where("insight_id = ?", insightID).Count(&total).Error
+	return total, err
+}
+
+// DeleteMessage deletes a chat message.
+func (r *ChatRepository) DeleteMessage(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.ChatMessage{}, id).Error
+}
+
+// DeleteMessagesByInsightID deletes all chat messages of an insight and its conversation summary.
+func (r *ChatRepository) DeleteMessagesByInsightID(ctx context.Context, insightID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.ChatSummary{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("insight_id = ?", insightID).Delete(&models.ChatMessage{}).Error
+	})
+}
+
+// GetSummary returns the conversation summary of an insight, or nil if there is none.
+func (r *ChatRepository) GetSummary(ctx context.Context, insightID uint) (*models.ChatSummary, error) {
+	var summary models.ChatSummary
+	err := r.db.WithContext(ctx).Where("insight_id = ?", insightID).Limit(1).Find(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	if summary.ID == 0 {
+		return nil, nil
+	}
+	return &summary, nil
+}
+
+// SaveSummary stores summary if the stored summary still covers up to
+// previousCoveredID (0 = no summary yet). Returns false if another writer won the race.
+func (r *ChatRepository) SaveSummary(ctx context.Context, summary *models.ChatSummary, previousCoveredID uint) (bool, error) {
+	if previousCoveredID == 0 {
+		result := r.db.WithContext(ctx).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "insight_id"}}, DoNothing: true}).
+			Create(summary)
+		return result.RowsAffected == 1, result.Error
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.ChatSummary{}).
+		Where("insight_id = ? AND covered_message_id = ?", summary.InsightID, previousCoveredID).
+		Updates(map[string]interface{}{
+			"content":            summary.Content,
+			"covered_message_id": summary.CoveredMessageID,
+			"message_count":      summary.MessageCount,
+			"model":              summary.Model,
+		})
+	return result.RowsAffected == 1, result.Error
 }
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vibe-backend/internal/models"
+)
+
+// GoogleAccountRepository handles database operations for linked Google accounts.
+type GoogleAccountRepository struct {
+	db *gorm.DB
+}
+
+// NewGoogleAccountRepository creates a new GoogleAccountRepository.
+func NewGoogleAccountRepository(db *gorm.DB) *GoogleAccountRepository {
+	return &GoogleAccountRepository{db: db}
+}
+
+// GetByUserID returns the Google account linked to a user.
+func (r *GoogleAccountRepository) GetByUserID(ctx context.Context, userID uint) (*models.GoogleAccount, error) {
+	var account models.GoogleAccount
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByGoogleID returns the account linked to a Google user ID.
+func (r *GoogleAccountRepository) GetByGoogleID(ctx context.Context, googleID string) (*models.GoogleAccount, error) {
+	var account models.GoogleAccount
+	err := r.db.WithContext(ctx).Where("google_id = ?", googleID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Upsert links a Google account to account.UserID, replacing any existing link.
+func (r *GoogleAccountRepository) Upsert(ctx context.Context, account *models.GoogleAccount) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"google_id", "email", "encrypted_token", "token_expiry", "updated_at"}),
+	}).Create(account).Error
+}
+
+// UpdateToken stores a refreshed token of a user's Google account.
+func (r *GoogleAccountRepository) UpdateToken(ctx context.Context, userID uint, encryptedToken string, expiry *time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.GoogleAccount{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"encrypted_token": encryptedToken,
+			"token_expiry":    expiry,
+		}).Error
+}
+
+// DeleteByUserID removes the Google account link of a user.
+func (r *GoogleAccountRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.GoogleAccount{}).Error
+}
@@ -33,20 +33,51 @@ func (r *InsightRepository) GetByID(ctx context.Context, id uint) (*models.Insig
 	return &insight, nil
 }
 
-// GetByIDWithRelations returns an insight by ID with highlights preloaded.
-func (r *InsightRepository) GetByIDWithRelations(ctx context.Context, id uint) (*models.Insight, error) {
+// GetByIDForUser returns an insight by ID if it belongs to userID.
+// Insights of other users are reported as gorm.ErrRecordNotFound.
+func (r *InsightRepository) GetByIDForUser(ctx context.Context, id, userID uint) (*models.Insight, error) {
 	var insight models.Insight
-	err := r.db.WithContext(ctx).
-		Preload("Highlights", func(db *gorm.DB) *gorm.DB {
-			return db.Order("start_offset ASC")
-		}).
-		First(&insight, id).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&insight, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &insight, nil
 }
 
+// GetByIDForMember returns an insight by ID if userID owns it or is a member of
+// its workspace, together with the role of userID: owner for the creator of the
+// insight, the membership role otherwise. Highlights are preloaded if requested.
+// Insights the user cannot see are reported as gorm.ErrRecordNotFound.
+func (r *InsightRepository) GetByIDForMember(ctx context.Context, id, userID uint, withHighlights bool) (*models.Insight, models.WorkspaceRole, error) {
+	memberships := r.db.WithContext(ctx).Model(&models.WorkspaceMember{}).Select("workspace_id").Where("user_id = ?", userID)
+	query := r.db.WithContext(ctx).Where("user_id = ? OR workspace_id IN (?)", userID, memberships)
+	if withHighlights {
+		query = query.Preload("Highlights", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_offset ASC")
+		})
+	}
+
+	var insight models.Insight
+	if err := query.First(&insight, id).Error; err != nil {
+		return nil, "", err
+	}
+	if insight.UserID == userID {
+		return &insight, models.WorkspaceRoleOwner, nil
+	}
+	if insight.WorkspaceID == nil {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+
+	var member models.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", *insight.WorkspaceID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, "", err
+	}
+	return &insight, member.Role, nil
+}
+
 // GetByUserID returns insights for a user, optionally filtered by status.
 func (r *InsightRepository) GetByUserID(ctx context.Context, userID uint, status *models.InsightStatus, limit, offset int) ([]models.Insight, int64, error) {
 	var insights []models.Insight
@@ -75,6 +106,17 @@ func (r *InsightRepository) GetByUserID(ctx context.Context, userID uint, status
 
 // GetByUserIDGroupedByDate returns insights grouped by today, yesterday, and previous.
 func (r *InsightRepository) GetByUserIDGroupedByDate(ctx context.Context, userID uint, search string, limit int) (*models.InsightListResponse, error) {
+	return r.groupedByDate(r.db.WithContext(ctx).Where("user_id = ?", userID), search, limit)
+}
+
+// GetByWorkspaceIDGroupedByDate returns the insights of a workspace grouped by
+// today, yesterday, and previous.
+func (r *InsightRepository) GetByWorkspaceIDGroupedByDate(ctx context.Context, workspaceID uint, search string, limit int) (*models.InsightListResponse, error) {
+	return r.groupedByDate(r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID), search, limit)
+}
+
+// groupedByDate runs query and groups the insights by today, yesterday, and previous.
+func (r *InsightRepository) groupedByDate(query *gorm.DB, search string, limit int) (*models.InsightListResponse, error) {
 	var insights []models.Insight
 
 	// Get current time boundaries
@@ -82,9 +124,7 @@ func (r *InsightRepository) GetByUserIDGroupedByDate(ctx context.Context, userID
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	yesterdayStart := todayStart.AddDate(0, 0, -1)
 
-	query := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Order("created_at DESC")
+	query = query.Order("created_at DESC")
 
 	// Apply search filter if provided
 	if search != "" {
@@ -110,6 +150,8 @@ func (r *InsightRepository) GetByUserIDGroupedByDate(ctx context.Context, userID
 	for _, insight := range insights {
 		item := models.InsightListItem{
 			ID:           insight.ID,
+			UserID:       insight.UserID,
+			WorkspaceID:  insight.WorkspaceID,
 			SourceType:   insight.SourceType,
 			Title:        insight.Title,
 			Author:       insight.Author,
@@ -156,21 +198,31 @@ func (r *InsightRepository) GetBySourceURL(ctx context.Context, sourceURL string
 	return &insight, nil
 }
 
-// GetByShareToken returns an insight by share token.
-func (r *InsightRepository) GetByShareToken(ctx context.Context, token string) (*models.Insight, error) {
+// GetForShare returns an insight by ID with highlights and, if requested, the
+// conversation preloaded for a share link.
+func (r *InsightRepository) GetForShare(ctx context.Context, id uint, withChat bool) (*models.Insight, error) {
 	var insight models.Insight
-	err := r.db.WithContext(ctx).
-		Where("share_token = ? AND is_public = ?", token, true).
+	query := r.db.WithContext(ctx).
 		Preload("Highlights", func(db *gorm.DB) *gorm.DB {
 			return db.Order("start_offset ASC")
-		}).
-		First(&insight).Error
-	if err != nil {
+		})
+	if withChat {
+		query = query.Preload("ChatMessages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		})
+	}
+	if err := query.First(&insight, id).Error; err != nil {
 		return nil, err
 	}
 	return &insight, nil
 }
 
+// SetWorkspace moves an insight into a workspace, or back to the personal
+// library of its creator when workspaceID is nil.
+func (r *InsightRepository) SetWorkspace(ctx context.Context, id uint, workspaceID *uint) error {
+	return r.db.WithContext(ctx).Model(&models.Insight{}).Where("id = ?", id).Update("workspace_id", workspaceID).Error
+}
+
 // Update updates an insight record.
 func (r *InsightRepository) Update(ctx context.Context, insight *models.Insight) error {
 	return r.db.WithContext(ctx).Save(insight).Error
@@ -198,6 +250,18 @@ func (r *InsightRepository) Delete(ctx context.Context, id uint) error {
 		if err := tx.Where("insight_id = ?", id).Delete(&models.ChatMessage{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.TranscriptChunk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.ChatSummary{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.ShareView{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("insight_id = ?", id).Delete(&models.ShareLink{}).Error; err != nil {
+			return err
+		}
 		// Soft delete the insight
 		return tx.Delete(&models.Insight{}, id).Error
 	})
@@ -230,54 +294,107 @@ func (r *InsightRepository) GetHighlightByID(ctx context.Context, id uint) (*mod
 	return &highlight, nil
 }
 
-// UpdateHighlight updates a highlight record.
-func (r *InsightRepository) UpdateHighlight(ctx context.Context, highlight *models.Highlight) error {
-	return r.db.WithContext(ctx).Save(highlight).Error
+// GetHighlightForInsight returns a highlight by ID if it belongs to the insight.
+// Highlights of other insights are reported as gorm.ErrRecordNotFound.
+func (r *InsightRepository) GetHighlightForInsight(ctx context.Context, insightID, id uint) (*models.Highlight, error) {
+	var highlight models.Highlight
+	err := r.db.WithContext(ctx).Where("insight_id = ?", insightID).First(&highlight, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &highlight, nil
 }
 
-// DeleteHighlight deletes a highlight record.
-func (r *InsightRepository) DeleteHighlight(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Highlight{}, id).Error
-}
+// AttachHighlights loads the highlight references of messages anchored to a highlight.
+// Messages whose highlight was deleted keep their HighlightID without a reference.
+func (r *InsightRepository) AttachHighlights(ctx context.Context, messages []models.ChatMessage) error {
+	var ids []uint
+	for _, m := range messages {
+		if m.HighlightID != nil {
+			ids = append(ids, *m.HighlightID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
 
-// --- ChatMessage operations ---
+	var highlights []models.Highlight
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&highlights).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]*models.Highlight, len(highlights))
+	for i := range highlights {
+		byID[highlights[i].ID] = &highlights[i]
+	}
 
-// CreateChatMessage creates a new chat message record.
-func (r *InsightRepository) CreateChatMessage(ctx context.Context, message *models.ChatMessage) error {
-	return r.db.WithContext(ctx).Create(message).Error
+	for i := range messages {
+		if messages[i].HighlightID == nil {
+			continue
+		}
+		if h, ok := byID[*messages[i].HighlightID]; ok && h.InsightID == messages[i].InsightID {
+			messages[i].Highlight = h.Ref()
+		}
+	}
+	return nil
 }
 
-// GetChatMessagesByInsightID returns all chat messages for an insight.
-func (r *InsightRepository) GetChatMessagesByInsightID(ctx context.Context, insightID uint) ([]models.ChatMessage, error) {
-	var messages []models.ChatMessage
-	err := r.db.WithContext(ctx).
-		Where("insight_id = ?", insightID).
-		Order("created_at ASC").
-		Find(&messages).Error
-	return messages, err
+// AttachHighlightAuthors loads the author references of highlights.
+func (r *InsightRepository) AttachHighlightAuthors(ctx context.Context, highlights []models.Highlight) error {
+	ids := make([]uint, len(highlights))
+	for i, h := range highlights {
+		ids[i] = h.UserID
+	}
+	authors, err := r.authors(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range highlights {
+		highlights[i].Author = authors[highlights[i].UserID]
+	}
+	return nil
 }
 
-// GetChatMessagesByInsightIDPaginated returns paginated chat messages for an insight.
-func (r *InsightRepository) GetChatMessagesByInsightIDPaginated(ctx context.Context, insightID uint, limit, offset int) ([]models.ChatMessage, int64, error) {
-	var messages []models.ChatMessage
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.ChatMessage{}).Where("insight_id = ?", insightID)
+// AttachMessageAuthors loads the author references of chat messages.
+// Assistant replies are attributed to the member who asked the question.
+func (r *InsightRepository) AttachMessageAuthors(ctx context.Context, messages []models.ChatMessage) error {
+	ids := make([]uint, len(messages))
+	for i, m := range messages {
+		ids[i] = m.UserID
+	}
+	authors, err := r.authors(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		messages[i].Author = authors[messages[i].UserID]
+	}
+	return nil
+}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// authors returns the author references of the given users by ID.
+// Deleted users have no reference.
+func (r *InsightRepository) authors(ctx context.Context, userIDs []uint) (map[uint]*models.AuthorRef, error) {
+	authors := make(map[uint]*models.AuthorRef)
+	if len(userIDs) == 0 {
+		return authors, nil
 	}
 
-	err := query.
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&messages).Error
+	var users []models.User
+	if err := r.db.WithContext(ctx).Select("id", "name").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		authors[u.ID] = &models.AuthorRef{ID: u.ID, Name: u.Name}
+	}
+	return authors, nil
+}
 
-	return messages, total, err
+// UpdateHighlight updates a highlight record.
+func (r *InsightRepository) UpdateHighlight(ctx context.Context, highlight *models.Highlight) error {
+	return r.db.WithContext(ctx).Save(highlight).Error
 }
 
-// DeleteChatMessagesByInsightID deletes all chat messages for an insight.
-func (r *InsightRepository) DeleteChatMessagesByInsightID(ctx context.Context, insightID uint) error {
-	return r.db.WithContext(ctx).Where("insight_id = ?", insightID).Delete(&models.ChatMessage{}).Error
+// DeleteHighlight deletes a highlight record.
+func (r *InsightRepository) DeleteHighlight(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Highlight{}, id).Error
 }
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vibe-backend/internal/models"
+)
+
+// ErrLeaseLost is returned when a worker no longer holds the lease of a job.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// ErrUserJobLimit is returned when a user already has the maximum number of jobs in flight.
+var ErrUserJobLimit = errors.New("user job limit reached")
+
+// staleJobError is the error recorded on jobs buried because their lease
+// expired during their final attempt.
+const staleJobError = "lease expired after final attempt"
+
+// jobLockClass namespaces the advisory locks taken by this repository.
+const jobLockClass = 7301
+
+// JobRepository handles database operations for background jobs.
+type JobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new JobRepository.
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Create inserts a new job.
+func (r *JobRepository) Create(ctx context.Context, job *models.Job) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// CreateWithUserLimit inserts a new job for job.UserID unless that user already has
+// limit or more jobs queued or running. Returns the user's in-flight count before insert.
+func (r *JobRepository) CreateWithUserLimit(ctx context.Context, job *models.Job, limit int) (int64, error) {
+	var inFlight int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize enqueues of the same user so concurrent requests cannot exceed the cap
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", jobLockClass, int32(*job.UserID)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Job{}).
+			Where("user_id = ? AND status IN ?", *job.UserID, []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}).
+			Count(&inFlight).Error; err != nil {
+			return err
+		}
+		if inFlight >= int64(limit) {
+			return ErrUserJobLimit
+		}
+		return tx.Create(job).Error
+	})
+	return inFlight, err
+}
+
+// CountInFlightByUser returns the number of queued or running jobs owned by a user.
+func (r *JobRepository) CountInFlightByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("user_id = ? AND status IN ?", userID, []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}).
+		Count(&count).Error
+	return count, err
+}
+
+// QueuePosition returns the 1-based position of the user's next queued job in the
+// global queue, or 0 when the user has nothing waiting.
+func (r *JobRepository) QueuePosition(ctx context.Context, userID uint) (int64, error) {
+	var next models.Job
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, models.JobStatusQueued).
+		Order("run_at ASC, id ASC").
+		First(&next).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var ahead int64
+	err = r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ?", models.JobStatusQueued).
+		Where("run_at < ? OR (run_at = ? AND id < ?)", next.RunAt, next.RunAt, next.ID).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, err
+	}
+	return ahead + 1, nil
+}
+
+// GetByID returns a job by ID.
+func (r *JobRepository) GetByID(ctx context.Context, id uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Lease atomically claims the next runnable job for a worker.
+// Returns gorm.ErrRecordNotFound when no job is ready.
+func (r *JobRepository) Lease(ctx context.Context, types []string, owner string, leaseFor time.Duration) (*models.Job, error) {
+	var job models.Job
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", models.JobStatusQueued, now)
+		if len(types) > 0 {
+			query = query.Where("type IN ?", types)
+		}
+		if err := query.Order("run_at ASC, id ASC").First(&job).Error; err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(leaseFor)
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LeaseOwner = owner
+		job.LeaseExpiresAt = &expiresAt
+		job.HeartbeatAt = &now
+
+		return tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":           job.Status,
+			"attempts":         job.Attempts,
+			"lease_owner":      owner,
+			"lease_expires_at": expiresAt,
+			"heartbeat_at":     now,
+			"updated_at":       now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Heartbeat extends the lease of a running job held by owner.
+func (r *JobRepository) Heartbeat(ctx context.Context, id uint, owner string, leaseFor time.Duration) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"lease_expires_at": now.Add(leaseFor),
+			"heartbeat_at":     now,
+			"updated_at":       now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Complete marks a running job as succeeded.
+func (r *JobRepository) Complete(ctx context.Context, id uint, owner string) error {
+	now := time.Now()
+	return r.finish(ctx, id, owner, map[string]interface{}{
+		"status":       models.JobStatusSucceeded,
+		"completed_at": now,
+		"last_error":   "",
+	})
+}
+
+// Retry puts a running job back in the queue to be attempted again at runAt.
+func (r *JobRepository) Retry(ctx context.Context, id uint, owner string, runAt time.Time, errMsg string) error {
+	return r.finish(ctx, id, owner, map[string]interface{}{
+		"status":     models.JobStatusQueued,
+		"run_at":     runAt,
+		"last_error": errMsg,
+	})
+}
+
+// Bury moves a running job to the dead-letter state.
+func (r *JobRepository) Bury(ctx context.Context, id uint, owner string, errMsg string) error {
+	now := time.Now()
+	return r.finish(ctx, id, owner, map[string]interface{}{
+		"status":       models.JobStatusDead,
+		"completed_at": now,
+		"last_error":   errMsg,
+	})
+}
+
+// Release returns a running job to the queue without consuming an attempt.
+// Used when a worker shuts down while the job is in flight.
+func (r *JobRepository) Release(ctx context.Context, id uint, owner string) error {
+	return r.finish(ctx, id, owner, map[string]interface{}{
+		"status":   models.JobStatusQueued,
+		"run_at":   time.Now(),
+		"attempts": gorm.Expr("GREATEST(attempts - 1, 0)"),
+	})
+}
+
+// finish clears the lease of a job held by owner and applies the given updates.
+func (r *JobRepository) finish(ctx context.Context, id uint, owner string, updates map[string]interface{}) error {
+	updates["lease_owner"] = ""
+	updates["lease_expires_at"] = nil
+	updates["updated_at"] = time.Now()
+
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// RecoverStale re-queues running jobs whose lease has expired, and buries the
+// ones that already used up all their attempts. Returns the number of jobs
+// re-queued and the buried jobs, whose dead-letter handlers still need to run.
+func (r *JobRepository) RecoverStale(ctx context.Context) (requeued int64, buried []models.Job, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Lock the exhausted jobs so concurrent recoveries bury each job only once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND lease_expires_at < ? AND attempts >= max_attempts", models.JobStatusRunning, now).
+			Find(&buried).Error; err != nil {
+			return err
+		}
+		if len(buried) > 0 {
+			ids := make([]uint, len(buried))
+			for i := range buried {
+				ids[i] = buried[i].ID
+				buried[i].Status = models.JobStatusDead
+				buried[i].LastError = staleJobError
+			}
+			if err := tx.Model(&models.Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":           models.JobStatusDead,
+				"lease_owner":      "",
+				"lease_expires_at": nil,
+				"completed_at":     now,
+				"last_error":       staleJobError,
+				"updated_at":       now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.Job{}).
+			Where("status = ? AND lease_expires_at < ? AND attempts < max_attempts", models.JobStatusRunning, now).
+			Updates(map[string]interface{}{
+				"status":           models.JobStatusQueued,
+				"lease_owner":      "",
+				"lease_expires_at": nil,
+				"run_at":           now,
+				"updated_at":       now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		requeued = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return requeued, buried, nil
+}
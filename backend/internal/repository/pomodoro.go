@@ -22,10 +22,11 @@ func (r *PomodoroRepository) Create(ctx context.Context, pomodoro *models.Pomodo
 	return r.db.WithContext(ctx).Create(pomodoro).Error
 }
 
-// GetByID returns a pomodoro by ID.
-func (r *PomodoroRepository) GetByID(ctx context.Context, id uint) (*models.Pomodoro, error) {
+// GetByIDForUser returns a pomodoro by ID if it belongs to userID.
+// Pomodoros of other users are reported as gorm.ErrRecordNotFound.
+func (r *PomodoroRepository) GetByIDForUser(ctx context.Context, id, userID uint) (*models.Pomodoro, error) {
 	var pomodoro models.Pomodoro
-	err := r.db.WithContext(ctx).First(&pomodoro, id).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&pomodoro, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -53,9 +54,17 @@ func (r *PomodoroRepository) Update(ctx context.Context, pomodoro *models.Pomodo
 	return r.db.WithContext(ctx).Save(pomodoro).Error
 }
 
-// Delete soft deletes a pomodoro.
-func (r *PomodoroRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Pomodoro{}, id).Error
+// DeleteForUser soft deletes a pomodoro of userID.
+// Returns gorm.ErrRecordNotFound if the user has no such pomodoro.
+func (r *PomodoroRepository) DeleteForUser(ctx context.Context, id, userID uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Pomodoro{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 // CountByUserID returns the count of pomodoros for a user.
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// SessionRepository handles database operations for login sessions and their refresh tokens.
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepository.
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create creates a session together with its first refresh token.
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+// GetByID returns a session by ID.
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetRefreshToken returns a refresh token by the hash of the token.
+func (r *SessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate marks the refresh token usedID as used at and stores its successor.
+// Returns false without storing next if usedID was already used, so two
+// concurrent refreshes with the same token cannot both succeed.
+func (r *SessionRepository) Rotate(ctx context.Context, usedID uint, next *models.RefreshToken, at time.Time) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", usedID).
+			Update("used_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Session{}).
+			Where("id = ?", next.SessionID).
+			Update("last_used_at", at).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// LastUsedRefreshToken returns the most recently used refresh token of a session.
+func (r *SessionRepository) LastUsedRefreshToken(ctx context.Context, sessionID string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND used_at IS NOT NULL", sessionID).
+		Order("used_at DESC, id DESC").
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke revokes a session. Revoking a revoked session is a no-op.
+func (r *SessionRepository) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+}
+
+// RevokeAllForUser revokes every active session of a user and returns how many were revoked.
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID uint, reason string, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeOthersForUser revokes every active session of a user except keepID and returns how many were revoked.
+func (r *SessionRepository) RevokeOthersForUser(ctx context.Context, userID uint, keepID, reason string, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteExpiredRefreshTokens deletes refresh tokens that expired before cutoff.
+func (r *SessionRepository) DeleteExpiredRefreshTokens(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", cutoff).
+		Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
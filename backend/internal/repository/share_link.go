@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// ShareLinkRepository handles database operations for insight share links.
+type ShareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewShareLinkRepository creates a new ShareLinkRepository.
+func NewShareLinkRepository(db *gorm.DB) *ShareLinkRepository {
+	return &ShareLinkRepository{db: db}
+}
+
+// Create creates a new share link record.
+func (r *ShareLinkRepository) Create(ctx context.Context, link *models.ShareLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+// GetByToken returns a share link by its token, including revoked links.
+func (r *ShareLinkRepository) GetByToken(ctx context.Context, token string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListByInsight returns the share links of an insight, newest first. Revoked links are included.
+func (r *ShareLinkRepository) ListByInsight(ctx context.Context, insightID uint) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ?", insightID).
+		Order("id DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// Revoke revokes a share link of an insight. Revoking a revoked link is a no-op.
+// Returns gorm.ErrRecordNotFound if the insight has no such link.
+func (r *ShareLinkRepository) Revoke(ctx context.Context, insightID, id uint, at time.Time) error {
+	var link models.ShareLink
+	if err := r.db.WithContext(ctx).Where("insight_id = ?", insightID).First(&link, id).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// RevokeAllForInsight revokes every active share link of an insight.
+func (r *ShareLinkRepository) RevokeAllForInsight(ctx context.Context, insightID uint, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.ShareLink{}).
+		Where("insight_id = ? AND revoked_at IS NULL", insightID).
+		Update("revoked_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// RecordView counts a view of a share link unless the link has been revoked
+// or has reached its view limit in the meantime. Returns false if the view
+// was not counted.
+func (r *ShareLinkRepository) RecordView(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_views = 0 OR view_count < max_views)", id).
+		Updates(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MigrateLegacyShares moves the single share of each insight from the former
+// insights.share_* columns into share_links and drops those columns. Links
+// keep their tokens, so shared URLs keep working. Shares that were not public
+// could not be opened and are not migrated.
+func (r *ShareLinkRepository) MigrateLegacyShares(ctx context.Context) (int, error) {
+	migrator := r.db.WithContext(ctx).Migrator()
+	if !migrator.HasColumn("insights", "share_token") {
+		return 0, nil
+	}
+
+	var legacy []struct {
+		ID            uint
+		UserID        uint
+		ShareToken    string
+		SharePassword string
+		ShareConfig   datatypes.JSON
+		SharedAt      *time.Time
+	}
+	if err := r.db.WithContext(ctx).Table("insights").
+		Select("id, user_id, share_token, share_password, share_config, shared_at").
+		Where("share_token IS NOT NULL AND share_token <> '' AND is_public = ? AND deleted_at IS NULL", true).
+		Find(&legacy).Error; err != nil {
+		return 0, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, share := range legacy {
+			link := &models.ShareLink{
+				InsightID:    share.ID,
+				UserID:       share.UserID,
+				Token:        share.ShareToken,
+				PasswordHash: share.SharePassword,
+				Config:       share.ShareConfig,
+			}
+			if share.SharedAt != nil {
+				link.CreatedAt = *share.SharedAt
+			}
+			if err := tx.Create(link).Error; err != nil {
+				return err
+			}
+		}
+		for _, column := range []string{"share_token", "share_password", "share_config", "is_public", "shared_at"} {
+			if err := tx.Migrator().DropColumn("insights", column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(legacy), nil
+}
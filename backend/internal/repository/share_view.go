@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// maxShareReferrers bounds the referrer hosts returned with share stats.
+const maxShareReferrers = 10
+
+// ShareViewRepository handles database operations for share link view events.
+type ShareViewRepository struct {
+	db *gorm.DB
+}
+
+// NewShareViewRepository creates a new ShareViewRepository.
+func NewShareViewRepository(db *gorm.DB) *ShareViewRepository {
+	return &ShareViewRepository{db: db}
+}
+
+// Create creates a new view event record.
+func (r *ShareViewRepository) Create(ctx context.Context, view *models.ShareView) error {
+	return r.db.WithContext(ctx).Create(view).Error
+}
+
+// ShareTotals are the all-time counters of an insight's share links.
+type ShareTotals struct {
+	Views          int64
+	UniqueVisitors int64
+	FailedPassword int64
+}
+
+// Totals returns the all-time counters of the share links of an insight.
+func (r *ShareViewRepository) Totals(ctx context.Context, insightID uint) (*ShareTotals, error) {
+	var totals ShareTotals
+	err := r.db.WithContext(ctx).
+		Model(&models.ShareView{}).
+		Select(`COUNT(*) FILTER (WHERE kind = ?) AS views,
+			COUNT(DISTINCT visitor_hash) FILTER (WHERE kind = ?) AS unique_visitors,
+			COUNT(*) FILTER (WHERE kind = ?) AS failed_password`,
+			models.ShareViewKindView, models.ShareViewKindView, models.ShareViewKindPasswordFailed).
+		Where("insight_id = ?", insightID).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// Daily returns the views of the share links of an insight per UTC day since
+// from. Days without views are omitted.
+func (r *ShareViewRepository) Daily(ctx context.Context, insightID uint, from time.Time) ([]models.ShareDailyStats, error) {
+	var days []models.ShareDailyStats
+	err := r.db.WithContext(ctx).
+		Model(&models.ShareView{}).
+		Select(`TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date,
+			COUNT(*) AS views,
+			COUNT(DISTINCT visitor_hash) AS unique_visitors`).
+		Where("insight_id = ? AND kind = ? AND created_at >= ?", insightID, models.ShareViewKindView, from).
+		Group("date").
+		Order("date ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+// ByLink returns the views of each share link of an insight that has been viewed.
+func (r *ShareViewRepository) ByLink(ctx context.Context, insightID uint) ([]models.ShareLinkStats, error) {
+	var links []models.ShareLinkStats
+	err := r.db.WithContext(ctx).
+		Model(&models.ShareView{}).
+		Select(`share_link_id, COUNT(*) AS views, COUNT(DISTINCT visitor_hash) AS unique_visitors`).
+		Where("insight_id = ? AND kind = ?", insightID, models.ShareViewKindView).
+		Group("share_link_id").
+		Order("views DESC").
+		Scan(&links).Error
+	return links, err
+}
+
+// ByUserAgentClass returns the views of the share links of an insight per user agent class.
+func (r *ShareViewRepository) ByUserAgentClass(ctx context.Context, insightID uint) ([]models.ShareCount, error) {
+	var counts []models.ShareCount
+	err := r.db.WithContext(ctx).
+		Model(&models.ShareView{}).
+		Select("user_agent_class AS key, COUNT(*) AS views").
+		Where("insight_id = ? AND kind = ?", insightID, models.ShareViewKindView).
+		Group("user_agent_class").
+		Order("views DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// TopReferrers returns the referrer hosts that sent the most views to the share links of an insight.
+func (r *ShareViewRepository) TopReferrers(ctx context.Context, insightID uint) ([]models.ShareCount, error) {
+	var counts []models.ShareCount
+	err := r.db.WithContext(ctx).
+		Model(&models.ShareView{}).
+		Select("referrer_host AS key, COUNT(*) AS views").
+		Where("insight_id = ? AND kind = ? AND referrer_host <> ''", insightID, models.ShareViewKindView).
+		Group("referrer_host").
+		Order("views DESC").
+		Limit(maxShareReferrers).
+		Scan(&counts).Error
+	return counts, err
+}
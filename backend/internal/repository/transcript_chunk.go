@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// TranscriptChunkRepository handles database operations for transcript chunks.
+type TranscriptChunkRepository struct {
+	db *gorm.DB
+}
+
+// NewTranscriptChunkRepository creates a new TranscriptChunkRepository.
+func NewTranscriptChunkRepository(db *gorm.DB) *TranscriptChunkRepository {
+	return &TranscriptChunkRepository{db: db}
+}
+
+// ReplaceForInsight replaces all chunks of an insight.
+func (r *TranscriptChunkRepository) ReplaceForInsight(ctx context.Context, insightID uint, chunks []models.TranscriptChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("insight_id = ?", insightID).Delete(&models.TranscriptChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(chunks, 100).Error
+	})
+}
+
+// ListByInsight returns the chunks of an insight in transcript order.
+func (r *TranscriptChunkRepository) ListByInsight(ctx context.Context, insightID uint) ([]models.TranscriptChunk, error) {
+	var chunks []models.TranscriptChunk
+	err := r.db.WithContext(ctx).
+		Where("insight_id = ?", insightID).
+		Order("position ASC").
+		Find(&chunks).Error
+	return chunks, err
+}
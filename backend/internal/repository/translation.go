@@ -36,6 +36,22 @@ func (r *TranslationRepository) GetByID(ctx context.Context, id uint) (*models.T
 	return &translation, nil
 }
 
+// GetByIDForUser returns a translation of userID by ID with dual subtitles.
+// Translations of other users are reported as gorm.ErrRecordNotFound.
+func (r *TranslationRepository) GetByIDForUser(ctx context.Context, id, userID uint) (*models.Translation, error) {
+	var translation models.Translation
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Preload("DualSubtitles", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_index ASC")
+		}).
+		First(&translation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
 // GetByVideoID returns translations for a video ID.
 func (r *TranslationRepository) GetByVideoID(ctx context.Context, videoID string, limit, offset int) ([]models.Translation, error) {
 	var translations []models.Translation
@@ -59,6 +75,26 @@ func (r *TranslationRepository) Update(ctx context.Context, translation *models.
 	return r.db.WithContext(ctx).Save(translation).Error
 }
 
+// SaveResult persists a finished translation together with its dual subtitles,
+// replacing subtitles left over from a previous attempt.
+func (r *TranslationRepository) SaveResult(ctx context.Context, translation *models.Translation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("translation_id = ?", translation.ID).Delete(&models.DualSubtitle{}).Error; err != nil {
+			return err
+		}
+		if len(translation.DualSubtitles) > 0 {
+			for i := range translation.DualSubtitles {
+				translation.DualSubtitles[i].ID = 0
+				translation.DualSubtitles[i].TranslationID = translation.ID
+			}
+			if err := tx.Create(&translation.DualSubtitles).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("DualSubtitles").Save(translation).Error
+	})
+}
+
 // Delete soft deletes a translation record.
 func (r *TranslationRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.Translation{}, id).Error
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// UsageRepository handles database operations for the LLM usage ledger.
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates a new UsageRepository.
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// Create appends an entry to the usage ledger.
+func (r *UsageRepository) Create(ctx context.Context, usage *models.LLMUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+// usageTotalsSelect aggregates ledger rows into models.UsageTotals columns.
+const usageTotalsSelect = "COUNT(*) AS calls, " +
+	"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+	"COALESCE(SUM(cost_usd), 0) AS cost_usd"
+
+// GetTotals returns the usage of a user in [from, to).
+func (r *UsageRepository) GetTotals(ctx context.Context, userID uint, from, to time.Time) (*models.UsageTotals, error) {
+	var totals models.UsageTotals
+	err := r.db.WithContext(ctx).Model(&models.LLMUsage{}).
+		Select(usageTotalsSelect).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// GetBreakdown returns the usage of a user in [from, to) grouped by column
+// ("feature" or "model"), highest token count first.
+func (r *UsageRepository) GetBreakdown(ctx context.Context, userID uint, from, to time.Time, column string) ([]models.UsageBreakdown, error) {
+	if column != "feature" && column != "model" {
+		column = "feature"
+	}
+
+	var rows []models.UsageBreakdown
+	err := r.db.WithContext(ctx).Model(&models.LLMUsage{}).
+		Select(column+" AS key, "+usageTotalsSelect).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Group(column).
+		Order("total_tokens DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -2,9 +2,8 @@ package repository
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -21,21 +20,16 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create creates a new user with hashed password and API key.
+// Create creates a new user with hashed password. An empty password creates
+// an account that cannot log in with a password (e.g. Google sign-in).
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
-	}
-	user.Password = string(hashedPassword)
-
-	// Generate API key
-	apiKey, err := generateAPIKey()
-	if err != nil {
-		return fmt.Errorf("failed to generate API key: %w", err)
+	if user.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		user.Password = string(hashedPassword)
 	}
-	user.APIKey = apiKey
 
 	return r.db.WithContext(ctx).Create(user).Error
 }
@@ -60,10 +54,11 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
-// GetByAPIKey returns a user by API key.
-func (r *UserRepository) GetByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
+// GetByEmailWithDeleted returns a user by email, including soft-deleted users
+// whose email stays taken until they are purged.
+func (r *UserRepository) GetByEmailWithDeleted(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("api_key = ?", apiKey).First(&user).Error
+	err := r.db.WithContext(ctx).Unscoped().Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -88,28 +83,39 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, userID uint, newPas
 		Update("password", string(hashedPassword)).Error
 }
 
-// VerifyPassword verifies a user's password.
-func (r *UserRepository) VerifyPassword(user *models.User, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-	return err == nil
+// MarkEmailVerified records that a user confirmed their email address.
+// Verifying a verified address keeps the original time.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", at).Error
 }
 
-// RegenerateAPIKey generates a new API key for a user.
-func (r *UserRepository) RegenerateAPIKey(ctx context.Context, userID uint) (string, error) {
-	apiKey, err := generateAPIKey()
-	if err != nil {
-		return "", fmt.Errorf("failed to generate API key: %w", err)
-	}
+// ListGoogleLinked returns the users that have a linked Google account.
+func (r *UserRepository) ListGoogleLinked(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", r.db.Model(&models.GoogleAccount{}).Select("user_id")).
+		Find(&users).Error
+	return users, err
+}
 
-	err = r.db.WithContext(ctx).
+// ClearPassword removes the password of a user, leaving only passwordless sign-in.
+func (r *UserRepository) ClearPassword(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
-		Update("api_key", apiKey).Error
-	if err != nil {
-		return "", err
-	}
+		Update("password", "").Error
+}
 
-	return apiKey, nil
+// VerifyPassword verifies a user's password. Users without a password never match.
+func (r *UserRepository) VerifyPassword(user *models.User, password string) bool {
+	if !user.HasPassword() {
+		return false
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	return err == nil
 }
 
 // Delete soft-deletes a user.
@@ -117,11 +123,32 @@ func (r *UserRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
 
-// generateAPIKey generates a random API key.
-func generateAPIKey() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// GetByIDWithDeleted returns a user by ID, including soft-deleted users.
+func (r *UserRepository) GetByIDWithDeleted(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().First(&user, id).Error
+	if err != nil {
+		return nil, err
 	}
-	return hex.EncodeToString(bytes), nil
+	return &user, nil
+}
+
+// Restore undoes the soft delete of a user.
+func (r *UserRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil).Error
+}
+
+// ListDeletedBefore returns the IDs of users soft-deleted before cutoff.
+func (r *UserRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error
+	return ids, err
 }
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// UserTokenRepository handles database operations for email verification and password reset tokens.
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewUserTokenRepository creates a new UserTokenRepository.
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// Replace stores token and uses up every other unused token of the same user
+// and purpose, so only the most recently sent link works.
+func (r *UserTokenRepository) Replace(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetByHash returns a token by the hash of the token.
+func (r *UserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume marks a token used at. Returns false if it was already used, so a
+// token cannot be redeemed twice by concurrent requests.
+func (r *UserTokenRepository) Consume(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteExpired deletes tokens that expired before cutoff.
+func (r *UserTokenRepository) DeleteExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", cutoff).
+		Delete(&models.UserToken{})
+	return result.RowsAffected, result.Error
+}
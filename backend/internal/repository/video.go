@@ -32,10 +32,21 @@ func (r *VideoRepository) GetAnalysisByID(ctx context.Context, id uint) (*models
 	return &analysis, nil
 }
 
-// GetAnalysisByJobID returns a video analysis by job ID.
-func (r *VideoRepository) GetAnalysisByJobID(ctx context.Context, jobID string) (*models.VideoAnalysis, error) {
+// GetAnalysisByIDForUser returns a video analysis by ID if it belongs to userID.
+// Analyses of other users are reported as gorm.ErrRecordNotFound.
+func (r *VideoRepository) GetAnalysisByIDForUser(ctx context.Context, id, userID uint) (*models.VideoAnalysis, error) {
 	var analysis models.VideoAnalysis
-	err := r.db.WithContext(ctx).Where("job_id = ?", jobID).First(&analysis).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&analysis, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+// GetAnalysisByJobID returns a video analysis of userID by job ID.
+func (r *VideoRepository) GetAnalysisByJobID(ctx context.Context, jobID string, userID uint) (*models.VideoAnalysis, error) {
+	var analysis models.VideoAnalysis
+	err := r.db.WithContext(ctx).Where("job_id = ? AND user_id = ?", jobID, userID).First(&analysis).Error
 	if err != nil {
 		return nil, err
 	}
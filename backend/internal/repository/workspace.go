@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"vibe-backend/internal/models"
+)
+
+// WorkspaceRepository handles database operations for workspaces and their members.
+type WorkspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository creates a new WorkspaceRepository.
+func NewWorkspaceRepository(db *gorm.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// Create creates a workspace and makes its owner a member with the owner role.
+func (r *WorkspaceRepository) Create(ctx context.Context, workspace *models.Workspace) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      workspace.OwnerID,
+			Role:        models.WorkspaceRoleOwner,
+		}).Error
+	})
+}
+
+// GetByID returns a workspace by ID.
+func (r *WorkspaceRepository) GetByID(ctx context.Context, id uint) (*models.Workspace, error) {
+	var workspace models.Workspace
+	err := r.db.WithContext(ctx).First(&workspace, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// Update updates a workspace record.
+func (r *WorkspaceRepository) Update(ctx context.Context, workspace *models.Workspace) error {
+	return r.db.WithContext(ctx).Save(workspace).Error
+}
+
+// Delete soft-deletes a workspace and removes its members. Insights of the
+// workspace return to the personal libraries of their creators.
+func (r *WorkspaceRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Insight{}).Where("workspace_id = ?", id).Update("workspace_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", id).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Workspace{}, id).Error
+	})
+}
+
+// ListMembershipsByUser returns the memberships of a user with their
+// workspaces, oldest first.
+func (r *WorkspaceRepository) ListMembershipsByUser(ctx context.Context, userID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Joins("Workspace").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspace_members.id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// GetMember returns the membership of userID in a workspace.
+// Returns gorm.ErrRecordNotFound if the user is not a member.
+func (r *WorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers returns the members of a workspace with their users, oldest first.
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Joins("User").
+		Where("workspace_members.workspace_id = ?", workspaceID).
+		Order("workspace_members.id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// AddMember adds a member to a workspace. Returns false if the user already is a member.
+func (r *WorkspaceRepository) AddMember(ctx context.Context, member *models.WorkspaceMember) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(member)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateMemberRole changes the role of a member.
+func (r *WorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID, userID uint, role models.WorkspaceRole) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember removes a member from a workspace. Insights the member added
+// return to their personal library.
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Insight{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+			Update("workspace_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&models.WorkspaceMember{}).Error
+	})
+}
@@ -1,19 +1,26 @@
 package router
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"vibe-backend/internal/cache"
 	"vibe-backend/internal/config"
 	"vibe-backend/internal/database"
 	"vibe-backend/internal/handlers"
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/mail"
 	"vibe-backend/internal/middleware"
+	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 	"vibe-backend/internal/services"
 )
 
 // New creates and configures a new Gin router.
-func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, log *zap.Logger) *gin.Engine {
+// Job handlers are registered on queue, which may be nil when the database is unavailable.
+func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, queue *jobs.Queue, log *zap.Logger) *gin.Engine {
 	// Set Gin mode based on environment
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -41,10 +48,57 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 		return r
 	}
 
+	// Job queue used by async handlers (nil interface when not configured)
+	var jobQueue handlers.JobQueue
+	if queue != nil {
+		jobQueue = queue
+	}
+
+	// Shared LLM client used by all AI services
+	llmProvider, err := llm.NewProvider(llm.ProviderConfig{
+		Name:    cfg.LLMProvider,
+		APIKey:  cfg.LLMKey(),
+		BaseURL: cfg.LLMBaseURL,
+	}, log)
+	if err != nil {
+		log.Error("Invalid LLM provider configuration, falling back to OpenRouter", zap.Error(err))
+		llmProvider = llm.NewOpenRouter(cfg.OpenRouterAPIKey, log)
+	}
+	llmClient := llm.NewClient(llmProvider, llm.Options{
+		Timeout:    cfg.LLMTimeout,
+		MaxRetries: cfg.LLMMaxRetries,
+	}, log)
+
+	// Per-user LLM usage ledger and monthly budgets
+	llmPrices, err := llm.ParsePriceTable(cfg.LLMPrices)
+	if err != nil {
+		log.Error("Invalid LLM_PRICES, costs will be recorded as 0", zap.Error(err))
+		llmPrices = llm.PriceTable{}
+	}
+	usageRepo := repository.NewUsageRepository(db.DB)
+	usageService := services.NewUsageService(usageRepo, llmPrices, llmClient.ProviderName(), cfg.LLMMonthlyTokenBudget, cfg.LLMMonthlyCostBudgetUSD, log)
+	llmClient.SetUsageRecorder(usageService)
+	usageHandler := handlers.NewUsageHandler(usageService, log)
+
 	// Initialize other handlers (require database)
 	pomodoroRepo := repository.NewPomodoroRepository(db.DB)
 	pomodoroHandler := handlers.NewPomodoroHandler(pomodoroRepo)
+
+	// Twitter/X thread fetching (syndication endpoints or local fixtures)
+	twitterFetcher, err := services.NewTwitterFetcher(services.TwitterConfig{
+		Backend:        cfg.TwitterBackend,
+		SyndicationURL: cfg.TwitterSyndicationURL,
+		FixturesDir:    cfg.TwitterFixturesDir,
+		Timeout:        cfg.TwitterTimeout,
+	}, log)
+	if err != nil {
+		log.Error("Invalid Twitter configuration, falling back to syndication", zap.Error(err))
+		twitterFetcher = services.NewTwitterSyndication(cfg.TwitterSyndicationURL, cfg.TwitterTimeout)
+	}
+	twitterService := services.NewTwitterService(twitterFetcher, cfg.TwitterMaxThreadPosts, log)
+
 	parserService := services.NewParserService(log)
+	parserService.SetTwitterService(twitterService)
 	parseHandler := handlers.NewParseHandler(parserService, log)
 
 	// Analysis handlers
@@ -53,37 +107,135 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 
 	// YouTube video analysis handlers
 	videoRepo := repository.NewVideoRepository(db.DB)
-	youtubeService := services.NewYouTubeService(cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
-	videoHandler := handlers.NewVideoHandler(videoRepo, youtubeService, log)
+	youtubeService := services.NewYouTubeService(llmClient, cfg.GeminiModel, log)
+	videoHandler := handlers.NewVideoHandler(videoRepo, youtubeService, jobQueue, log)
 
 	// Transcript service (yt-dlp based subtitle extraction)
 	transcriptService := services.NewTranscriptService(log)
 
 	// Translation service and handlers
 	translationRepo := repository.NewTranslationRepository(db.DB)
-	translationService := services.NewTranslationService(cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
-	translationHandler := handlers.NewTranslationHandler(translationRepo, translationService, transcriptService, log)
+	translationService := services.NewTranslationService(llmClient, cfg.ModelOrDefault(cfg.TranslationModel), log)
+	translationService.SetUsageService(usageService) // Enforce monthly LLM budgets
+	translationHandler := handlers.NewTranslationHandler(translationRepo, translationService, transcriptService, jobQueue, log)
 
 	// User authentication handlers
 	userRepo := repository.NewUserRepository(db.DB)
-	userHandler := handlers.NewUserHandler(userRepo, log)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.DB)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, log)
+	if err := apiKeyService.MigrateLegacyKeys(context.Background()); err != nil {
+		log.Error("Failed to migrate legacy API keys", zap.Error(err))
+	}
+	sessionRepo := repository.NewSessionRepository(db.DB)
+	sessionService := services.NewSessionService(sessionRepo, userRepo, cfg.SessionSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, log)
+	if err := sessionService.PruneExpired(context.Background()); err != nil {
+		log.Error("Failed to prune expired refresh tokens", zap.Error(err))
+	}
+	authenticator := services.NewAuthenticator(sessionService, apiKeyService) // Access tokens and API keys
+	mailer, err := mail.NewMailer(mail.Config{
+		Driver:       cfg.MailDriver,
+		From:         cfg.MailFrom,
+		Dir:          cfg.MailDir,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	}, log)
+	if err != nil {
+		log.Error("Invalid mail configuration, emails will only be logged", zap.Error(err))
+		mailer = mail.NewLogMailer(log)
+	}
+	userTokenRepo := repository.NewUserTokenRepository(db.DB)
+	accountService := services.NewAccountService(userTokenRepo, userRepo, sessionService, mailer, cfg.FrontendURL, cfg.EmailVerificationTTL, cfg.PasswordResetTTL, log)
+	if err := accountService.MigratePlaceholderPasswords(context.Background()); err != nil {
+		log.Error("Failed to remove placeholder passwords", zap.Error(err))
+	}
+	if err := accountService.PruneExpired(context.Background()); err != nil {
+		log.Error("Failed to prune expired account tokens", zap.Error(err))
+	}
+	authAudit := services.NewAuthAuditService(repository.NewAuthEventRepository(db.DB), cfg.AuthEventRetention, log)
+	if err := authAudit.PruneExpired(context.Background()); err != nil {
+		log.Error("Failed to prune expired auth events", zap.Error(err))
+	}
+	attemptLimits := services.AttemptLimits{
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		BaseBackoff:   cfg.LoginBackoffBase,
+		MaxBackoff:    cfg.LoginBackoffMax,
+		Lockout:       cfg.LoginLockout,
+	}
+	attemptStore := services.NewAttemptStore(cache) // Failure counters in Redis (memory if disabled)
+	loginAttempts := services.NewAttemptLimiter(attemptStore, "login", attemptLimits)
+	userHandler := handlers.NewUserHandler(userRepo, apiKeyService, sessionService, accountService, loginAttempts, authAudit, cfg.RequireEmailVerification, log)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, log)
 
 	// InsightFlow handlers
 	insightRepo := repository.NewInsightRepository(db.DB)
 	insightProcessor := services.NewInsightProcessor(insightRepo, youtubeService, log)
+	insightProcessor.SetTwitterService(twitterService)         // Unroll Twitter/X threads
 	insightProcessor.SetTranslationService(translationService) // Inject translation service
-	insightHandler := handlers.NewInsightHandler(insightRepo, insightProcessor, log)
+	summaryService := services.NewSummaryService(llmClient, cfg.ModelOrDefault(cfg.SummaryModel), log)
+	insightProcessor.SetSummaryService(summaryService) // Inject summary service
+	chunkRepo := repository.NewTranscriptChunkRepository(db.DB)
+	retrievalService := services.NewRetrievalService(chunkRepo, llmClient, cfg.EmbeddingModel, log)
+	insightProcessor.SetRetrievalService(retrievalService) // Index transcripts for chat retrieval
+	progressBroker := services.NewProgressBroker()
+	insightProcessor.SetProgressBroker(progressBroker) // Publish progress for SSE subscribers
+	shareService := services.NewShareService(repository.NewShareLinkRepository(db.DB), repository.NewShareViewRepository(db.DB), insightRepo, cfg.ShareSecret, cfg.ShareAccessTTL, log)
+	if err := shareService.MigrateLegacyShares(context.Background()); err != nil {
+		log.Error("Failed to migrate legacy insight shares", zap.Error(err))
+	}
+	insightHandler := handlers.NewInsightHandler(insightRepo, shareService, jobQueue, progressBroker, log)
+	insightHandler.SetShareProtection(services.NewAttemptLimiter(attemptStore, "share", attemptLimits), authAudit) // Back off failed share passwords
+	workspaceService := services.NewWorkspaceService(repository.NewWorkspaceRepository(db.DB), insightRepo, userRepo, log)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService, log)
+
+	// Register background job handlers
+	if queue != nil {
+		queue.Register(models.JobTypeInsightProcess, insightProcessor.HandleJob)
+		queue.OnDeadLetter(models.JobTypeInsightProcess, insightProcessor.HandleDeadJob)
+		queue.Register(models.JobTypeVideoAnalyze, videoHandler.HandleAnalysisJob)
+		queue.OnDeadLetter(models.JobTypeVideoAnalyze, videoHandler.HandleDeadAnalysisJob)
+		queue.Register(models.JobTypeTranslationProcess, translationHandler.HandleTranslationJob)
+		queue.OnDeadLetter(models.JobTypeTranslationProcess, translationHandler.HandleDeadTranslationJob)
+	}
 
 	// YouTube Data API v3 handlers (OAuth + API endpoints)
 	oauthService := services.NewOAuthService(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL, log)
+	oauthService.SetStateStore(services.NewOAuthStateStore(cache)) // Single-use OAuth states in Redis (memory if disabled)
 	youtubeAPIService := services.NewYouTubeAPIService(cfg.YouTubeAPIKey, cache, oauthService, log)
-	youtubeAPIHandler := handlers.NewYouTubeAPIHandler(youtubeAPIService, youtubeService, oauthService, userRepo, log)
+	if cfg.GoogleTokenEncryptionKey == "" {
+		log.Warn("⚠️  GOOGLE_TOKEN_ENCRYPTION_KEY not set, using a random key: linked Google accounts must re-authorize after restart")
+	}
+	googleAccountRepo := repository.NewGoogleAccountRepository(db.DB)
+	googleAccountService := services.NewGoogleAccountService(googleAccountRepo, oauthService, services.NewTokenCipher(cfg.GoogleTokenEncryptionKey), log)
+	youtubeAPIHandler := handlers.NewYouTubeAPIHandler(youtubeAPIService, youtubeService, oauthService, userRepo, sessionService, googleAccountService, log)
+
+	// Account data export and deletion
+	accountRepo := repository.NewAccountRepository(db.DB)
+	exportService := services.NewExportService(accountRepo, userRepo, cfg.ExportDir, cfg.ExportTTL, log)
+	if err := exportService.PruneExpired(context.Background()); err != nil {
+		log.Error("Failed to prune expired account exports", zap.Error(err))
+	}
+	deletionService := services.NewAccountDeletionService(accountRepo, userRepo, sessionService, apiKeyService, googleAccountService, exportService, accountService, cfg.AccountDeletionGrace, log)
+	if err := deletionService.PurgeDue(context.Background()); err != nil {
+		log.Error("Failed to purge deleted accounts", zap.Error(err))
+	}
+	accountHandler := handlers.NewAccountHandler(userRepo, exportService, deletionService, accountService, loginAttempts, authAudit, jobQueue, log)
+	if queue != nil {
+		queue.Register(models.JobTypeAccountExport, exportService.HandleJob)
+		queue.OnDeadLetter(models.JobTypeAccountExport, exportService.HandleDeadJob)
+		queue.Register(models.JobTypeAccountPurge, deletionService.HandlePurgeJob)
+	}
 
 	transcriptHandler := handlers.NewTranscriptHandler(transcriptService, log)
 
 	// Chat handlers
 	chatRepo := repository.NewChatRepository(db.DB)
-	chatService := services.NewChatService(chatRepo, videoRepo, insightRepo, cfg.OpenRouterAPIKey, cfg.GeminiModel, log)
+	chatService := services.NewChatService(chatRepo, insightRepo, llmClient, cfg.ModelOrDefault(cfg.ChatModel), log)
+	chatService.SetUsageService(usageService)                          // Enforce monthly LLM budgets
+	chatService.SetRetrievalService(retrievalService, cfg.ChatRAGTopK) // Ground answers in transcript passages
+	chatService.SetMemoryThreshold(cfg.ChatMemoryThresholdTokens)      // Summarize long conversations
 	chatHandler := handlers.NewChatHandler(chatService, log)
 
 	// API routes
@@ -94,21 +246,45 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 		{
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
-			
+			auth.POST("/refresh", userHandler.Refresh)
+			auth.POST("/logout", userHandler.Logout)
+
+			// Email verification and password reset (rate limited per IP)
+			emailLinks := auth.Group("", middleware.RateLimit(middleware.DefaultRateLimitConfig()))
+			{
+				emailLinks.POST("/verify-email", userHandler.VerifyEmail)
+				emailLinks.POST("/forgot-password", userHandler.ForgotPassword)
+				emailLinks.POST("/reset-password", userHandler.ResetPassword)
+				emailLinks.POST("/restore-account", accountHandler.RestoreAccount)
+			}
+
 			// Protected auth routes
 			authProtected := auth.Group("")
-			authProtected.Use(middleware.Auth(userRepo, log))
+			authProtected.Use(middleware.Auth(authenticator, log))
 			{
-				authProtected.GET("/profile", userHandler.GetProfile)
-				authProtected.POST("/regenerate-key", userHandler.RegenerateAPIKey)
+				authProtected.GET("/profile", middleware.RequireScope(models.ScopeRead, log), userHandler.GetProfile)
+				authProtected.GET("/events", middleware.RequireScope(models.ScopeAdmin, log), userHandler.ListAuthEvents)
+
+				// Session and API key management (admin scope)
+				apiKeys := authProtected.Group("", middleware.RequireScope(models.ScopeAdmin, log))
+				{
+					apiKeys.POST("/logout-all", userHandler.LogoutAll)
+					apiKeys.POST("/change-password", userHandler.ChangePassword)
+					apiKeys.POST("/verify-email/resend", userHandler.ResendVerification)
+					apiKeys.POST("/regenerate-key", userHandler.RegenerateAPIKey)
+					apiKeys.GET("/api-keys", apiKeyHandler.List)
+					apiKeys.POST("/api-keys", apiKeyHandler.Create)
+					apiKeys.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+				}
 			}
 		}
 
 		// Parse routes
 		api.POST("/parse", parseHandler.Parse)
 
-		// Analysis routes
+		// Analysis routes (protected by authentication)
 		analysis := api.Group("/analysis")
+		analysis.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeInsights, log))
 		{
 			analysis.GET("", analysisHandler.List)
 			analysis.POST("", analysisHandler.Create)
@@ -117,8 +293,9 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 			analysis.DELETE("/:id", analysisHandler.Delete)
 		}
 
-		// Pomodoro routes
+		// Pomodoro routes (protected by authentication)
 		pomodoros := api.Group("/pomodoros")
+		pomodoros.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeInsights, log))
 		{
 			pomodoros.GET("", pomodoroHandler.List)
 			pomodoros.POST("", pomodoroHandler.Create)
@@ -131,8 +308,9 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 		// YouTube video analysis routes (API v1)
 		v1 := api.Group("/v1")
 		{
-			// Video routes
+			// Video routes (protected by authentication)
 			videos := v1.Group("/videos")
+			videos.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeInsights, log))
 			{
 				videos.POST("/metadata", videoHandler.GetMetadata)
 				videos.POST("/analyze", videoHandler.AnalyzeVideo)
@@ -142,18 +320,21 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 			}
 
 			// History routes
-			v1.GET("/history", videoHandler.GetHistory)
+			v1.GET("/history", middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeRead, log), videoHandler.GetHistory)
 
 			// YouTube Data API v3 routes
 			// OAuth 2.0 authentication endpoints
 			auth := v1.Group("/auth")
 			{
-				auth.GET("/google/url", youtubeAPIHandler.GetAuthURL)
+				auth.GET("/google/url", middleware.OptionalAuth(authenticator, log), youtubeAPIHandler.GetAuthURL)
 				auth.POST("/google/callback", youtubeAPIHandler.HandleCallback)
-				auth.POST("/google/refresh", youtubeAPIHandler.RefreshToken)
+				auth.GET("/google", middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeRead, log), youtubeAPIHandler.GetGoogleAccount)
+				auth.DELETE("/google", middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeAdmin, log), youtubeAPIHandler.UnlinkGoogleAccount)
 			}
 
+			// Private playlists and captions use the caller's linked Google account
 			youtube := v1.Group("/youtube")
+			youtube.Use(middleware.OptionalAuth(authenticator, log))
 			{
 				youtube.GET("/video", youtubeAPIHandler.GetVideoMetadata)
 				youtube.GET("/playlist", youtubeAPIHandler.GetPlaylist)
@@ -168,44 +349,97 @@ func New(cfg *config.Config, db *database.PostgresDB, cache *cache.RedisCache, l
 			// Transcript extraction endpoint (yt-dlp based)
 			v1.POST("/transcript", transcriptHandler.GetTranscript)
 
-			// Translation routes
-			v1.POST("/translate", translationHandler.Translate)
-			v1.GET("/translate/:id", translationHandler.GetTranslation)
+			// Translation routes (protected by authentication)
+			translate := v1.Group("/translate")
+			translate.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeInsights, log))
+			{
+				translate.POST("", translationHandler.Translate)
+				translate.GET("/:id", translationHandler.GetTranslation)
+			}
 
 			// InsightFlow routes (protected by authentication)
 			insights := v1.Group("/insights")
-			insights.Use(middleware.Auth(userRepo, log))
+			insights.Use(middleware.Auth(authenticator, log))
 			{
-				insights.GET("", insightHandler.List)
-				insights.POST("", insightHandler.Create)
-				insights.GET("/:id", insightHandler.Get)
-				insights.PATCH("/:id", insightHandler.Update)
-				insights.DELETE("/:id", insightHandler.Delete)
-				insights.POST("/:id/process", insightHandler.Process)
-
-				// Share routes
-				insights.POST("/:id/share", insightHandler.ShareInsight)
-				insights.DELETE("/:id/share", insightHandler.DeleteShare)
-
-				// Highlight routes
-				insights.GET("/:id/highlights", insightHandler.ListHighlights)
-				insights.POST("/:id/highlights", insightHandler.CreateHighlight)
-				insights.PATCH("/:id/highlights/:highlightId", insightHandler.UpdateHighlight)
-				insights.DELETE("/:id/highlights/:highlightId", insightHandler.DeleteHighlight)
-
-				// Chat routes (InsightHandler)
-				insights.GET("/:id/chat", insightHandler.ListChatMessages)
-				insights.POST("/:id/chat", insightHandler.CreateChatMessage)
-				insights.DELETE("/:id/chat", insightHandler.ClearChatHistory)
-
-				// Entity analysis route (ChatHandler)
-				insights.POST("/:id/analyze-entities", chatHandler.AnalyzeEntities)
+				// Insight, share and highlight routes (insights scope)
+				content := insights.Group("", middleware.RequireScope(models.ScopeInsights, log))
+				{
+					content.GET("", insightHandler.List)
+					content.POST("", insightHandler.Create)
+					content.GET("/:id", insightHandler.Get)
+					content.PATCH("/:id", insightHandler.Update)
+					content.DELETE("/:id", insightHandler.Delete)
+					content.POST("/:id/process", insightHandler.Process)
+					content.GET("/:id/events", insightHandler.Events)
+
+					// Share routes
+					content.POST("/:id/share", insightHandler.ShareInsight)
+					content.DELETE("/:id/share", insightHandler.DeleteShare)
+					content.GET("/:id/shares", insightHandler.ListShares)
+					content.DELETE("/:id/shares/:shareId", insightHandler.RevokeShare)
+					content.GET("/:id/share/stats", insightHandler.ShareStats)
+
+					// Highlight routes
+					content.GET("/:id/highlights", insightHandler.ListHighlights)
+					content.POST("/:id/highlights", insightHandler.CreateHighlight)
+					content.PATCH("/:id/highlights/:highlightId", insightHandler.UpdateHighlight)
+					content.DELETE("/:id/highlights/:highlightId", insightHandler.DeleteHighlight)
+
+					// Move into or out of a workspace
+					content.PUT("/:id/workspace", workspaceHandler.MoveInsight)
+				}
+
+				// Chat routes (chat scope)
+				chat := insights.Group("/:id", middleware.RequireScope(models.ScopeChat, log))
+				{
+					chat.GET("/chat", chatHandler.GetHistory)
+					chat.POST("/chat", chatHandler.Chat)
+					chat.DELETE("/chat", chatHandler.ClearHistory)
+					chat.POST("/chat/regenerate", chatHandler.Regenerate)
+					chat.POST("/analyze-entities", chatHandler.AnalyzeEntities)
+				}
 			}
 
+			// Workspaces: insight libraries shared with members (insights scope)
+			workspaces := v1.Group("/workspaces")
+			workspaces.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeInsights, log))
+			{
+				workspaces.GET("", workspaceHandler.List)
+				workspaces.POST("", workspaceHandler.Create)
+				workspaces.GET("/:id", workspaceHandler.Get)
+				workspaces.PATCH("/:id", workspaceHandler.Update)
+				workspaces.DELETE("/:id", workspaceHandler.Delete)
+				workspaces.GET("/:id/insights", workspaceHandler.ListInsights)
+				workspaces.GET("/:id/members", workspaceHandler.ListMembers)
+				workspaces.POST("/:id/members", workspaceHandler.AddMember)
+				workspaces.PATCH("/:id/members/:userId", workspaceHandler.UpdateMember)
+				workspaces.DELETE("/:id/members/:userId", workspaceHandler.RemoveMember)
+			}
+
+			// Data export and deletion of the authenticated account (admin scope)
+			account := v1.Group("/account")
+			account.Use(middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeAdmin, log))
+			{
+				account.DELETE("", accountHandler.DeleteAccount)
+				account.POST("/exports", accountHandler.RequestExport)
+				account.GET("/exports/:id", accountHandler.GetExport)
+				account.GET("/exports/:id/download", accountHandler.DownloadExport)
+			}
+
+			// LLM usage of the authenticated user
+			v1.GET("/usage", middleware.Auth(authenticator, log), middleware.RequireScope(models.ScopeRead, log), usageHandler.Get)
+
 			// Shared insight (public access, with rate limiting to prevent brute-force)
-			v1.GET("/shared/:token", middleware.ShareAccessRateLimit(), insightHandler.GetShared)
+			shareRateLimit := middleware.ShareAccessRateLimit()
+			v1.GET("/shared/:token", shareRateLimit, insightHandler.GetShared)
+			v1.POST("/shared/:token/unlock", shareRateLimit, insightHandler.UnlockShare)
 		}
 	}
 
+	// Server-rendered share pages with link previews (public access, rate limited like the API)
+	sharePageRateLimit := middleware.ShareAccessRateLimit()
+	r.GET("/s/:token", sharePageRateLimit, insightHandler.SharePage)
+	r.POST("/s/:token", sharePageRateLimit, insightHandler.SharePage)
+
 	return r
 }
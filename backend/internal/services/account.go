@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/mail"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+const (
+	// userTokenTag starts every emailed token, so leaked tokens are easy to recognise.
+	userTokenTag = "vu_"
+
+	// mailSendTimeout bounds the delivery of a single email.
+	mailSendTimeout = 30 * time.Second
+
+	// legacyOAuthPassword is the shared password accounts created through Google
+	// sign-in used to get; MigratePlaceholderPasswords removes it.
+	legacyOAuthPassword = "google-oauth-user"
+)
+
+// AccountService handles email verification, password reset and password
+// changes. Emailed tokens are single-use, expire and are stored as SHA-256 hashes.
+type AccountService struct {
+	tokens          *repository.UserTokenRepository
+	userRepo        *repository.UserRepository
+	sessions        *SessionService
+	mailer          mail.Mailer
+	frontendURL     string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+	log             *zap.Logger
+}
+
+// NewAccountService creates a new AccountService. Links in emails point to frontendURL.
+func NewAccountService(tokens *repository.UserTokenRepository, userRepo *repository.UserRepository, sessions *SessionService, mailer mail.Mailer, frontendURL string, verificationTTL, resetTTL time.Duration, log *zap.Logger) *AccountService {
+	return &AccountService{
+		tokens:          tokens,
+		userRepo:        userRepo,
+		sessions:        sessions,
+		mailer:          mailer,
+		frontendURL:     strings.TrimRight(frontendURL, "/"),
+		verificationTTL: verificationTTL,
+		resetTTL:        resetTTL,
+		log:             log,
+	}
+}
+
+// generateUserToken returns a new random emailed token.
+func generateUserToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return userTokenTag + hex.EncodeToString(bytes), nil
+}
+
+// issue stores a new token for user, invalidating earlier ones of the same purpose.
+func (s *AccountService) issue(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	plaintext, err := generateUserToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(plaintext),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokens.Replace(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to save token: %w", err)
+	}
+	return plaintext, nil
+}
+
+// redeem uses up a token of the given purpose. Returns models.ErrInvalidUserToken
+// for unknown, expired, used or mismatched tokens. Only account restore tokens
+// work for deleted accounts, and only for them.
+func (s *AccountService) redeem(ctx context.Context, purpose, plaintext string) (*models.UserToken, *models.User, error) {
+	now := time.Now()
+	token, err := s.tokens.GetByHash(ctx, hashToken(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrInvalidUserToken
+		}
+		return nil, nil, fmt.Errorf("failed to look up token: %w", err)
+	}
+	if token.Purpose != purpose || token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, nil, models.ErrInvalidUserToken
+	}
+
+	user, err := s.userRepo.GetByIDWithDeleted(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrInvalidUserToken
+		}
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.DeletedAt.Valid != (purpose == models.UserTokenAccountRestore) {
+		return nil, nil, models.ErrInvalidUserToken
+	}
+
+	consumed, err := s.tokens.Consume(ctx, token.ID, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to use token: %w", err)
+	}
+	if !consumed {
+		// Lost a race with another request using the same token
+		return nil, nil, models.ErrInvalidUserToken
+	}
+	return token, user, nil
+}
+
+// link returns the frontend URL of path carrying token.
+func (s *AccountService) link(path, token string) string {
+	return s.frontendURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers msg in the background, so request latency does not depend on
+// the mail server and does not reveal whether an address has an account.
+func (s *AccountService) send(msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.log.Error("Failed to send email",
+				zap.String("subject", msg.Subject),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// SendVerification emails a verification link to user. Returns
+// models.ErrEmailAlreadyVerified if the address is already verified.
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.EmailVerified() {
+		return models.ErrEmailAlreadyVerified
+	}
+	token, err := s.issue(ctx, user, models.UserTokenEmailVerification, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(mail.Message{
+		To:      user.Email,
+		Subject: "验证你的邮箱地址",
+		Body: fmt.Sprintf(`你好 %s，
+
+请打开下面的链接验证你的邮箱地址：
+
+%s
+
+链接 %s 内有效，且只能使用一次。如果这不是你本人的操作，请忽略此邮件。
+`, user.Name, s.link("/auth/verify-email", token), formatTTL(s.verificationTTL)),
+	})
+	s.log.Info("Verification email sent", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// VerifyEmail confirms the email address of the token's user.
+func (s *AccountService) VerifyEmail(ctx context.Context, plaintext string) (*models.User, error) {
+	token, user, err := s.redeem(ctx, models.UserTokenEmailVerification, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if token.Email != user.Email {
+		// The link was sent to an address the account no longer uses
+		return nil, models.ErrInvalidUserToken
+	}
+
+	now := time.Now()
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+
+	s.log.Info("Email verified", zap.Uint("user_id", user.ID))
+	return user, nil
+}
+
+// RequestPasswordReset emails a password reset link to the account with email.
+// Unknown addresses are not reported, so the endpoint cannot be used to find accounts.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.log.Info("Password reset requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := s.issue(ctx, user, models.UserTokenPasswordReset, s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(mail.Message{
+		To:      user.Email,
+		Subject: "重置你的密码",
+		Body: fmt.Sprintf(`你好 %s，
+
+我们收到了重置你账户密码的请求。请打开下面的链接设置新密码：
+
+%s
+
+链接 %s 内有效，且只能使用一次。如果这不是你本人的操作，请忽略此邮件，你的密码不会改变。
+`, user.Name, s.link("/auth/reset-password", token), formatTTL(s.resetTTL)),
+	})
+	s.log.Info("Password reset email sent", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and ends every session
+// of the user. Receiving the email proves control of the address, so it is
+// marked verified as well.
+func (s *AccountService) ResetPassword(ctx context.Context, plaintext, newPassword string) (*models.User, error) {
+	token, user, err := s.redeem(ctx, models.UserTokenPasswordReset, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, newPassword); err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	if token.Email == user.Email {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID, time.Now()); err != nil {
+			s.log.Warn("Failed to mark email verified after reset", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+	}
+	if _, err := s.sessions.EndOtherSessions(ctx, user.ID, ""); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Password reset", zap.Uint("user_id", user.ID))
+	s.notifyPasswordChanged(user)
+	return user, nil
+}
+
+// ChangePassword changes the password of user and ends their other sessions.
+// The current password is required unless the account has none yet (Google
+// sign-in); returns models.ErrInvalidCurrentPassword if it does not match.
+func (s *AccountService) ChangePassword(ctx context.Context, user *models.User, keepSessionID, currentPassword, newPassword string) error {
+	if user.HasPassword() && !s.userRepo.VerifyPassword(user, currentPassword) {
+		return models.ErrInvalidCurrentPassword
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, newPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := s.sessions.EndOtherSessions(ctx, user.ID, keepSessionID); err != nil {
+		return err
+	}
+
+	s.log.Info("Password changed", zap.Uint("user_id", user.ID))
+	s.notifyPasswordChanged(user)
+	return nil
+}
+
+// notifyPasswordChanged tells the user their password changed, in case it was not them.
+func (s *AccountService) notifyPasswordChanged(user *models.User) {
+	s.send(mail.Message{
+		To:      user.Email,
+		Subject: "你的密码已修改",
+		Body: fmt.Sprintf(`你好 %s，
+
+你的账户密码刚刚被修改，其他设备上的登录已失效。
+
+如果这不是你本人的操作，请立即通过下面的链接重置密码：
+
+%s
+`, user.Name, s.frontendURL+"/auth/forgot-password"),
+	})
+}
+
+// SendRestoreLink emails user, whose account was just deleted, a link that
+// cancels the deletion within grace.
+func (s *AccountService) SendRestoreLink(ctx context.Context, user *models.User, grace time.Duration) error {
+	token, err := s.issue(ctx, user, models.UserTokenAccountRestore, grace)
+	if err != nil {
+		return err
+	}
+
+	s.send(mail.Message{
+		To:      user.Email,
+		Subject: "你的账户将被删除",
+		Body: fmt.Sprintf(`你好 %s，
+
+你的账户已被停用，分享链接和 API 密钥已失效，所有数据将在 %s 后永久删除。
+
+如果你改变了主意，请在此之前打开下面的链接恢复账户：
+
+%s
+
+如果这不是你本人的操作，请立即恢复账户并修改密码。
+`, user.Name, formatTTL(grace), s.link("/auth/restore-account", token)),
+	})
+	s.log.Info("Account restore email sent", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// RestoreAccount cancels the deletion of the token's account. Shares, API keys
+// and sessions revoked by the deletion stay revoked.
+func (s *AccountService) RestoreAccount(ctx context.Context, plaintext string) (*models.User, error) {
+	_, user, err := s.redeem(ctx, models.UserTokenAccountRestore, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Restore(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("failed to restore account: %w", err)
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+
+	s.log.Info("Account restored", zap.Uint("user_id", user.ID))
+	return user, nil
+}
+
+// MigratePlaceholderPasswords removes the shared placeholder password that
+// accounts created through Google sign-in used to get, so nobody can log in
+// to them with it.
+func (s *AccountService) MigratePlaceholderPasswords(ctx context.Context) error {
+	users, err := s.userRepo.ListGoogleLinked(ctx)
+	if err != nil {
+		return err
+	}
+	cleared := 0
+	for i := range users {
+		if !s.userRepo.VerifyPassword(&users[i], legacyOAuthPassword) {
+			continue
+		}
+		if err := s.userRepo.ClearPassword(ctx, users[i].ID); err != nil {
+			return err
+		}
+		cleared++
+	}
+	if cleared > 0 {
+		s.log.Info("✅ Placeholder passwords of Google accounts removed", zap.Int("users", cleared))
+	}
+	return nil
+}
+
+// PruneExpired deletes emailed tokens that have expired.
+func (s *AccountService) PruneExpired(ctx context.Context) error {
+	deleted, err := s.tokens.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.Info("Expired account tokens deleted", zap.Int64("tokens", deleted))
+	}
+	return nil
+}
+
+// formatTTL renders a link lifetime for emails, e.g. "1 小时" or "30 分钟".
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d 小时", int(ttl/time.Hour))
+	}
+	return fmt.Sprintf("%d 分钟", int(ttl.Round(time.Minute)/time.Minute))
+}
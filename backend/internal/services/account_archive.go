@@ -0,0 +1,182 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"vibe-backend/internal/models"
+)
+
+// writeAccountArchive writes data as a ZIP archive: a JSON file per kind of
+// record plus a Markdown document per insight and video analysis.
+func writeAccountArchive(w io.Writer, data *models.AccountData) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"account.json", data.User},
+		{"insights.json", data.Insights},
+		{"translations.json", data.Translations},
+		{"pomodoros.json", data.Pomodoros},
+		{"video_analyses.json", data.VideoAnalyses},
+	}
+	for _, file := range files {
+		if err := writeArchiveJSON(zw, file.name, file.v); err != nil {
+			return err
+		}
+	}
+
+	for i := range data.Insights {
+		insight := &data.Insights[i]
+		name := fmt.Sprintf("insights/%d.md", insight.ID)
+		if err := writeArchiveFile(zw, name, insightMarkdown(insight)); err != nil {
+			return err
+		}
+	}
+	for i := range data.VideoAnalyses {
+		analysis := &data.VideoAnalyses[i]
+		name := fmt.Sprintf("video_analyses/%d.md", analysis.ID)
+		if err := writeArchiveFile(zw, name, videoAnalysisMarkdown(analysis)); err != nil {
+			return err
+		}
+	}
+
+	if err := writeArchiveFile(zw, "README.md", accountReadme(data)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeArchiveJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeArchiveFile(zw *zip.Writer, name, content string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := io.WriteString(f, content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// accountReadme describes the archive.
+func accountReadme(data *models.AccountData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s 的数据导出\n\n", data.User.Email)
+	fmt.Fprintf(&b, "导出时间: %s\n\n", data.ExportedAt.Format("2006-01-02 15:04:05 MST"))
+	b.WriteString("| 文件 | 内容 | 数量 |\n|------|------|------|\n")
+	fmt.Fprintf(&b, "| account.json | 账户信息 | 1 |\n")
+	fmt.Fprintf(&b, "| insights.json, insights/*.md | Insight（含转录、高亮和 AI 对话） | %d |\n", len(data.Insights))
+	fmt.Fprintf(&b, "| translations.json | 翻译（含双语字幕） | %d |\n", len(data.Translations))
+	fmt.Fprintf(&b, "| pomodoros.json | 番茄钟 | %d |\n", len(data.Pomodoros))
+	fmt.Fprintf(&b, "| video_analyses.json, video_analyses/*.md | 视频分析（含章节、转录和核心观点） | %d |\n", len(data.VideoAnalyses))
+	return b.String()
+}
+
+// insightMarkdown renders an insight with its highlights, transcript and chat.
+func insightMarkdown(insight *models.Insight) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", insight.Title)
+	if insight.Author != "" {
+		fmt.Fprintf(&b, "**作者**: %s\n\n", insight.Author)
+	}
+	fmt.Fprintf(&b, "**来源**: %s\n\n", insight.SourceURL)
+	fmt.Fprintf(&b, "**创建时间**: %s\n\n", insight.CreatedAt.Format("2006-01-02 15:04:05"))
+	b.WriteString("---\n\n")
+
+	if insight.Summary != "" {
+		fmt.Fprintf(&b, "## 摘要\n\n%s\n\n", insight.Summary)
+	}
+
+	var keyPoints []string
+	if len(insight.KeyPoints) > 0 && json.Unmarshal(insight.KeyPoints, &keyPoints) == nil && len(keyPoints) > 0 {
+		b.WriteString("## 核心观点\n\n")
+		for i, point := range keyPoints {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, point)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(insight.Highlights) > 0 {
+		b.WriteString("## 高亮\n\n")
+		for _, h := range insight.Highlights {
+			fmt.Fprintf(&b, "> %s\n\n", strings.ReplaceAll(h.Text, "\n", "\n> "))
+			if h.Note != "" {
+				fmt.Fprintf(&b, "笔记: %s\n\n", h.Note)
+			}
+		}
+	}
+
+	var transcripts []models.TranscriptItem
+	if len(insight.Transcripts) > 0 && json.Unmarshal(insight.Transcripts, &transcripts) == nil && len(transcripts) > 0 {
+		b.WriteString("## 完整转录\n\n")
+		for _, item := range transcripts {
+			fmt.Fprintf(&b, "**[%s]** %s\n\n", item.Timestamp, item.Text)
+			if item.TranslatedText != "" {
+				fmt.Fprintf(&b, "%s\n\n", item.TranslatedText)
+			}
+		}
+	} else if insight.RawContent != "" {
+		fmt.Fprintf(&b, "## 原文\n\n%s\n\n", insight.RawContent)
+	}
+
+	if len(insight.ChatMessages) > 0 {
+		b.WriteString("## AI 对话\n\n")
+		for _, msg := range insight.ChatMessages {
+			speaker := "AI"
+			if msg.Role == "user" {
+				speaker = "我"
+			}
+			fmt.Fprintf(&b, "**%s** (%s): %s\n\n", speaker, msg.CreatedAt.Format("2006-01-02 15:04"), msg.Content)
+		}
+	}
+
+	return b.String()
+}
+
+// videoAnalysisMarkdown renders a video analysis in the layout of the single-video export.
+func videoAnalysisMarkdown(analysis *models.VideoAnalysisExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", analysis.Title)
+	fmt.Fprintf(&b, "**作者**: %s\n\n", analysis.Author)
+	fmt.Fprintf(&b, "**视频ID**: %s\n\n", analysis.VideoID)
+	fmt.Fprintf(&b, "**分析时间**: %s\n\n", analysis.CreatedAt.Format("2006-01-02 15:04:05"))
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "## 摘要\n\n%s\n\n", analysis.Summary)
+
+	b.WriteString("## 核心观点\n\n")
+	for i, kp := range analysis.KeyPoints {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, kp.Content)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 章节\n\n")
+	for _, ch := range analysis.Chapters {
+		fmt.Fprintf(&b, "### [%s] %s\n\n", ch.Timestamp, ch.Title)
+	}
+
+	b.WriteString("## 完整转录\n\n")
+	for _, tr := range analysis.Transcriptions {
+		fmt.Fprintf(&b, "**[%s]** %s\n\n", tr.Timestamp, tr.Text)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"vibe-backend/internal/models"
+)
+
+func testAccountData() *models.AccountData {
+	return &models.AccountData{
+		ExportedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		User:       models.UserResponse{ID: 1, Email: "a@example.com", Name: "A"},
+		Insights: []models.Insight{{
+			ID:          7,
+			Title:       "Talk",
+			SourceURL:   "https://www.youtube.com/watch?v=abc",
+			Summary:     "A summary",
+			KeyPoints:   []byte(`["first","second"]`),
+			Transcripts: []byte(`[{"timestamp":"00:05","seconds":5,"text":"hello","translated_text":"你好"}]`),
+			Highlights:  []models.Highlight{{Text: "hello", Note: "greeting"}},
+			ChatMessages: []models.ChatMessage{
+				{Role: "user", Content: "What is it about?"},
+				{Role: "assistant", Content: "Greetings."},
+			},
+		}},
+		Translations: []models.Translation{{ID: 3, TargetLanguage: "zh"}},
+		Pomodoros:    []models.Pomodoro{{ID: 4, Title: "Focus"}},
+		VideoAnalyses: []models.VideoAnalysisExport{{
+			VideoAnalysis: models.VideoAnalysis{ID: 9, Title: "Video", VideoID: "xyz"},
+			Chapters:      []models.Chapter{{Title: "Intro", Timestamp: "00:00"}},
+			KeyPoints:     []models.KeyPoint{{Content: "point"}},
+		}},
+	}
+}
+
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestWriteAccountArchive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeAccountArchive(&buf, testAccountData()); err != nil {
+		t.Fatal(err)
+	}
+	files := readArchive(t, buf.Bytes())
+
+	for _, name := range []string{
+		"README.md", "account.json", "insights.json", "translations.json",
+		"pomodoros.json", "video_analyses.json", "insights/7.md", "video_analyses/9.md",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	var insights []models.Insight
+	if err := json.Unmarshal([]byte(files["insights.json"]), &insights); err != nil {
+		t.Fatalf("insights.json: %v", err)
+	}
+	if len(insights) != 1 || len(insights[0].ChatMessages) != 2 || len(insights[0].Highlights) != 1 {
+		t.Fatalf("insights.json = %+v, want the insight with its chat and highlights", insights)
+	}
+
+	var analyses []models.VideoAnalysisExport
+	if err := json.Unmarshal([]byte(files["video_analyses.json"]), &analyses); err != nil {
+		t.Fatalf("video_analyses.json: %v", err)
+	}
+	if len(analyses) != 1 || analyses[0].VideoID != "xyz" || len(analyses[0].Chapters) != 1 {
+		t.Fatalf("video_analyses.json = %+v, want the analysis with its chapters", analyses)
+	}
+}
+
+func TestInsightMarkdown(t *testing.T) {
+	md := insightMarkdown(&testAccountData().Insights[0])
+	for _, want := range []string{
+		"# Talk", "A summary", "1. first", "2. second",
+		"> hello", "笔记: greeting", "**[00:05]** hello", "你好",
+		"**我**", "What is it about?", "Greetings.",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// AccountDeletionService deletes accounts. Deleting an account disables it at
+// once: sessions, API keys and share links are revoked and the user can no
+// longer log in. Everything the user owns is permanently deleted once the
+// grace period has passed, unless the deletion is cancelled from the email link.
+type AccountDeletionService struct {
+	repo     *repository.AccountRepository
+	userRepo *repository.UserRepository
+	sessions *SessionService
+	apiKeys  *APIKeyService
+	google   *GoogleAccountService
+	exports  *ExportService
+	accounts *AccountService
+	grace    time.Duration
+	log      *zap.Logger
+}
+
+// NewAccountDeletionService creates a new AccountDeletionService.
+func NewAccountDeletionService(
+	repo *repository.AccountRepository,
+	userRepo *repository.UserRepository,
+	sessions *SessionService,
+	apiKeys *APIKeyService,
+	google *GoogleAccountService,
+	exports *ExportService,
+	accounts *AccountService,
+	grace time.Duration,
+	log *zap.Logger,
+) *AccountDeletionService {
+	return &AccountDeletionService{
+		repo:     repo,
+		userRepo: userRepo,
+		sessions: sessions,
+		apiKeys:  apiKeys,
+		google:   google,
+		exports:  exports,
+		accounts: accounts,
+		grace:    grace,
+		log:      log,
+	}
+}
+
+// Delete disables the account of user and returns when its data will be
+// purged. The password is required unless the account has none (Google
+// sign-in); returns models.ErrInvalidCurrentPassword if it does not match.
+func (s *AccountDeletionService) Delete(ctx context.Context, user *models.User, password string) (time.Time, error) {
+	if user.HasPassword() && !s.userRepo.VerifyPassword(user, password) {
+		return time.Time{}, models.ErrInvalidCurrentPassword
+	}
+
+	shares, err := s.repo.RevokeShares(ctx, user.ID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to revoke shares: %w", err)
+	}
+	if err := s.apiKeys.RevokeAll(ctx, user.ID); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.sessions.EndAccountSessions(ctx, user.ID); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.exports.RemoveForUser(ctx, user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete exports: %w", err)
+	}
+
+	// The Google grant is revoked now rather than at purge time. If Google
+	// cannot be reached the link is still removed when the account is purged.
+	if err := s.google.Unlink(ctx, user.ID); err != nil && !errors.Is(err, models.ErrGoogleNotLinked) {
+		s.log.Warn("Failed to unlink Google account of deleted user", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete user: %w", err)
+	}
+	purgeAt := time.Now().Add(s.grace)
+
+	if err := s.accounts.SendRestoreLink(ctx, user, s.grace); err != nil {
+		s.log.Error("Failed to send account restore email", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	s.log.Info("Account deleted",
+		zap.Uint("user_id", user.ID),
+		zap.Int64("shares_revoked", shares),
+		zap.Time("purge_at", purgeAt),
+	)
+	return purgeAt, nil
+}
+
+// Purge permanently deletes a deleted account whose grace period has passed.
+// Accounts that were restored, or deleted again more recently, are left alone.
+func (s *AccountDeletionService) Purge(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByIDWithDeleted(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // already purged
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.DeletedAt.Valid || time.Now().Before(user.DeletedAt.Time.Add(s.grace)) {
+		return nil
+	}
+
+	if err := s.exports.RemoveForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete exports: %w", err)
+	}
+	if err := s.repo.Purge(ctx, userID); err != nil {
+		return fmt.Errorf("failed to purge account: %w", err)
+	}
+
+	s.log.Info("Account purged", zap.Uint("user_id", userID))
+	return nil
+}
+
+// HandlePurgeJob purges the account of an account.purge job.
+func (s *AccountDeletionService) HandlePurgeJob(ctx context.Context, job *models.Job) error {
+	var payload models.AccountPurgeJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+	return s.Purge(ctx, payload.UserID)
+}
+
+// PurgeDue purges every deleted account whose grace period has passed, in
+// case its purge job was lost.
+func (s *AccountDeletionService) PurgeDue(ctx context.Context) error {
+	ids, err := s.userRepo.ListDeletedBefore(ctx, time.Now().Add(-s.grace))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.Purge(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// ExportService builds downloadable ZIP archives of everything a user owns.
+// Archives are written to a local directory and removed once they expire.
+type ExportService struct {
+	repo     *repository.AccountRepository
+	userRepo *repository.UserRepository
+	dir      string
+	ttl      time.Duration
+	log      *zap.Logger
+}
+
+// NewExportService creates a new ExportService. Archives are written to dir
+// and can be downloaded for ttl after they are built.
+func NewExportService(repo *repository.AccountRepository, userRepo *repository.UserRepository, dir string, ttl time.Duration, log *zap.Logger) *ExportService {
+	return &ExportService{
+		repo:     repo,
+		userRepo: userRepo,
+		dir:      dir,
+		ttl:      ttl,
+		log:      log,
+	}
+}
+
+// Request creates a pending export of a user. The caller enqueues the
+// account.export job that builds it.
+func (s *ExportService) Request(ctx context.Context, userID uint) (*models.AccountExport, error) {
+	export := &models.AccountExport{
+		UserID: userID,
+		Status: models.AccountExportStatusPending,
+	}
+	if err := s.repo.CreateExport(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+	return export, nil
+}
+
+// Discard deletes an export that could not be enqueued.
+func (s *ExportService) Discard(ctx context.Context, id uint) error {
+	return s.repo.DeleteExport(ctx, id)
+}
+
+// Get returns an export of a user. Returns models.ErrExportNotFound for
+// unknown, foreign and expired exports.
+func (s *ExportService) Get(ctx context.Context, userID, id uint) (*models.AccountExport, error) {
+	export, err := s.repo.GetExportForUser(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		return nil, models.ErrExportNotFound
+	}
+	return export, nil
+}
+
+// Archive returns the path of a completed export's archive and the file name
+// to download it as. Returns models.ErrExportNotReady until it is built.
+func (s *ExportService) Archive(ctx context.Context, userID, id uint) (string, string, error) {
+	export, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return "", "", err
+	}
+	if export.Status != models.AccountExportStatusCompleted {
+		return "", "", models.ErrExportNotReady
+	}
+	name := fmt.Sprintf("vibe-export-%s.zip", export.CompletedAt.Format("20060102-150405"))
+	return export.FilePath, name, nil
+}
+
+// HandleJob builds the archive of an account.export job.
+func (s *ExportService) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload models.AccountExportJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	export, err := s.repo.GetExport(ctx, payload.ExportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("export %d not found: %w", payload.ExportID, err))
+		}
+		return fmt.Errorf("failed to get export: %w", err)
+	}
+	user, err := s.userRepo.GetByID(ctx, export.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("user %d of export %d not found: %w", export.UserID, export.ID, err))
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	export.Status = models.AccountExportStatusProcessing
+	if err := s.repo.UpdateExport(ctx, export); err != nil {
+		return fmt.Errorf("failed to update export status: %w", err)
+	}
+
+	data, err := s.repo.LoadData(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load account data: %w", err)
+	}
+	data.ExportedAt = time.Now()
+	data.User = user.ToResponse()
+
+	path, size, err := s.writeArchive(export.ID, data)
+	if err != nil {
+		return err
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(s.ttl)
+	export.Status = models.AccountExportStatusCompleted
+	export.FilePath = path
+	export.SizeBytes = size
+	export.ErrorMessage = ""
+	export.CompletedAt = &completedAt
+	export.ExpiresAt = &expiresAt
+	if err := s.repo.UpdateExport(ctx, export); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+
+	s.log.Info("Account export built",
+		zap.Uint("export_id", export.ID),
+		zap.Uint("user_id", user.ID),
+		zap.Int64("size_bytes", size),
+	)
+	return nil
+}
+
+// writeArchive writes the archive of an export to the export directory.
+// Returns the path and size of the file.
+func (s *ExportService) writeArchive(exportID uint, data *models.AccountData) (string, int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	// Random names keep archives of different exports from being guessed
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", 0, fmt.Errorf("failed to name export: %w", err)
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("export-%d-%s.zip", exportID, hex.EncodeToString(random)))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err := writeAccountArchive(f, data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", 0, fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", 0, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat export: %w", err)
+	}
+	return path, info.Size(), nil
+}
+
+// HandleDeadJob marks an export failed once its job has exhausted all retries.
+func (s *ExportService) HandleDeadJob(ctx context.Context, job *models.Job, err error) {
+	var payload models.AccountExportJobPayload
+	if decodeErr := jobs.DecodePayload(job, &payload); decodeErr != nil {
+		return
+	}
+	export, getErr := s.repo.GetExport(ctx, payload.ExportID)
+	if getErr != nil {
+		return
+	}
+	export.Status = models.AccountExportStatusFailed
+	export.ErrorMessage = err.Error()
+	if updateErr := s.repo.UpdateExport(ctx, export); updateErr != nil {
+		s.log.Error("Failed to mark export failed", zap.Uint("export_id", export.ID), zap.Error(updateErr))
+	}
+}
+
+// RemoveForUser deletes the archives and records of every export of a user.
+func (s *ExportService) RemoveForUser(ctx context.Context, userID uint) error {
+	exports, err := s.repo.ListExportsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, exports)
+}
+
+// PruneExpired deletes the archives and records of expired exports.
+func (s *ExportService) PruneExpired(ctx context.Context) error {
+	exports, err := s.repo.ListExpiredExports(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := s.remove(ctx, exports); err != nil {
+		return err
+	}
+	if len(exports) > 0 {
+		s.log.Info("Expired account exports deleted", zap.Int("exports", len(exports)))
+	}
+	return nil
+}
+
+func (s *ExportService) remove(ctx context.Context, exports []models.AccountExport) error {
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to delete export file: %w", err)
+			}
+		}
+		if err := s.repo.DeleteExport(ctx, export.ID); err != nil {
+			return fmt.Errorf("failed to delete export: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTTL(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:        "1 小时",
+		48 * time.Hour:   "48 小时",
+		30 * time.Minute: "30 分钟",
+		90 * time.Minute: "90 分钟",
+	}
+	for ttl, want := range tests {
+		if got := formatTTL(ttl); got != want {
+			t.Errorf("formatTTL(%v) = %q, want %q", ttl, got, want)
+		}
+	}
+}
+
+func TestAccountLink(t *testing.T) {
+	s := &AccountService{frontendURL: "https://app.example.com"}
+	token, err := generateUserToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, userTokenTag) || len(token) != len(userTokenTag)+64 {
+		t.Fatalf("token = %q", token)
+	}
+
+	got := s.link("/auth/reset-password", token)
+	if got != "https://app.example.com/auth/reset-password?token="+token {
+		t.Fatalf("link = %q", got)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+const (
+	// apiKeyTag starts every generated key, so leaked keys are easy to recognise.
+	apiKeyTag = "vk_"
+
+	// apiKeyPrefixLen is the number of leading key characters stored in clear to identify a key.
+	apiKeyPrefixLen = 11
+
+	// apiKeyLastUsedResolution bounds how often last_used_at is written for a key.
+	apiKeyLastUsedResolution = time.Minute
+)
+
+// APIKeyService issues, authenticates and revokes API keys. Keys are stored as
+// SHA-256 hashes; the plaintext is returned once when a key is created.
+type APIKeyService struct {
+	repo     *repository.APIKeyRepository
+	userRepo *repository.UserRepository
+	log      *zap.Logger
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(repo *repository.APIKeyRepository, userRepo *repository.UserRepository, log *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo:     repo,
+		userRepo: userRepo,
+		log:      log,
+	}
+}
+
+// hashToken returns the hex SHA-256 of an API key or refresh token. Both are
+// random and long, so a fast unsalted hash is enough and allows lookup by hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyPrefix returns the part of a key shown in listings.
+func apiKeyPrefix(key string) string {
+	if len(key) <= apiKeyPrefixLen {
+		return key
+	}
+	return key[:apiKeyPrefixLen]
+}
+
+// generateAPIKey returns a new random key.
+func generateAPIKey() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return apiKeyTag + hex.EncodeToString(bytes), nil
+}
+
+// MigrateLegacyKeys hashes the plaintext keys of the former users.api_key column into api_keys.
+func (s *APIKeyService) MigrateLegacyKeys(ctx context.Context) error {
+	migrated, err := s.repo.MigrateLegacyKeys(ctx, hashToken, apiKeyPrefix)
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		s.log.Info("✅ Legacy API keys migrated to hashed storage", zap.Int("keys", migrated))
+	}
+	return nil
+}
+
+// Create issues a new key for a user. Returns models.ErrInvalidScope for unknown scopes.
+func (s *APIKeyService) Create(ctx context.Context, userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.ValidScope(scope) {
+			return nil, "", models.ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, "", models.ErrInvalidScope
+	}
+
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    apiKeyPrefix(plaintext),
+		KeyHash:   hashToken(plaintext),
+		Scopes:    strings.Join(unique, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	s.log.Info("API key created",
+		zap.Uint("user_id", userID),
+		zap.Uint("key_id", key.ID),
+		zap.String("prefix", key.Prefix),
+		zap.String("scopes", key.Scopes),
+	)
+	return key, plaintext, nil
+}
+
+// Rotate revokes a key and issues a replacement with the same name, scopes and lifetime.
+func (s *APIKeyService) Rotate(ctx context.Context, userID uint, old *models.APIKey) (*models.APIKey, string, error) {
+	var expiresAt *time.Time
+	if old.ExpiresAt != nil {
+		t := time.Now().Add(old.ExpiresAt.Sub(old.CreatedAt))
+		expiresAt = &t
+	}
+
+	key, plaintext, err := s.Create(ctx, userID, old.Name, old.ScopeList(), expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.Revoke(ctx, userID, old.ID); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// List returns the keys of a user, newest first.
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]models.APIKeyResponse, error) {
+	keys, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = keys[i].ToResponse()
+	}
+	return response, nil
+}
+
+// Revoke revokes a key of a user. Returns models.ErrAPIKeyNotFound for keys of other users.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	if err := s.repo.Revoke(ctx, keyID, userID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.log.Info("API key revoked",
+		zap.Uint("user_id", userID),
+		zap.Uint("key_id", keyID),
+	)
+	return nil
+}
+
+// RevokeAll revokes every active key of a user.
+func (s *APIKeyService) RevokeAll(ctx context.Context, userID uint) error {
+	if err := s.repo.RevokeAllForUser(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+	s.log.Info("All API keys revoked", zap.Uint("user_id", userID))
+	return nil
+}
+
+// Authenticate resolves an API key to its user and scopes. Returns
+// models.ErrInvalidAPIKey, models.ErrAPIKeyExpired or models.ErrAPIKeyRevoked
+// for keys that cannot be used.
+func (s *APIKeyService) Authenticate(ctx context.Context, credential string) (*models.AuthIdentity, error) {
+	key, err := s.repo.GetByHash(ctx, hashToken(credential))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, models.ErrAPIKeyRevoked
+	}
+	if !key.Active(now) {
+		return nil, models.ErrAPIKeyExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, key.ID, now, apiKeyLastUsedResolution); err != nil {
+		s.log.Warn("Failed to record API key use", zap.Uint("key_id", key.ID), zap.Error(err))
+	}
+
+	return &models.AuthIdentity{
+		User:   user,
+		Scopes: key.ScopeList(),
+		APIKey: key,
+	}, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"vibe-backend/internal/cache"
+)
+
+const (
+	// subjectFreeAttempts is how many failures per subject (account, share link)
+	// are allowed before backoff starts.
+	subjectFreeAttempts = 3
+
+	// ipFreeAttempts is how many failures per client IP are allowed before
+	// backoff starts. Higher than per subject, as IPs may be shared.
+	ipFreeAttempts = 10
+)
+
+// AttemptLimits configures brute-force protection. Failures beyond the free
+// attempts delay the next attempt by BaseBackoff, doubling per failure up to
+// MaxBackoff; MaxFailures locks the subject or IP out for Lockout. Counters
+// are forgotten Lockout after the last failure.
+type AttemptLimits struct {
+	MaxFailures   int // failures per subject before lockout
+	IPMaxFailures int // failures per client IP before lockout
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	Lockout       time.Duration
+}
+
+// attemptPolicy is the backoff policy of one kind of counter.
+type attemptPolicy struct {
+	freeAttempts int
+	maxFailures  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	lockout      time.Duration
+}
+
+// delay returns how long to wait at now before the next attempt, after count
+// failures of which the last was at last.
+func (p attemptPolicy) delay(count int, last, now time.Time) time.Duration {
+	var until time.Time
+	switch {
+	case p.maxFailures > 0 && count >= p.maxFailures:
+		until = last.Add(p.lockout)
+	case count > p.freeAttempts:
+		backoff := p.maxBackoff
+		if exp := count - p.freeAttempts - 1; exp < 30 {
+			if d := p.baseBackoff << exp; d < backoff {
+				backoff = d
+			}
+		}
+		until = last.Add(backoff)
+	default:
+		return 0
+	}
+	if wait := until.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// AttemptLimiter counts failed attempts, such as logins or share password
+// checks, per subject and per client IP.
+type AttemptLimiter struct {
+	store     AttemptStore
+	namespace string
+	subject   attemptPolicy
+	ip        attemptPolicy
+	now       func() time.Time
+}
+
+// NewAttemptLimiter creates a limiter whose counters are kept in store under
+// namespace, e.g. "login".
+func NewAttemptLimiter(store AttemptStore, namespace string, limits AttemptLimits) *AttemptLimiter {
+	policy := func(free, max int) attemptPolicy {
+		return attemptPolicy{
+			freeAttempts: free,
+			maxFailures:  max,
+			baseBackoff:  limits.BaseBackoff,
+			maxBackoff:   limits.MaxBackoff,
+			lockout:      limits.Lockout,
+		}
+	}
+	return &AttemptLimiter{
+		store:     store,
+		namespace: namespace,
+		subject:   policy(subjectFreeAttempts, limits.MaxFailures),
+		ip:        policy(ipFreeAttempts, limits.IPMaxFailures),
+		now:       time.Now,
+	}
+}
+
+// keys returns the counter keys of subject and ip with their policies. An
+// empty subject is not counted. Subjects are hashed, so emails and share
+// tokens are not stored in the clear.
+func (l *AttemptLimiter) keys(subject, ip string) ([]string, []attemptPolicy) {
+	keys := []string{"attempts:" + l.namespace + ":ip:" + ip}
+	policies := []attemptPolicy{l.ip}
+	if subject != "" {
+		keys = append(keys, l.subjectKey(subject))
+		policies = append(policies, l.subject)
+	}
+	return keys, policies
+}
+
+func (l *AttemptLimiter) subjectKey(subject string) string {
+	return "attempts:" + l.namespace + ":subject:" + hashToken(subject)
+}
+
+// Check returns how long the client must wait before attempting subject
+// again; 0 if it may try now.
+func (l *AttemptLimiter) Check(ctx context.Context, subject, ip string) (time.Duration, error) {
+	now := l.now()
+	keys, policies := l.keys(subject, ip)
+
+	var wait time.Duration
+	for i, key := range keys {
+		count, last, err := l.store.Failures(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if d := policies[i].delay(count, last, now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// Fail records a failed attempt and returns how long the client must now wait.
+func (l *AttemptLimiter) Fail(ctx context.Context, subject, ip string) (time.Duration, error) {
+	now := l.now()
+	keys, policies := l.keys(subject, ip)
+
+	var wait time.Duration
+	for i, key := range keys {
+		count, err := l.store.AddFailure(ctx, key, now, policies[i].lockout)
+		if err != nil {
+			return 0, err
+		}
+		if d := policies[i].delay(count, now, now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// Succeed clears the failures of subject after a successful attempt. The IP
+// counter is kept, so an attacker cannot reset it by logging in to their own account.
+func (l *AttemptLimiter) Succeed(ctx context.Context, subject string) error {
+	return l.store.Reset(ctx, l.subjectKey(subject))
+}
+
+// AttemptStore keeps failure counters. Counters expire ttl after their last failure.
+type AttemptStore interface {
+	Failures(ctx context.Context, key string) (count int, last time.Time, err error)
+	AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (int, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// NewAttemptStore returns a Redis-backed store, or an in-memory store when
+// Redis is disabled (counters are then per instance).
+func NewAttemptStore(redisCache *cache.RedisCache) AttemptStore {
+	if redisCache != nil {
+		return &redisAttemptStore{client: redisCache.Client()}
+	}
+	return &memoryAttemptStore{counters: make(map[string]*memoryAttemptCounter)}
+}
+
+// redisAttemptStore keeps each counter in a Redis hash with its count and the
+// time of the last failure in Unix milliseconds.
+type redisAttemptStore struct {
+	client *redis.Client
+}
+
+func (s *redisAttemptStore) Failures(ctx context.Context, key string) (int, time.Time, error) {
+	fields, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	count, _ := strconv.Atoi(fields["count"])
+	lastMillis, _ := strconv.ParseInt(fields["last"], 10, 64)
+	return count, time.UnixMilli(lastMillis), nil
+}
+
+func (s *redisAttemptStore) AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.HIncrBy(ctx, key, "count", 1)
+		pipe.HSet(ctx, key, "last", at.UnixMilli())
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *redisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// memoryAttemptStore keeps counters in process memory.
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryAttemptCounter
+}
+
+type memoryAttemptCounter struct {
+	count     int
+	last      time.Time
+	expiresAt time.Time
+}
+
+func (s *memoryAttemptStore) Failures(ctx context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || time.Now().After(counter.expiresAt) {
+		return 0, time.Time{}, nil
+	}
+	return counter.count, counter.last, nil
+}
+
+func (s *memoryAttemptStore) AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Expired counters are dropped on the next failure.
+	now := time.Now()
+	for k, counter := range s.counters {
+		if now.After(counter.expiresAt) {
+			delete(s.counters, k)
+		}
+	}
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryAttemptCounter{}
+		s.counters[key] = counter
+	}
+	counter.count++
+	counter.last = at
+	counter.expiresAt = now.Add(ttl)
+	return counter.count, nil
+}
+
+func (s *memoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counters, key)
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestAttemptLimiter(limits AttemptLimits) (*AttemptLimiter, *time.Time) {
+	now := time.Now()
+	l := NewAttemptLimiter(&memoryAttemptStore{counters: make(map[string]*memoryAttemptCounter)}, "test", limits)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+var testAttemptLimits = AttemptLimits{
+	MaxFailures:   6,
+	IPMaxFailures: 20,
+	BaseBackoff:   time.Second,
+	MaxBackoff:    time.Minute,
+	Lockout:       15 * time.Minute,
+}
+
+func TestAttemptPolicyDelay(t *testing.T) {
+	p := attemptPolicy{freeAttempts: 3, maxFailures: 10, baseBackoff: time.Second, maxBackoff: 8 * time.Second, lockout: time.Hour}
+	now := time.Now()
+
+	for count, want := range map[int]time.Duration{
+		0:  0,
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		6:  4 * time.Second,
+		8:  8 * time.Second, // capped
+		9:  8 * time.Second,
+		10: time.Hour, // locked out
+		50: time.Hour,
+	} {
+		if got := p.delay(count, now, now); got != want {
+			t.Errorf("delay(%d) = %v, want %v", count, got, want)
+		}
+	}
+
+	if got := p.delay(5, now.Add(-time.Second), now); got != time.Second {
+		t.Errorf("delay after waiting = %v, want the remaining 1s", got)
+	}
+	if got := p.delay(10, now.Add(-2*time.Hour), now); got != 0 {
+		t.Errorf("delay after lockout ended = %v, want 0", got)
+	}
+}
+
+func TestAttemptLimiterBacksOffAndLocksOut(t *testing.T) {
+	l, now := newTestAttemptLimiter(testAttemptLimits)
+	ctx := context.Background()
+
+	for i := 1; i <= subjectFreeAttempts; i++ {
+		if wait, _ := l.Fail(ctx, "a@example.com", "10.0.0.1"); wait != 0 {
+			t.Fatalf("failure %d: wait = %v, want none within free attempts", i, wait)
+		}
+	}
+	if wait, _ := l.Fail(ctx, "a@example.com", "10.0.0.1"); wait != time.Second {
+		t.Fatalf("first backoff = %v, want 1s", wait)
+	}
+	if wait, _ := l.Check(ctx, "a@example.com", "10.0.0.1"); wait != time.Second {
+		t.Fatalf("Check = %v, want 1s", wait)
+	}
+
+	// Another account from another IP is not affected
+	if wait, _ := l.Check(ctx, "b@example.com", "10.0.0.2"); wait != 0 {
+		t.Fatalf("unrelated Check = %v, want 0", wait)
+	}
+
+	for i := subjectFreeAttempts + 2; i <= testAttemptLimits.MaxFailures; i++ {
+		l.Fail(ctx, "a@example.com", "10.0.0.1")
+	}
+	if wait, _ := l.Check(ctx, "a@example.com", "10.0.0.3"); wait != testAttemptLimits.Lockout {
+		t.Fatalf("Check after max failures = %v, want lockout from any IP", wait)
+	}
+
+	*now = now.Add(testAttemptLimits.Lockout)
+	if wait, _ := l.Check(ctx, "a@example.com", "10.0.0.3"); wait != 0 {
+		t.Fatalf("Check after lockout = %v, want 0", wait)
+	}
+}
+
+func TestAttemptLimiterCountsPerIP(t *testing.T) {
+	l, _ := newTestAttemptLimiter(testAttemptLimits)
+	ctx := context.Background()
+
+	// Spraying one password over many accounts trips the IP counter
+	for i := 0; i < testAttemptLimits.IPMaxFailures; i++ {
+		l.Fail(ctx, string(rune('a'+i))+"@example.com", "10.0.0.1")
+	}
+	if wait, _ := l.Check(ctx, "new@example.com", "10.0.0.1"); wait != testAttemptLimits.Lockout {
+		t.Fatalf("Check from sprayed IP = %v, want lockout", wait)
+	}
+	if wait, _ := l.Check(ctx, "new@example.com", "10.0.0.2"); wait != 0 {
+		t.Fatalf("Check from other IP = %v, want 0", wait)
+	}
+}
+
+func TestAttemptLimiterSucceedKeepsIPCounter(t *testing.T) {
+	l, _ := newTestAttemptLimiter(testAttemptLimits)
+	ctx := context.Background()
+
+	for i := 0; i < testAttemptLimits.MaxFailures; i++ {
+		l.Fail(ctx, "a@example.com", "10.0.0.1")
+	}
+	if err := l.Succeed(ctx, "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.Check(ctx, "a@example.com", "10.0.0.2"); wait != 0 {
+		t.Fatalf("Check after success = %v, want the account counter cleared", wait)
+	}
+
+	count, _, _ := l.store.Failures(ctx, "attempts:test:ip:10.0.0.1")
+	if count != testAttemptLimits.MaxFailures {
+		t.Fatalf("IP failures after success = %d, want %d", count, testAttemptLimits.MaxFailures)
+	}
+}
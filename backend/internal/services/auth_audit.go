@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// authEventWriteTimeout bounds the write of a single audit event, so a slow
+// database does not hold up the request that triggered it.
+const authEventWriteTimeout = 5 * time.Second
+
+// AuthAuditService records the authentication audit trail.
+type AuthAuditService struct {
+	repo      *repository.AuthEventRepository
+	retention time.Duration
+	log       *zap.Logger
+}
+
+// NewAuthAuditService creates a new AuthAuditService. Events older than
+// retention are removed by PruneExpired; 0 keeps them forever.
+func NewAuthAuditService(repo *repository.AuthEventRepository, retention time.Duration, log *zap.Logger) *AuthAuditService {
+	return &AuthAuditService{
+		repo:      repo,
+		retention: retention,
+		log:       log,
+	}
+}
+
+// Record stores event. Failures are logged but not returned: a missing audit
+// entry must not fail the login or share access it describes.
+func (s *AuthAuditService) Record(ctx context.Context, event *models.AuthEvent) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), authEventWriteTimeout)
+	defer cancel()
+
+	if err := s.repo.Create(ctx, event); err != nil {
+		s.log.Error("Failed to record auth event",
+			zap.String("type", event.Type),
+			zap.String("ip", event.IPAddress),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListForUser returns the most recent events of a user, newest first.
+func (s *AuthAuditService) ListForUser(ctx context.Context, userID uint, limit int) ([]models.AuthEvent, error) {
+	return s.repo.ListByUser(ctx, userID, limit)
+}
+
+// PruneExpired deletes events older than the retention period.
+func (s *AuthAuditService) PruneExpired(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	deleted, err := s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.Info("Expired auth events deleted", zap.Int64("events", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+
+	"vibe-backend/internal/models"
+)
+
+// Authenticator authenticates request credentials of either kind: session
+// access tokens issued to browser clients and API keys used by scripts.
+type Authenticator struct {
+	sessions *SessionService
+	apiKeys  *APIKeyService
+}
+
+// NewAuthenticator creates a new Authenticator.
+func NewAuthenticator(sessions *SessionService, apiKeys *APIKeyService) *Authenticator {
+	return &Authenticator{
+		sessions: sessions,
+		apiKeys:  apiKeys,
+	}
+}
+
+// Authenticate resolves credential with the session service if it is an access
+// token and with the API key service otherwise.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*models.AuthIdentity, error) {
+	if IsAccessToken(credential) {
+		return a.sessions.Authenticate(ctx, credential)
+	}
+	return a.apiKeys.Authenticate(ctx, credential)
+}
@@ -1,37 +1,44 @@
 package services
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
+	"sync"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 )
 
-// ChatService handles AI chat operations.
+// chatHistoryMaxMessages bounds the recent messages sent to the model verbatim.
+// Older turns reach the model through the conversation summary (see chat_memory.go).
+const chatHistoryMaxMessages = 20
+
+// ChatService handles AI chat about insights: ownership checks, persistence,
+// history windowing, streaming and non-streaming replies and regeneration.
 type ChatService struct {
-	chatRepo         *repository.ChatRepository
-	videoRepo        *repository.VideoRepository
-	insightRepo      *repository.InsightRepository
-	openRouterAPIKey string
-	chatModel        string
-	httpClient       *http.Client
-	log              *zap.Logger
+	chatRepo    *repository.ChatRepository
+	insightRepo *repository.InsightRepository
+	llm         *llm.Client
+	usage       *UsageService
+	retrieval   *RetrievalService
+	topK        int // transcript passages retrieved per chat turn
+	chatModel   string
+	log         *zap.Logger
+
+	memoryThreshold int      // unsummarized history tokens that trigger summarization
+	compacting      sync.Map // insight ID -> struct{}, summarizations in progress
 }
 
 // NewChatService creates a new ChatService.
 func NewChatService(
 	chatRepo *repository.ChatRepository,
-	videoRepo *repository.VideoRepository,
 	insightRepo *repository.InsightRepository,
-	apiKey string,
+	client *llm.Client,
 	chatModel string,
 	log *zap.Logger,
 ) *ChatService {
@@ -39,115 +46,412 @@ func NewChatService(
 		chatModel = "anthropic/claude-3-5-sonnet"
 	}
 
-	// Validate API key on service initialization
-	if apiKey == "" {
-		log.Error("⚠️  OpenRouter API 密钥未设置",
-			zap.String("环境变量", "OPENROUTER_API_KEY"),
-			zap.String("影响功能", "聊天、实体分析等AI功能将无法使用"),
-			zap.String("解决方案", "请在 .env 文件中设置 OPENROUTER_API_KEY，访问 https://openrouter.ai/ 获取密钥"),
-		)
-	} else {
-		maskedKey := apiKey[:10] + "..." + apiKey[len(apiKey)-4:]
-		log.Info("✅ OpenRouter API 服务已初始化",
-			zap.String("model", chatModel),
-			zap.String("api_key", maskedKey),
-		)
-	}
+	log.Info("✅ 聊天服务已初始化",
+		zap.String("provider", client.ProviderName()),
+		zap.String("model", chatModel),
+	)
 
 	return &ChatService{
-		chatRepo:         chatRepo,
-		videoRepo:        videoRepo,
-		insightRepo:      insightRepo,
-		openRouterAPIKey: apiKey,
-		chatModel:        chatModel,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
-		log: log,
+		chatRepo:    chatRepo,
+		insightRepo: insightRepo,
+		llm:         client,
+		chatModel:   chatModel,
+		log:         log,
+
+		memoryThreshold: defaultChatMemoryThreshold,
+	}
+}
+
+// SetUsageService sets the usage service enforcing monthly LLM budgets (for dependency injection).
+func (s *ChatService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
+// SetRetrievalService sets the service retrieving transcript passages for each chat turn (for dependency injection).
+func (s *ChatService) SetRetrievalService(retrieval *RetrievalService, topK int) {
+	s.retrieval = retrieval
+	s.topK = topK
+}
+
+// checkBudget returns models.ErrLLMBudgetExceeded if the user of ctx has exhausted their budget.
+func (s *ChatService) checkBudget(ctx context.Context) error {
+	if s.usage == nil {
+		return nil
+	}
+	return s.usage.CheckBudget(ctx)
+}
+
+// GetInsightForUser returns an insight the user owns or sees as a workspace
+// member, with the role of the user on it. Returns models.ErrChatInsightNotFound
+// otherwise, also for insights of other users.
+func (s *ChatService) GetInsightForUser(ctx context.Context, insightID, userID uint) (*models.Insight, models.WorkspaceRole, error) {
+	insight, role, err := s.insightRepo.GetByIDForMember(ctx, insightID, userID, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrChatInsightNotFound
+		}
+		return nil, "", fmt.Errorf("failed to get insight: %w", err)
+	}
+	return insight, role, nil
+}
+
+// chatTurn is a prepared request for an assistant reply.
+type chatTurn struct {
+	insight     *models.Insight
+	userID      uint
+	highlightID *uint
+	messages    []llm.Message
+	passages    []models.TranscriptPassage
+	userMessage *models.ChatMessage // nil when regenerating
+}
+
+// ChatStream saves the user message and streams the assistant reply.
+// The stream is started before returning, so LLM failures are returned as errors.
+func (s *ChatService) ChatStream(ctx context.Context, insight *models.Insight, userID uint, message string, highlightID *uint) (<-chan models.ChatStreamEvent, error) {
+	turn, err := s.newTurn(ctx, insight, userID, message, highlightID)
+	if err != nil {
+		return nil, err
 	}
+	return s.streamTurn(ctx, turn)
 }
 
-// ChatStream sends a message and returns a channel for streaming responses.
-func (s *ChatService) ChatStream(ctx context.Context, insightID uint, message string, highlightID *uint) (<-chan models.ChatStreamEvent, error) {
-	// Get the insight for context
-	insight, err := s.insightRepo.GetByID(ctx, insightID)
+// Chat saves the user message and returns the complete assistant reply.
+func (s *ChatService) Chat(ctx context.Context, insight *models.Insight, userID uint, message string, highlightID *uint) (*models.ChatReplyResponse, error) {
+	turn, err := s.newTurn(ctx, insight, userID, message, highlightID)
 	if err != nil {
-		return nil, fmt.Errorf("insight not found: %w", err)
+		return nil, err
 	}
+	return s.completeTurn(ctx, turn)
+}
 
-	// Get existing chat history
-	history, err := s.chatRepo.GetMessagesByAnalysisID(ctx, insightID)
+// RegenerateStream replaces the latest assistant reply with a new streamed one.
+// Only the author of the question and members who manage the insight may
+// regenerate; role is the role of userID on the insight.
+func (s *ChatService) RegenerateStream(ctx context.Context, insight *models.Insight, userID uint, role models.WorkspaceRole) (<-chan models.ChatStreamEvent, error) {
+	turn, err := s.regenerationTurn(ctx, insight, userID, role)
 	if err != nil {
-		s.log.Warn("Failed to get chat history", zap.Error(err))
-		history = []models.ChatMessage{}
+		return nil, err
+	}
+	return s.streamTurn(ctx, turn)
+}
+
+// Regenerate replaces the latest assistant reply with a new one. Permissions
+// are checked as for RegenerateStream.
+func (s *ChatService) Regenerate(ctx context.Context, insight *models.Insight, userID uint, role models.WorkspaceRole) (*models.ChatReplyResponse, error) {
+	turn, err := s.regenerationTurn(ctx, insight, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeTurn(ctx, turn)
+}
+
+// newTurn saves a user message and prepares the reply to it.
+func (s *ChatService) newTurn(ctx context.Context, insight *models.Insight, userID uint, message string, highlightID *uint) (*chatTurn, error) {
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
+	}
+
+	// Load the highlight the question is anchored to
+	var focus *highlightFocus
+	if highlightID != nil {
+		var err error
+		if focus, err = s.loadHighlightFocus(ctx, insight, *highlightID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load history before saving the new message
+	summary, history, err := s.loadMemory(ctx, insight.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Save user message
 	userMessage := &models.ChatMessage{
-		InsightID:   insightID,
-		UserID:      0, // TODO: Get from context/auth
-		Role:        "user",
+		InsightID:   insight.ID,
+		UserID:      userID,
+		Role:        llm.RoleUser,
 		Content:     message,
 		HighlightID: highlightID,
 	}
 	if err := s.chatRepo.CreateMessage(ctx, userMessage); err != nil {
-		s.log.Error("Failed to save user message", zap.Error(err))
+		return nil, fmt.Errorf("failed to save user message: %w", err)
 	}
 
-	// Build system prompt with context
-	systemPrompt := s.buildSystemPrompt(insight)
+	turn := s.prepareTurn(ctx, insight, userID, summary, history, message, focus)
+	turn.highlightID = highlightID
+	turn.userMessage = userMessage
+	return turn, nil
+}
 
-	// Build messages array
-	messages := s.buildMessages(systemPrompt, history, message)
+// regenerationTurn deletes the latest assistant reply (if any) and prepares a
+// new reply to the user message before it, credited to the author of that message.
+func (s *ChatService) regenerationTurn(ctx context.Context, insight *models.Insight, userID uint, role models.WorkspaceRole) (*chatTurn, error) {
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
+	}
 
-	// Create response channel
-	responseChan := make(chan models.ChatStreamEvent, 100)
+	// The latest messages are never summarized, so the question is always in recent
+	summary, recent, err := s.loadMemory(ctx, insight.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The conversation must end with a user message, optionally followed by its reply
+	end := len(recent)
+	var reply *models.ChatMessage
+	if end > 0 && recent[end-1].Role == llm.RoleAssistant {
+		reply = &recent[end-1]
+		end--
+	}
+	if end == 0 || recent[end-1].Role != llm.RoleUser {
+		return nil, models.ErrNothingToRegenerate
+	}
+	question := recent[end-1]
+	history := recent[:end-1]
+	if !canRegenerate(&question, userID, role) {
+		return nil, models.ErrInsightForbidden
+	}
+
+	// The highlight may have been deleted since; regenerate without it then
+	var focus *highlightFocus
+	if question.HighlightID != nil {
+		focus, err = s.loadHighlightFocus(ctx, insight, *question.HighlightID)
+		if err != nil && !errors.Is(err, models.ErrHighlightNotFound) {
+			return nil, err
+		}
+	}
+
+	if reply != nil {
+		if err := s.chatRepo.DeleteMessage(ctx, reply.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete previous reply: %w", err)
+		}
+	}
+
+	turn := s.prepareTurn(ctx, insight, question.UserID, summary, history, question.Content, focus)
+	turn.highlightID = question.HighlightID
+	return turn, nil
+}
 
-	// Start streaming in goroutine
+// canRegenerate reports whether userID with role may replace the reply to
+// question: its author may, and so may members who manage the insight.
+func canRegenerate(question *models.ChatMessage, userID uint, role models.WorkspaceRole) bool {
+	return question.UserID == userID || role.CanManage()
+}
+
+// prepareTurn retrieves context and builds the prompt for a reply to message.
+func (s *ChatService) prepareTurn(ctx context.Context, insight *models.Insight, userID uint, summary *models.ChatSummary, history []models.ChatMessage, message string, focus *highlightFocus) *chatTurn {
+	// Retrieve the transcript passages relevant to this question (and highlight)
+	query := message
+	if focus != nil {
+		query = focus.highlight.Text + "\n" + message
+	}
+	passages := s.retrievePassages(ctx, insight, query)
+
+	systemPrompt := s.buildSystemPrompt(insight, passages)
+	if focus != nil {
+		systemPrompt += focus.prompt()
+	}
+	if summary != nil {
+		systemPrompt += "\n\n之前对话的摘要（更早的消息已折叠，仅供参考）：\n" + summary.Content
+	}
+
+	return &chatTurn{
+		insight:  insight,
+		userID:   userID,
+		messages: s.buildMessages(systemPrompt, history, message),
+		passages: passages,
+	}
+}
+
+// chatRequest builds the LLM request for a turn.
+func (s *ChatService) chatRequest(turn *chatTurn) llm.Request {
+	return llm.Request{
+		Feature:  llm.FeatureChat,
+		Model:    s.chatModel,
+		Messages: turn.messages,
+	}
+}
+
+// completeTurn generates a reply without streaming and saves it.
+func (s *ChatService) completeTurn(ctx context.Context, turn *chatTurn) (*models.ChatReplyResponse, error) {
+	resp, err := s.llm.Complete(ctx, s.chatRequest(turn))
+	if err != nil {
+		s.log.Error("Failed to generate chat reply",
+			zap.Uint("insight_id", turn.insight.ID),
+			zap.String("error_kind", string(llm.KindOf(err))),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to generate reply: %w", err)
+	}
+
+	reply, err := s.saveReply(ctx, turn, resp.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ChatReplyResponse{
+		Reply:   chatMessageResponse(reply),
+		Sources: turn.passages,
+	}
+	if turn.userMessage != nil {
+		message := chatMessageResponse(turn.userMessage)
+		result.Message = &message
+	}
+	return result, nil
+}
+
+// streamTurn starts streaming a reply; it is saved once the stream completes.
+func (s *ChatService) streamTurn(ctx context.Context, turn *chatTurn) (<-chan models.ChatStreamEvent, error) {
+	events, err := s.llm.Stream(ctx, s.chatRequest(turn))
+	if err != nil {
+		s.log.Error("Failed to start chat stream",
+			zap.Uint("insight_id", turn.insight.ID),
+			zap.String("error_kind", string(llm.KindOf(err))),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to start reply stream: %w", err)
+	}
+
+	responseChan := make(chan models.ChatStreamEvent, 100)
 	go func() {
 		defer close(responseChan)
-		s.streamFromOpenRouter(ctx, messages, insightID, responseChan)
+		s.streamReply(ctx, turn, events, responseChan)
 	}()
-
 	return responseChan, nil
 }
 
-// GetChatHistory returns the chat history for an analysis.
-func (s *ChatService) GetChatHistory(ctx context.Context, analysisID uint) (*models.ChatHistoryResponse, error) {
-	messages, err := s.chatRepo.GetMessagesByAnalysisID(ctx, analysisID)
+// streamReply forwards LLM deltas and saves the assistant reply once complete.
+func (s *ChatService) streamReply(ctx context.Context, turn *chatTurn, events <-chan llm.StreamEvent, responseChan chan<- models.ChatStreamEvent) {
+	var fullContent strings.Builder
+	var streamErr error
+	for event := range events {
+		if event.Err != nil {
+			s.log.Error("Chat stream interrupted",
+				zap.Error(event.Err),
+				zap.Uint("insight_id", turn.insight.ID),
+			)
+			streamErr = event.Err
+			break
+		}
+		if event.Delta != "" {
+			fullContent.WriteString(event.Delta)
+			// Once the client is gone nobody drains responseChan; keep reading so the reply is saved
+			select {
+			case responseChan <- models.ChatStreamEvent{
+				Role:    llm.RoleAssistant,
+				Content: event.Delta,
+				Done:    false,
+			}:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	final := models.ChatStreamEvent{Done: true}
+	if streamErr != nil {
+		final.Error = models.ErrChatFailed.Message
+	}
+
+	// Save whatever was generated; the client may already have disconnected
+	if fullContent.Len() > 0 {
+		reply, err := s.saveReply(context.WithoutCancel(ctx), turn, fullContent.String())
+		if err == nil {
+			final.Role = llm.RoleAssistant
+			final.MessageID = &reply.ID
+			// The passages the answer may cite
+			final.Sources = turn.passages
+		}
+	}
+	select {
+	case responseChan <- final:
+	case <-ctx.Done():
+	}
+}
+
+// saveReply persists the assistant reply of a turn, threaded under the same highlight as the question.
+func (s *ChatService) saveReply(ctx context.Context, turn *chatTurn, content string) (*models.ChatMessage, error) {
+	reply := &models.ChatMessage{
+		InsightID:   turn.insight.ID,
+		UserID:      turn.userID,
+		Role:        llm.RoleAssistant,
+		Content:     content,
+		HighlightID: turn.highlightID,
+	}
+	if err := s.chatRepo.CreateMessage(ctx, reply); err != nil {
+		s.log.Error("Failed to save assistant message",
+			zap.Uint("insight_id", turn.insight.ID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to save reply: %w", err)
+	}
+
+	s.scheduleCompaction(ctx, turn.insight.ID)
+	return reply, nil
+}
+
+// GetChatHistory returns up to limit messages of an insight's conversation
+// before the message beforeID (0 = the latest messages), oldest first.
+func (s *ChatService) GetChatHistory(ctx context.Context, insightID uint, beforeID uint, limit int) (*models.ChatHistoryResponse, error) {
+	// Fetch one extra message to know whether older messages exist
+	messages, err := s.chatRepo.GetMessagesBefore(ctx, insightID, beforeID, limit+1)
 	if err != nil {
 		return nil, err
 	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[1:]
+	}
 
-	response := &models.ChatHistoryResponse{
-		Messages: make([]models.ChatMessageResponse, len(messages)),
+	total, err := s.chatRepo.CountMessages(ctx, insightID)
+	if err != nil {
+		return nil, err
 	}
 
-	for i, msg := range messages {
-		response.Messages[i] = models.ChatMessageResponse{
-			ID:        msg.ID,
-			Role:      msg.Role,
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-		}
+	if err := s.insightRepo.AttachHighlights(ctx, messages); err != nil {
+		s.log.Warn("Failed to load highlights for chat history", zap.Error(err))
+	}
+	if err := s.insightRepo.AttachMessageAuthors(ctx, messages); err != nil {
+		s.log.Warn("Failed to load authors for chat history", zap.Error(err))
 	}
 
+	response := &models.ChatHistoryResponse{
+		Messages: make([]models.ChatMessageResponse, len(messages)),
+		Total:    total,
+		HasMore:  hasMore,
+	}
+	for i := range messages {
+		response.Messages[i] = chatMessageResponse(&messages[i])
+	}
 	return response, nil
 }
 
+// ClearHistory deletes the conversation of an insight.
+func (s *ChatService) ClearHistory(ctx context.Context, insightID uint) error {
+	return s.chatRepo.DeleteMessagesByInsightID(ctx, insightID)
+}
+
+// chatMessageResponse converts a chat message to its API representation.
+func chatMessageResponse(msg *models.ChatMessage) models.ChatMessageResponse {
+	return models.ChatMessageResponse{
+		ID:          msg.ID,
+		Role:        msg.Role,
+		Content:     msg.Content,
+		HighlightID: msg.HighlightID,
+		Highlight:   msg.Highlight,
+		Author:      msg.Author,
+		CreatedAt:   msg.CreatedAt,
+	}
+}
+
 // AnalyzeEntities analyzes the content and returns detected entities and suggestions.
-func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*models.AnalyzeEntitiesResponse, error) {
+func (s *ChatService) AnalyzeEntities(ctx context.Context, insight *models.Insight) (*models.AnalyzeEntitiesResponse, error) {
+	insightID := insight.ID
 	s.log.Info("Starting entity analysis",
 		zap.Uint("insight_id", insightID),
 	)
 
-	insight, err := s.insightRepo.GetByID(ctx, insightID)
-	if err != nil {
-		s.log.Error("Failed to fetch insight",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("insight not found: %w", err)
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
 	}
 
 	s.log.Debug("Insight data retrieved",
@@ -176,45 +480,28 @@ func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*mod
 
 只返回JSON，不要其他文字。`, insight.Title, insight.Author, insight.Summary)
 
-	s.log.Debug("Calling OpenRouter API",
+	s.log.Debug("Calling LLM API",
 		zap.Uint("insight_id", insightID),
 		zap.String("model", s.chatModel),
 	)
 
-	response, err := s.callOpenRouter(ctx, prompt)
+	var result models.AnalyzeEntitiesResponse
+	resp, err := s.llm.CompleteJSON(ctx, llm.UserPrompt(llm.FeatureEntities, s.chatModel, prompt), &result)
 	if err != nil {
-		s.log.Error("Failed to analyze entities",
+		fields := []zap.Field{
 			zap.Uint("insight_id", insightID),
 			zap.String("model", s.chatModel),
 			zap.Error(err),
-		)
+		}
+		if resp != nil {
+			// The call succeeded but the model did not return valid JSON
+			s.log.Error("Failed to parse entity response", append(fields, zap.String("raw_response", resp.Content))...)
+			return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		}
+		s.log.Error("Failed to analyze entities", fields...)
 		return nil, fmt.Errorf("failed to call AI service: %w", err)
 	}
 
-	s.log.Debug("OpenRouter API response received",
-		zap.Uint("insight_id", insightID),
-		zap.Int("response_length", len(response)),
-	)
-
-	// Parse response
-	var result models.AnalyzeEntitiesResponse
-	cleanedResponse := s.cleanJSONResponse(response)
-	
-	s.log.Debug("Cleaned JSON response",
-		zap.Uint("insight_id", insightID),
-		zap.String("cleaned_response", cleanedResponse),
-	)
-
-	if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
-		s.log.Error("Failed to parse entity response",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-			zap.String("raw_response", response),
-			zap.String("cleaned_response", cleanedResponse),
-		)
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
-	}
-
 	// 确保 entities 和 suggestions 不为 nil（即使为空数组）
 	if result.Entities == nil {
 		result.Entities = []models.Entity{}
@@ -232,9 +519,26 @@ func (s *ChatService) AnalyzeEntities(ctx context.Context, insightID uint) (*mod
 	return &result, nil
 }
 
-// buildSystemPrompt creates the system prompt with insight context.
-func (s *ChatService) buildSystemPrompt(insight *models.Insight) string {
-	return fmt.Sprintf(`你是一个智能阅读助手。用户正在阅读以下内容：
+// retrievePassages returns the transcript passages most relevant to message.
+// Retrieval is optional: failures are logged and the chat continues without passages.
+func (s *ChatService) retrievePassages(ctx context.Context, insight *models.Insight, message string) []models.TranscriptPassage {
+	if s.retrieval == nil {
+		return nil
+	}
+	passages, err := s.retrieval.Search(ctx, insight, message, s.topK)
+	if err != nil {
+		s.log.Warn("Failed to retrieve transcript passages",
+			zap.Uint("insight_id", insight.ID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return passages
+}
+
+// buildSystemPrompt creates the system prompt with insight context and retrieved transcript passages.
+func (s *ChatService) buildSystemPrompt(insight *models.Insight, passages []models.TranscriptPassage) string {
+	prompt := fmt.Sprintf(`你是一个智能阅读助手。用户正在阅读以下内容：
 
 标题: %s
 作者: %s
@@ -245,303 +549,59 @@ func (s *ChatService) buildSystemPrompt(insight *models.Insight) string {
 2. 如果内容中没有相关信息，可以结合你的知识回答，但需说明
 3. 保持回答简洁、有洞察力
 4. 支持 Markdown 格式`, insight.Title, insight.Author, insight.Summary)
-}
-
-// buildMessages constructs the messages array for the API call.
-func (s *ChatService) buildMessages(systemPrompt string, history []models.ChatMessage, newMessage string) []map[string]string {
-	messages := make([]map[string]string, 0, len(history)+2)
-
-	// Add system message
-	messages = append(messages, map[string]string{
-		"role":    "system",
-		"content": systemPrompt,
-	})
 
-	// Add history (limit to last 10 messages to control context size)
-	historyStart := 0
-	if len(history) > 10 {
-		historyStart = len(history) - 10
-	}
-	for _, msg := range history[historyStart:] {
-		messages = append(messages, map[string]string{
-			"role":    msg.Role,
-			"content": msg.Content,
-		})
+	if len(passages) == 0 {
+		return prompt
 	}
 
-	// Add new user message
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": newMessage,
-	})
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString(`
+5. 引用下列字幕片段时，在相关句子末尾标注片段的时间戳，格式严格为 [mm:ss]（例如 [05:12]），不要编造未列出的时间戳
 
-	return messages
-}
-
-// streamFromOpenRouter handles the SSE streaming from OpenRouter.
-func (s *ChatService) streamFromOpenRouter(ctx context.Context, messages []map[string]string, insightID uint, responseChan chan<- models.ChatStreamEvent) {
-	const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
-
-	requestBody := map[string]interface{}{
-		"model":    s.chatModel,
-		"messages": messages,
-		"stream":   true,
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		s.log.Error("Failed to marshal request", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		s.log.Error("Failed to create request", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openRouterAPIKey)
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("HTTP-Referer", "https://github.com/your-repo/vibe-engineering-playbook")
-	req.Header.Set("X-Title", "VIBE Engineering Playbook")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.log.Error("Failed to call OpenRouter API", zap.Error(err))
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var errorBody bytes.Buffer
-		errorBody.ReadFrom(resp.Body)
-		errorBodyStr := errorBody.String()
-		
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效 (流式请求)",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
-				zap.String("api_key_prefix", s.maskAPIKey()),
-				zap.Uint("insight_id", insightID),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
-			)
-		} else {
-			s.log.Error("OpenRouter streaming API error",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
-				zap.Uint("insight_id", insightID),
-			)
-		}
-		
-		responseChan <- models.ChatStreamEvent{Done: true}
-		return
-	}
-
-	// Read SSE stream
-	var fullContent strings.Builder
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, ":") {
-			continue
-		}
-
-		// Parse SSE data
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-
-			// Check for stream end
-			if data == "[DONE]" {
-				break
-			}
-
-			// Parse chunk
-			var chunk struct {
-				Choices []struct {
-					Delta struct {
-						Content string `json:"content"`
-					} `json:"delta"`
-					FinishReason *string `json:"finish_reason"`
-				} `json:"choices"`
-			}
-
-			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-				continue
-			}
-
-			if len(chunk.Choices) > 0 {
-				content := chunk.Choices[0].Delta.Content
-				if content != "" {
-					fullContent.WriteString(content)
-					responseChan <- models.ChatStreamEvent{
-						Role:    "assistant",
-						Content: content,
-						Done:    false,
-					}
-				}
-			}
-		}
-	}
-
-	// Save assistant message
-	if fullContent.Len() > 0 {
-		assistantMessage := &models.ChatMessage{
-			InsightID: insightID,
-			UserID:    0, // TODO: Get from context/auth
-			Role:      "assistant",
-			Content:   fullContent.String(),
-		}
-		if err := s.chatRepo.CreateMessage(ctx, assistantMessage); err != nil {
-			s.log.Error("Failed to save assistant message", zap.Error(err))
-		}
-
-		// Send final event with message ID
-		responseChan <- models.ChatStreamEvent{
-			Role:      "assistant",
-			Content:   "",
-			Done:      true,
-			MessageID: &assistantMessage.ID,
-		}
-	} else {
-		responseChan <- models.ChatStreamEvent{Done: true}
+与问题相关的字幕片段：
+`)
+	for _, p := range passages {
+		fmt.Fprintf(&b, "\n[%s] %s\n", p.Timestamp, p.Text)
 	}
+	return b.String()
 }
 
-// callOpenRouter makes a non-streaming call to OpenRouter.
-func (s *ChatService) callOpenRouter(ctx context.Context, prompt string) (string, error) {
-	const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
-
-	requestBody := map[string]interface{}{
-		"model": s.chatModel,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openRouterAPIKey)
-	req.Header.Set("HTTP-Referer", "https://github.com/your-repo/vibe-engineering-playbook")
-	req.Header.Set("X-Title", "VIBE Engineering Playbook")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call OpenRouter API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		var errorBody bytes.Buffer
-		errorBody.ReadFrom(resp.Body)
-		errorBodyStr := errorBody.String()
-		
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", errorBodyStr),
-				zap.String("api_key_prefix", s.maskAPIKey()),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
-			)
-			return "", fmt.Errorf("OpenRouter API 认证失败（401）: %s - 请检查 OPENROUTER_API_KEY 是否有效", errorBodyStr)
+// buildMessages constructs the messages array for the API call. History is
+// windowed to the most recent messages that fit twice the memory threshold,
+// which summarization normally keeps the unsummarized history under.
+func (s *ChatService) buildMessages(systemPrompt string, history []models.ChatMessage, newMessage string) []llm.Message {
+	historyStart := len(history)
+	budget := 2 * s.memoryThreshold
+	for historyStart > 0 && len(history)-historyStart < chatHistoryMaxMessages {
+		cost := s.messageTokens(history[historyStart-1])
+		if cost > budget {
+			break
 		}
-		
-		s.log.Error("OpenRouter API returned error",
-			zap.Int("status_code", resp.StatusCode),
-			zap.String("response_body", errorBodyStr),
-		)
-		return "", fmt.Errorf("OpenRouter API error: status %d, body: %s", resp.StatusCode, errorBodyStr)
-	}
-
-	var response struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-			Code    string `json:"code"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		budget -= cost
+		historyStart--
 	}
 
-	// Check for API-level errors
-	if response.Error != nil {
-		return "", fmt.Errorf("OpenRouter API error: %s (code: %s)", response.Error.Message, response.Error.Code)
-	}
-
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no response from API")
-	}
-
-	return response.Choices[0].Message.Content, nil
-}
-
-// cleanJSONResponse removes markdown code blocks from JSON response.
-func (s *ChatService) cleanJSONResponse(response string) string {
-	cleaned := strings.TrimSpace(response)
-
-	// Remove markdown code blocks
-	if strings.HasPrefix(cleaned, "```json") {
-		cleaned = strings.TrimPrefix(cleaned, "```json")
-	} else if strings.HasPrefix(cleaned, "```") {
-		cleaned = strings.TrimPrefix(cleaned, "```")
-	}
+	messages := make([]llm.Message, 0, len(history)-historyStart+2)
 
-	if strings.HasSuffix(cleaned, "```") {
-		cleaned = strings.TrimSuffix(cleaned, "```")
-	}
-
-	cleaned = strings.TrimSpace(cleaned)
+	// Add system message
+	messages = append(messages, llm.Message{
+		Role:    llm.RoleSystem,
+		Content: systemPrompt,
+	})
 
-	// Extract JSON if wrapped in text
-	if !strings.HasPrefix(cleaned, "{") && !strings.HasPrefix(cleaned, "[") {
-		startIdx := strings.Index(cleaned, "{")
-		if startIdx == -1 {
-			startIdx = strings.Index(cleaned, "[")
-		}
-		endIdx := strings.LastIndex(cleaned, "}")
-		if endIdx == -1 {
-			endIdx = strings.LastIndex(cleaned, "]")
-		}
-		if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
-			cleaned = cleaned[startIdx : endIdx+1]
-		}
+	for _, msg := range history[historyStart:] {
+		messages = append(messages, llm.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
 	}
 
-	return cleaned
-}
+	// Add new user message
+	messages = append(messages, llm.Message{
+		Role:    llm.RoleUser,
+		Content: newMessage,
+	})
 
-// maskAPIKey returns a masked version of the API key for logging (shows only first 10 chars).
-func (s *ChatService) maskAPIKey() string {
-	if s.openRouterAPIKey == "" {
-		return "<未设置>"
-	}
-	if len(s.openRouterAPIKey) <= 10 {
-		return "***"
-	}
-	return s.openRouterAPIKey[:10] + "..." + s.openRouterAPIKey[len(s.openRouterAPIKey)-4:]
+	return messages
 }
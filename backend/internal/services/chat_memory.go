@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+const (
+	// defaultChatMemoryThreshold is the number of tokens of unsummarized history
+	// above which older turns are folded into the conversation summary.
+	defaultChatMemoryThreshold = 3000
+
+	// chatMemoryFetchLimit bounds the unsummarized messages loaded per turn.
+	chatMemoryFetchLimit = 100
+
+	// chatMemoryMinRecent is the number of latest messages never summarized,
+	// so the last exchange (and a question awaiting regeneration) stays verbatim.
+	chatMemoryMinRecent = 4
+
+	// ChatMemoryPromptVersion identifies the summarization prompt template.
+	ChatMemoryPromptVersion = "chat-memory-v1"
+)
+
+// SetMemoryThreshold sets the token threshold of unsummarized history above
+// which older turns are summarized (for dependency injection).
+func (s *ChatService) SetMemoryThreshold(tokens int) {
+	if tokens > 0 {
+		s.memoryThreshold = tokens
+	}
+}
+
+// loadMemory returns the conversation summary of an insight (nil if none) and
+// the messages after it, oldest first.
+func (s *ChatService) loadMemory(ctx context.Context, insightID uint) (*models.ChatSummary, []models.ChatMessage, error) {
+	summary, err := s.chatRepo.GetSummary(ctx, insightID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get chat summary: %w", err)
+	}
+
+	var coveredID uint
+	if summary != nil {
+		coveredID = summary.CoveredMessageID
+	}
+	history, err := s.chatRepo.GetMessagesBetween(ctx, insightID, coveredID, 0, chatMemoryFetchLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+	return summary, history, nil
+}
+
+// messageTokens estimates the tokens of a chat message for the chat model.
+func (s *ChatService) messageTokens(msg models.ChatMessage) int {
+	return llm.EstimateTokensForModel(s.chatModel, msg.Content) + 4
+}
+
+// scheduleCompaction summarizes older turns of an insight's conversation in the
+// background if its unsummarized history crossed the threshold.
+func (s *ChatService) scheduleCompaction(ctx context.Context, insightID uint) {
+	// One compaction per insight at a time in this process; SaveSummary guards across processes
+	if _, running := s.compacting.LoadOrStore(insightID, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer s.compacting.Delete(insightID)
+		if err := s.compactMemory(context.WithoutCancel(ctx), insightID); err != nil {
+			s.log.Warn("⚠️  Failed to summarize chat history",
+				zap.Uint("insight_id", insightID),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// compactMemory folds the oldest unsummarized messages into the conversation
+// summary, keeping the latest messages that fit in half the threshold verbatim.
+func (s *ChatService) compactMemory(ctx context.Context, insightID uint) error {
+	summary, history, err := s.loadMemory(ctx, insightID)
+	if err != nil {
+		return err
+	}
+
+	fold, total := s.messagesToFold(history)
+	if len(fold) == 0 {
+		return nil
+	}
+	keep := len(history) - len(fold)
+
+	var previous string
+	var previousCovered uint
+	var previousCount int
+	if summary != nil {
+		previous = summary.Content
+		previousCovered = summary.CoveredMessageID
+		previousCount = summary.MessageCount
+	}
+
+	content, err := s.summarizeTurns(ctx, previous, fold)
+	if err != nil {
+		return err
+	}
+
+	updated := &models.ChatSummary{
+		InsightID:        insightID,
+		Content:          content,
+		CoveredMessageID: fold[len(fold)-1].ID,
+		MessageCount:     previousCount + len(fold),
+		Model:            s.chatModel,
+	}
+	saved, err := s.chatRepo.SaveSummary(ctx, updated, previousCovered)
+	if err != nil {
+		return fmt.Errorf("failed to save chat summary: %w", err)
+	}
+	if !saved {
+		s.log.Debug("Chat summary was updated concurrently, skipping", zap.Uint("insight_id", insightID))
+		return nil
+	}
+
+	s.log.Info("Chat history summarized",
+		zap.Uint("insight_id", insightID),
+		zap.Int("folded_messages", len(fold)),
+		zap.Int("kept_messages", keep),
+		zap.Int("history_tokens", total),
+	)
+	return nil
+}
+
+// messagesToFold returns the oldest messages of history to fold into the
+// summary, none unless its total tokens (also returned) exceed the threshold.
+// The newest messages within half the threshold, and at least
+// chatMemoryMinRecent of them, are kept verbatim.
+func (s *ChatService) messagesToFold(history []models.ChatMessage) ([]models.ChatMessage, int) {
+	total := 0
+	for _, msg := range history {
+		total += s.messageTokens(msg)
+	}
+	if total <= s.memoryThreshold || len(history) <= chatMemoryMinRecent {
+		return nil, total
+	}
+
+	keep, kept := 0, 0
+	for i := len(history) - 1; i >= 0; i-- {
+		cost := s.messageTokens(history[i])
+		if keep >= chatMemoryMinRecent && kept+cost > s.memoryThreshold/2 {
+			break
+		}
+		kept += cost
+		keep++
+	}
+	return history[:len(history)-keep], total
+}
+
+// summarizeTurns asks the model to merge messages into the previous summary.
+func (s *ChatService) summarizeTurns(ctx context.Context, previous string, messages []models.ChatMessage) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		role := "用户"
+		if msg.Role == llm.RoleAssistant {
+			role = "助手"
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", role, msg.Content)
+	}
+	if previous == "" {
+		previous = "（无）"
+	}
+
+	prompt := fmt.Sprintf(`你负责维护一段关于某个视频/文章的长对话的记忆。请把「已有摘要」和「新增对话」合并成一份更新后的摘要，供之后的对话继续使用。
+
+要求：
+1. 保留用户的问题、关注点和目标，以及助手给出的关键结论、数据和引用的时间戳（如 [05:12]）
+2. 保留尚未解决的问题和用户表达的偏好
+3. 删除寒暄和重复内容，不要编造对话中没有的信息
+4. 使用对话所用的语言，控制在 400 字以内，只输出摘要正文
+
+已有摘要：
+%s
+
+新增对话：
+%s`, previous, transcript.String())
+
+	req := llm.UserPrompt(llm.FeatureChatMemory, s.chatModel, prompt)
+	req.Temperature = llm.Float(0.2)
+	req.MaxTokens = 1000
+	content, err := s.llm.CompleteText(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return content, nil
+}
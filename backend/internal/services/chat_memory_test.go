@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+func testHistory(n int) []models.ChatMessage {
+	history := make([]models.ChatMessage, n)
+	for i := range history {
+		role := llm.RoleUser
+		if i%2 == 1 {
+			role = llm.RoleAssistant
+		}
+		history[i] = models.ChatMessage{ID: uint(i + 1), Role: role, Content: strings.Repeat("word ", 20)}
+	}
+	return history
+}
+
+func TestMessagesToFold(t *testing.T) {
+	s := &ChatService{chatModel: "gpt-4o-mini", log: zap.NewNop()}
+	cost := s.messageTokens(testHistory(1)[0])
+
+	for _, tc := range []struct {
+		name      string
+		messages  int
+		threshold int
+		wantFold  int
+	}{
+		{"below threshold", 10, 11 * cost, 0},
+		{"at threshold", 10, 10 * cost, 0},
+		{"just above threshold", 10, 10*cost - 1, 6},
+		{"keeps half the threshold", 20, 12 * cost, 14},
+		{"keeps the minimum recent turns", 10, 1, 10 - chatMemoryMinRecent},
+		{"too few messages", chatMemoryMinRecent, 1, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s.memoryThreshold = tc.threshold
+			history := testHistory(tc.messages)
+
+			fold, total := s.messagesToFold(history)
+			if total != tc.messages*cost {
+				t.Fatalf("total = %d, want %d", total, tc.messages*cost)
+			}
+			if len(fold) != tc.wantFold {
+				t.Fatalf("folded %d messages, want %d", len(fold), tc.wantFold)
+			}
+			if len(fold) > 0 && (fold[0].ID != 1 || fold[len(fold)-1].ID != uint(tc.wantFold)) {
+				t.Fatalf("folded messages %d..%d, want the oldest %d", fold[0].ID, fold[len(fold)-1].ID, tc.wantFold)
+			}
+		})
+	}
+}
+
+func TestSummarizeTurns(t *testing.T) {
+	var prompt string
+	fake := llm.NewFake(func(req llm.Request) (string, error) {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return "  merged summary  ", nil
+	})
+	s := &ChatService{llm: llm.NewClient(fake, llm.Options{MaxRetries: 0}, zap.NewNop()), chatModel: "gpt-4o-mini", log: zap.NewNop()}
+	messages := []models.ChatMessage{
+		{Role: llm.RoleUser, Content: "What happens at [05:12]?"},
+		{Role: llm.RoleAssistant, Content: "The speaker shows the demo."},
+	}
+
+	got, err := s.summarizeTurns(context.Background(), "earlier summary", messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "merged summary" {
+		t.Fatalf("summary = %q", got)
+	}
+	for _, part := range []string{"earlier summary", "用户: What happens at [05:12]?", "助手: The speaker shows the demo."} {
+		if !strings.Contains(prompt, part) {
+			t.Errorf("prompt does not contain %q:\n%s", part, prompt)
+		}
+	}
+
+	if _, err := s.summarizeTurns(context.Background(), "", messages); err != nil || !strings.Contains(prompt, "（无）") {
+		t.Fatalf("first summary prompt = %q, %v", prompt, err)
+	}
+
+	fake = llm.NewFake(func(llm.Request) (string, error) { return " ", nil })
+	s.llm = llm.NewClient(fake, llm.Options{MaxRetries: 0}, zap.NewNop())
+	if _, err := s.summarizeTurns(context.Background(), "", messages); err == nil {
+		t.Fatal("empty summary accepted")
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"vibe-backend/internal/models"
+)
+
+func TestCanRegenerate(t *testing.T) {
+	const author, other = 1, 2
+	question := &models.ChatMessage{UserID: author}
+
+	for _, tc := range []struct {
+		name   string
+		userID uint
+		role   models.WorkspaceRole
+		want   bool
+	}{
+		{"author as editor", author, models.WorkspaceRoleEditor, true},
+		{"author as owner", author, models.WorkspaceRoleOwner, true},
+		{"owner of another member's question", other, models.WorkspaceRoleOwner, true},
+		{"editor of another member's question", other, models.WorkspaceRoleEditor, false},
+		{"viewer of another member's question", other, models.WorkspaceRoleViewer, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := canRegenerate(question, tc.userID, tc.role); got != tc.want {
+				t.Fatalf("canRegenerate = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// GoogleAccountService stores the Google accounts linked to users, encrypted
+// at rest, and hands out token sources that refresh and persist tokens
+// server-side, so clients never hold Google tokens.
+type GoogleAccountService struct {
+	repo   *repository.GoogleAccountRepository
+	oauth  *OAuthService
+	cipher *TokenCipher
+	log    *zap.Logger
+}
+
+// NewGoogleAccountService creates a new GoogleAccountService.
+func NewGoogleAccountService(repo *repository.GoogleAccountRepository, oauth *OAuthService, cipher *TokenCipher, log *zap.Logger) *GoogleAccountService {
+	return &GoogleAccountService{
+		repo:   repo,
+		oauth:  oauth,
+		cipher: cipher,
+		log:    log,
+	}
+}
+
+// encryptToken serializes and encrypts a token for storage.
+func (s *GoogleAccountService) encryptToken(token *oauth2.Token) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token: %w", err)
+	}
+	return s.cipher.Encrypt(data)
+}
+
+// decryptToken reverses encryptToken.
+func (s *GoogleAccountService) decryptToken(encrypted string) (*oauth2.Token, error) {
+	data, err := s.cipher.Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	return &token, nil
+}
+
+// LinkedUserID returns the user a Google user ID is linked to, 0 if none.
+func (s *GoogleAccountService) LinkedUserID(ctx context.Context, googleID string) (uint, error) {
+	account, err := s.repo.GetByGoogleID(ctx, googleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get Google account: %w", err)
+	}
+	return account.UserID, nil
+}
+
+// Link links a Google account to a user after the OAuth callback. Google only
+// returns a refresh token on first consent, so an existing one is kept when
+// token has none. Returns models.ErrGoogleAccountInUse if the Google account
+// is linked to another user.
+func (s *GoogleAccountService) Link(ctx context.Context, userID uint, info *GoogleUserInfo, token *oauth2.Token) (*models.GoogleAccount, error) {
+	linkedTo, err := s.LinkedUserID(ctx, info.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linkedTo != 0 && linkedTo != userID {
+		return nil, models.ErrGoogleAccountInUse
+	}
+
+	if token.RefreshToken == "" {
+		if existing, err := s.repo.GetByUserID(ctx, userID); err == nil {
+			if old, err := s.decryptToken(existing.EncryptedToken); err == nil {
+				token.RefreshToken = old.RefreshToken
+			}
+		}
+	}
+
+	encrypted, err := s.encryptToken(token)
+	if err != nil {
+		return nil, err
+	}
+	account := &models.GoogleAccount{
+		UserID:         userID,
+		GoogleID:       info.ID,
+		Email:          info.Email,
+		EncryptedToken: encrypted,
+	}
+	if !token.Expiry.IsZero() {
+		account.TokenExpiry = &token.Expiry
+	}
+	if err := s.repo.Upsert(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to save Google account: %w", err)
+	}
+
+	s.log.Info("Google account linked",
+		zap.Uint("user_id", userID),
+		zap.String("google_email", info.Email),
+		zap.Bool("has_refresh_token", token.RefreshToken != ""),
+	)
+	return account, nil
+}
+
+// Status returns the Google account link of a user.
+func (s *GoogleAccountService) Status(ctx context.Context, userID uint) (*models.GoogleAccountResponse, error) {
+	account, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.GoogleAccountResponse{Linked: false}, nil
+		}
+		return nil, err
+	}
+	return &models.GoogleAccountResponse{
+		Linked:   true,
+		Email:    account.Email,
+		LinkedAt: &account.UpdatedAt,
+	}, nil
+}
+
+// TokenSource returns a token source for the Google account of a user.
+// Refreshed tokens are written back, encrypted. Returns
+// models.ErrGoogleNotLinked if the user has no linked account and
+// models.ErrGoogleRelinkRequired if the stored token cannot be used.
+func (s *GoogleAccountService) TokenSource(ctx context.Context, userID uint) (oauth2.TokenSource, error) {
+	account, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrGoogleNotLinked
+		}
+		return nil, fmt.Errorf("failed to get Google account: %w", err)
+	}
+
+	token, err := s.decryptToken(account.EncryptedToken)
+	if err != nil {
+		s.log.Warn("Failed to decrypt stored Google token",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, models.ErrGoogleRelinkRequired
+	}
+
+	return &persistingTokenSource{
+		base:   s.oauth.TokenSource(context.WithoutCancel(ctx), token),
+		last:   token,
+		userID: userID,
+		svc:    s,
+	}, nil
+}
+
+// Unlink revokes the Google grant of a user and removes the link. Returns
+// models.ErrGoogleNotLinked if the user has no linked account.
+func (s *GoogleAccountService) Unlink(ctx context.Context, userID uint) error {
+	account, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrGoogleNotLinked
+		}
+		return fmt.Errorf("failed to get Google account: %w", err)
+	}
+
+	// Revoking the refresh token revokes the whole grant. A token we cannot
+	// decrypt can no longer be used by us either, so the link is just removed.
+	if token, err := s.decryptToken(account.EncryptedToken); err == nil {
+		revoke := token.RefreshToken
+		if revoke == "" {
+			revoke = token.AccessToken
+		}
+		if err := s.oauth.RevokeToken(ctx, revoke); err != nil {
+			s.log.Error("Failed to revoke Google token",
+				zap.Uint("user_id", userID),
+				zap.Error(err),
+			)
+			return models.ErrGoogleRevokeFailed
+		}
+	}
+
+	if err := s.repo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete Google account: %w", err)
+	}
+
+	s.log.Info("Google account unlinked",
+		zap.Uint("user_id", userID),
+		zap.String("google_email", account.Email),
+	)
+	return nil
+}
+
+// persistingTokenSource wraps a refreshing token source and stores every new
+// token it returns, so rotated tokens survive the request.
+type persistingTokenSource struct {
+	base   oauth2.TokenSource
+	userID uint
+	svc    *GoogleAccountService
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+// Token returns a valid token, refreshing and persisting it if needed.
+func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := p.base.Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			p.svc.log.Warn("Google grant is no longer valid",
+				zap.Uint("user_id", p.userID),
+			)
+			return nil, models.ErrGoogleRelinkRequired
+		}
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last != nil && token.AccessToken == p.last.AccessToken {
+		return token, nil
+	}
+	p.last = token
+
+	var expiry *time.Time
+	if !token.Expiry.IsZero() {
+		expiry = &token.Expiry
+	}
+	encrypted, err := p.svc.encryptToken(token)
+	if err == nil {
+		err = p.svc.repo.UpdateToken(context.Background(), p.userID, encrypted, expiry)
+	}
+	if err != nil {
+		// The token is still valid for this request; the next one refreshes again.
+		p.svc.log.Warn("Failed to persist refreshed Google token",
+			zap.Uint("user_id", p.userID),
+			zap.Error(err),
+		)
+	} else {
+		p.svc.log.Debug("Refreshed Google token persisted", zap.Uint("user_id", p.userID))
+	}
+	return token, nil
+}
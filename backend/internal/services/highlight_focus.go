@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+)
+
+// highlightNearbyItems is the number of transcript items included on each side of a highlight.
+const highlightNearbyItems = 3
+
+// highlightFocus is the focused context of a question asked about a highlight.
+type highlightFocus struct {
+	highlight *models.Highlight
+	nearby    []models.TranscriptItem // transcript items around the highlight, in order
+	first     int                     // index in nearby of the first highlighted item, -1 if not located
+	last      int
+}
+
+// loadHighlightFocus loads a highlight of insight and the transcript around it.
+// Returns models.ErrHighlightNotFound if it does not exist or belongs to another insight.
+func (s *ChatService) loadHighlightFocus(ctx context.Context, insight *models.Insight, highlightID uint) (*highlightFocus, error) {
+	highlight, err := s.insightRepo.GetHighlightByID(ctx, highlightID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrHighlightNotFound
+		}
+		return nil, fmt.Errorf("failed to get highlight: %w", err)
+	}
+	if highlight.InsightID != insight.ID {
+		return nil, models.ErrHighlightNotFound
+	}
+
+	var items []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &items); err != nil {
+			s.log.Warn("Failed to parse transcripts for highlight context",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+			items = nil
+		}
+	}
+	return newHighlightFocus(highlight, items), nil
+}
+
+// newHighlightFocus builds the focus of highlight from the transcript items
+// around it, clamped to the transcript bounds.
+func newHighlightFocus(highlight *models.Highlight, items []models.TranscriptItem) *highlightFocus {
+	focus := &highlightFocus{highlight: highlight, first: -1, last: -1}
+
+	first, last := locateHighlight(items, highlight)
+	if first < 0 {
+		return focus
+	}
+	from := max(first-highlightNearbyItems, 0)
+	to := min(last+highlightNearbyItems+1, len(items))
+	focus.nearby = items[from:to]
+	focus.first = first - from
+	focus.last = last - from
+	return focus
+}
+
+// locateHighlight returns the range of transcript items a highlight covers.
+// Offsets are character positions in the transcript texts joined by "\n", as
+// rendered by the transcript view; if they don't match the highlighted text
+// (e.g. it was made in the translated view) the text is searched instead.
+// Returns -1, -1 if the highlight cannot be located.
+func locateHighlight(items []models.TranscriptItem, h *models.Highlight) (int, int) {
+	first, last := -1, -1
+	pos := 0
+	for i, item := range items {
+		start := pos
+		end := start + utf8.RuneCountInString(item.Text)
+		pos = end + 1 // "\n" separator
+
+		if end > h.StartOffset && start < h.EndOffset {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first >= 0 && offsetsMatchText(items[first:last+1], h) {
+		return first, last
+	}
+
+	// Fall back to searching for the highlighted text (or its beginning)
+	needle := strings.TrimSpace(h.Text)
+	if r := []rune(needle); len(r) > 40 {
+		needle = string(r[:40])
+	}
+	if needle == "" {
+		return -1, -1
+	}
+	for i, item := range items {
+		if strings.Contains(item.Text, needle) || strings.Contains(item.TranslatedText, needle) {
+			return i, i
+		}
+	}
+	return -1, -1
+}
+
+// offsetsMatchText reports whether items contain the start of the highlighted text.
+func offsetsMatchText(items []models.TranscriptItem, h *models.Highlight) bool {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.Text
+	}
+	joined := strings.Join(texts, "\n")
+
+	prefix := strings.TrimSpace(h.Text)
+	if r := []rune(prefix); len(r) > 20 {
+		prefix = string(r[:20])
+	}
+	return strings.Contains(joined, prefix)
+}
+
+// prompt renders the focus as a section appended to the system prompt.
+func (f *highlightFocus) prompt() string {
+	var b strings.Builder
+	b.WriteString("\n\n用户正在针对自己高亮的片段提问，请围绕该片段回答：\n")
+	fmt.Fprintf(&b, "\n高亮内容: 「%s」\n", f.highlight.Text)
+	fmt.Fprintf(&b, "位置: 字幕第 %d-%d 个字符", f.highlight.StartOffset, f.highlight.EndOffset)
+	if f.first >= 0 {
+		fmt.Fprintf(&b, "，约 [%s]", formatCitationTimestamp(f.nearby[f.first].Seconds))
+	}
+	b.WriteString("\n")
+	if note := strings.TrimSpace(f.highlight.Note); note != "" {
+		fmt.Fprintf(&b, "用户笔记: %s\n", note)
+	}
+
+	if len(f.nearby) > 0 {
+		b.WriteString("\n高亮附近的字幕（» 标记高亮所在行）：\n")
+		for i, item := range f.nearby {
+			marker := "  "
+			if i >= f.first && i <= f.last {
+				marker = "» "
+			}
+			fmt.Fprintf(&b, "%s[%s] %s\n", marker, formatCitationTimestamp(item.Seconds), item.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"vibe-backend/internal/models"
+)
+
+func TestNewHighlightFocus(t *testing.T) {
+	// "item 0\nitem 1\n...": item i spans offsets [7i, 7i+6)
+	items := make([]models.TranscriptItem, 10)
+	for i := range items {
+		items[i] = models.TranscriptItem{Text: fmt.Sprintf("item %d", i), Seconds: i * 10}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		start, end  int
+		text        string
+		from, to    int // expected window items[from:to]
+		first, last int
+	}{
+		{"start of content", 0, 6, "item 0", 0, 4, 0, 0},
+		{"end of content", 63, 69, "item 9", 6, 10, 3, 3},
+		{"end offset past content", 68, 500, "9", 6, 10, 3, 3},
+		{"middle spanning two items", 28, 41, "item 4\nitem 5", 1, 9, 3, 4},
+		{"offsets from translated view", 0, 6, "item 7", 4, 10, 3, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			highlight := &models.Highlight{StartOffset: tc.start, EndOffset: tc.end, Text: tc.text}
+			focus := newHighlightFocus(highlight, items)
+
+			if focus.first != tc.first || focus.last != tc.last {
+				t.Fatalf("highlighted = %d..%d, want %d..%d", focus.first, focus.last, tc.first, tc.last)
+			}
+			want := items[tc.from:tc.to]
+			if len(focus.nearby) != len(want) || focus.nearby[0] != want[0] || focus.nearby[len(want)-1] != want[len(want)-1] {
+				t.Fatalf("nearby = %v, want %v", focus.nearby, want)
+			}
+		})
+	}
+
+	focus := newHighlightFocus(&models.Highlight{StartOffset: 100, EndOffset: 110, Text: "missing"}, items)
+	if focus.first != -1 || focus.nearby != nil {
+		t.Fatalf("unlocated highlight focus = %+v", focus)
+	}
+	if prompt := focus.prompt(); strings.Contains(prompt, "高亮附近的字幕") {
+		t.Fatalf("unlocated highlight prompt lists transcript:\n%s", prompt)
+	}
+
+	highlight := &models.Highlight{StartOffset: 0, EndOffset: 6, Text: "item 0", Note: "why?"}
+	prompt := newHighlightFocus(highlight, items).prompt()
+	for _, line := range []string{"» [00:00] item 0\n", "  [00:30] item 3\n", "用户笔记: why?\n"} {
+		if !strings.Contains(prompt, line) {
+			t.Errorf("prompt does not contain %q:\n%s", line, prompt)
+		}
+	}
+	if strings.Contains(prompt, "item 4") {
+		t.Errorf("prompt includes items outside the window:\n%s", prompt)
+	}
+}
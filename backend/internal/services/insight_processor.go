@@ -3,20 +3,34 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"vibe-backend/internal/jobs"
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 	"vibe-backend/internal/repository"
 )
 
-// InsightProcessor handles async processing of insights.
+// transcriptTranslationBatchSize is the number of transcript segments translated per request.
+// Translating in batches keeps prompts bounded and lets us report progress.
+const transcriptTranslationBatchSize = 40
+
+// InsightProcessor handles background processing of insights.
 type InsightProcessor struct {
 	repo               *repository.InsightRepository
 	youtubeService     *YouTubeService
+	twitterService     *TwitterService
 	translationService *TranslationService
+	summaryService     *SummaryService
+	retrievalService   *RetrievalService
+	progress           *ProgressBroker
 	log                *zap.Logger
 }
 
@@ -34,49 +48,112 @@ func NewInsightProcessor(
 	}
 }
 
+// SetTwitterService sets the service fetching Twitter/X threads (for dependency injection).
+func (p *InsightProcessor) SetTwitterService(svc *TwitterService) {
+	p.twitterService = svc
+}
+
 // SetTranslationService sets the translation service (for dependency injection).
 func (p *InsightProcessor) SetTranslationService(svc *TranslationService) {
 	p.translationService = svc
 }
 
-// ProcessInsightAsync starts async processing of an insight.
-// This should be called in a goroutine.
-func (p *InsightProcessor) ProcessInsightAsync(ctx context.Context, insightID uint) {
-	p.log.Info("Starting async insight processing", zap.Uint("insight_id", insightID))
+// SetSummaryService sets the summary service (for dependency injection).
+func (p *InsightProcessor) SetSummaryService(svc *SummaryService) {
+	p.summaryService = svc
+}
+
+// SetRetrievalService sets the service indexing transcripts for chat retrieval (for dependency injection).
+func (p *InsightProcessor) SetRetrievalService(svc *RetrievalService) {
+	p.retrievalService = svc
+}
+
+// SetProgressBroker sets the broker progress events are published to (for dependency injection).
+func (p *InsightProcessor) SetProgressBroker(broker *ProgressBroker) {
+	p.progress = broker
+}
+
+// publish sends a progress event if a broker is configured.
+func (p *InsightProcessor) publish(event ProgressEvent) {
+	if p.progress != nil {
+		p.progress.Publish(event)
+	}
+}
+
+// publishStage announces that a processing stage has started.
+func (p *InsightProcessor) publishStage(insightID uint, stage string) {
+	p.publish(ProgressEvent{InsightID: insightID, Type: ProgressEventStage, Stage: stage})
+}
+
+// HandleJob processes an insight.process job.
+func (p *InsightProcessor) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload models.InsightJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	err := p.ProcessInsight(ctx, payload.InsightID)
+	if err != nil && !jobs.IsPermanent(err) && job.Attempts < job.MaxAttempts {
+		p.publish(ProgressEvent{
+			InsightID: payload.InsightID,
+			Type:      ProgressEventRetry,
+			Error:     err.Error(),
+		})
+	}
+	return err
+}
+
+// HandleDeadJob marks the insight as failed once its job has exhausted all retries.
+func (p *InsightProcessor) HandleDeadJob(ctx context.Context, job *models.Job, err error) {
+	var payload models.InsightJobPayload
+	if decodeErr := jobs.DecodePayload(job, &payload); decodeErr != nil {
+		return
+	}
+	p.MarkFailed(ctx, payload.InsightID, err.Error())
+}
+
+// ProcessInsight fetches and processes the content of an insight.
+// Transient failures are returned so the job queue can retry; errors that cannot
+// succeed on retry are wrapped with jobs.Permanent.
+func (p *InsightProcessor) ProcessInsight(ctx context.Context, insightID uint) error {
+	p.log.Info("Starting insight processing", zap.Uint("insight_id", insightID))
 
 	// Get the insight
 	insight, err := p.repo.GetByID(ctx, insightID)
 	if err != nil {
-		p.log.Error("Failed to get insight for processing",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("insight %d not found: %w", insightID, err))
+		}
+		return fmt.Errorf("failed to get insight for processing: %w", err)
 	}
 
+	// Attribute LLM usage of this job to the insight owner
+	ctx = llm.WithUserID(ctx, insight.UserID)
+
 	// Update status to processing
 	if err := p.repo.UpdateStatus(ctx, insightID, models.InsightStatusProcessing, ""); err != nil {
-		p.log.Error("Failed to update insight status to processing",
-			zap.Uint("insight_id", insightID),
-			zap.Error(err),
-		)
-		return
+		return fmt.Errorf("failed to update insight status to processing: %w", err)
 	}
+	p.publish(ProgressEvent{InsightID: insightID, Type: ProgressEventStatus, Status: models.InsightStatusProcessing})
 
 	// Detect source type and process accordingly
 	sourceType, err := p.detectSourceType(insight.SourceURL)
 	if err != nil {
-		p.handleProcessingError(ctx, insightID, fmt.Sprintf("无法识别来源类型: %v", err))
-		return
+		return jobs.Permanent(fmt.Errorf("无法识别来源类型: %v", err))
 	}
 
 	insight.SourceType = sourceType
 
 	switch sourceType {
 	case models.SourceTypeYouTube:
-		p.processYouTubeInsight(ctx, insight)
+		return p.processYouTubeInsight(ctx, insight)
+	case models.SourceTypeTwitter:
+		if p.twitterService == nil {
+			return jobs.Permanent(fmt.Errorf("暂不支持的来源类型: %s", sourceType))
+		}
+		return p.processTwitterInsight(ctx, insight)
 	default:
-		p.handleProcessingError(ctx, insightID, fmt.Sprintf("暂不支持的来源类型: %s", sourceType))
+		return jobs.Permanent(fmt.Errorf("暂不支持的来源类型: %s", sourceType))
 	}
 }
 
@@ -89,8 +166,8 @@ func (p *InsightProcessor) detectSourceType(sourceURL string) (models.SourceType
 		return models.SourceTypeYouTube, nil
 	}
 
-	// Twitter/X patterns
-	if strings.Contains(lowerURL, "twitter.com") || strings.Contains(lowerURL, "x.com") {
+	// Twitter/X hosts, matched exactly so hosts like dropbox.com are not mistaken for x.com
+	if parsedURL, err := url.Parse(sourceURL); err == nil && IsTwitterHost(parsedURL.Hostname()) {
 		return models.SourceTypeTwitter, nil
 	}
 
@@ -105,7 +182,7 @@ func (p *InsightProcessor) detectSourceType(sourceURL string) (models.SourceType
 }
 
 // processYouTubeInsight processes a YouTube video insight.
-func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *models.Insight) {
+func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *models.Insight) error {
 	p.log.Info("Processing YouTube insight",
 		zap.Uint("insight_id", insight.ID),
 		zap.String("source_url", insight.SourceURL),
@@ -114,13 +191,13 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 	// Extract video ID
 	videoID, err := p.youtubeService.ExtractVideoID(insight.SourceURL)
 	if err != nil {
-		p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("无效的 YouTube URL: %v", err))
-		return
+		return jobs.Permanent(fmt.Errorf("无效的 YouTube URL: %v", err))
 	}
 
 	insight.SourceID = videoID
 
 	// Fetch video metadata with multiple fallback methods
+	p.publishStage(insight.ID, ProgressStageMetadata)
 	var metadata *VideoMetadata
 	
 	// Method 1: Try YouTube Data API v3 (fastest if configured)
@@ -142,8 +219,7 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 			// Method 3: Try Gemini/OpenRouter (last resort, requires valid API key)
 			metadata, err = p.youtubeService.GetVideoMetadata(ctx, insight.SourceURL)
 			if err != nil {
-				p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("无法获取视频元数据: 所有方法都失败了。YouTube API: 未配置或失败, yt-dlp: %v, OpenRouter API: %v", err, err))
-				return
+				return fmt.Errorf("无法获取视频元数据: 所有方法都失败了。YouTube API: 未配置或失败, yt-dlp: %v, OpenRouter API: %v", err, err)
 			}
 		}
 	}
@@ -155,6 +231,7 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 	insight.Duration = metadata.Duration
 
 	// Fetch structured transcripts
+	p.publishStage(insight.ID, ProgressStageTranscript)
 	transcriptResponse, err := p.youtubeService.FetchYouTubeTranscriptStructured(ctx, videoID)
 	if err != nil {
 		p.log.Warn("Failed to fetch structured transcripts",
@@ -164,7 +241,7 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 		// Transcripts are optional, continue processing
 	} else {
 		// Convert transcripts to the format expected by Insight model
-		transcripts, err := p.convertTranscriptsToInsightFormat(transcriptResponse, insight.TargetLang)
+		transcripts, err := p.convertTranscriptsToInsightFormat(ctx, insight.ID, transcriptResponse, insight.TargetLang)
 		if err != nil {
 			p.log.Warn("Failed to convert transcripts",
 				zap.String("video_id", videoID),
@@ -178,6 +255,126 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 		}
 	}
 
+	// Generate summary and key points from the raw content
+	if err := p.summarizeInsight(ctx, insight); err != nil {
+		return err
+	}
+
+	if err := p.completeInsight(ctx, insight); err != nil {
+		return err
+	}
+
+	p.log.Info("Successfully processed YouTube insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("title", insight.Title),
+		zap.Int("duration", insight.Duration),
+	)
+	return nil
+}
+
+// processTwitterInsight processes a Twitter/X thread insight. The thread is
+// unrolled from the linked post and stored as raw content, translated post by
+// post and summarized like a video transcript.
+func (p *InsightProcessor) processTwitterInsight(ctx context.Context, insight *models.Insight) error {
+	p.log.Info("Processing Twitter insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("source_url", insight.SourceURL),
+	)
+
+	tweetID, err := ExtractTweetID(insight.SourceURL)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("无效的 Twitter URL: %v", err))
+	}
+
+	insight.SourceID = tweetID
+
+	// Unroll the thread
+	p.publishStage(insight.ID, ProgressStageMetadata)
+	thread, err := p.twitterService.FetchThread(ctx, tweetID)
+	if errors.Is(err, ErrTweetNotFound) {
+		return jobs.Permanent(fmt.Errorf("推文不存在、已删除或受保护: %s", tweetID))
+	}
+	if err != nil {
+		return fmt.Errorf("无法获取推文: %v", err)
+	}
+
+	if thread.MayContinue {
+		p.log.Warn("⚠️  推文串可能还有后续推文未获取，请使用串中最后一条推文的链接",
+			zap.Uint("insight_id", insight.ID),
+			zap.String("tweet_id", tweetID),
+		)
+	}
+
+	first := thread.Posts[0]
+	insight.Title = tweetTitle(&first)
+	insight.Author = fmt.Sprintf("%s (@%s)", first.AuthorName, first.AuthorHandle)
+	insight.ThumbnailURL = tweetThumbnail(thread)
+	if !first.CreatedAt.IsZero() {
+		publishedAt := first.CreatedAt
+		insight.PublishedAt = &publishedAt
+	}
+	insight.RawContent = FormatThread(thread, nil)
+
+	// Translate the posts; line breaks are flattened since posts are translated as a numbered list
+	texts := make([]string, len(thread.Posts))
+	for i, post := range thread.Posts {
+		texts[i] = strings.Join(strings.Fields(post.Text), " ")
+	}
+	if translations := p.translateTexts(ctx, insight.ID, texts, insight.TargetLang); translations != nil {
+		insight.TransContent = FormatThread(thread, translations)
+	}
+
+	// Generate summary and key points from the thread
+	if err := p.summarizeInsight(ctx, insight); err != nil {
+		return err
+	}
+
+	if err := p.completeInsight(ctx, insight); err != nil {
+		return err
+	}
+
+	p.log.Info("Successfully processed Twitter insight",
+		zap.Uint("insight_id", insight.ID),
+		zap.String("title", insight.Title),
+		zap.Int("posts", len(thread.Posts)),
+	)
+	return nil
+}
+
+// tweetTitleLength is the number of runes of the first post used as the title of a thread.
+const tweetTitleLength = 100
+
+// tweetTitle returns the title of a thread: the first line of its first post,
+// or its author when the post has no text.
+func tweetTitle(post *models.TwitterPost) string {
+	title := strings.TrimSpace(strings.SplitN(post.Text, "\n", 2)[0])
+	if title == "" {
+		return fmt.Sprintf("%s (@%s) 的推文", post.AuthorName, post.AuthorHandle)
+	}
+	if runes := []rune(title); len(runes) > tweetTitleLength {
+		title = strings.TrimSpace(string(runes[:tweetTitleLength-1])) + "…"
+	}
+	return title
+}
+
+// tweetThumbnail returns the first image of a thread, or the avatar of its author.
+func tweetThumbnail(thread *models.TwitterThread) string {
+	for _, post := range thread.Posts {
+		for _, media := range post.Media {
+			if media.Type == models.TwitterMediaPhoto {
+				return media.URL
+			}
+			if media.PreviewURL != "" {
+				return media.PreviewURL
+			}
+		}
+	}
+	return thread.Posts[0].AuthorAvatarURL
+}
+
+// completeInsight saves a processed insight, indexes its content for chat
+// retrieval and announces its completion.
+func (p *InsightProcessor) completeInsight(ctx context.Context, insight *models.Insight) error {
 	// Update insight with all collected data
 	insight.Status = models.InsightStatusCompleted
 
@@ -186,20 +383,78 @@ func (p *InsightProcessor) processYouTubeInsight(ctx context.Context, insight *m
 			zap.Uint("insight_id", insight.ID),
 			zap.Error(err),
 		)
-		p.handleProcessingError(ctx, insight.ID, fmt.Sprintf("保存处理结果失败: %v", err))
-		return
+		return fmt.Errorf("保存处理结果失败: %v", err)
 	}
 
-	p.log.Info("Successfully processed YouTube insight",
+	// Index the transcript for chat retrieval (optional, chat indexes lazily on failure)
+	if p.retrievalService != nil {
+		if _, err := p.retrievalService.IndexInsight(ctx, insight); err != nil {
+			p.log.Warn("⚠️  字幕索引失败，聊天将在首次提问时重建索引",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	p.publish(ProgressEvent{InsightID: insight.ID, Type: ProgressEventStatus, Status: models.InsightStatusCompleted})
+	return nil
+}
+
+// summarizeInsight fills Summary and KeyPoints using the summary service.
+// Summarization is optional: permanent failures are logged and processing
+// continues without a summary. Retryable failures (rate limits, timeouts,
+// provider outages) are returned so the job queue retries the insight.
+func (p *InsightProcessor) summarizeInsight(ctx context.Context, insight *models.Insight) error {
+	if p.summaryService == nil || strings.TrimSpace(insight.RawContent) == "" {
+		p.log.Info("ℹ️  跳过摘要生成",
+			zap.Uint("insight_id", insight.ID),
+			zap.Bool("has_summary_service", p.summaryService != nil),
+			zap.Int("raw_content_length", len(insight.RawContent)),
+		)
+		return nil
+	}
+
+	p.publishStage(insight.ID, ProgressStageSummary)
+	result, err := p.summaryService.Summarize(ctx, insight.Title, insight.Author, insight.RawContent, insight.TargetLang)
+	if err != nil {
+		if llm.IsRetryable(err) || ctx.Err() != nil {
+			return fmt.Errorf("摘要生成失败: %w", err)
+		}
+		p.log.Warn("⚠️  摘要生成失败，Insight 将不包含摘要",
+			zap.Uint("insight_id", insight.ID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	keyPoints, err := json.Marshal(result.KeyPoints)
+	if err != nil {
+		p.log.Warn("Failed to marshal key points",
+			zap.Uint("insight_id", insight.ID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	now := time.Now()
+	insight.Summary = result.Summary
+	insight.KeyPoints = keyPoints
+	insight.SummaryModel = result.Model
+	insight.SummaryPromptVersion = result.PromptVersion
+	insight.SummarizedAt = &now
+
+	p.log.Info("✅ 成功生成摘要",
 		zap.Uint("insight_id", insight.ID),
-		zap.String("title", insight.Title),
-		zap.Int("duration", insight.Duration),
+		zap.Int("summary_length", len(result.Summary)),
+		zap.Int("key_points", len(result.KeyPoints)),
+		zap.String("model", result.Model),
 	)
+	return nil
 }
 
 // convertTranscriptsToInsightFormat converts YouTube transcripts to the Insight model format.
 // It also translates the transcripts to the target language if translation service is available.
-func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.YouTubeTranscriptResponse, targetLang string) ([]byte, error) {
+// Translation progress is published per batch.
+func (p *InsightProcessor) convertTranscriptsToInsightFormat(ctx context.Context, insightID uint, response *models.YouTubeTranscriptResponse, targetLang string) ([]byte, error) {
 	// Convert to TranscriptItem array format expected by the Insight model
 	var transcriptItems []models.TranscriptItem
 
@@ -245,57 +500,95 @@ func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.Yo
 	}
 
 	// Translate transcripts if translation service is available and target language is set
+	texts := make([]string, len(transcriptItems))
+	for i, item := range transcriptItems {
+		texts[i] = item.Text
+	}
+	for i, translation := range p.translateTexts(ctx, insightID, texts, targetLang) {
+		transcriptItems[i].TranslatedText = translation
+	}
+
+	return json.Marshal(transcriptItems)
+}
+
+// translateTexts translates texts to targetLang in batches, publishing
+// translation progress per batch. Translation is optional: it returns nil when
+// the texts are already in targetLang or cannot be translated, and empty
+// strings for texts left untranslated by a failed batch.
+func (p *InsightProcessor) translateTexts(ctx context.Context, insightID uint, texts []string, targetLang string) []string {
+	if len(texts) == 0 {
+		return nil
+	}
+
+	var result []string
 	if p.translationService != nil && targetLang != "" {
-		p.log.Info("Attempting to translate transcripts",
-			zap.Int("count", len(transcriptItems)),
+		p.log.Info("Attempting to translate texts",
+			zap.Int("count", len(texts)),
 			zap.String("target_lang", targetLang),
 		)
 
-		// Extract texts for batch translation
-		texts := make([]string, len(transcriptItems))
-		for i, item := range transcriptItems {
-			texts[i] = item.Text
-		}
-
 		// Detect source language from first segment
 		var sourceLang string
-		if len(texts) > 0 {
-			detected, err := p.translationService.DetectLanguage(context.Background(), texts[0])
-			if err != nil {
-				p.log.Warn("Failed to detect source language, skipping translation",
-					zap.Error(err),
-				)
-			} else {
-				sourceLang = detected
-				p.log.Info("Detected source language",
-					zap.String("source_lang", sourceLang),
-				)
-			}
+		detected, err := p.translationService.DetectLanguage(ctx, texts[0])
+		if err != nil {
+			p.log.Warn("Failed to detect source language, skipping translation",
+				zap.Error(err),
+			)
+		} else {
+			sourceLang = detected
+			p.log.Info("Detected source language",
+				zap.String("source_lang", sourceLang),
+			)
 		}
 
 		// Only attempt translation if we detected the source language
 		if sourceLang != "" && sourceLang != targetLang {
-			// Batch translate
-			translations, err := p.translationService.TranslateBatch(context.Background(), texts, sourceLang, targetLang)
-			if err != nil {
-				p.log.Warn("⚠️  翻译失败，字幕仍包含原文",
-					zap.Error(err),
-					zap.String("原因", "OpenRouter API 可能未配置或密钥无效"),
-					zap.String("影响", "前端将只显示原文字幕，不影响基本功能"),
-				)
-			} else {
-				// Add translations to transcript items
+			p.publishStage(insightID, ProgressStageTranslation)
+			result = make([]string, len(texts))
+
+			// Translate in batches so progress can be reported
+			translated := 0
+			for start := 0; start < len(texts); start += transcriptTranslationBatchSize {
+				end := start + transcriptTranslationBatchSize
+				if end > len(texts) {
+					end = len(texts)
+				}
+
+				translations, err := p.translationService.TranslateBatch(ctx, texts[start:end], sourceLang, targetLang)
+				if err != nil {
+					p.log.Warn("⚠️  翻译失败，内容仍保留原文",
+						zap.Error(err),
+						zap.Int("已翻译数量", translated),
+						zap.String("原因", "OpenRouter API 可能未配置或密钥无效"),
+						zap.String("影响", "前端将只显示原文，不影响基本功能"),
+					)
+					break
+				}
+
+				// Collect translations of the batch
 				for i, translation := range translations {
-					if i < len(transcriptItems) {
-						transcriptItems[i].TranslatedText = translation
+					if start+i < end {
+						result[start+i] = translation
 					}
 				}
-				p.log.Info("✅ 成功翻译字幕",
-					zap.Int("翻译数量", len(translations)),
-					zap.String("源语言", sourceLang),
-					zap.String("目标语言", targetLang),
-				)
+				translated = end
+
+				p.publish(ProgressEvent{
+					InsightID: insightID,
+					Type:      ProgressEventProgress,
+					Stage:     ProgressStageTranslation,
+					Progress:  translated * 100 / len(texts),
+				})
 			}
+
+			if translated == 0 {
+				return nil
+			}
+			p.log.Info("✅ 成功翻译内容",
+				zap.Int("翻译数量", translated),
+				zap.String("源语言", sourceLang),
+				zap.String("目标语言", targetLang),
+			)
 		} else if sourceLang == targetLang {
 			p.log.Info("源语言与目标语言相同，跳过翻译",
 				zap.String("language", sourceLang),
@@ -305,11 +598,11 @@ func (p *InsightProcessor) convertTranscriptsToInsightFormat(response *models.Yo
 		p.log.Info("ℹ️  翻译服务未配置或目标语言未设置",
 			zap.Bool("has_translation_service", p.translationService != nil),
 			zap.String("target_lang", targetLang),
-			zap.String("说明", "字幕将只包含原文，这不影响基本功能"),
+			zap.String("说明", "内容将只包含原文，这不影响基本功能"),
 		)
 	}
 
-	return json.Marshal(transcriptItems)
+	return result
 }
 
 // extractRawContentFromTranscripts extracts plain text content from transcripts.
@@ -338,8 +631,8 @@ func (p *InsightProcessor) extractRawContentFromTranscripts(response *models.You
 	return strings.Join(textParts, " ")
 }
 
-// handleProcessingError updates the insight status to failed with an error message.
-func (p *InsightProcessor) handleProcessingError(ctx context.Context, insightID uint, errorMsg string) {
+// MarkFailed updates the insight status to failed with an error message.
+func (p *InsightProcessor) MarkFailed(ctx context.Context, insightID uint, errorMsg string) {
 	p.log.Error("Insight processing failed",
 		zap.Uint("insight_id", insightID),
 		zap.String("error", errorMsg),
@@ -351,4 +644,10 @@ func (p *InsightProcessor) handleProcessingError(ctx context.Context, insightID
 			zap.Error(err),
 		)
 	}
+	p.publish(ProgressEvent{
+		InsightID: insightID,
+		Type:      ProgressEventStatus,
+		Status:    models.InsightStatusFailed,
+		Error:     errorMsg,
+	})
 }
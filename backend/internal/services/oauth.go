@@ -2,20 +2,37 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/youtube/v3"
+	"vibe-backend/internal/models"
+)
+
+const (
+	// googleRevokeURL is Google's OAuth 2.0 token revocation endpoint.
+	googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+	// oauthStateTTL bounds how long a user may take to complete the Google consent screen.
+	oauthStateTTL = 10 * time.Minute
 )
 
 // OAuthService handles Google OAuth 2.0 authentication.
 type OAuthService struct {
 	config *oauth2.Config
+	states OAuthStateStore
 	log    *zap.Logger
 }
 
@@ -36,35 +53,114 @@ func NewOAuthService(clientID, clientSecret, redirectURL string, log *zap.Logger
 
 	return &OAuthService{
 		config: config,
+		states: NewOAuthStateStore(nil),
 		log:    log,
 	}
 }
 
-// GetAuthURL generates the OAuth 2.0 authorization URL.
-func (s *OAuthService) GetAuthURL(state string) string {
-	return s.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+// SetStateStore sets the store for pending authorization requests (for dependency injection).
+// Defaults to an in-memory store.
+func (s *OAuthService) SetStateStore(states OAuthStateStore) {
+	s.states = states
+}
+
+// randomToken returns n random bytes, base64url encoded.
+func randomToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
 
-// ExchangeCode exchanges the authorization code for an access token.
-func (s *OAuthService) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	token, err := s.config.Exchange(ctx, code)
+// StartAuth begins an authorization request and returns the Google
+// authorization URL and a binding value the caller must set as a cookie in
+// the user's browser; CompleteAuth only accepts the state together with it.
+// linkUserID is the user linking a Google account, 0 for login.
+func (s *OAuthService) StartAuth(ctx context.Context, linkUserID uint) (authURL, binding string, err error) {
+	state, err := randomToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	binding, err = randomToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate binding: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if err := s.states.Save(ctx, state, &OAuthState{
+		BindingHash: hashToken(binding),
+		Verifier:    verifier,
+		LinkUserID:  linkUserID,
+	}, oauthStateTTL); err != nil {
+		return "", "", fmt.Errorf("failed to save state: %w", err)
+	}
+
+	authURL = s.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	return authURL, binding, nil
+}
+
+// CompleteAuth consumes the state of an authorization request. The state is
+// single-use and must come from the browser holding binding. Returns
+// models.ErrInvalidOAuthState otherwise.
+func (s *OAuthService) CompleteAuth(ctx context.Context, state, binding string) (*OAuthState, error) {
+	if state == "" || binding == "" {
+		return nil, models.ErrInvalidOAuthState
+	}
+
+	data, err := s.states.Consume(ctx, state)
+	if err != nil {
+		if errors.Is(err, errOAuthStateNotFound) {
+			return nil, models.ErrInvalidOAuthState
+		}
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.BindingHash), []byte(hashToken(binding))) != 1 {
+		s.log.Warn("OAuth state presented without its binding cookie")
+		return nil, models.ErrInvalidOAuthState
+	}
+	return data, nil
+}
+
+// ExchangeCode exchanges the authorization code for an access token, proving
+// possession of the PKCE verifier of the authorization request.
+func (s *OAuthService) ExchangeCode(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	token, err := s.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 	return token, nil
 }
 
-// RefreshToken refreshes an expired access token.
-func (s *OAuthService) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
-	token := &oauth2.Token{
-		RefreshToken: refreshToken,
+// TokenSource returns a token source that refreshes token when it expires.
+func (s *OAuthService) TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	return s.config.TokenSource(ctx, token)
+}
+
+// RevokeToken revokes a token with Google. Revoking a refresh token also
+// revokes its access tokens. A token Google no longer knows counts as revoked.
+func (s *OAuthService) RevokeToken(ctx context.Context, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleRevokeURL,
+		strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revoke request: %w", err)
 	}
-	tokenSource := s.config.TokenSource(ctx, token)
-	newToken, err := tokenSource.Token()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to refresh token: %w", err)
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "invalid_token") {
+		return nil
 	}
-	return newToken, nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to revoke token: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
 }
 
 // ValidateToken validates an access token.
@@ -82,24 +178,6 @@ func (s *OAuthService) ValidateToken(ctx context.Context, token string) (bool, e
 	return true, nil
 }
 
-// TokenToJSON converts an OAuth2 token to JSON string for storage.
-func (s *OAuthService) TokenToJSON(token *oauth2.Token) (string, error) {
-	data, err := json.Marshal(token)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal token: %w", err)
-	}
-	return string(data), nil
-}
-
-// TokenFromJSON converts a JSON string to an OAuth2 token.
-func (s *OAuthService) TokenFromJSON(tokenJSON string) (*oauth2.Token, error) {
-	var token oauth2.Token
-	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
-	}
-	return &token, nil
-}
-
 // GetClient returns an HTTP client configured with the OAuth token.
 func (s *OAuthService) GetClient(ctx context.Context, token *oauth2.Token) *oauth2.Config {
 	return s.config
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"vibe-backend/internal/cache"
+)
+
+// errOAuthStateNotFound is returned by OAuthStateStore.Consume for unknown,
+// expired or already used states.
+var errOAuthStateNotFound = errors.New("oauth state not found")
+
+// OAuthState is the server-side record of an authorization request, keyed by
+// the state parameter sent to Google.
+type OAuthState struct {
+	BindingHash string `json:"binding_hash"`           // hex SHA-256 of the browser-binding cookie
+	Verifier    string `json:"verifier"`               // PKCE code verifier
+	LinkUserID  uint   `json:"link_user_id,omitempty"` // user linking a Google account, 0 for login
+}
+
+// OAuthStateStore keeps pending authorization requests. Consume is single-use:
+// a state is removed by the first attempt to consume it.
+type OAuthStateStore interface {
+	Save(ctx context.Context, state string, data *OAuthState, ttl time.Duration) error
+	Consume(ctx context.Context, state string) (*OAuthState, error)
+}
+
+// NewOAuthStateStore returns a Redis-backed store, or an in-memory store when
+// Redis is disabled (states are then bound to this instance).
+func NewOAuthStateStore(redisCache *cache.RedisCache) OAuthStateStore {
+	if redisCache != nil {
+		return &redisOAuthStateStore{client: redisCache.Client()}
+	}
+	return &memoryOAuthStateStore{states: make(map[string]memoryOAuthState)}
+}
+
+// redisOAuthStateStore stores states in Redis.
+type redisOAuthStateStore struct {
+	client *redis.Client
+}
+
+func oauthStateKey(state string) string {
+	return "oauth:state:" + state
+}
+
+func (s *redisOAuthStateStore) Save(ctx context.Context, state string, data *OAuthState, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, oauthStateKey(state), payload, ttl).Err()
+}
+
+func (s *redisOAuthStateStore) Consume(ctx context.Context, state string) (*OAuthState, error) {
+	payload, err := s.client.GetDel(ctx, oauthStateKey(state)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errOAuthStateNotFound
+		}
+		return nil, err
+	}
+	var data OAuthState
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// memoryOAuthStateStore stores states in process memory.
+type memoryOAuthStateStore struct {
+	mu     sync.Mutex
+	states map[string]memoryOAuthState
+}
+
+type memoryOAuthState struct {
+	data      OAuthState
+	expiresAt time.Time
+}
+
+func (s *memoryOAuthStateStore) Save(ctx context.Context, state string, data *OAuthState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Abandoned authorizations are dropped on the next save.
+	now := time.Now()
+	for key, entry := range s.states {
+		if now.After(entry.expiresAt) {
+			delete(s.states, key)
+		}
+	}
+	s.states[state] = memoryOAuthState{data: *data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryOAuthStateStore) Consume(ctx context.Context, state string) (*OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.states[state]
+	if !ok {
+		return nil, errOAuthStateNotFound
+	}
+	delete(s.states, state)
+	if time.Now().After(entry.expiresAt) {
+		return nil, errOAuthStateNotFound
+	}
+	return &entry.data, nil
+}
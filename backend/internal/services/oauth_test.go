@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"vibe-backend/internal/models"
+)
+
+// startAuth starts an authorization request and returns its state and binding.
+func startAuth(t *testing.T, s *OAuthService) (*url.URL, string, string) {
+	t.Helper()
+	authURL, binding, err := s.StartAuth(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed, parsed.Query().Get("state"), binding
+}
+
+func TestCompleteAuthRejectsStateMismatch(t *testing.T) {
+	s := NewOAuthService("client", "secret", "http://localhost/callback", zap.NewNop())
+	ctx := context.Background()
+	_, state, binding := startAuth(t, s)
+	_, otherState, otherBinding := startAuth(t, s)
+
+	for _, tc := range []struct {
+		name, state, binding string
+	}{
+		{"unknown state", "forged", binding},
+		{"empty state", "", binding},
+		{"empty binding", state, ""},
+	} {
+		if _, err := s.CompleteAuth(ctx, tc.state, tc.binding); !errors.Is(err, models.ErrInvalidOAuthState) {
+			t.Errorf("%s: err = %v, want ErrInvalidOAuthState", tc.name, err)
+		}
+	}
+
+	// A state presented with another browser's binding is consumed and rejected
+	if _, err := s.CompleteAuth(ctx, otherState, binding); !errors.Is(err, models.ErrInvalidOAuthState) {
+		t.Fatalf("swapped binding: err = %v, want ErrInvalidOAuthState", err)
+	}
+	if _, err := s.CompleteAuth(ctx, otherState, otherBinding); !errors.Is(err, models.ErrInvalidOAuthState) {
+		t.Fatalf("state reused after a failed attempt: err = %v", err)
+	}
+
+	data, err := s.CompleteAuth(ctx, state, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Verifier == "" || data.LinkUserID != 0 {
+		t.Fatalf("state data = %+v", data)
+	}
+	if _, err := s.CompleteAuth(ctx, state, binding); !errors.Is(err, models.ErrInvalidOAuthState) {
+		t.Fatalf("replayed state: err = %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestCompleteAuthRejectsExpiredState(t *testing.T) {
+	store := NewOAuthStateStore(nil)
+	s := NewOAuthService("client", "secret", "http://localhost/callback", zap.NewNop())
+	s.SetStateStore(store)
+	ctx := context.Background()
+
+	binding := "binding"
+	data := &OAuthState{BindingHash: hashToken(binding), Verifier: oauth2.GenerateVerifier()}
+	if err := store.Save(ctx, "expired", data, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, "fresh", data, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.CompleteAuth(ctx, "expired", binding); !errors.Is(err, models.ErrInvalidOAuthState) {
+		t.Fatalf("expired state: err = %v, want ErrInvalidOAuthState", err)
+	}
+	if _, err := s.CompleteAuth(ctx, "fresh", binding); err != nil {
+		t.Fatalf("fresh state: %v", err)
+	}
+}
+
+func TestExchangeCodeRequiresPKCEVerifier(t *testing.T) {
+	var challenge string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	s := NewOAuthService("client", "secret", "http://localhost/callback", zap.NewNop())
+	s.config.Endpoint = oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token", AuthStyle: oauth2.AuthStyleInParams}
+	ctx := context.Background()
+
+	authURL, state, binding := startAuth(t, s)
+	if authURL.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("authorization URL without S256 challenge: %s", authURL)
+	}
+	challenge = authURL.Query().Get("code_challenge")
+
+	data, err := s.CompleteAuth(ctx, state, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ExchangeCode(ctx, "code", oauth2.GenerateVerifier()); err == nil {
+		t.Fatal("code exchanged with another verifier")
+	}
+	token, err := s.ExchangeCode(ctx, "code", data.Verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Fatalf("token = %+v", token)
+	}
+}
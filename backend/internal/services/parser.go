@@ -23,7 +23,8 @@ var (
 
 // ParserService handles URL parsing and content extraction.
 type ParserService struct {
-	log *zap.Logger
+	twitterService *TwitterService
+	log            *zap.Logger
 }
 
 // NewParserService creates a new ParserService.
@@ -31,6 +32,11 @@ func NewParserService(log *zap.Logger) *ParserService {
 	return &ParserService{log: log}
 }
 
+// SetTwitterService sets the service fetching Twitter/X posts (for dependency injection).
+func (s *ParserService) SetTwitterService(svc *TwitterService) {
+	s.twitterService = svc
+}
+
 // Parse parses a URL and extracts metadata.
 func (s *ParserService) Parse(ctx context.Context, rawURL string) (*models.ParsedContent, error) {
 	// Validate and detect source
@@ -85,7 +91,7 @@ func (s *ParserService) detectSource(rawURL string) (models.ContentSource, error
 	}
 
 	// Check for Twitter/X
-	if strings.Contains(host, "twitter.com") || strings.Contains(host, "x.com") {
+	if IsTwitterHost(parsedURL.Hostname()) {
 		return models.SourceTwitter, nil
 	}
 
@@ -124,29 +130,29 @@ func (s *ParserService) parseYouTube(ctx context.Context, rawURL string) (*model
 
 // parseTwitter extracts metadata from a Twitter/X URL.
 func (s *ParserService) parseTwitter(ctx context.Context, rawURL string) (*models.ParsedContent, error) {
-	// TODO: Implement Twitter API integration or web scraping
-	// For now, return mock data for demonstration purposes
-
-	s.log.Warn("Twitter parsing using mock data - implement Twitter API integration",
-		zap.String("url", rawURL),
-	)
+	tweetID, err := ExtractTweetID(rawURL)
+	if err != nil || s.twitterService == nil {
+		return nil, ErrParsingFailed
+	}
 
-	// Extract tweet ID (basic implementation)
-	tweetID, err := s.extractTwitterTweetID(rawURL)
+	post, err := s.twitterService.FetchPost(ctx, tweetID)
 	if err != nil {
+		s.log.Warn("Failed to fetch tweet",
+			zap.String("tweet_id", tweetID),
+			zap.Error(err),
+		)
 		return nil, ErrParsingFailed
 	}
 
-	// Mock content - replace with actual API call
-	publishedAt := time.Now().Add(-6 * time.Hour) // Mock: 6 hours ago
-
 	content := &models.ParsedContent{
 		Source:       models.SourceTwitter,
-		Title:        fmt.Sprintf("Tweet: %s", tweetID),
-		Author:       "@username (TODO: fetch from API)",
-		Summary:      s.generateMockSummary("Twitter", tweetID),
-		ThumbnailURL: "https://abs.twimg.com/icons/apple-touch-icon-192x192.png", // Default Twitter icon
-		PublishedAt:  &publishedAt,
+		Title:        tweetTitle(post),
+		Author:       fmt.Sprintf("%s (@%s)", post.AuthorName, post.AuthorHandle),
+		Summary:      post.Text,
+		ThumbnailURL: tweetThumbnail(&models.TwitterThread{Posts: []models.TwitterPost{*post}}),
+	}
+	if !post.CreatedAt.IsZero() {
+		content.PublishedAt = &post.CreatedAt
 	}
 
 	return content, nil
@@ -179,26 +185,6 @@ func (s *ParserService) extractYouTubeVideoID(rawURL string) (string, error) {
 	return "", errors.New("could not extract YouTube video ID")
 }
 
-// extractTwitterTweetID extracts the tweet ID from a Twitter/X URL.
-func (s *ParserService) extractTwitterTweetID(rawURL string) (string, error) {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return "", err
-	}
-
-	// Twitter URL format: https://twitter.com/username/status/1234567890
-	// or: https://x.com/username/status/1234567890
-	parts := strings.Split(strings.TrimPrefix(parsedURL.Path, "/"), "/")
-
-	for i, part := range parts {
-		if part == "status" && i+1 < len(parts) {
-			return parts[i+1], nil
-		}
-	}
-
-	return "", errors.New("could not extract Twitter tweet ID")
-}
-
 // generateMockSummary generates a mock AI summary.
 // TODO: Replace with actual AI/LLM integration (OpenAI, Anthropic, etc.)
 func (s *ParserService) generateMockSummary(source, id string) string {
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"vibe-backend/internal/models"
+)
+
+// Insight processing stages reported to progress subscribers.
+const (
+	ProgressStageMetadata    = "metadata"
+	ProgressStageTranscript  = "transcript"
+	ProgressStageTranslation = "translation"
+	ProgressStageSummary     = "summary"
+)
+
+// Progress event types (also used as the SSE event name).
+const (
+	ProgressEventStage    = "stage"    // a processing stage started
+	ProgressEventProgress = "progress" // percentage progress within the current stage
+	ProgressEventRetry    = "retry"    // attempt failed, the job will be retried
+	ProgressEventStatus   = "status"   // insight status changed (final when completed/failed)
+)
+
+// progressSubscriberBuffer is the channel buffer per subscriber. Slow subscribers
+// drop intermediate events rather than blocking the processor; final events
+// replace the oldest buffered event so they are always delivered.
+const progressSubscriberBuffer = 32
+
+// ProgressEvent describes a change in the processing state of an insight.
+type ProgressEvent struct {
+	InsightID uint                 `json:"insight_id"`
+	Type      string               `json:"type"`
+	Stage     string               `json:"stage,omitempty"`
+	Progress  int                  `json:"progress,omitempty"` // 0-100
+	Status    models.InsightStatus `json:"status,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// IsFinal reports whether the event ends the processing of an insight.
+func (e ProgressEvent) IsFinal() bool {
+	return e.Type == ProgressEventStatus &&
+		(e.Status == models.InsightStatusCompleted || e.Status == models.InsightStatusFailed)
+}
+
+// ProgressBroker fans out insight progress events to in-process subscribers
+// (e.g. several browser tabs watching the same insight).
+type ProgressBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan ProgressEvent]struct{}
+	last        map[uint]ProgressEvent
+}
+
+// NewProgressBroker creates a new ProgressBroker.
+func NewProgressBroker() *ProgressBroker {
+	return &ProgressBroker{
+		subscribers: make(map[uint]map[chan ProgressEvent]struct{}),
+		last:        make(map[uint]ProgressEvent),
+	}
+}
+
+// Subscribe registers a subscriber for an insight. It returns the event channel,
+// the most recent event (if processing is in progress) and an unsubscribe function.
+func (b *ProgressBroker) Subscribe(insightID uint) (<-chan ProgressEvent, *ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, progressSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[insightID] == nil {
+		b.subscribers[insightID] = make(map[chan ProgressEvent]struct{})
+	}
+	b.subscribers[insightID][ch] = struct{}{}
+	var last *ProgressEvent
+	if event, ok := b.last[insightID]; ok {
+		last = &event
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if subs := b.subscribers[insightID]; subs != nil {
+				delete(subs, ch)
+				if len(subs) == 0 {
+					delete(b.subscribers, insightID)
+				}
+			}
+		})
+	}
+
+	return ch, last, unsubscribe
+}
+
+// Publish delivers an event to all subscribers of the insight.
+func (b *ProgressBroker) Publish(event ProgressEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.IsFinal() {
+		// Final state is persisted on the insight; no need to replay it
+		delete(b.last, event.InsightID)
+	} else {
+		b.last[event.InsightID] = event
+	}
+
+	for ch := range b.subscribers[event.InsightID] {
+		select {
+		case ch <- event:
+		default:
+			if !event.IsFinal() {
+				// Subscriber is not keeping up, drop the event
+				continue
+			}
+			// Make room for the final event: only publishers send, and they hold b.mu
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- event
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"vibe-backend/internal/models"
+)
+
+func TestProgressBrokerDeliversFinalEventToSlowSubscriber(t *testing.T) {
+	b := NewProgressBroker()
+	events, _, unsubscribe := b.Subscribe(1)
+	defer unsubscribe()
+
+	// Fill the buffer and then some; nobody is reading
+	for i := 0; i < progressSubscriberBuffer*2; i++ {
+		b.Publish(ProgressEvent{InsightID: 1, Type: ProgressEventProgress, Progress: i % 100})
+	}
+	b.Publish(ProgressEvent{InsightID: 1, Type: ProgressEventStatus, Status: models.InsightStatusCompleted})
+
+	var last ProgressEvent
+	for len(events) > 0 {
+		last = <-events
+	}
+	if !last.IsFinal() {
+		t.Fatalf("last buffered event = %+v, want the final status event", last)
+	}
+}
+
+func TestProgressBrokerReplaysLastEventUntilFinal(t *testing.T) {
+	b := NewProgressBroker()
+	b.Publish(ProgressEvent{InsightID: 2, Type: ProgressEventStage, Stage: ProgressStageSummary})
+
+	_, last, unsubscribe := b.Subscribe(2)
+	unsubscribe()
+	if last == nil || last.Stage != ProgressStageSummary {
+		t.Fatalf("replayed event = %+v, want summary stage", last)
+	}
+
+	b.Publish(ProgressEvent{InsightID: 2, Type: ProgressEventStatus, Status: models.InsightStatusFailed})
+	_, last, unsubscribe = b.Subscribe(2)
+	unsubscribe()
+	if last != nil {
+		t.Fatalf("replayed event after final = %+v, want none", last)
+	}
+}
+
+func TestProgressBrokerUnsubscribe(t *testing.T) {
+	b := NewProgressBroker()
+	events, _, unsubscribe := b.Subscribe(3)
+	unsubscribe()
+	unsubscribe() // idempotent
+
+	b.Publish(ProgressEvent{InsightID: 3, Type: ProgressEventProgress, Progress: 50})
+	if len(events) != 0 {
+		t.Fatal("unsubscribed channel received an event")
+	}
+}
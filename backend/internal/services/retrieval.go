@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// Transcript chunking limits. Chunks close at whichever limit is reached first,
+// so every chunk maps to a short, citable time range.
+const (
+	chunkMaxChars   = 800
+	chunkMaxSeconds = 90
+
+	// embeddingBatchSize is the number of chunks embedded per request.
+	embeddingBatchSize = 64
+
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// RetrievalService chunks insight transcripts and retrieves the passages most
+// relevant to a chat question. Passages are ranked by embedding similarity when
+// an embedding model is configured, and by BM25 otherwise or on failure.
+type RetrievalService struct {
+	repo           *repository.TranscriptChunkRepository
+	llm            *llm.Client
+	embeddingModel string // empty = BM25 only
+	log            *zap.Logger
+}
+
+// NewRetrievalService creates a new RetrievalService.
+func NewRetrievalService(repo *repository.TranscriptChunkRepository, client *llm.Client, embeddingModel string, log *zap.Logger) *RetrievalService {
+	if embeddingModel != "" && !client.CanEmbed() {
+		log.Warn("⚠️  LLM provider does not support embeddings, using BM25 retrieval",
+			zap.String("provider", client.ProviderName()),
+		)
+		embeddingModel = ""
+	}
+
+	mode := "bm25"
+	if embeddingModel != "" {
+		mode = "embeddings"
+	}
+	log.Info("✅ 字幕检索服务已初始化",
+		zap.String("mode", mode),
+		zap.String("embedding_model", embeddingModel),
+	)
+
+	return &RetrievalService{
+		repo:           repo,
+		llm:            client,
+		embeddingModel: embeddingModel,
+		log:            log,
+	}
+}
+
+// IndexInsight chunks the transcripts of an insight and replaces its index.
+// Embedding failures are logged and leave the chunks searchable with BM25.
+func (s *RetrievalService) IndexInsight(ctx context.Context, insight *models.Insight) ([]models.TranscriptChunk, error) {
+	var items []models.TranscriptItem
+	if len(insight.Transcripts) > 0 {
+		if err := json.Unmarshal(insight.Transcripts, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse transcripts: %w", err)
+		}
+	}
+
+	chunks := chunkTranscript(insight.ID, items)
+	if s.embeddingModel != "" && len(chunks) > 0 {
+		if err := s.embedChunks(ctx, chunks); err != nil {
+			s.log.Warn("⚠️  Failed to embed transcript chunks, falling back to BM25",
+				zap.Uint("insight_id", insight.ID),
+				zap.Error(err),
+			)
+			for i := range chunks {
+				chunks[i].Embedding = nil
+				chunks[i].EmbeddingModel = ""
+			}
+		}
+	}
+
+	if err := s.repo.ReplaceForInsight(ctx, insight.ID, chunks); err != nil {
+		return nil, fmt.Errorf("failed to save transcript chunks: %w", err)
+	}
+
+	s.log.Info("Transcript indexed",
+		zap.Uint("insight_id", insight.ID),
+		zap.Int("segments", len(items)),
+		zap.Int("chunks", len(chunks)),
+	)
+	return chunks, nil
+}
+
+// Search returns up to k passages of the insight transcript most relevant to
+// query, in transcript order. Insights indexed before retrieval existed are
+// indexed on first use.
+func (s *RetrievalService) Search(ctx context.Context, insight *models.Insight, query string, k int) ([]models.TranscriptPassage, error) {
+	if k <= 0 || strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	chunks, err := s.repo.ListByInsight(ctx, insight.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transcript chunks: %w", err)
+	}
+	if len(chunks) == 0 && len(insight.Transcripts) > 0 {
+		if chunks, err = s.IndexInsight(ctx, insight); err != nil {
+			return nil, err
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	scores, ok := s.scoreByEmbedding(ctx, chunks, query)
+	if !ok {
+		scores = scoreBM25(chunks, query)
+	}
+
+	// Rank, keep the top k with a positive score, then restore transcript order
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	top := make([]int, 0, k)
+	for _, i := range order {
+		if len(top) == k || scores[i] <= 0 {
+			break
+		}
+		top = append(top, i)
+	}
+	sort.Ints(top)
+
+	passages := make([]models.TranscriptPassage, len(top))
+	for n, i := range top {
+		chunk := chunks[i]
+		passages[n] = models.TranscriptPassage{
+			Timestamp:    formatCitationTimestamp(chunk.StartSeconds),
+			StartSeconds: chunk.StartSeconds,
+			EndSeconds:   chunk.EndSeconds,
+			Text:         chunk.Text,
+			Score:        scores[i],
+		}
+	}
+	return passages, nil
+}
+
+// scoreByEmbedding scores chunks by cosine similarity to the query embedding.
+// Returns false if embeddings are unavailable for this insight or the query.
+func (s *RetrievalService) scoreByEmbedding(ctx context.Context, chunks []models.TranscriptChunk, query string) ([]float64, bool) {
+	if s.embeddingModel == "" {
+		return nil, false
+	}
+
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		if chunk.EmbeddingModel != s.embeddingModel || len(chunk.Embedding) == 0 {
+			return nil, false
+		}
+		if err := json.Unmarshal(chunk.Embedding, &vectors[i]); err != nil {
+			return nil, false
+		}
+	}
+
+	resp, err := s.llm.Embed(ctx, llm.EmbeddingRequest{
+		Feature: llm.FeatureEmbedding,
+		Model:   s.embeddingModel,
+		Inputs:  []string{query},
+	})
+	if err != nil {
+		s.log.Warn("⚠️  Failed to embed chat query, falling back to BM25", zap.Error(err))
+		return nil, false
+	}
+
+	scores := make([]float64, len(chunks))
+	for i, vec := range vectors {
+		scores[i] = llm.CosineSimilarity(resp.Vectors[0], vec)
+	}
+	return scores, true
+}
+
+// embedChunks computes the embeddings of chunks in batches.
+func (s *RetrievalService) embedChunks(ctx context.Context, chunks []models.TranscriptChunk) error {
+	for start := 0; start < len(chunks); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(chunks))
+
+		inputs := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			inputs = append(inputs, chunkSearchText(chunk))
+		}
+
+		resp, err := s.llm.Embed(ctx, llm.EmbeddingRequest{
+			Feature: llm.FeatureEmbedding,
+			Model:   s.embeddingModel,
+			Inputs:  inputs,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, vec := range resp.Vectors {
+			data, err := json.Marshal(vec)
+			if err != nil {
+				return err
+			}
+			chunks[start+i].Embedding = data
+			chunks[start+i].EmbeddingModel = s.embeddingModel
+		}
+	}
+	return nil
+}
+
+// chunkTranscript groups consecutive transcript segments into chunks.
+func chunkTranscript(insightID uint, items []models.TranscriptItem) []models.TranscriptChunk {
+	var chunks []models.TranscriptChunk
+	var text, translated strings.Builder
+	start, end := 0, 0
+
+	flush := func() {
+		if text.Len() == 0 && translated.Len() == 0 {
+			return
+		}
+		chunks = append(chunks, models.TranscriptChunk{
+			InsightID:      insightID,
+			Position:       len(chunks),
+			StartSeconds:   start,
+			EndSeconds:     end,
+			Text:           strings.TrimSpace(text.String()),
+			TranslatedText: strings.TrimSpace(translated.String()),
+		})
+		text.Reset()
+		translated.Reset()
+	}
+
+	for _, item := range items {
+		if strings.TrimSpace(item.Text) == "" && strings.TrimSpace(item.TranslatedText) == "" {
+			continue
+		}
+		if text.Len() > 0 && (text.Len()+len(item.Text) > chunkMaxChars || item.Seconds-start >= chunkMaxSeconds) {
+			flush()
+		}
+		if text.Len() == 0 && translated.Len() == 0 {
+			start = item.Seconds
+		}
+		end = item.Seconds
+
+		text.WriteString(item.Text)
+		text.WriteString(" ")
+		if item.TranslatedText != "" {
+			translated.WriteString(item.TranslatedText)
+			translated.WriteString(" ")
+		}
+	}
+	flush()
+	return chunks
+}
+
+// chunkSearchText is the text a chunk is matched on: the original and its translation,
+// so questions asked in either language find it.
+func chunkSearchText(chunk models.TranscriptChunk) string {
+	if chunk.TranslatedText == "" {
+		return chunk.Text
+	}
+	return chunk.Text + "\n" + chunk.TranslatedText
+}
+
+// scoreBM25 scores chunks against query with Okapi BM25.
+func scoreBM25(chunks []models.TranscriptChunk, query string) []float64 {
+	docs := make([][]string, len(chunks))
+	docFreq := make(map[string]int)
+	totalLen := 0
+	for i, chunk := range chunks {
+		docs[i] = tokenize(chunkSearchText(chunk))
+		totalLen += len(docs[i])
+
+		seen := make(map[string]bool)
+		for _, term := range docs[i] {
+			if !seen[term] {
+				seen[term] = true
+				docFreq[term]++
+			}
+		}
+	}
+
+	n := float64(len(chunks))
+	avgLen := float64(totalLen) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	queryTerms := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		queryTerms[term] = true
+	}
+
+	scores := make([]float64, len(chunks))
+	for i, doc := range docs {
+		tf := make(map[string]int)
+		for _, term := range doc {
+			if queryTerms[term] {
+				tf[term]++
+			}
+		}
+		docLen := float64(len(doc))
+		for term, freq := range tf {
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			f := float64(freq)
+			scores[i] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+		}
+	}
+	return scores
+}
+
+// tokenize lowercases text and splits it into terms. Latin text is split into
+// words; CJK runs, which have no spaces, are split into character bigrams.
+func tokenize(text string) []string {
+	var terms []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 1 || (len(word) == 1 && unicode.IsDigit(word[0])) {
+			terms = append(terms, string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			terms = append(terms, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			terms = append(terms, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+// formatCitationTimestamp formats seconds as mm:ss (minutes are not wrapped into hours).
+func formatCitationTimestamp(seconds int) string {
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
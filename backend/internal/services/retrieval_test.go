@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+// ranking returns the positions of the chunks with a positive score, best first.
+func ranking(scores []float64) string {
+	var order []int
+	for i, score := range scores {
+		if score > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	ids := make([]string, len(order))
+	for n, i := range order {
+		ids[n] = strconv.Itoa(i)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestTokenize(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"Hello, World!", "hello|world"},
+		{"a I go 7", "go|7"},
+		{"机器学习", "机器|器学|学习"},
+		{"学", "学"},
+		{"GPT模型 v2", "gpt|模型|v2"},
+		{"  ...  ", ""},
+	} {
+		if got := strings.Join(tokenize(tc.text), "|"); got != tc.want {
+			t.Errorf("tokenize(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestScoreBM25(t *testing.T) {
+	chunks := []models.TranscriptChunk{
+		{Text: "the cat sat on the mat"},
+		{Text: "dogs and cats are pets"},
+		{Text: "cat cat cat cat"},
+		{Text: "nothing relevant here", TranslatedText: "一只猫在睡觉"},
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"cat", "2,0"},
+		{"Cat MAT", "0,2"},
+		{"猫在哪里", "3"},
+		{"dogs", "1"},
+		{"giraffe", ""},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			if got := ranking(scoreBM25(chunks, tc.query)); got != tc.want {
+				t.Fatalf("ranking = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if scores := scoreBM25(nil, "cat"); len(scores) != 0 {
+		t.Fatalf("empty index scores = %v", scores)
+	}
+}
+
+func TestScoreByEmbeddingFallsBackToBM25(t *testing.T) {
+	client := llm.NewClient(llm.NewFake(nil), llm.Options{MaxRetries: 0}, zap.NewNop())
+	ctx := context.Background()
+	chunks := []models.TranscriptChunk{
+		{Text: "stock market fell sharply"},
+		{Text: "my cat sleeps on the mat"},
+	}
+
+	if _, ok := NewRetrievalService(nil, client, "", zap.NewNop()).scoreByEmbedding(ctx, chunks, "cat"); ok {
+		t.Fatal("BM25-only service scored by embedding")
+	}
+
+	s := NewRetrievalService(nil, client, "embed-v1", zap.NewNop())
+	if _, ok := s.scoreByEmbedding(ctx, chunks, "cat"); ok {
+		t.Fatal("chunks without embeddings scored by embedding")
+	}
+
+	if err := s.embedChunks(ctx, chunks); err != nil {
+		t.Fatal(err)
+	}
+	scores, ok := s.scoreByEmbedding(ctx, chunks, "cat sleeps")
+	if !ok {
+		t.Fatal("embedded chunks not scored by embedding")
+	}
+	if scores[1] <= scores[0] {
+		t.Fatalf("scores = %v, want the cat chunk first", scores)
+	}
+
+	s.embeddingModel = "embed-v2"
+	if _, ok := s.scoreByEmbedding(ctx, chunks, "cat"); ok {
+		t.Fatal("chunks embedded by another model scored by embedding")
+	}
+}
+
+func TestSearchWithoutQuery(t *testing.T) {
+	s := &RetrievalService{log: zap.NewNop()}
+	insight := &models.Insight{ID: 1}
+	for _, tc := range []struct {
+		query string
+		k     int
+	}{
+		{"  ", 5},
+		{"cat", 0},
+	} {
+		passages, err := s.Search(context.Background(), insight, tc.query, tc.k)
+		if err != nil || passages != nil {
+			t.Errorf("Search(%q, %d) = %v, %v, want no passages", tc.query, tc.k, passages, err)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+const (
+	// refreshTokenTag starts every refresh token, so leaked tokens are easy to recognise.
+	refreshTokenTag = "vr_"
+
+	// accessTokenHeader is the encoded JOSE header of every access token (HS256 JWT).
+	accessTokenHeader = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"
+
+	// refreshReuseGrace is how long the most recently rotated refresh token is
+	// still accepted. Browser tabs share one refresh token, so two tabs may
+	// refresh with the same token at nearly the same time.
+	refreshReuseGrace = 10 * time.Second
+
+	// Reasons recorded on revoked sessions.
+	revokedLogout       = "logout"
+	revokedLogoutAll    = "logout_all"
+	revokedRefreshReuse = "refresh_reuse"
+	revokedPassword     = "password_change"
+	revokedAccount      = "account_deleted"
+)
+
+// accessClaims are the claims of an access token.
+type accessClaims struct {
+	Subject   string `json:"sub"` // user ID
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// sessionStore persists sessions and refresh tokens (implemented by repository.SessionRepository).
+type sessionStore interface {
+	Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, usedID uint, next *models.RefreshToken, at time.Time) (bool, error)
+	LastUsedRefreshToken(ctx context.Context, sessionID string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, id, reason string, at time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uint, reason string, at time.Time) (int64, error)
+	RevokeOthersForUser(ctx context.Context, userID uint, keepID, reason string, at time.Time) (int64, error)
+	DeleteExpiredRefreshTokens(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// SessionService issues short-lived access tokens (HS256 JWTs) and rotating,
+// single-use refresh tokens for browser clients. Refresh tokens are stored as
+// SHA-256 hashes; reusing a rotated refresh token revokes the whole session,
+// except for the latest rotated token within a short grace period, which gets
+// the successor that was already issued for it.
+type SessionService struct {
+	repo       sessionStore
+	userRepo   *repository.UserRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	reuseGrace time.Duration
+	log        *zap.Logger
+}
+
+// NewSessionService creates a new SessionService. If secret is empty a random
+// one is generated, so sessions do not survive a restart.
+func NewSessionService(repo *repository.SessionRepository, userRepo *repository.UserRepository, secret string, accessTTL, refreshTTL time.Duration, log *zap.Logger) *SessionService {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate session secret: %v", err))
+		}
+		log.Warn("⚠️  SESSION_SECRET not set, using a random secret: sessions end on restart")
+	}
+
+	return &SessionService{
+		repo:       repo,
+		userRepo:   userRepo,
+		secret:     key,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		reuseGrace: refreshReuseGrace,
+		log:        log,
+	}
+}
+
+// IsAccessToken reports whether credential looks like an access token rather than an API key.
+func IsAccessToken(credential string) bool {
+	return strings.HasPrefix(credential, accessTokenHeader+".") && strings.Count(credential, ".") == 2
+}
+
+// sign returns the base64url HMAC-SHA256 of data.
+func (s *SessionService) sign(data string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signAccessToken returns a signed access token for claims.
+func (s *SessionService) signAccessToken(claims accessClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := accessTokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned), nil
+}
+
+// parseAccessToken verifies an access token and returns its claims.
+func (s *SessionService) parseAccessToken(token string, now time.Time) (*accessClaims, error) {
+	if !IsAccessToken(token) {
+		return nil, models.ErrInvalidAccessToken
+	}
+	dot := strings.LastIndexByte(token, '.')
+	if !hmac.Equal([]byte(token[dot+1:]), []byte(s.sign(token[:dot]))) {
+		return nil, models.ErrInvalidAccessToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(token[len(accessTokenHeader)+1 : dot])
+	if err != nil {
+		return nil, models.ErrInvalidAccessToken
+	}
+	var claims accessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.SessionID == "" {
+		return nil, models.ErrInvalidAccessToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, models.ErrAccessTokenExpired
+	}
+	return &claims, nil
+}
+
+// generateRefreshToken returns a new random refresh token.
+func generateRefreshToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return refreshTokenTag + hex.EncodeToString(bytes), nil
+}
+
+// newRefreshToken returns a refresh token record for sessionID and the plaintext token.
+func (s *SessionService) newRefreshToken(sessionID string, now time.Time) (*models.RefreshToken, string, error) {
+	plaintext, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return &models.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: hashToken(plaintext),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}, plaintext, nil
+}
+
+// successorRefreshToken returns the refresh token that replaces used when it is
+// rotated, and the plaintext token. Successors are derived from the used token
+// with the session secret, so replaying a token within the grace period yields
+// the same successor and the session never forks into parallel token chains.
+func (s *SessionService) successorRefreshToken(used *models.RefreshToken, now time.Time) (*models.RefreshToken, string) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("refresh:" + used.TokenHash))
+	plaintext := refreshTokenTag + hex.EncodeToString(mac.Sum(nil))
+	return &models.RefreshToken{
+		SessionID: used.SessionID,
+		TokenHash: hashToken(plaintext),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}, plaintext
+}
+
+// tokens returns the token pair for a session.
+func (s *SessionService) tokens(userID uint, sessionID, refreshToken string, now time.Time) (*models.SessionTokens, error) {
+	accessToken, err := s.signAccessToken(accessClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &models.SessionTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Start starts a session for a user who has just logged in.
+func (s *SessionService) Start(ctx context.Context, userID uint, userAgent, ipAddress string) (*models.SessionTokens, error) {
+	now := time.Now()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	session := &models.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastUsedAt: &now,
+	}
+	token, refreshToken, err := s.newRefreshToken(session.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, session, token); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	s.log.Info("Session started",
+		zap.Uint("user_id", userID),
+		zap.String("session_id", session.ID),
+	)
+	return s.tokens(userID, session.ID, refreshToken, now)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is used up; presenting it again revokes the session and returns
+// models.ErrRefreshTokenReused, unless it is the latest rotated token and was
+// used less than refreshReuseGrace ago. Returns models.ErrInvalidRefreshToken
+// for unknown, expired or revoked tokens.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*models.SessionTokens, error) {
+	now := time.Now()
+	token, session, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return s.refreshReused(ctx, token, session, now)
+	}
+
+	next, nextToken := s.successorRefreshToken(token, now)
+	rotated, err := s.repo.Rotate(ctx, token.ID, next, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !rotated {
+		// Lost a race with another refresh using the same token
+		token, err = s.repo.GetRefreshToken(ctx, token.TokenHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+		}
+		return s.refreshReused(ctx, token, session, now)
+	}
+
+	return s.tokens(session.UserID, session.ID, nextToken, now)
+}
+
+// refreshReused handles a refresh with an already used token. Within the grace
+// period the latest rotated token gets a token pair with the successor already
+// issued for it, so concurrent refreshes from several tabs all succeed and
+// share one token chain; anything else revokes the session.
+func (s *SessionService) refreshReused(ctx context.Context, token *models.RefreshToken, session *models.Session, now time.Time) (*models.SessionTokens, error) {
+	if token.UsedAt == nil || now.Sub(*token.UsedAt) > s.reuseGrace {
+		return nil, s.revokeReused(ctx, session)
+	}
+	last, err := s.repo.LastUsedRefreshToken(ctx, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	if last.ID != token.ID {
+		// The session has rotated again since; this token is stale
+		return nil, s.revokeReused(ctx, session)
+	}
+
+	next, nextToken := s.successorRefreshToken(token, now)
+	if _, err := s.repo.GetRefreshToken(ctx, next.TokenHash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Rotated with another session secret, e.g. before a restart
+			return nil, s.revokeReused(ctx, session)
+		}
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	s.log.Info("Concurrent refresh within grace period",
+		zap.Uint("user_id", session.UserID),
+		zap.String("session_id", session.ID),
+	)
+	return s.tokens(session.UserID, session.ID, nextToken, now)
+}
+
+// lookupRefreshToken returns a refresh token and its session.
+func (s *SessionService) lookupRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, *models.Session, error) {
+	token, err := s.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrInvalidRefreshToken
+		}
+		return nil, nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	session, err := s.repo.GetByID(ctx, token.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrInvalidRefreshToken
+		}
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return token, session, nil
+}
+
+// revokeReused ends a session whose rotated refresh token was presented again:
+// either the token leaked or the client misbehaves, so neither copy may continue.
+func (s *SessionService) revokeReused(ctx context.Context, session *models.Session) error {
+	s.log.Warn("Refresh token reuse detected, revoking session",
+		zap.Uint("user_id", session.UserID),
+		zap.String("session_id", session.ID),
+	)
+	if err := s.repo.Revoke(ctx, session.ID, revokedRefreshReuse, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return models.ErrRefreshTokenReused
+}
+
+// Logout ends the session of a refresh token. Ending an ended session is a no-op.
+func (s *SessionService) Logout(ctx context.Context, refreshToken string) error {
+	_, session, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.EndSession(ctx, session.UserID, session.ID)
+}
+
+// EndSession revokes a session of a user.
+func (s *SessionService) EndSession(ctx context.Context, userID uint, sessionID string) error {
+	if err := s.repo.Revoke(ctx, sessionID, revokedLogout, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.log.Info("Session ended",
+		zap.Uint("user_id", userID),
+		zap.String("session_id", sessionID),
+	)
+	return nil
+}
+
+// LogoutAll revokes every session of a user. API keys are not affected.
+func (s *SessionService) LogoutAll(ctx context.Context, userID uint) (int64, error) {
+	count, err := s.repo.RevokeAllForUser(ctx, userID, revokedLogoutAll, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.log.Info("All sessions ended",
+		zap.Uint("user_id", userID),
+		zap.Int64("sessions", count),
+	)
+	return count, nil
+}
+
+// EndOtherSessions revokes every session of a user except keepSessionID (all
+// sessions when it is empty) after a password change. API keys are not affected.
+func (s *SessionService) EndOtherSessions(ctx context.Context, userID uint, keepSessionID string) (int64, error) {
+	count, err := s.repo.RevokeOthersForUser(ctx, userID, keepSessionID, revokedPassword, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.log.Info("Other sessions ended after password change",
+		zap.Uint("user_id", userID),
+		zap.Int64("sessions", count),
+	)
+	return count, nil
+}
+
+// EndAccountSessions revokes every session of a user whose account is being deleted.
+func (s *SessionService) EndAccountSessions(ctx context.Context, userID uint) error {
+	count, err := s.repo.RevokeAllForUser(ctx, userID, revokedAccount, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.log.Info("Sessions of deleted account ended",
+		zap.Uint("user_id", userID),
+		zap.Int64("sessions", count),
+	)
+	return nil
+}
+
+// PruneExpired deletes refresh tokens that have expired.
+func (s *SessionService) PruneExpired(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredRefreshTokens(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.Info("Expired refresh tokens deleted", zap.Int64("tokens", deleted))
+	}
+	return nil
+}
+
+// Authenticate resolves an access token to its user. Access tokens grant every
+// scope. Returns models.ErrInvalidAccessToken, models.ErrAccessTokenExpired or
+// models.ErrSessionRevoked for tokens that cannot be used.
+func (s *SessionService) Authenticate(ctx context.Context, credential string) (*models.AuthIdentity, error) {
+	claims, err := s.parseAccessToken(credential, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return nil, models.ErrInvalidAccessToken
+	}
+
+	session, err := s.repo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSessionRevoked
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.RevokedAt != nil || session.UserID != uint(userID) {
+		return nil, models.ErrSessionRevoked
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSessionRevoked
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &models.AuthIdentity{
+		User:      user,
+		Scopes:    []string{models.ScopeAdmin},
+		SessionID: session.ID,
+	}, nil
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/models"
+)
+
+// memorySessionStore is an in-memory sessionStore.
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+	tokens   []*models.RefreshToken
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[string]*models.Session)}
+}
+
+func (m *memorySessionStore) addToken(token *models.RefreshToken) {
+	token.ID = uint(len(m.tokens) + 1)
+	token.CreatedAt = time.Now()
+	copied := *token
+	m.tokens = append(m.tokens, &copied)
+}
+
+func (m *memorySessionStore) Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *session
+	m.sessions[session.ID] = &copied
+	token.SessionID = session.ID
+	m.addToken(token)
+	return nil
+}
+
+func (m *memorySessionStore) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (m *memorySessionStore) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memorySessionStore) Rotate(ctx context.Context, usedID uint, next *models.RefreshToken, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	used := m.tokens[usedID-1]
+	if used.UsedAt != nil {
+		return false, nil
+	}
+	used.UsedAt = &at
+	m.addToken(next)
+	return true, nil
+}
+
+func (m *memorySessionStore) LastUsedRefreshToken(ctx context.Context, sessionID string) (*models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last *models.RefreshToken
+	for _, token := range m.tokens {
+		if token.SessionID != sessionID || token.UsedAt == nil {
+			continue
+		}
+		if last == nil || !token.UsedAt.Before(*last.UsedAt) {
+			last = token
+		}
+	}
+	if last == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *last
+	return &copied, nil
+}
+
+func (m *memorySessionStore) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, ok := m.sessions[id]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &at
+		session.RevokedReason = reason
+	}
+	return nil
+}
+
+func (m *memorySessionStore) RevokeAllForUser(ctx context.Context, userID uint, reason string, at time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			session.RevokedReason = reason
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memorySessionStore) RevokeOthersForUser(ctx context.Context, userID uint, keepID, reason string, at time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.ID != keepID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			session.RevokedReason = reason
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memorySessionStore) DeleteExpiredRefreshTokens(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+// revoked reports whether the only session in the store has been revoked.
+func (m *memorySessionStore) revoked() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, session := range m.sessions {
+		return session.RevokedAt != nil
+	}
+	return false
+}
+
+// backdateUse moves the use time of every used token back by d.
+func (m *memorySessionStore) backdateUse(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.UsedAt != nil {
+			at := token.UsedAt.Add(-d)
+			token.UsedAt = &at
+		}
+	}
+}
+
+func newTestSessionService(store sessionStore) *SessionService {
+	return &SessionService{
+		repo:       store,
+		secret:     []byte("test-secret"),
+		accessTTL:  time.Minute,
+		refreshTTL: time.Hour,
+		reuseGrace: refreshReuseGrace,
+		log:        zap.NewNop(),
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	store := newMemorySessionStore()
+	s := newTestSessionService(store)
+	ctx := context.Background()
+
+	first, err := s.Start(ctx, 1, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh did not rotate the refresh token")
+	}
+	if _, err := s.parseAccessToken(second.AccessToken, time.Now()); err != nil {
+		t.Fatalf("refreshed access token invalid: %v", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("second refresh failed: %v", err)
+	}
+}
+
+func TestRefreshReuseAfterGraceRevokesSession(t *testing.T) {
+	store := newMemorySessionStore()
+	s := newTestSessionService(store)
+	ctx := context.Background()
+
+	first, _ := s.Start(ctx, 1, "test", "127.0.0.1")
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.backdateUse(refreshReuseGrace + time.Second)
+
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, models.ErrRefreshTokenReused) {
+		t.Fatalf("reuse error = %v, want ErrRefreshTokenReused", err)
+	}
+	if !store.revoked() {
+		t.Fatal("session not revoked after refresh token reuse")
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Fatalf("refresh on revoked session = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshReuseOfStaleTokenRevokesSession(t *testing.T) {
+	store := newMemorySessionStore()
+	s := newTestSessionService(store)
+	ctx := context.Background()
+
+	first, _ := s.Start(ctx, 1, "test", "127.0.0.1")
+	second, _ := s.Refresh(ctx, first.RefreshToken)
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// first is within the grace period but no longer the latest rotated token
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, models.ErrRefreshTokenReused) {
+		t.Fatalf("stale reuse error = %v, want ErrRefreshTokenReused", err)
+	}
+	if !store.revoked() {
+		t.Fatal("session not revoked after stale refresh token reuse")
+	}
+}
+
+func TestConcurrentRefreshWithinGrace(t *testing.T) {
+	store := newMemorySessionStore()
+	s := newTestSessionService(store)
+	ctx := context.Background()
+
+	first, _ := s.Start(ctx, 1, "test", "127.0.0.1")
+
+	// Several tabs refresh with the same token at once
+	const tabs = 4
+	var wg sync.WaitGroup
+	results := make([]*models.SessionTokens, tabs)
+	errs := make([]error, tabs)
+	for i := 0; i < tabs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.Refresh(ctx, first.RefreshToken)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("tab %d refresh failed: %v", i, err)
+		}
+	}
+	if store.revoked() {
+		t.Fatal("concurrent refreshes revoked the session")
+	}
+
+	// All tabs continue on the same token chain
+	for i := 1; i < tabs; i++ {
+		if results[i].RefreshToken != results[0].RefreshToken {
+			t.Fatalf("tab %d got another refresh token, the session forked", i)
+		}
+	}
+	if _, err := s.Refresh(ctx, results[tabs-1].RefreshToken); err != nil {
+		t.Fatalf("refresh with a concurrently issued token failed: %v", err)
+	}
+}
+
+func TestRefreshReplayWithinGraceKeepsOneChain(t *testing.T) {
+	store := newMemorySessionStore()
+	s := newTestSessionService(store)
+	ctx := context.Background()
+
+	first, _ := s.Start(ctx, 1, "test", "127.0.0.1")
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		replayed, err := s.Refresh(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("replay %d failed: %v", i+1, err)
+		}
+		if replayed.RefreshToken != second.RefreshToken {
+			t.Fatalf("replay %d minted a new refresh token", i+1)
+		}
+	}
+	if len(store.tokens) != 2 {
+		t.Fatalf("session has %d refresh tokens, want 2", len(store.tokens))
+	}
+
+	// Moving the chain on makes the replayed token stale
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, models.ErrRefreshTokenReused) {
+		t.Fatalf("stale replay error = %v, want ErrRefreshTokenReused", err)
+	}
+}
+
+func TestRefreshUnknownToken(t *testing.T) {
+	s := newTestSessionService(newMemorySessionStore())
+	if _, err := s.Refresh(context.Background(), "vr_unknown"); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Fatalf("error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
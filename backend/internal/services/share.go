@@ -0,0 +1,580 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// defaultShareConfig is used for links without a readable content selection.
+var defaultShareConfig = models.ShareConfigData{
+	IncludeSummary:   true,
+	IncludeKeyPoints: true,
+}
+
+// Share stats periods in days
+const (
+	defaultShareStatsDays = 30
+	maxShareStatsDays     = 365
+)
+
+// ShareService manages the share links of insights and resolves them for
+// public viewers. Every link has its own content selection, optional password,
+// expiry and view limit; revoking one link leaves the others working.
+type ShareService struct {
+	repo      *repository.ShareLinkRepository
+	views     *repository.ShareViewRepository
+	insights  *repository.InsightRepository
+	secret    []byte // HMAC key for visitor hashes and access tokens
+	accessTTL time.Duration
+	log       *zap.Logger
+
+	now func() time.Time
+}
+
+// NewShareService creates a new ShareService. If secret is empty a random one
+// is generated, so returning visitors count as new and unlocked links lock
+// again after a restart.
+func NewShareService(repo *repository.ShareLinkRepository, views *repository.ShareViewRepository, insights *repository.InsightRepository, secret string, accessTTL time.Duration, log *zap.Logger) *ShareService {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate share secret: %v", err))
+		}
+		log.Warn("⚠️  SHARE_SECRET not set, using a random secret: unique visitor counts and share unlocks reset on restart")
+	}
+
+	return &ShareService{
+		repo:      repo,
+		views:     views,
+		insights:  insights,
+		secret:    key,
+		accessTTL: accessTTL,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// MigrateLegacyShares moves the single share of each insight from the former insights columns into share_links.
+func (s *ShareService) MigrateLegacyShares(ctx context.Context) error {
+	migrated, err := s.repo.MigrateLegacyShares(ctx)
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		s.log.Info("✅ Legacy insight shares migrated to share links", zap.Int("links", migrated))
+	}
+	return nil
+}
+
+// generateShareToken generates a cryptographically secure random token.
+func generateShareToken() (string, error) {
+	bytes := make([]byte, 32) // 256 bits
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// Create creates a new share link of an insight on behalf of userID.
+// Existing links of the insight are not affected. Returns
+// models.ErrInvalidShareClip if the requested clip is too long or contains
+// no transcript lines.
+func (s *ShareService) Create(ctx context.Context, insight *models.Insight, userID uint, req *models.ShareInsightRequest) (*models.ShareLink, error) {
+	if req.Clip != nil {
+		if req.Clip.EndSeconds-req.Clip.StartSeconds > models.MaxShareClipSeconds ||
+			len(clipLines(transcriptItems(insight), req.Clip)) == 0 {
+			return nil, models.ErrInvalidShareClip
+		}
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+
+	config, err := json.Marshal(models.ShareConfigData{
+		IncludeSummary:    req.IncludeSummary,
+		IncludeKeyPoints:  req.IncludeKeyPoints,
+		IncludeHighlights: req.IncludeHighlights,
+		IncludeChat:       req.IncludeChat,
+		Clip:              req.Clip,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal share config: %w", err)
+	}
+
+	link := &models.ShareLink{
+		InsightID: insight.ID,
+		UserID:    userID,
+		Token:     token,
+		Label:     req.Label,
+		Config:    config,
+		ExpiresAt: req.ExpiresAt,
+		MaxViews:  req.MaxViews,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash share password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if err := s.repo.Create(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	return link, nil
+}
+
+// List returns the share links of an insight, newest first, including revoked ones.
+func (s *ShareService) List(ctx context.Context, insightID uint) ([]models.ShareLink, error) {
+	return s.repo.ListByInsight(ctx, insightID)
+}
+
+// Revoke revokes one share link of an insight.
+// Returns models.ErrShareNotFound if the insight has no such link.
+func (s *ShareService) Revoke(ctx context.Context, insightID, linkID uint) error {
+	if err := s.repo.Revoke(ctx, insightID, linkID, s.now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrShareNotFound
+		}
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll revokes every active share link of an insight.
+func (s *ShareService) RevokeAll(ctx context.Context, insightID uint) (int64, error) {
+	return s.repo.RevokeAllForInsight(ctx, insightID, s.now())
+}
+
+// Resolve returns the link with the given token if it can be viewed.
+// Returns models.ErrShareNotFound for unknown or revoked links,
+// models.ErrShareExpired and models.ErrShareExhausted otherwise.
+func (s *ShareService) Resolve(ctx context.Context, token string) (*models.ShareLink, error) {
+	link, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrShareNotFound
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if err := checkShareLink(link, s.now()); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// checkShareLink returns the error a viewer of link gets at now, or nil if the link can be viewed.
+func checkShareLink(link *models.ShareLink, now time.Time) error {
+	switch {
+	case link.RevokedAt != nil:
+		return models.ErrShareNotFound
+	case link.Expired(now):
+		return models.ErrShareExpired
+	case link.Exhausted():
+		return models.ErrShareExhausted
+	}
+	return nil
+}
+
+// CheckPassword reports whether password opens a password protected link.
+func (s *ShareService) CheckPassword(link *models.ShareLink, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) == nil
+}
+
+// IssueAccess returns an access token that opens link until it expires, for a
+// viewer who has entered the link's password. The token is only valid for
+// this link and stops working when the link is revoked.
+func (s *ShareService) IssueAccess(link *models.ShareLink) *models.ShareAccessResponse {
+	expiresAt := s.now().Add(s.accessTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return &models.ShareAccessResponse{
+		AccessToken: expires + "." + s.signAccess(link, expires),
+		ExpiresIn:   int(s.accessTTL.Seconds()),
+		ExpiresAt:   expiresAt,
+	}
+}
+
+// CheckAccess reports whether token is an unexpired access token issued for link.
+func (s *ShareService) CheckAccess(link *models.ShareLink, token string) bool {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() >= expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signAccess(link, expires)))
+}
+
+// signAccess returns the base64url HMAC-SHA256 of an access token of link
+// expiring at expires. The link's password hash is signed too, so tokens
+// issued for a former password stop working.
+func (s *ShareService) signAccess(link *models.ShareLink, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("share-access\x00" + link.Token + "\x00" + link.PasswordHash + "\x00" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Open counts a view of a resolved link by visitor and returns the shared content.
+// Returns models.ErrShareExhausted if the last allowed view was taken concurrently,
+// and models.ErrShareNotFound if the insight no longer exists.
+func (s *ShareService) Open(ctx context.Context, link *models.ShareLink, visitor models.ShareVisitor) (*models.SharedInsightResponse, error) {
+	config := shareConfig(link)
+	insight, err := s.insights.GetForShare(ctx, link.InsightID, config.IncludeChat)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrShareNotFound
+		}
+		return nil, fmt.Errorf("failed to get shared insight: %w", err)
+	}
+
+	counted, err := s.repo.RecordView(ctx, link.ID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record share view: %w", err)
+	}
+	if !counted {
+		return nil, models.ErrShareExhausted
+	}
+	s.recordEvent(ctx, link, models.ShareViewKindView, visitor)
+
+	return s.sharedResponse(link, config, insight), nil
+}
+
+// Preview returns the shared content of a resolved link for a link preview
+// crawler without counting a view, so unfurling a link in a chat does not use
+// up its view limit. Returns models.ErrShareNotFound if the insight no longer exists.
+func (s *ShareService) Preview(ctx context.Context, link *models.ShareLink) (*models.SharedInsightResponse, error) {
+	config := shareConfig(link)
+	insight, err := s.insights.GetForShare(ctx, link.InsightID, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrShareNotFound
+		}
+		return nil, fmt.Errorf("failed to get shared insight: %w", err)
+	}
+	return s.sharedResponse(link, config, insight), nil
+}
+
+// RecordFailedPassword records a wrong password given for link by visitor.
+func (s *ShareService) RecordFailedPassword(ctx context.Context, link *models.ShareLink, visitor models.ShareVisitor) {
+	s.recordEvent(ctx, link, models.ShareViewKindPasswordFailed, visitor)
+}
+
+// recordEvent stores an anonymized access event of link. Analytics are best
+// effort: failures are logged and never fail the request.
+func (s *ShareService) recordEvent(ctx context.Context, link *models.ShareLink, kind string, visitor models.ShareVisitor) {
+	view := &models.ShareView{
+		ShareLinkID:    link.ID,
+		InsightID:      link.InsightID,
+		Kind:           kind,
+		VisitorHash:    s.visitorHash(visitor.IP),
+		UserAgentClass: ClassifyUserAgent(visitor.UserAgent),
+		ReferrerHost:   referrerHost(visitor.Referrer),
+		CreatedAt:      s.now(),
+	}
+	if err := s.views.Create(ctx, view); err != nil {
+		s.log.Warn("Failed to record share view",
+			zap.Uint("share_link_id", link.ID),
+			zap.String("kind", kind),
+			zap.Error(err),
+		)
+	}
+}
+
+// visitorHash returns the keyed hash that identifies repeat visitors without storing their IP address.
+func (s *ShareService) visitorHash(ip string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ClassifyUserAgent reduces a User-Agent header to one of the models.UserAgentClass* values.
+func ClassifyUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return models.UserAgentClassOther
+	case containsAny(ua, "bot", "crawler", "spider", "slurp", "preview", "facebookexternalhit", "curl/", "wget/", "python-requests", "headless"):
+		return models.UserAgentClassBot
+	case containsAny(ua, "ipad", "tablet", "kindle", "silk/") || (strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return models.UserAgentClassTablet
+	case containsAny(ua, "mobi", "iphone", "ipod", "android", "windows phone"):
+		return models.UserAgentClassMobile
+	case containsAny(ua, "windows", "macintosh", "mac os x", "x11", "linux", "cros"):
+		return models.UserAgentClassDesktop
+	}
+	return models.UserAgentClassOther
+}
+
+// containsAny reports whether s contains any of substrs.
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// referrerHost returns the lower-cased host name of a Referer header, or "" if there is none.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if len(host) > 255 {
+		return ""
+	}
+	return host
+}
+
+// Stats returns the view analytics of the share links of an insight. The daily
+// series covers the last days days (30 by default, at most 365), including days
+// without views.
+func (s *ShareService) Stats(ctx context.Context, insightID uint, days int) (*models.ShareStatsResponse, error) {
+	if days <= 0 {
+		days = defaultShareStatsDays
+	}
+	if days > maxShareStatsDays {
+		days = maxShareStatsDays
+	}
+
+	totals, err := s.views.Totals(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count share views: %w", err)
+	}
+
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -(days - 1))
+	daily, err := s.views.Daily(ctx, insightID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily share views: %w", err)
+	}
+
+	links, err := s.repo.ListByInsight(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	byLink, err := s.views.ByLink(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share views per link: %w", err)
+	}
+
+	userAgents, err := s.views.ByUserAgentClass(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share views per user agent: %w", err)
+	}
+	referrers, err := s.views.TopReferrers(ctx, insightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share referrers: %w", err)
+	}
+
+	return &models.ShareStatsResponse{
+		TotalViews:             totals.Views,
+		UniqueVisitors:         totals.UniqueVisitors,
+		FailedPasswordAttempts: totals.FailedPassword,
+		Days:                   days,
+		Daily:                  fillDailyStats(daily, from, days),
+		Links:                  linkStats(links, byLink),
+		UserAgentClasses:       userAgents,
+		Referrers:              referrers,
+	}, nil
+}
+
+// fillDailyStats returns one entry per day for days days starting at from,
+// taking the counts from daily and zero for days without views.
+func fillDailyStats(daily []models.ShareDailyStats, from time.Time, days int) []models.ShareDailyStats {
+	byDate := make(map[string]models.ShareDailyStats, len(daily))
+	for _, day := range daily {
+		byDate[day.Date] = day
+	}
+
+	series := make([]models.ShareDailyStats, days)
+	for i := range series {
+		date := from.AddDate(0, 0, i).Format("2006-01-02")
+		series[i] = models.ShareDailyStats{Date: date}
+		if day, ok := byDate[date]; ok {
+			series[i].Views = day.Views
+			series[i].UniqueVisitors = day.UniqueVisitors
+		}
+	}
+	return series
+}
+
+// linkStats returns the view counts of every link in links, in the same order,
+// taking the counts from viewed and zero for links without views.
+func linkStats(links []models.ShareLink, viewed []models.ShareLinkStats) []models.ShareLinkStats {
+	byID := make(map[uint]models.ShareLinkStats, len(viewed))
+	for _, stats := range viewed {
+		byID[stats.ShareLinkID] = stats
+	}
+
+	result := make([]models.ShareLinkStats, len(links))
+	for i, link := range links {
+		stats := byID[link.ID]
+		result[i] = models.ShareLinkStats{
+			ShareLinkID:    link.ID,
+			Label:          link.Label,
+			Views:          stats.Views,
+			UniqueVisitors: stats.UniqueVisitors,
+		}
+	}
+	return result
+}
+
+// shareConfig returns the content selection of a link.
+func shareConfig(link *models.ShareLink) models.ShareConfigData {
+	if len(link.Config) == 0 {
+		return defaultShareConfig
+	}
+	var config models.ShareConfigData
+	if err := json.Unmarshal(link.Config, &config); err != nil {
+		return defaultShareConfig
+	}
+	return config
+}
+
+// sharedResponse builds the public view of insight filtered by the link's content selection.
+func (s *ShareService) sharedResponse(link *models.ShareLink, config models.ShareConfigData, insight *models.Insight) *models.SharedInsightResponse {
+	sharedAt := link.CreatedAt
+	response := &models.SharedInsightResponse{
+		Title:        insight.Title,
+		Author:       insight.Author,
+		ThumbnailURL: insight.ThumbnailURL,
+		SharedBy:     "用户",
+		SharedAt:     &sharedAt,
+		SourceType:   insight.SourceType,
+		SourceURL:    insight.SourceURL,
+		Content:      models.SharedContent{},
+	}
+
+	// Apply content filtering based on share config
+	if config.IncludeSummary {
+		response.Content.Summary = insight.Summary
+	}
+
+	if config.IncludeKeyPoints && len(insight.KeyPoints) > 0 {
+		var keyPoints []string
+		if err := json.Unmarshal(insight.KeyPoints, &keyPoints); err != nil {
+			s.log.Warn("Failed to unmarshal key_points", zap.Error(err))
+		} else {
+			response.Content.KeyPoints = keyPoints
+		}
+	}
+
+	if config.IncludeHighlights && len(insight.Highlights) > 0 {
+		response.Content.Highlights = insight.Highlights
+	}
+
+	if config.IncludeChat && len(insight.ChatMessages) > 0 {
+		response.Content.Chat = insight.ChatMessages
+	}
+
+	if config.Clip != nil {
+		response.Content.Clip = &models.SharedClip{
+			StartSeconds:   config.Clip.StartSeconds,
+			EndSeconds:     config.Clip.EndSeconds,
+			StartTimestamp: SecondsToTimestamp(config.Clip.StartSeconds),
+			EndTimestamp:   SecondsToTimestamp(config.Clip.EndSeconds),
+			Comment:        config.Clip.Comment,
+			URL:            clipURL(insight, config.Clip.StartSeconds),
+			Lines:          clipLines(transcriptItems(insight), config.Clip),
+		}
+	}
+
+	return response
+}
+
+// transcriptItems returns the timed transcript lines of an insight, or nil if it has none.
+func transcriptItems(insight *models.Insight) []models.TranscriptItem {
+	if len(insight.Transcripts) == 0 {
+		return nil
+	}
+	var items []models.TranscriptItem
+	if err := json.Unmarshal(insight.Transcripts, &items); err != nil {
+		return nil
+	}
+	return items
+}
+
+// clipLines returns the transcript lines spoken during clip. A line lasts
+// until the next one starts; the last line is assumed to last to the end of
+// the video.
+func clipLines(items []models.TranscriptItem, clip *models.ShareClip) []models.TranscriptItem {
+	var lines []models.TranscriptItem
+	for i, item := range items {
+		if item.Seconds >= clip.EndSeconds {
+			continue
+		}
+		if i+1 < len(items) && items[i+1].Seconds <= clip.StartSeconds {
+			continue
+		}
+		lines = append(lines, item)
+	}
+	return lines
+}
+
+// clipURL returns the URL that plays the original video of a YouTube insight
+// from start seconds, or "" for other sources.
+func clipURL(insight *models.Insight, start int) string {
+	if insight.SourceType != models.SourceTypeYouTube {
+		return ""
+	}
+	if insight.SourceID != "" {
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s&t=%ds", url.QueryEscape(insight.SourceID), start)
+	}
+
+	u, err := url.Parse(insight.SourceURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	query := u.Query()
+	query.Set("t", fmt.Sprintf("%ds", start))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// LinkResponse converts a share link to its API representation for the insight's owner.
+func (s *ShareService) LinkResponse(link *models.ShareLink) models.ShareInsightResponse {
+	return models.ShareInsightResponse{
+		ID:           link.ID,
+		Label:        link.Label,
+		ShareToken:   link.Token,
+		ShareURL:     "/api/v1/shared/" + link.Token,
+		PageURL:      "/s/" + link.Token,
+		Config:       shareConfig(link),
+		HasPassword:  link.HasPassword(),
+		ExpiresAt:    link.ExpiresAt,
+		MaxViews:     link.MaxViews,
+		ViewCount:    link.ViewCount,
+		LastViewedAt: link.LastViewedAt,
+		RevokedAt:    link.RevokedAt,
+		Active:       link.Active(s.now()),
+		CreatedAt:    link.CreatedAt,
+	}
+}
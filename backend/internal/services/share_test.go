@@ -0,0 +1,276 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/models"
+)
+
+func TestCheckShareLink(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	for _, tc := range []struct {
+		name string
+		link models.ShareLink
+		want error
+	}{
+		{"unlimited", models.ShareLink{}, nil},
+		{"before expiry", models.ShareLink{ExpiresAt: &future}, nil},
+		{"views left", models.ShareLink{MaxViews: 3, ViewCount: 2}, nil},
+		{"revoked", models.ShareLink{RevokedAt: &past}, models.ErrShareNotFound},
+		{"expired", models.ShareLink{ExpiresAt: &past}, models.ErrShareExpired},
+		{"expires now", models.ShareLink{ExpiresAt: &now}, models.ErrShareExpired},
+		{"views used up", models.ShareLink{MaxViews: 3, ViewCount: 3}, models.ErrShareExhausted},
+		{"revoked wins", models.ShareLink{RevokedAt: &past, ExpiresAt: &past}, models.ErrShareNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkShareLink(&tc.link, now); !errors.Is(err, tc.want) {
+				t.Fatalf("checkShareLink = %v, want %v", err, tc.want)
+			}
+			if active := tc.link.Active(now); active != (tc.want == nil) {
+				t.Fatalf("Active = %v, want %v", active, tc.want == nil)
+			}
+		})
+	}
+}
+
+func TestShareConfigDefaults(t *testing.T) {
+	if got := shareConfig(&models.ShareLink{}); got != defaultShareConfig {
+		t.Fatalf("config without selection = %+v, want defaults", got)
+	}
+	if got := shareConfig(&models.ShareLink{Config: []byte("{broken")}); got != defaultShareConfig {
+		t.Fatalf("unreadable config = %+v, want defaults", got)
+	}
+	got := shareConfig(&models.ShareLink{Config: []byte(`{"include_chat": true}`)})
+	if !got.IncludeChat || got.IncludeSummary {
+		t.Fatalf("config = %+v, want chat only", got)
+	}
+}
+
+func TestClassifyUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		ua   string
+		want string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/126.0 Safari/537.36", models.UserAgentClassDesktop},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 Version/17.5 Safari/605.1.15", models.UserAgentClassDesktop},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", models.UserAgentClassMobile},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/126.0 Mobile Safari/537.36", models.UserAgentClassMobile},
+		{"Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", models.UserAgentClassTablet},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 Chrome/126.0 Safari/537.36", models.UserAgentClassTablet},
+		{"Twitterbot/1.0", models.UserAgentClassBot},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", models.UserAgentClassBot},
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", models.UserAgentClassBot},
+		{"curl/8.5.0", models.UserAgentClassBot},
+		{"", models.UserAgentClassOther},
+		{"SomeClient/2.0", models.UserAgentClassOther},
+	} {
+		if got := ClassifyUserAgent(tc.ua); got != tc.want {
+			t.Errorf("ClassifyUserAgent(%q) = %q, want %q", tc.ua, got, tc.want)
+		}
+	}
+}
+
+func TestReferrerHost(t *testing.T) {
+	for _, tc := range []struct {
+		referrer string
+		want     string
+	}{
+		{"https://t.co/abc123", "t.co"},
+		{"https://News.Ycombinator.com/item?id=1", "news.ycombinator.com"},
+		{"http://localhost:3000/insights/1", "localhost"},
+		{"", ""},
+		{"not a url", ""},
+		{"://broken", ""},
+	} {
+		if got := referrerHost(tc.referrer); got != tc.want {
+			t.Errorf("referrerHost(%q) = %q, want %q", tc.referrer, got, tc.want)
+		}
+	}
+}
+
+func TestVisitorHash(t *testing.T) {
+	s := &ShareService{secret: []byte("secret")}
+	other := &ShareService{secret: []byte("other")}
+
+	hash := s.visitorHash("203.0.113.7")
+	if hash == "203.0.113.7" || len(hash) != 64 {
+		t.Fatalf("visitorHash = %q, want a hex HMAC", hash)
+	}
+	if s.visitorHash("203.0.113.7") != hash {
+		t.Fatal("visitorHash is not stable for the same IP")
+	}
+	if s.visitorHash("203.0.113.8") == hash {
+		t.Fatal("visitorHash is equal for different IPs")
+	}
+	if other.visitorHash("203.0.113.7") == hash {
+		t.Fatal("visitorHash does not depend on the secret")
+	}
+}
+
+func TestFillDailyStats(t *testing.T) {
+	from := time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC)
+	daily := []models.ShareDailyStats{
+		{Date: "2024-02-28", Views: 5, UniqueVisitors: 2},
+		{Date: "2024-03-01", Views: 1, UniqueVisitors: 1},
+	}
+
+	got := fillDailyStats(daily, from, 4)
+	want := []models.ShareDailyStats{
+		{Date: "2024-02-27"},
+		{Date: "2024-02-28", Views: 5, UniqueVisitors: 2},
+		{Date: "2024-02-29"},
+		{Date: "2024-03-01", Views: 1, UniqueVisitors: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fillDailyStats returned %d days, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLinkStatsIncludesUnviewedLinks(t *testing.T) {
+	links := []models.ShareLink{{ID: 3, Label: "newsletter"}, {ID: 1}}
+	viewed := []models.ShareLinkStats{{ShareLinkID: 1, Views: 4, UniqueVisitors: 3}}
+
+	got := linkStats(links, viewed)
+	want := []models.ShareLinkStats{
+		{ShareLinkID: 3, Label: "newsletter"},
+		{ShareLinkID: 1, Views: 4, UniqueVisitors: 3},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestShareAccessToken(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &ShareService{secret: []byte("secret"), accessTTL: time.Hour, now: func() time.Time { return now }}
+	link := &models.ShareLink{Token: "abc", PasswordHash: "hash-1"}
+
+	access := s.IssueAccess(link)
+	if access.ExpiresIn != 3600 || !access.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("IssueAccess expiry = %d / %v, want 3600 / %v", access.ExpiresIn, access.ExpiresAt, now.Add(time.Hour))
+	}
+	if !s.CheckAccess(link, access.AccessToken) {
+		t.Fatal("CheckAccess rejected a fresh token")
+	}
+
+	for _, tc := range []struct {
+		name  string
+		link  *models.ShareLink
+		token string
+	}{
+		{"other link", &models.ShareLink{Token: "xyz", PasswordHash: "hash-1"}, access.AccessToken},
+		{"password changed", &models.ShareLink{Token: "abc", PasswordHash: "hash-2"}, access.AccessToken},
+		{"extended expiry", link, "9999999999" + access.AccessToken[strings.IndexByte(access.AccessToken, '.'):]},
+		{"tampered signature", link, access.AccessToken + "x"},
+		{"malformed", link, "not-a-token"},
+		{"empty", link, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if s.CheckAccess(tc.link, tc.token) {
+				t.Fatalf("CheckAccess accepted %q", tc.token)
+			}
+		})
+	}
+
+	now = now.Add(time.Hour)
+	if s.CheckAccess(link, access.AccessToken) {
+		t.Fatal("CheckAccess accepted an expired token")
+	}
+}
+
+func TestClipLines(t *testing.T) {
+	items := []models.TranscriptItem{
+		{Seconds: 0, Text: "a"},
+		{Seconds: 10, Text: "b"},
+		{Seconds: 20, Text: "c"},
+		{Seconds: 30, Text: "d"},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		start, end int
+		want       string
+	}{
+		{"aligned", 10, 30, "bc"},
+		{"starts mid line", 15, 25, "bc"},
+		{"inside one line", 12, 14, "b"},
+		{"last line runs to the end", 100, 140, "d"},
+		{"whole video", 0, 1000, "abcd"},
+		{"ends where a line starts", 0, 10, "a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			for _, line := range clipLines(items, &models.ShareClip{StartSeconds: tc.start, EndSeconds: tc.end}) {
+				got += line.Text
+			}
+			if got != tc.want {
+				t.Fatalf("clipLines(%d, %d) = %q, want %q", tc.start, tc.end, got, tc.want)
+			}
+		})
+	}
+
+	if lines := clipLines(nil, &models.ShareClip{EndSeconds: 10}); len(lines) != 0 {
+		t.Fatalf("clipLines without transcript = %v, want none", lines)
+	}
+}
+
+func TestClipURL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		insight models.Insight
+		want    string
+	}{
+		{"video id", models.Insight{SourceType: models.SourceTypeYouTube, SourceID: "dQw4w9WgXcQ"}, "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s"},
+		{"source url", models.Insight{SourceType: models.SourceTypeYouTube, SourceURL: "https://youtu.be/dQw4w9WgXcQ?t=5"}, "https://youtu.be/dQw4w9WgXcQ?t=42s"},
+		{"not youtube", models.Insight{SourceType: models.SourceTypePodcast, SourceURL: "https://example.com/ep1"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := clipURL(&tc.insight, 42); got != tc.want {
+				t.Fatalf("clipURL = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSharedResponseClip(t *testing.T) {
+	s := &ShareService{log: zap.NewNop()}
+	insight := &models.Insight{
+		SourceType:  models.SourceTypeYouTube,
+		SourceID:    "dQw4w9WgXcQ",
+		Summary:     "summary",
+		Transcripts: []byte(`[{"timestamp":"00:00","seconds":0,"text":"intro"},{"timestamp":"01:05","seconds":65,"text":"point","translated_text":"要点"},{"timestamp":"01:50","seconds":110,"text":"outro"}]`),
+	}
+	config := models.ShareConfigData{Clip: &models.ShareClip{StartSeconds: 65, EndSeconds: 105, Comment: "the part that matters"}}
+
+	response := s.sharedResponse(&models.ShareLink{}, config, insight)
+	if response.Content.Summary != "" {
+		t.Fatalf("Summary = %q, want it excluded", response.Content.Summary)
+	}
+	clip := response.Content.Clip
+	if clip == nil {
+		t.Fatal("Clip = nil")
+	}
+	if clip.StartTimestamp != "01:05" || clip.EndTimestamp != "01:45" || clip.Comment != "the part that matters" {
+		t.Fatalf("Clip = %+v", clip)
+	}
+	if clip.URL != "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=65s" {
+		t.Fatalf("Clip.URL = %q", clip.URL)
+	}
+	if len(clip.Lines) != 1 || clip.Lines[0].Text != "point" || clip.Lines[0].TranslatedText != "要点" {
+		t.Fatalf("Clip.Lines = %+v", clip.Lines)
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+)
+
+// SummaryPromptVersion identifies the prompt template used to generate summaries.
+// Bump it whenever the prompts below change so stored summaries can be traced back.
+const SummaryPromptVersion = "summary-v1"
+
+const (
+	// summaryChunkSize is the maximum number of runes sent to the model per chunk.
+	summaryChunkSize = 12000
+	// summaryMaxKeyPoints caps the number of key points kept on an insight.
+	summaryMaxKeyPoints = 8
+)
+
+// SummaryResult is the structured output of the summarization stage.
+type SummaryResult struct {
+	Summary       string   `json:"summary"`
+	KeyPoints     []string `json:"key_points"`
+	Model         string   `json:"-"`
+	PromptVersion string   `json:"-"`
+}
+
+// SummaryService generates AI summaries and key points for insight content.
+type SummaryService struct {
+	llm   *llm.Client
+	model string
+	log   *zap.Logger
+}
+
+// NewSummaryService creates a new SummaryService.
+// Uses the shared LLM client with the configured summary model.
+func NewSummaryService(client *llm.Client, model string, log *zap.Logger) *SummaryService {
+	if model == "" {
+		model = "google/gemini-3-flash-preview"
+	}
+
+	return &SummaryService{
+		llm:   client,
+		model: model,
+		log:   log,
+	}
+}
+
+// Summarize generates a structured summary and key points for the given content.
+// Long content is split into chunks which are condensed first (map step) and then
+// merged into the final summary (reduce step).
+func (s *SummaryService) Summarize(ctx context.Context, title, author, content, targetLang string) (*SummaryResult, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("no content to summarize")
+	}
+
+	chunks := splitIntoChunks(content, summaryChunkSize)
+
+	s.log.Info("Starting summarization",
+		zap.String("title", title),
+		zap.Int("content_length", len([]rune(content))),
+		zap.Int("chunks", len(chunks)),
+		zap.String("model", s.model),
+	)
+
+	source := content
+	if len(chunks) > 1 {
+		notes := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			note, err := s.summarizeChunk(ctx, title, chunk, i+1, len(chunks))
+			if err != nil {
+				return nil, fmt.Errorf("failed to summarize chunk %d/%d: %w", i+1, len(chunks), err)
+			}
+			notes = append(notes, fmt.Sprintf("[Part %d]\n%s", i+1, note))
+		}
+		source = strings.Join(notes, "\n\n")
+	}
+
+	prompt := fmt.Sprintf(`You are summarizing a piece of content for a reading assistant.
+
+Title: %s
+Author: %s
+
+Content:
+%s
+
+Write the result in %s and return ONLY a JSON object with this structure:
+{
+  "summary": "a structured Markdown summary: one overview paragraph followed by 2-4 short sections with ### headings",
+  "key_points": ["key point 1", "key point 2"]
+}
+
+Rules:
+- key_points must contain between 3 and %d concise, self-contained statements
+- Only use information present in the content, do not invent facts
+- Do not wrap the JSON in Markdown code fences`, title, author, source, languageName(targetLang), summaryMaxKeyPoints)
+
+	req := llm.UserPrompt(llm.FeatureSummary, s.model, prompt)
+	req.Temperature = llm.Float(0.3)
+	req.MaxTokens = 2000
+
+	var result SummaryResult
+	resp, err := s.llm.CompleteJSON(ctx, req, &result)
+	if err != nil {
+		if resp != nil {
+			s.log.Error("Failed to parse summary response",
+				zap.Error(err),
+				zap.String("raw_response", resp.Content),
+			)
+		}
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	result.Summary = strings.TrimSpace(result.Summary)
+	if result.Summary == "" {
+		return nil, fmt.Errorf("model returned an empty summary")
+	}
+
+	keyPoints := make([]string, 0, len(result.KeyPoints))
+	for _, kp := range result.KeyPoints {
+		if kp = strings.TrimSpace(kp); kp != "" {
+			keyPoints = append(keyPoints, kp)
+		}
+	}
+	if len(keyPoints) > summaryMaxKeyPoints {
+		keyPoints = keyPoints[:summaryMaxKeyPoints]
+	}
+	result.KeyPoints = keyPoints
+	result.Model = s.model
+	result.PromptVersion = SummaryPromptVersion
+
+	return &result, nil
+}
+
+// summarizeChunk condenses a single chunk into dense notes for the reduce step.
+func (s *SummaryService) summarizeChunk(ctx context.Context, title, chunk string, index, total int) (string, error) {
+	prompt := fmt.Sprintf(`The following is part %d of %d of the content titled "%s".
+Extract the important facts, arguments, numbers and conclusions as dense bullet notes.
+Keep the original language. Return ONLY the notes.
+
+%s`, index, total, title, chunk)
+
+	req := llm.UserPrompt(llm.FeatureSummary, s.model, prompt)
+	req.Temperature = llm.Float(0.2)
+	req.MaxTokens = 1200
+
+	notes, err := s.llm.CompleteText(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(notes), nil
+}
+
+// splitIntoChunks splits text into chunks of at most size runes, preferring to
+// break on sentence endings or whitespace so that chunks stay readable.
+// Returns no chunks for blank text.
+func splitIntoChunks(text string, size int) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	runes := []rune(text)
+	if len(runes) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			if chunk := strings.TrimSpace(string(runes[start:])); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+			break
+		}
+
+		// Look back for a natural break point in the last fifth of the chunk
+		cut := end
+		for i := end; i > start+size*4/5; i-- {
+			r := runes[i-1]
+			if r == '.' || r == '!' || r == '?' || r == '。' || r == '！' || r == '？' || r == '\n' {
+				cut = i
+				break
+			}
+			if cut == end && unicode.IsSpace(r) {
+				cut = i
+			}
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:cut])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		start = cut
+	}
+
+	return chunks
+}
+
+// languageName returns a human readable language name for prompts.
+func languageName(code string) string {
+	switch strings.ToLower(code) {
+	case "", "zh", "zh-cn", "zh-hans":
+		return "Simplified Chinese"
+	case "zh-tw", "zh-hant":
+		return "Traditional Chinese"
+	case "en":
+		return "English"
+	case "ja":
+		return "Japanese"
+	case "ko":
+		return "Korean"
+	case "es":
+		return "Spanish"
+	case "fr":
+		return "French"
+	case "de":
+		return "German"
+	default:
+		return code
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{"empty", "", 10, nil},
+		{"blank", " \n\t ", 10, nil},
+		{"fits", "short text", 10, []string{"short text"}},
+		{"exact size", "0123456789", 10, []string{"0123456789"}},
+		{"sentence break", "Alpha beta gamma. Delta epsilon zeta eta.", 20, []string{"Alpha beta gamma.", "Delta epsilon zeta", "eta."}},
+		{"space break", "aaaa bbbb cccc dddd", 10, []string{"aaaa bbbb", "cccc dddd"}},
+		{"oversized line", strings.Repeat("x", 25), 10, []string{strings.Repeat("x", 10), strings.Repeat("x", 10), strings.Repeat("x", 5)}},
+		{"multibyte", "第一句话很长。第二句话也很长。第三句", 8, []string{"第一句话很长。", "第二句话也很长。", "第三句"}},
+		{"trailing whitespace", "aaaa bbbb" + strings.Repeat(" ", 12), 10, []string{"aaaa bbbb"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := splitIntoChunks(tc.text, tc.size)
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+				t.Fatalf("splitIntoChunks = %q, want %q", got, tc.want)
+			}
+			for _, chunk := range got {
+				if n := utf8.RuneCountInString(chunk); n > tc.size || n == 0 || !utf8.ValidString(chunk) {
+					t.Fatalf("chunk %q has %d runes, want 1..%d valid runes", chunk, n, tc.size)
+				}
+			}
+		})
+	}
+}
+
+// summaryResponder answers chunk prompts with notes and summary prompts with JSON.
+func summaryResponder(req llm.Request) (string, error) {
+	if req.JSON {
+		return `{"summary": " Overview ", "key_points": ["a", " ", "b", "c", "d", "e", "f", "g", "h", "i", "j"]}`, nil
+	}
+	return "notes", nil
+}
+
+func newTestSummaryService(responder llm.FakeResponder) (*SummaryService, *llm.Fake) {
+	fake := llm.NewFake(responder)
+	client := llm.NewClient(fake, llm.Options{MaxRetries: 0}, zap.NewNop())
+	return NewSummaryService(client, "test-model", zap.NewNop()), fake
+}
+
+func TestSummarizeShortContent(t *testing.T) {
+	s, fake := newTestSummaryService(summaryResponder)
+
+	result, err := s.Summarize(context.Background(), "Title", "Author", "Short content.", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary != "Overview" || result.Model != "test-model" || result.PromptVersion != SummaryPromptVersion {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(result.KeyPoints) != summaryMaxKeyPoints || result.KeyPoints[1] != "b" {
+		t.Fatalf("key points = %q, want blanks dropped and capped at %d", result.KeyPoints, summaryMaxKeyPoints)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || !strings.Contains(calls[0].Messages[0].Content, "Short content.") {
+		t.Fatalf("calls = %+v, want a single summary call over the content", calls)
+	}
+}
+
+func TestSummarizeMapReduce(t *testing.T) {
+	s, fake := newTestSummaryService(summaryResponder)
+	content := strings.Repeat("A sentence of the long transcript. ", summaryChunkSize/10)
+
+	if _, err := s.Summarize(context.Background(), "Title", "Author", content, "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.Calls()
+	chunks := len(splitIntoChunks(content, summaryChunkSize))
+	if chunks < 2 || len(calls) != chunks+1 {
+		t.Fatalf("%d calls for %d chunks, want one per chunk plus the reduce step", len(calls), chunks)
+	}
+	for i, call := range calls[:chunks] {
+		if call.JSON || !strings.Contains(call.Messages[0].Content, "part") {
+			t.Fatalf("call %d is not a chunk call", i)
+		}
+	}
+	reduce := calls[chunks].Messages[0].Content
+	if !calls[chunks].JSON || !strings.Contains(reduce, "[Part 1]\nnotes") || strings.Contains(reduce, "long transcript") {
+		t.Fatalf("reduce prompt does not summarize the chunk notes:\n%.300s", reduce)
+	}
+}
+
+func TestSummarizeEmptyContent(t *testing.T) {
+	s, fake := newTestSummaryService(summaryResponder)
+	if _, err := s.Summarize(context.Background(), "Title", "Author", "  \n ", "en"); err == nil {
+		t.Fatal("empty content summarized")
+	}
+	if len(fake.Calls()) != 0 {
+		t.Fatal("empty content sent to the model")
+	}
+}
+
+func TestSummarizeInsightRetriesTransientErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		kind      llm.ErrorKind
+		wantRetry bool
+	}{
+		{"rate limit", llm.ErrorKindRateLimit, true},
+		{"server", llm.ErrorKindServer, true},
+		{"timeout", llm.ErrorKindTimeout, true},
+		{"invalid request", llm.ErrorKindInvalidRequest, false},
+		{"auth", llm.ErrorKindAuth, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newTestSummaryService(func(req llm.Request) (string, error) {
+				return "", &llm.Error{Kind: tc.kind, Provider: "Fake"}
+			})
+			p := &InsightProcessor{summaryService: s, log: zap.NewNop()}
+			insight := &models.Insight{Title: "Title", RawContent: "Some content."}
+
+			err := p.summarizeInsight(context.Background(), insight)
+			if tc.wantRetry {
+				if !llm.IsRetryable(err) {
+					t.Fatalf("error = %v, want a retryable error for the job queue", err)
+				}
+				return
+			}
+			if err != nil || insight.Summary != "" {
+				t.Fatalf("error = %v, summary %q: want the insight to complete without a summary", err, insight.Summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// TokenCipher encrypts secrets stored at rest (such as OAuth tokens) with AES-256-GCM.
+type TokenCipher struct {
+	aead cipher.AEAD
+}
+
+// NewTokenCipher creates a TokenCipher from a passphrase; the AES key is its
+// SHA-256. An empty passphrase selects a random key, so data encrypted by this
+// process cannot be decrypted after a restart.
+func NewTokenCipher(passphrase string) *TokenCipher {
+	var key []byte
+	if passphrase == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate encryption key: %v", err))
+		}
+	} else {
+		sum := sha256.Sum256([]byte(passphrase))
+		key = sum[:]
+	}
+
+	// A 32-byte key always yields a valid AES-256 block and GCM mode.
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	return &TokenCipher{aead: aead}
+}
+
+// Encrypt returns the base64 nonce and ciphertext of plaintext.
+func (c *TokenCipher) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. It fails if the data was encrypted with another key or modified.
+func (c *TokenCipher) Decrypt(encrypted string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	return c.aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestTokenCipherRoundTrip(t *testing.T) {
+	c := NewTokenCipher("passphrase")
+
+	for _, plaintext := range []string{`{"access_token":"ya29.a0","refresh_token":"1//0g"}`, ""} {
+		encrypted, err := c.Encrypt([]byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := c.Encrypt([]byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted == again {
+			t.Fatal("encrypting twice produced the same ciphertext")
+		}
+
+		decrypted, err := NewTokenCipher("passphrase").Decrypt(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decrypted) != plaintext {
+			t.Fatalf("decrypted = %q, want %q", decrypted, plaintext)
+		}
+	}
+}
+
+func TestTokenCipherRejectsTampering(t *testing.T) {
+	c := NewTokenCipher("passphrase")
+	encrypted, err := c.Encrypt([]byte("refresh-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(encrypted)
+
+	flip := func(i int) string {
+		tampered := bytes.Clone(sealed)
+		tampered[i] ^= 1
+		return base64.StdEncoding.EncodeToString(tampered)
+	}
+
+	for name, input := range map[string]string{
+		"nonce":      flip(0),
+		"ciphertext": flip(len(sealed) / 2),
+		"tag":        flip(len(sealed) - 1),
+		"truncated":  base64.StdEncoding.EncodeToString(sealed[:len(sealed)-1]),
+		"too short":  base64.StdEncoding.EncodeToString(sealed[:4]),
+		"not base64": "%%%",
+	} {
+		if _, err := c.Decrypt(input); err == nil {
+			t.Errorf("tampered %s accepted", name)
+		}
+	}
+
+	for name, other := range map[string]*TokenCipher{
+		"other passphrase": NewTokenCipher("other passphrase"),
+		"random key":       NewTokenCipher(""),
+	} {
+		if _, err := other.Decrypt(encrypted); err == nil {
+			t.Errorf("decrypted with %s", name)
+		}
+	}
+}
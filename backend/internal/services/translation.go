@@ -1,14 +1,11 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 
 	"go.uber.org/zap"
@@ -16,35 +13,32 @@ import (
 
 // TranslationService handles translation operations.
 type TranslationService struct {
-	apiKey string
-	model  string
-	log    *zap.Logger
+	llm   *llm.Client
+	usage *UsageService
+	model string
+	log   *zap.Logger
 }
 
 // NewTranslationService creates a new TranslationService.
-// Uses OpenRouter API with Gemini model for translation.
-func NewTranslationService(apiKey, model string, log *zap.Logger) *TranslationService {
-	// Validate API key on service initialization
-	if apiKey == "" {
-		log.Warn("⚠️  OpenRouter API 密钥未设置（翻译服务）",
-			zap.String("环境变量", "OPENROUTER_API_KEY"),
-			zap.String("影响功能", "翻译功能将无法使用"),
-		)
-	} else {
-		maskedKey := apiKey[:10] + "..." + apiKey[len(apiKey)-4:]
-		log.Info("✅ 翻译服务已初始化",
-			zap.String("model", model),
-			zap.String("api_key", maskedKey),
-		)
-	}
-	
+// Uses the shared LLM client with the configured translation model.
+func NewTranslationService(client *llm.Client, model string, log *zap.Logger) *TranslationService {
+	log.Info("✅ 翻译服务已初始化",
+		zap.String("provider", client.ProviderName()),
+		zap.String("model", model),
+	)
+
 	return &TranslationService{
-		apiKey: apiKey,
-		model:  model,
-		log:    log,
+		llm:   client,
+		model: model,
+		log:   log,
 	}
 }
 
+// SetUsageService sets the usage service enforcing monthly LLM budgets (for dependency injection).
+func (s *TranslationService) SetUsageService(usage *UsageService) {
+	s.usage = usage
+}
+
 // DetectLanguage detects the language of the input text.
 func (s *TranslationService) DetectLanguage(ctx context.Context, text string) (string, error) {
 	// Use OpenRouter API to detect language
@@ -54,19 +48,7 @@ Text: %s
 
 Language code:`, text)
 
-	req := map[string]interface{}{
-		"model": s.model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.1,
-		"max_tokens":  10,
-	}
-
-	result, err := s.callOpenRouter(ctx, req)
+	result, err := s.complete(ctx, prompt, 0.1, 10)
 	if err != nil {
 		return "", fmt.Errorf("language detection failed: %w", err)
 	}
@@ -104,19 +86,7 @@ Text: %s
 Translation:`, s.getLanguageName(targetLang), text)
 	}
 
-	req := map[string]interface{}{
-		"model": s.model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.3,
-		"max_tokens":  2000,
-	}
-
-	result, err := s.callOpenRouter(ctx, req)
+	result, err := s.complete(ctx, prompt, 0.3, 2000)
 	if err != nil {
 		return "", fmt.Errorf("translation failed: %w", err)
 	}
@@ -130,6 +100,12 @@ func (s *TranslationService) TranslateBatch(ctx context.Context, texts []string,
 		return []string{}, nil
 	}
 
+	if s.usage != nil {
+		if err := s.usage.CheckBudget(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build batch translation prompt
 	var textList strings.Builder
 	for i, text := range texts {
@@ -149,19 +125,7 @@ Translations:`, s.getLanguageName(sourceLang), s.getLanguageName(targetLang), te
 Translations:`, s.getLanguageName(targetLang), textList.String())
 	}
 
-	req := map[string]interface{}{
-		"model": s.model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.3,
-		"max_tokens":  4000,
-	}
-
-	result, err := s.callOpenRouter(ctx, req)
+	result, err := s.complete(ctx, prompt, 0.3, 4000)
 	if err != nil {
 		return nil, fmt.Errorf("batch translation failed: %w", err)
 	}
@@ -220,82 +184,12 @@ func (s *TranslationService) parseNumberedTranslations(result string, expectedCo
 	return translations
 }
 
-// callOpenRouter makes a request to the OpenRouter API.
-func (s *TranslationService) callOpenRouter(ctx context.Context, request map[string]interface{}) (string, error) {
-	if s.apiKey == "" {
-		return "", fmt.Errorf("OpenRouter API key not configured")
-	}
-
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
-	req.Header.Set("HTTP-Referer", "https://vibe-engineering-playbook-l8kw.vercel.app")
-	req.Header.Set("X-Title", "Vibe Translation Service")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效 (翻译服务)",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", string(body)),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
-			)
-			return "", fmt.Errorf("OpenRouter API 认证失败（401）: %s - 请检查 OPENROUTER_API_KEY 是否有效", string(body))
-		}
-		
-		s.log.Error("OpenRouter API error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)),
-		)
-		return "", fmt.Errorf("OpenRouter API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if result.Error != nil {
-		return "", fmt.Errorf("OpenRouter API error: %s", result.Error.Message)
-	}
-
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from OpenRouter API")
-	}
-
-	return result.Choices[0].Message.Content, nil
+// complete sends a single-prompt completion request with the translation model.
+func (s *TranslationService) complete(ctx context.Context, prompt string, temperature float64, maxTokens int) (string, error) {
+	req := llm.UserPrompt(llm.FeatureTranslation, s.model, prompt)
+	req.Temperature = llm.Float(temperature)
+	req.MaxTokens = maxTokens
+	return s.llm.CompleteText(ctx, req)
 }
 
 // getLanguageName returns the full language name for a language code.
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/models"
+)
+
+// Twitter fetch backends
+const (
+	TwitterBackendSyndication = "syndication" // public embed endpoints, no API key needed
+	TwitterBackendFake        = "fake"        // posts from JSON fixture files
+)
+
+const (
+	// defaultTwitterSyndicationURL serves the post data of embedded tweets.
+	defaultTwitterSyndicationURL = "https://cdn.syndication.twimg.com"
+
+	// defaultMaxThreadPosts bounds how far a thread is unrolled.
+	defaultMaxThreadPosts = 50
+)
+
+var (
+	// ErrTweetNotFound is returned for posts that do not exist, were deleted or are protected.
+	ErrTweetNotFound = errors.New("tweet not found, deleted or protected")
+)
+
+// TwitterFetcher loads single posts from Twitter/X.
+type TwitterFetcher interface {
+	// FetchPost returns the post with the given ID, or ErrTweetNotFound.
+	FetchPost(ctx context.Context, id string) (*models.TwitterPost, error)
+}
+
+// TwitterReplyFetcher is implemented by backends that can list the replies to
+// a post, which lets threads be unrolled forward from their first post.
+type TwitterReplyFetcher interface {
+	// FetchReplies returns the direct replies to the post with the given ID, oldest first.
+	FetchReplies(ctx context.Context, id string) ([]models.TwitterPost, error)
+}
+
+// TwitterConfig configures the backend used to fetch posts.
+type TwitterConfig struct {
+	Backend        string // syndication (default) or fake
+	SyndicationURL string
+	FixturesDir    string // JSON fixtures of the fake backend
+	Timeout        time.Duration
+}
+
+// NewTwitterFetcher creates the fetch backend described by cfg.
+func NewTwitterFetcher(cfg TwitterConfig, log *zap.Logger) (TwitterFetcher, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", TwitterBackendSyndication:
+		return NewTwitterSyndication(cfg.SyndicationURL, cfg.Timeout), nil
+	case TwitterBackendFake:
+		log.Warn("⚠️  使用 fake Twitter 后端，推文来自本地 fixture 文件", zap.String("dir", cfg.FixturesDir))
+		return LoadTwitterFixtures(cfg.FixturesDir)
+	default:
+		return nil, fmt.Errorf("unknown Twitter backend %q", cfg.Backend)
+	}
+}
+
+// TwitterService unrolls Twitter/X threads.
+type TwitterService struct {
+	fetcher  TwitterFetcher
+	maxPosts int
+	log      *zap.Logger
+}
+
+// NewTwitterService creates a new TwitterService. maxPosts bounds the length
+// of unrolled threads (50 when not positive).
+func NewTwitterService(fetcher TwitterFetcher, maxPosts int, log *zap.Logger) *TwitterService {
+	if maxPosts <= 0 {
+		maxPosts = defaultMaxThreadPosts
+	}
+	return &TwitterService{
+		fetcher:  fetcher,
+		maxPosts: maxPosts,
+		log:      log,
+	}
+}
+
+// IsTwitterHost reports whether host (without port) is a Twitter/X web host.
+func IsTwitterHost(host string) bool {
+	host = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(host), "www."), "mobile.")
+	return host == "x.com" || host == "twitter.com"
+}
+
+// ExtractTweetID extracts the post ID from a Twitter/X status URL such as
+// https://x.com/user/status/1234567890.
+func ExtractTweetID(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	for i, part := range parts {
+		if (part == "status" || part == "statuses") && i+1 < len(parts) {
+			if _, err := strconv.ParseUint(parts[i+1], 10, 64); err == nil {
+				return parts[i+1], nil
+			}
+		}
+	}
+
+	return "", errors.New("could not extract Twitter tweet ID")
+}
+
+// FetchPost returns a single post.
+func (s *TwitterService) FetchPost(ctx context.Context, id string) (*models.TwitterPost, error) {
+	return s.fetcher.FetchPost(ctx, id)
+}
+
+// FetchThread unrolls the thread containing the post id: replies of the
+// author to their own posts are followed back to the first post of the thread
+// and, when the backend can list replies, forward to its last post. Backends
+// that cannot list replies (syndication) only see earlier posts, so threads
+// linked by an earlier post are marked MayContinue when that post has replies.
+// Returns ErrTweetNotFound if the post itself cannot be loaded; unavailable
+// posts end the thread.
+func (s *TwitterService) FetchThread(ctx context.Context, id string) (*models.TwitterThread, error) {
+	post, err := s.fetcher.FetchPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := []models.TwitterPost{*post}
+	for len(posts) < s.maxPosts && post.InReplyToID != "" {
+		parent, err := s.fetcher.FetchPost(ctx, post.InReplyToID)
+		if errors.Is(err, ErrTweetNotFound) {
+			s.log.Info("Thread starts after an unavailable post",
+				zap.String("tweet_id", post.InReplyToID),
+			)
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tweet %s of the thread: %w", post.InReplyToID, err)
+		}
+		if !strings.EqualFold(parent.AuthorHandle, post.AuthorHandle) {
+			break // a reply to someone else starts the thread
+		}
+		posts = append(posts, *parent)
+		post = parent
+	}
+
+	// Oldest first
+	for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+		posts[i], posts[j] = posts[j], posts[i]
+	}
+	thread := &models.TwitterThread{Posts: posts}
+
+	replies, ok := s.fetcher.(TwitterReplyFetcher)
+	if !ok {
+		last := thread.Posts[len(thread.Posts)-1]
+		thread.MayContinue = last.ReplyCount > 0
+		return thread, nil
+	}
+	for len(thread.Posts) < s.maxPosts {
+		last := thread.Posts[len(thread.Posts)-1]
+		next, err := s.nextInThread(ctx, replies, &last)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		thread.Posts = append(thread.Posts, *next)
+	}
+	return thread, nil
+}
+
+// nextInThread returns the earliest reply of the author of post to their own
+// post, or nil when the thread ends with post.
+func (s *TwitterService) nextInThread(ctx context.Context, replies TwitterReplyFetcher, post *models.TwitterPost) (*models.TwitterPost, error) {
+	candidates, err := replies.FetchReplies(ctx, post.ID)
+	if errors.Is(err, ErrTweetNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch replies to tweet %s: %w", post.ID, err)
+	}
+	for i := range candidates {
+		if strings.EqualFold(candidates[i].AuthorHandle, post.AuthorHandle) {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// threadMayContinueNote ends formatted threads whose later posts could not be fetched.
+const threadMayContinueNote = "(此推文串可能还有后续推文未获取：请使用串中最后一条推文的链接)"
+
+// FormatThread renders a thread as plain text, one numbered block per post
+// with its author and media links. texts replaces the post texts when not
+// nil, e.g. with their translations.
+func FormatThread(thread *models.TwitterThread, texts []string) string {
+	var b strings.Builder
+	for i, post := range thread.Posts {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d/%d] %s (@%s)", i+1, len(thread.Posts), post.AuthorName, post.AuthorHandle)
+		if !post.CreatedAt.IsZero() {
+			b.WriteString(" · " + post.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"))
+		}
+
+		text := post.Text
+		if texts != nil && i < len(texts) && texts[i] != "" {
+			text = texts[i]
+		}
+		if text != "" {
+			b.WriteString("\n" + text)
+		}
+		for _, media := range post.Media {
+			fmt.Fprintf(&b, "\n%s: %s", media.Type, media.URL)
+		}
+	}
+	if thread.MayContinue {
+		b.WriteString("\n\n" + threadMayContinueNote)
+	}
+	return b.String()
+}
+
+// TwitterSyndication fetches posts from the syndication endpoints behind
+// embedded tweets, which need no API key.
+type TwitterSyndication struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewTwitterSyndication creates a syndication backend. An empty baseURL uses
+// the public endpoint.
+func NewTwitterSyndication(baseURL string, timeout time.Duration) *TwitterSyndication {
+	if baseURL == "" {
+		baseURL = defaultTwitterSyndicationURL
+	}
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &TwitterSyndication{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// syndicationTweet is the subset of a syndication tweet-result used to build a post.
+type syndicationTweet struct {
+	TypeName          string `json:"__typename"`
+	IDStr             string `json:"id_str"`
+	Text              string `json:"text"`
+	CreatedAt         string `json:"created_at"`
+	InReplyToIDStr    string `json:"in_reply_to_status_id_str"`
+	ConversationCount int    `json:"conversation_count"`
+	DisplayTextRange  []int  `json:"display_text_range"`
+	User              struct {
+		Name                 string `json:"name"`
+		ScreenName           string `json:"screen_name"`
+		ProfileImageURLHTTPS string `json:"profile_image_url_https"`
+	} `json:"user"`
+	Entities struct {
+		URLs []struct {
+			URL         string `json:"url"`
+			ExpandedURL string `json:"expanded_url"`
+		} `json:"urls"`
+		Media []struct {
+			URL string `json:"url"`
+		} `json:"media"`
+	} `json:"entities"`
+	MediaDetails []struct {
+		Type          string `json:"type"`
+		MediaURLHTTPS string `json:"media_url_https"`
+		VideoInfo     struct {
+			Variants []struct {
+				Bitrate     int    `json:"bitrate"`
+				ContentType string `json:"content_type"`
+				URL         string `json:"url"`
+			} `json:"variants"`
+		} `json:"video_info"`
+	} `json:"mediaDetails"`
+}
+
+// FetchPost implements TwitterFetcher.
+func (t *TwitterSyndication) FetchPost(ctx context.Context, id string) (*models.TwitterPost, error) {
+	numericID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrTweetNotFound
+	}
+
+	query := url.Values{}
+	query.Set("id", id)
+	query.Set("lang", "en")
+	query.Set("token", syndicationToken(numericID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"/tweet-result?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("syndication request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTweetNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("syndication returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tweet syndicationTweet
+	if err := json.NewDecoder(resp.Body).Decode(&tweet); err != nil {
+		return nil, fmt.Errorf("failed to decode syndication response: %w", err)
+	}
+	if tweet.IDStr == "" || tweet.TypeName == "TweetTombstone" {
+		return nil, ErrTweetNotFound
+	}
+	return tweet.post(), nil
+}
+
+// post converts a syndication tweet to a post.
+func (t *syndicationTweet) post() *models.TwitterPost {
+	post := &models.TwitterPost{
+		ID:              t.IDStr,
+		AuthorName:      t.User.Name,
+		AuthorHandle:    t.User.ScreenName,
+		AuthorAvatarURL: t.User.ProfileImageURLHTTPS,
+		InReplyToID:     t.InReplyToIDStr,
+		ReplyCount:      t.ConversationCount,
+	}
+	if createdAt, err := time.Parse(time.RFC3339, t.CreatedAt); err == nil {
+		post.CreatedAt = createdAt
+	}
+
+	// Drop the leading @mentions of replies and trailing media links
+	text := []rune(t.Text)
+	if len(t.DisplayTextRange) == 2 && 0 <= t.DisplayTextRange[0] && t.DisplayTextRange[0] <= t.DisplayTextRange[1] && t.DisplayTextRange[1] <= len(text) {
+		text = text[t.DisplayTextRange[0]:t.DisplayTextRange[1]]
+	}
+	replacements := make([]string, 0, 2*(len(t.Entities.URLs)+len(t.Entities.Media)))
+	for _, link := range t.Entities.URLs {
+		replacements = append(replacements, link.URL, link.ExpandedURL)
+	}
+	for _, media := range t.Entities.Media {
+		replacements = append(replacements, media.URL, "")
+	}
+	post.Text = strings.TrimSpace(html.UnescapeString(strings.NewReplacer(replacements...).Replace(string(text))))
+
+	for _, media := range t.MediaDetails {
+		switch media.Type {
+		case models.TwitterMediaPhoto:
+			post.Media = append(post.Media, models.TwitterMedia{Type: media.Type, URL: media.MediaURLHTTPS})
+		case models.TwitterMediaVideo, models.TwitterMediaGIF:
+			best, bitrate := "", -1
+			for _, variant := range media.VideoInfo.Variants {
+				if variant.ContentType == "video/mp4" && variant.Bitrate > bitrate {
+					best, bitrate = variant.URL, variant.Bitrate
+				}
+			}
+			if best == "" {
+				best = media.MediaURLHTTPS
+			}
+			post.Media = append(post.Media, models.TwitterMedia{Type: media.Type, URL: best, PreviewURL: media.MediaURLHTTPS})
+		}
+	}
+	return post
+}
+
+// syndicationToken computes the token the embed script sends with a post ID:
+// (id / 1e15 * π) in base 36 without zeros and the radix point.
+func syndicationToken(id uint64) string {
+	value := float64(id) / 1e15 * math.Pi
+	integer := math.Floor(value)
+
+	var b strings.Builder
+	b.WriteString(strconv.FormatUint(uint64(integer), 36))
+	fraction := value - integer
+	for i := 0; i < 12 && fraction > 0; i++ {
+		fraction *= 36
+		digit := int(fraction)
+		b.WriteByte(strconv.FormatInt(int64(digit), 36)[0])
+		fraction -= float64(digit)
+	}
+	return strings.ReplaceAll(b.String(), "0", "")
+}
+
+// TwitterFake is a TwitterFetcher serving posts from memory, for local
+// development and tests.
+type TwitterFake struct {
+	posts map[string]models.TwitterPost
+}
+
+// NewTwitterFake creates a fake backend serving posts.
+func NewTwitterFake(posts ...models.TwitterPost) *TwitterFake {
+	fake := &TwitterFake{posts: make(map[string]models.TwitterPost, len(posts))}
+	for _, post := range posts {
+		fake.posts[post.ID] = post
+	}
+	return fake
+}
+
+// LoadTwitterFixtures creates a fake backend serving the posts of the *.json
+// files in dir, each holding an array of models.TwitterPost.
+func LoadTwitterFixtures(dir string) (*TwitterFake, error) {
+	if dir == "" {
+		return nil, errors.New("TWITTER_FIXTURES_DIR is required for the fake Twitter backend")
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	fake := NewTwitterFake()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var posts []models.TwitterPost
+		if err := json.Unmarshal(data, &posts); err != nil {
+			return nil, fmt.Errorf("invalid Twitter fixture %s: %w", file, err)
+		}
+		for _, post := range posts {
+			fake.posts[post.ID] = post
+		}
+	}
+	return fake, nil
+}
+
+// FetchPost implements TwitterFetcher.
+func (f *TwitterFake) FetchPost(ctx context.Context, id string) (*models.TwitterPost, error) {
+	post, ok := f.posts[id]
+	if !ok {
+		return nil, ErrTweetNotFound
+	}
+	return &post, nil
+}
+
+// FetchReplies implements TwitterReplyFetcher.
+func (f *TwitterFake) FetchReplies(ctx context.Context, id string) ([]models.TwitterPost, error) {
+	if _, ok := f.posts[id]; !ok {
+		return nil, ErrTweetNotFound
+	}
+	var replies []models.TwitterPost
+	for _, post := range f.posts {
+		if post.InReplyToID == id {
+			replies = append(replies, post)
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+			return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+		}
+		// Post IDs grow over time
+		if len(replies[i].ID) != len(replies[j].ID) {
+			return len(replies[i].ID) < len(replies[j].ID)
+		}
+		return replies[i].ID < replies[j].ID
+	})
+	return replies, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/models"
+)
+
+func testPost(id, handle, replyTo, text string) models.TwitterPost {
+	return models.TwitterPost{ID: id, AuthorName: strings.ToUpper(handle), AuthorHandle: handle, InReplyToID: replyTo, Text: text}
+}
+
+func threadIDs(thread *models.TwitterThread) string {
+	ids := make([]string, len(thread.Posts))
+	for i, post := range thread.Posts {
+		ids[i] = post.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFetchThread(t *testing.T) {
+	fake := NewTwitterFake(
+		testPost("1", "bob", "", "question"),
+		testPost("2", "alice", "1", "first"),
+		testPost("3", "Alice", "2", "second"),
+		testPost("4", "alice", "3", "third"),
+		testPost("5", "alice", "404", "after a deleted post"),
+		testPost("6", "alice", "5", "reply"),
+	)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		id       string
+		maxPosts int
+		want     string
+	}{
+		{"last post, stops at other author", "4", 0, "2,3,4"},
+		{"first post unrolls forward", "2", 0, "2,3,4"},
+		{"middle post", "3", 0, "2,3,4"},
+		{"max posts", "4", 2, "3,4"},
+		{"max posts forward", "2", 2, "2,3"},
+		{"missing parent ends thread", "6", 0, "5,6"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			thread, err := NewTwitterService(fake, tc.maxPosts, zap.NewNop()).FetchThread(ctx, tc.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := threadIDs(thread); got != tc.want {
+				t.Fatalf("thread = %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := NewTwitterService(fake, 0, zap.NewNop()).FetchThread(ctx, "404"); !errors.Is(err, ErrTweetNotFound) {
+		t.Fatalf("missing post error = %v, want ErrTweetNotFound", err)
+	}
+}
+
+// postOnlyFetcher hides the reply listing of a backend, like syndication.
+type postOnlyFetcher struct {
+	TwitterFetcher
+}
+
+func TestFetchThreadWithoutReplies(t *testing.T) {
+	first := testPost("1", "alice", "", "first")
+	first.ReplyCount = 3
+	fetcher := postOnlyFetcher{NewTwitterFake(first, testPost("2", "alice", "1", "second"))}
+	s := NewTwitterService(fetcher, 0, zap.NewNop())
+	ctx := context.Background()
+
+	thread, err := s.FetchThread(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := threadIDs(thread); got != "1" || !thread.MayContinue {
+		t.Fatalf("thread = %s (may continue %v), want 1 marked as possibly continuing", got, thread.MayContinue)
+	}
+	if !strings.HasSuffix(FormatThread(thread, nil), threadMayContinueNote) {
+		t.Fatal("formatted thread does not say it may continue")
+	}
+
+	thread, err = s.FetchThread(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := threadIDs(thread); got != "1,2" || thread.MayContinue {
+		t.Fatalf("thread = %s (may continue %v), want complete 1,2", got, thread.MayContinue)
+	}
+}
+
+func TestIsTwitterHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"x.com":              true,
+		"www.x.com":          true,
+		"twitter.com":        true,
+		"mobile.twitter.com": true,
+		"WWW.Twitter.com":    true,
+		"dropbox.com":        false,
+		"box.com":            false,
+		"fox.com":            false,
+		"x.com.evil.io":      false,
+		"nottwitter.com":     false,
+	} {
+		if got := IsTwitterHost(host); got != want {
+			t.Errorf("IsTwitterHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	p := &InsightProcessor{}
+	if got, err := p.detectSourceType("https://www.dropbox.com/s/abc/talk.mp3"); err == nil && got == models.SourceTypeTwitter {
+		t.Fatal("dropbox.com detected as Twitter")
+	}
+	if got, err := p.detectSourceType("https://x.com/alice/status/1"); err != nil || got != models.SourceTypeTwitter {
+		t.Fatalf("x.com detected as %q, %v", got, err)
+	}
+}
+
+func TestExtractTweetID(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{"https://x.com/alice/status/1234567890", "1234567890"},
+		{"https://twitter.com/alice/status/1234567890/photo/1?s=20", "1234567890"},
+		{"https://mobile.twitter.com/i/web/status/42", "42"},
+		{"https://x.com/alice", ""},
+		{"https://x.com/alice/status/abc", ""},
+	} {
+		got, err := ExtractTweetID(tc.url)
+		if got != tc.want || (err != nil) != (tc.want == "") {
+			t.Errorf("ExtractTweetID(%q) = %q, %v, want %q", tc.url, got, err, tc.want)
+		}
+	}
+}
+
+func TestFormatThread(t *testing.T) {
+	first := testPost("1", "alice", "", "Hello")
+	first.CreatedAt = time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	first.Media = []models.TwitterMedia{{Type: models.TwitterMediaPhoto, URL: "https://pbs.twimg.com/a.jpg"}}
+	thread := &models.TwitterThread{Posts: []models.TwitterPost{first, testPost("2", "alice", "1", "World")}}
+
+	want := "[1/2] ALICE (@alice) · 2024-05-01 08:30 UTC\nHello\nphoto: https://pbs.twimg.com/a.jpg\n\n[2/2] ALICE (@alice)\nWorld"
+	if got := FormatThread(thread, nil); got != want {
+		t.Fatalf("FormatThread =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatThread(thread, []string{"你好", ""}); !strings.Contains(got, "\n你好\n") || !strings.HasSuffix(got, "\nWorld") {
+		t.Fatalf("translated thread =\n%s", got)
+	}
+}
+
+func TestTwitterSyndicationFetchPost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tweet-result" || r.URL.Query().Get("token") == "" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		switch r.URL.Query().Get("id") {
+		case "20":
+			w.Write([]byte(`{"__typename": "Tweet", "id_str": "20", "created_at": "2024-05-01T08:30:00.000Z",
+				"text": "@bob Read https://t.co/abc &amp; more https://t.co/pic", "display_text_range": [5, 54],
+				"in_reply_to_status_id_str": "19", "conversation_count": 2,
+				"user": {"name": "Alice", "screen_name": "alice", "profile_image_url_https": "https://pbs.twimg.com/avatar.jpg"},
+				"entities": {"urls": [{"url": "https://t.co/abc", "expanded_url": "https://example.com/post"}],
+					"media": [{"url": "https://t.co/pic"}]},
+				"mediaDetails": [
+					{"type": "photo", "media_url_https": "https://pbs.twimg.com/photo.jpg"},
+					{"type": "video", "media_url_https": "https://pbs.twimg.com/thumb.jpg", "video_info": {"variants": [
+						{"content_type": "application/x-mpegURL", "url": "https://video.twimg.com/v.m3u8"},
+						{"bitrate": 256000, "content_type": "video/mp4", "url": "https://video.twimg.com/low.mp4"},
+						{"bitrate": 2176000, "content_type": "video/mp4", "url": "https://video.twimg.com/high.mp4"}]}}]}`))
+		case "21":
+			w.Write([]byte(`{"__typename": "TweetTombstone", "tombstone": {}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewTwitterSyndication(server.URL, time.Second)
+	ctx := context.Background()
+
+	post, err := fetcher.FetchPost(ctx, "20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.AuthorHandle != "alice" || post.AuthorName != "Alice" || post.InReplyToID != "19" || post.ReplyCount != 2 {
+		t.Fatalf("post = %+v", post)
+	}
+	if post.Text != "Read https://example.com/post & more" {
+		t.Fatalf("text = %q", post.Text)
+	}
+	if !post.CreatedAt.Equal(time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("created at = %v", post.CreatedAt)
+	}
+	if len(post.Media) != 2 || post.Media[0].URL != "https://pbs.twimg.com/photo.jpg" ||
+		post.Media[1].URL != "https://video.twimg.com/high.mp4" || post.Media[1].PreviewURL != "https://pbs.twimg.com/thumb.jpg" {
+		t.Fatalf("media = %+v", post.Media)
+	}
+
+	for _, id := range []string{"21", "22", "not-an-id"} {
+		if _, err := fetcher.FetchPost(ctx, id); !errors.Is(err, ErrTweetNotFound) {
+			t.Errorf("FetchPost(%s) = %v, want ErrTweetNotFound", id, err)
+		}
+	}
+}
+
+func TestLoadTwitterFixtures(t *testing.T) {
+	dir := t.TempDir()
+	fixture := `[{"id": "1", "author_handle": "alice", "text": "first"}, {"id": "2", "author_handle": "alice", "in_reply_to_id": "1", "text": "second"}]`
+	if err := os.WriteFile(filepath.Join(dir, "thread.json"), []byte(fixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher, err := NewTwitterFetcher(TwitterConfig{Backend: TwitterBackendFake, FixturesDir: dir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := NewTwitterService(fetcher, 0, zap.NewNop()).FetchThread(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := threadIDs(thread); got != "1,2" {
+		t.Fatalf("thread = %s, want 1,2", got)
+	}
+
+	if _, err := NewTwitterFetcher(TwitterConfig{Backend: "api"}, zap.NewNop()); err == nil {
+		t.Fatal("unknown backend accepted")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"vibe-backend/internal/llm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// UsageService records LLM usage per user and enforces monthly budgets.
+// It implements llm.UsageRecorder.
+type UsageService struct {
+	repo              *repository.UsageRepository
+	prices            llm.PriceTable
+	provider          string
+	monthlyTokenLimit int64   // 0 = unlimited
+	monthlyCostLimit  float64 // USD, 0 = unlimited
+	log               *zap.Logger
+}
+
+// NewUsageService creates a new UsageService.
+func NewUsageService(
+	repo *repository.UsageRepository,
+	prices llm.PriceTable,
+	provider string,
+	monthlyTokenLimit int64,
+	monthlyCostLimit float64,
+	log *zap.Logger,
+) *UsageService {
+	log.Info("✅ LLM 用量统计已初始化",
+		zap.Int("priced_models", len(prices)),
+		zap.Int64("monthly_token_budget", monthlyTokenLimit),
+		zap.Float64("monthly_cost_budget_usd", monthlyCostLimit),
+	)
+
+	return &UsageService{
+		repo:              repo,
+		prices:            prices,
+		provider:          provider,
+		monthlyTokenLimit: monthlyTokenLimit,
+		monthlyCostLimit:  monthlyCostLimit,
+		log:               log,
+	}
+}
+
+// RecordUsage implements llm.UsageRecorder. Calls are attributed to the user
+// set with llm.WithUserID; failures are logged and never fail the LLM call.
+func (s *UsageService) RecordUsage(ctx context.Context, feature, model string, usage llm.Usage) {
+	entry := &models.LLMUsage{
+		Feature:          feature,
+		Provider:         s.provider,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CostUSD:          s.prices.Cost(model, usage),
+		Estimated:        usage.Estimated,
+	}
+	if userID, ok := llm.UserIDFromContext(ctx); ok {
+		entry.UserID = &userID
+	}
+
+	// The request context may already be cancelled once a stream finishes
+	if err := s.repo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		s.log.Warn("⚠️  Failed to record LLM usage",
+			zap.String("feature", feature),
+			zap.String("model", model),
+			zap.Error(err),
+		)
+	}
+}
+
+// CheckBudget returns models.ErrLLMBudgetExceeded if the user attributed to ctx
+// has exhausted a monthly budget. Calls without a user or budget always pass.
+func (s *UsageService) CheckBudget(ctx context.Context) error {
+	if s.monthlyTokenLimit <= 0 && s.monthlyCostLimit <= 0 {
+		return nil
+	}
+	userID, ok := llm.UserIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	from, to := monthBounds(time.Now())
+	totals, err := s.repo.GetTotals(ctx, userID, from, to)
+	if err != nil {
+		// Don't block AI features because the ledger is unavailable
+		s.log.Warn("⚠️  Failed to check LLM budget", zap.Uint("user_id", userID), zap.Error(err))
+		return nil
+	}
+
+	if s.exhausted(totals) {
+		s.log.Info("ℹ️  LLM monthly budget exhausted",
+			zap.Uint("user_id", userID),
+			zap.Int64("total_tokens", totals.TotalTokens),
+			zap.Float64("cost_usd", totals.CostUSD),
+		)
+		return models.ErrLLMBudgetExceeded
+	}
+	return nil
+}
+
+// GetMonthlyUsage returns the usage of a user in the calendar month (UTC) containing month.
+func (s *UsageService) GetMonthlyUsage(ctx context.Context, userID uint, month time.Time) (*models.UsageResponse, error) {
+	from, to := monthBounds(month)
+
+	totals, err := s.repo.GetTotals(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage totals: %w", err)
+	}
+	byFeature, err := s.repo.GetBreakdown(ctx, userID, from, to, "feature")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage by feature: %w", err)
+	}
+	byModel, err := s.repo.GetBreakdown(ctx, userID, from, to, "model")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage by model: %w", err)
+	}
+
+	budget := models.UsageBudget{
+		MonthlyTokens:  s.monthlyTokenLimit,
+		MonthlyCostUSD: s.monthlyCostLimit,
+		Exhausted:      s.exhausted(totals),
+	}
+	if s.monthlyTokenLimit > 0 {
+		remaining := max(s.monthlyTokenLimit-totals.TotalTokens, 0)
+		budget.RemainingTokens = &remaining
+	}
+	if s.monthlyCostLimit > 0 {
+		remaining := max(s.monthlyCostLimit-totals.CostUSD, 0)
+		budget.RemainingCostUSD = &remaining
+	}
+
+	return &models.UsageResponse{
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Totals:      *totals,
+		Budget:      budget,
+		ByFeature:   byFeature,
+		ByModel:     byModel,
+	}, nil
+}
+
+// exhausted reports whether totals reach any configured budget.
+func (s *UsageService) exhausted(totals *models.UsageTotals) bool {
+	if s.monthlyTokenLimit > 0 && totals.TotalTokens >= s.monthlyTokenLimit {
+		return true
+	}
+	return s.monthlyCostLimit > 0 && totals.CostUSD >= s.monthlyCostLimit
+}
+
+// monthBounds returns the start of the UTC calendar month containing t and the start of the next one.
+func monthBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0)
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"vibe-backend/internal/models"
+	"vibe-backend/internal/repository"
+)
+
+// workspaceStore persists workspaces and their members (implemented by repository.WorkspaceRepository).
+type workspaceStore interface {
+	Create(ctx context.Context, workspace *models.Workspace) error
+	GetByID(ctx context.Context, id uint) (*models.Workspace, error)
+	Update(ctx context.Context, workspace *models.Workspace) error
+	Delete(ctx context.Context, id uint) error
+	ListMembershipsByUser(ctx context.Context, userID uint) ([]models.WorkspaceMember, error)
+	GetMember(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error)
+	AddMember(ctx context.Context, member *models.WorkspaceMember) (bool, error)
+	UpdateMemberRole(ctx context.Context, workspaceID, userID uint, role models.WorkspaceRole) error
+	RemoveMember(ctx context.Context, workspaceID, userID uint) error
+}
+
+// workspaceInsightStore lists and moves the insights of workspaces (implemented by repository.InsightRepository).
+type workspaceInsightStore interface {
+	GetByIDForMember(ctx context.Context, id, userID uint, withHighlights bool) (*models.Insight, models.WorkspaceRole, error)
+	GetByWorkspaceIDGroupedByDate(ctx context.Context, workspaceID uint, search string, limit int) (*models.InsightListResponse, error)
+	SetWorkspace(ctx context.Context, id uint, workspaceID *uint) error
+}
+
+// WorkspaceService manages workspaces, their members and which insights they share.
+//
+// Every member sees the insights of a workspace with their highlights and
+// chats. Editors also add highlights and chat messages; the owner manages
+// members and may delete, share or remove any insight of the workspace.
+// Users who are not members are told the workspace does not exist.
+type WorkspaceService struct {
+	repo     workspaceStore
+	insights workspaceInsightStore
+	userRepo *repository.UserRepository
+	log      *zap.Logger
+}
+
+// NewWorkspaceService creates a new WorkspaceService.
+func NewWorkspaceService(repo *repository.WorkspaceRepository, insights *repository.InsightRepository, userRepo *repository.UserRepository, log *zap.Logger) *WorkspaceService {
+	return &WorkspaceService{
+		repo:     repo,
+		insights: insights,
+		userRepo: userRepo,
+		log:      log,
+	}
+}
+
+// workspaceResponse converts a workspace to its API representation for a member with role.
+func workspaceResponse(workspace *models.Workspace, role models.WorkspaceRole) models.WorkspaceResponse {
+	return models.WorkspaceResponse{
+		ID:          workspace.ID,
+		Name:        workspace.Name,
+		Description: workspace.Description,
+		OwnerID:     workspace.OwnerID,
+		Role:        role,
+		CreatedAt:   workspace.CreatedAt,
+	}
+}
+
+// membership returns the workspace and the membership of userID in it.
+// Returns models.ErrWorkspaceNotFound if the workspace does not exist or the user is not a member.
+func (s *WorkspaceService) membership(ctx context.Context, workspaceID, userID uint) (*models.Workspace, *models.WorkspaceMember, error) {
+	member, err := s.repo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrWorkspaceNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	workspace, err := s.repo.GetByID(ctx, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrWorkspaceNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return workspace, member, nil
+}
+
+// managed returns the workspace if userID is its owner.
+func (s *WorkspaceService) managed(ctx context.Context, workspaceID, userID uint) (*models.Workspace, error) {
+	workspace, member, err := s.membership(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Role.CanManage() {
+		return nil, models.ErrWorkspaceForbidden
+	}
+	return workspace, nil
+}
+
+// Create creates a workspace owned by userID.
+func (s *WorkspaceService) Create(ctx context.Context, userID uint, req *models.CreateWorkspaceRequest) (*models.WorkspaceResponse, error) {
+	workspace := &models.Workspace{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		OwnerID:     userID,
+	}
+	if err := s.repo.Create(ctx, workspace); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	response := workspaceResponse(workspace, models.WorkspaceRoleOwner)
+	return &response, nil
+}
+
+// List returns the workspaces userID is a member of.
+func (s *WorkspaceService) List(ctx context.Context, userID uint) ([]models.WorkspaceResponse, error) {
+	members, err := s.repo.ListMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	response := make([]models.WorkspaceResponse, 0, len(members))
+	for _, m := range members {
+		if m.Workspace == nil {
+			continue
+		}
+		response = append(response, workspaceResponse(m.Workspace, m.Role))
+	}
+	return response, nil
+}
+
+// Get returns a workspace userID is a member of.
+func (s *WorkspaceService) Get(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceResponse, error) {
+	workspace, member, err := s.membership(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := workspaceResponse(workspace, member.Role)
+	return &response, nil
+}
+
+// Update renames or describes a workspace. Only the owner may update it.
+func (s *WorkspaceService) Update(ctx context.Context, workspaceID, userID uint, req *models.UpdateWorkspaceRequest) (*models.WorkspaceResponse, error) {
+	workspace, err := s.managed(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		workspace.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		workspace.Description = *req.Description
+	}
+	if err := s.repo.Update(ctx, workspace); err != nil {
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+	response := workspaceResponse(workspace, models.WorkspaceRoleOwner)
+	return &response, nil
+}
+
+// Delete deletes a workspace. Its insights return to their creators. Only the owner may delete it.
+func (s *WorkspaceService) Delete(ctx context.Context, workspaceID, userID uint) error {
+	if _, err := s.managed(ctx, workspaceID, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, workspaceID); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	s.log.Info("Workspace deleted", zap.Uint("workspace_id", workspaceID), zap.Uint("user_id", userID))
+	return nil
+}
+
+// ListMembers returns the members of a workspace userID is a member of.
+func (s *WorkspaceService) ListMembers(ctx context.Context, workspaceID, userID uint) ([]models.WorkspaceMemberResponse, error) {
+	if _, _, err := s.membership(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+	response := make([]models.WorkspaceMemberResponse, 0, len(members))
+	for _, m := range members {
+		if m.User == nil {
+			continue
+		}
+		response = append(response, models.WorkspaceMemberResponse{
+			UserID:   m.UserID,
+			Name:     m.User.Name,
+			Email:    m.User.Email,
+			Role:     m.Role,
+			JoinedAt: m.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// AddMember adds the registered user with the given email to a workspace.
+// Only the owner may add members.
+func (s *WorkspaceService) AddMember(ctx context.Context, workspaceID, userID uint, req *models.AddWorkspaceMemberRequest) (*models.WorkspaceMemberResponse, error) {
+	if _, err := s.managed(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	member := &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Role:        req.Role,
+	}
+	added, err := s.repo.AddMember(ctx, member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add workspace member: %w", err)
+	}
+	if !added {
+		return nil, models.ErrMemberExists
+	}
+
+	return &models.WorkspaceMemberResponse{
+		UserID:   user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}, nil
+}
+
+// UpdateMember changes the role of a member. Only the owner may change roles,
+// and the owner's own role cannot be changed.
+func (s *WorkspaceService) UpdateMember(ctx context.Context, workspaceID, userID, memberID uint, role models.WorkspaceRole) error {
+	workspace, err := s.managed(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID == workspace.OwnerID {
+		return models.ErrOwnerMembership
+	}
+	if _, err := s.member(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateMemberRole(ctx, workspaceID, memberID, role); err != nil {
+		return fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember removes a member from a workspace. The owner may remove any
+// other member and members may leave; the owner cannot leave. Insights the
+// member added return to their personal library.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, workspaceID, userID, memberID uint) error {
+	workspace, member, err := s.membership(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !member.Role.CanManage() {
+		return models.ErrWorkspaceForbidden
+	}
+	if memberID == workspace.OwnerID {
+		return models.ErrOwnerMembership
+	}
+	if _, err := s.member(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveMember(ctx, workspaceID, memberID); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	return nil
+}
+
+// member returns the membership of memberID, or models.ErrMemberNotFound.
+func (s *WorkspaceService) member(ctx context.Context, workspaceID, memberID uint) (*models.WorkspaceMember, error) {
+	member, err := s.repo.GetMember(ctx, workspaceID, memberID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return member, nil
+}
+
+// ListInsights returns the insights of a workspace grouped by date, for any member.
+func (s *WorkspaceService) ListInsights(ctx context.Context, workspaceID, userID uint, search string, limit int) (*models.InsightListResponse, error) {
+	if _, _, err := s.membership(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+	return s.insights.GetByWorkspaceIDGroupedByDate(ctx, workspaceID, search, limit)
+}
+
+// MoveInsight moves an insight into a workspace, or out of its workspace when
+// workspaceID is nil, and returns the moved insight.
+//
+// Only the creator of an insight may move it into a workspace, and only into
+// one they can edit. The creator and the workspace owner may take it out again.
+func (s *WorkspaceService) MoveInsight(ctx context.Context, insightID, userID uint, workspaceID *uint) (*models.Insight, error) {
+	insight, role, err := s.insights.GetByIDForMember(ctx, insightID, userID, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChatInsightNotFound
+		}
+		return nil, fmt.Errorf("failed to get insight: %w", err)
+	}
+
+	if workspaceID == nil {
+		if insight.UserID != userID && !role.CanManage() {
+			return nil, models.ErrInsightForbidden
+		}
+	} else {
+		if insight.UserID != userID {
+			return nil, models.ErrInsightForbidden
+		}
+		_, member, err := s.membership(ctx, *workspaceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !member.Role.CanEdit() {
+			return nil, models.ErrWorkspaceForbidden
+		}
+	}
+
+	if err := s.insights.SetWorkspace(ctx, insight.ID, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to move insight: %w", err)
+	}
+	insight.WorkspaceID = workspaceID
+	return insight, nil
+}
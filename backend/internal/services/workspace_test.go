@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vibe-backend/internal/models"
+)
+
+// memoryWorkspaceStore is an in-memory workspaceStore and workspaceInsightStore.
+type memoryWorkspaceStore struct {
+	workspaces map[uint]*models.Workspace
+	members    []models.WorkspaceMember
+	insights   map[uint]*models.Insight
+}
+
+func newMemoryWorkspaceStore() *memoryWorkspaceStore {
+	return &memoryWorkspaceStore{
+		workspaces: make(map[uint]*models.Workspace),
+		insights:   make(map[uint]*models.Insight),
+	}
+}
+
+func (m *memoryWorkspaceStore) Create(ctx context.Context, workspace *models.Workspace) error {
+	workspace.ID = uint(len(m.workspaces) + 1)
+	m.workspaces[workspace.ID] = workspace
+	m.members = append(m.members, models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: workspace.OwnerID, Role: models.WorkspaceRoleOwner})
+	return nil
+}
+
+func (m *memoryWorkspaceStore) GetByID(ctx context.Context, id uint) (*models.Workspace, error) {
+	if workspace, ok := m.workspaces[id]; ok {
+		return workspace, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryWorkspaceStore) Update(ctx context.Context, workspace *models.Workspace) error {
+	m.workspaces[workspace.ID] = workspace
+	return nil
+}
+
+func (m *memoryWorkspaceStore) Delete(ctx context.Context, id uint) error {
+	delete(m.workspaces, id)
+	return nil
+}
+
+func (m *memoryWorkspaceStore) ListMembershipsByUser(ctx context.Context, userID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	for _, member := range m.members {
+		if member.UserID == userID {
+			member.Workspace = m.workspaces[member.WorkspaceID]
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *memoryWorkspaceStore) GetMember(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	for i := range m.members {
+		if m.members[i].WorkspaceID == workspaceID && m.members[i].UserID == userID {
+			member := m.members[i]
+			return &member, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryWorkspaceStore) ListMembers(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	for _, member := range m.members {
+		if member.WorkspaceID == workspaceID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *memoryWorkspaceStore) AddMember(ctx context.Context, member *models.WorkspaceMember) (bool, error) {
+	if _, err := m.GetMember(ctx, member.WorkspaceID, member.UserID); err == nil {
+		return false, nil
+	}
+	m.members = append(m.members, *member)
+	return true, nil
+}
+
+func (m *memoryWorkspaceStore) UpdateMemberRole(ctx context.Context, workspaceID, userID uint, role models.WorkspaceRole) error {
+	for i := range m.members {
+		if m.members[i].WorkspaceID == workspaceID && m.members[i].UserID == userID {
+			m.members[i].Role = role
+		}
+	}
+	return nil
+}
+
+func (m *memoryWorkspaceStore) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	kept := m.members[:0]
+	for _, member := range m.members {
+		if member.WorkspaceID != workspaceID || member.UserID != userID {
+			kept = append(kept, member)
+		}
+	}
+	m.members = kept
+	return nil
+}
+
+func (m *memoryWorkspaceStore) GetByIDForMember(ctx context.Context, id, userID uint, withHighlights bool) (*models.Insight, models.WorkspaceRole, error) {
+	insight, ok := m.insights[id]
+	if !ok {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+	if insight.UserID == userID {
+		return insight, models.WorkspaceRoleOwner, nil
+	}
+	if insight.WorkspaceID == nil {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+	member, err := m.GetMember(ctx, *insight.WorkspaceID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	return insight, member.Role, nil
+}
+
+func (m *memoryWorkspaceStore) GetByWorkspaceIDGroupedByDate(ctx context.Context, workspaceID uint, search string, limit int) (*models.InsightListResponse, error) {
+	return &models.InsightListResponse{}, nil
+}
+
+func (m *memoryWorkspaceStore) SetWorkspace(ctx context.Context, id uint, workspaceID *uint) error {
+	m.insights[id].WorkspaceID = workspaceID
+	return nil
+}
+
+// newTestWorkspace returns a service with workspace 1 owned by user 1, where
+// user 2 is an editor and user 3 a viewer.
+func newTestWorkspace(t *testing.T) (*WorkspaceService, *memoryWorkspaceStore) {
+	t.Helper()
+	store := newMemoryWorkspaceStore()
+	s := &WorkspaceService{repo: store, insights: store, log: zap.NewNop()}
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, 1, &models.CreateWorkspaceRequest{Name: "Research"}); err != nil {
+		t.Fatal(err)
+	}
+	store.members = append(store.members,
+		models.WorkspaceMember{WorkspaceID: 1, UserID: 2, Role: models.WorkspaceRoleEditor},
+		models.WorkspaceMember{WorkspaceID: 1, UserID: 3, Role: models.WorkspaceRoleViewer},
+	)
+	return s, store
+}
+
+func TestMoveInsightIntoWorkspace(t *testing.T) {
+	s, store := newTestWorkspace(t)
+	ctx := context.Background()
+	workspaceID := uint(1)
+	store.insights[10] = &models.Insight{ID: 10, UserID: 2}
+	store.insights[11] = &models.Insight{ID: 11, UserID: 3}
+
+	if _, err := s.MoveInsight(ctx, 10, 1, &workspaceID); !errors.Is(err, models.ErrChatInsightNotFound) {
+		t.Fatalf("moving another user's insight = %v, want not found", err)
+	}
+	if _, err := s.MoveInsight(ctx, 11, 3, &workspaceID); !errors.Is(err, models.ErrWorkspaceForbidden) {
+		t.Fatalf("viewer moving into workspace = %v, want forbidden", err)
+	}
+	if _, err := s.MoveInsight(ctx, 10, 2, &workspaceID); err != nil {
+		t.Fatalf("editor moving own insight = %v", err)
+	}
+
+	// Now shared: members see it with their role, but only the creator moves it elsewhere
+	if _, role, err := store.GetByIDForMember(ctx, 10, 3, false); err != nil || role != models.WorkspaceRoleViewer {
+		t.Fatalf("viewer access = %v, %v", role, err)
+	}
+	if _, err := s.MoveInsight(ctx, 10, 1, &workspaceID); !errors.Is(err, models.ErrInsightForbidden) {
+		t.Fatalf("owner moving a member's insight = %v, want forbidden", err)
+	}
+}
+
+func TestMoveInsightOutOfWorkspace(t *testing.T) {
+	s, store := newTestWorkspace(t)
+	ctx := context.Background()
+	workspaceID := uint(1)
+	store.insights[10] = &models.Insight{ID: 10, UserID: 2, WorkspaceID: &workspaceID}
+
+	if _, err := s.MoveInsight(ctx, 10, 3, nil); !errors.Is(err, models.ErrInsightForbidden) {
+		t.Fatalf("viewer removing insight = %v, want forbidden", err)
+	}
+	insight, err := s.MoveInsight(ctx, 10, 1, nil)
+	if err != nil {
+		t.Fatalf("workspace owner removing insight = %v", err)
+	}
+	if insight.WorkspaceID != nil {
+		t.Fatal("insight still in workspace")
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	s, _ := newTestWorkspace(t)
+	ctx := context.Background()
+
+	if err := s.RemoveMember(ctx, 1, 2, 3); !errors.Is(err, models.ErrWorkspaceForbidden) {
+		t.Fatalf("editor removing another member = %v, want forbidden", err)
+	}
+	if err := s.RemoveMember(ctx, 1, 1, 1); !errors.Is(err, models.ErrOwnerMembership) {
+		t.Fatalf("owner leaving = %v, want owner membership error", err)
+	}
+	if err := s.RemoveMember(ctx, 1, 3, 3); err != nil {
+		t.Fatalf("viewer leaving = %v", err)
+	}
+	if _, err := s.Get(ctx, 1, 3); !errors.Is(err, models.ErrWorkspaceNotFound) {
+		t.Fatalf("former member reading workspace = %v, want not found", err)
+	}
+	if err := s.UpdateMember(ctx, 1, 1, 1, models.WorkspaceRoleViewer); !errors.Is(err, models.ErrOwnerMembership) {
+		t.Fatalf("demoting the owner = %v, want owner membership error", err)
+	}
+}
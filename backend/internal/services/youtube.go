@@ -19,20 +19,21 @@ import (
 
 	"go.uber.org/zap"
 
+	"vibe-backend/internal/llm"
 	"vibe-backend/internal/models"
 )
 
-// YouTubeService handles YouTube video operations using OpenRouter and Gemini.
+// YouTubeService handles YouTube video operations using the LLM client and Gemini.
 type YouTubeService struct {
-	openRouterAPIKey string
-	youtubeAPIKey    string // YouTube Data API v3 key
-	geminiModel      string
-	httpClient       *http.Client
-	log              *zap.Logger
+	llm           *llm.Client
+	youtubeAPIKey string // YouTube Data API v3 key
+	geminiModel   string
+	httpClient    *http.Client
+	log           *zap.Logger
 }
 
 // NewYouTubeService creates a new YouTubeService.
-func NewYouTubeService(apiKey string, geminiModel string, log *zap.Logger) *YouTubeService {
+func NewYouTubeService(client *llm.Client, geminiModel string, log *zap.Logger) *YouTubeService {
 	// Use default model if not provided
 	if geminiModel == "" {
 		geminiModel = "google/gemini-3-flash-preview"
@@ -41,24 +42,15 @@ func NewYouTubeService(apiKey string, geminiModel string, log *zap.Logger) *YouT
 	// Get YouTube API key from environment
 	youtubeAPIKey := os.Getenv("YOUTUBE_API_KEY")
 
-	// Validate API key on service initialization
-	if apiKey == "" {
-		log.Warn("⚠️  OpenRouter API 密钥未设置（YouTube服务）",
-			zap.String("环境变量", "OPENROUTER_API_KEY"),
-			zap.String("影响功能", "视频分析功能将无法使用"),
-		)
-	} else {
-		maskedKey := apiKey[:10] + "..." + apiKey[len(apiKey)-4:]
-		log.Info("✅ YouTube分析服务已初始化",
-			zap.String("model", geminiModel),
-			zap.String("api_key", maskedKey),
-		)
-	}
+	log.Info("✅ YouTube分析服务已初始化",
+		zap.String("provider", client.ProviderName()),
+		zap.String("model", geminiModel),
+	)
 
 	return &YouTubeService{
-		openRouterAPIKey: apiKey,
-		youtubeAPIKey:    youtubeAPIKey,
-		geminiModel:      geminiModel,
+		llm:           client,
+		youtubeAPIKey: youtubeAPIKey,
+		geminiModel:   geminiModel,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
@@ -1808,88 +1800,13 @@ type VideoMetadataWithAI struct {
 	} `json:"metadata"`
 }
 
-// callGemini calls the Gemini API via OpenRouter.
+// callGemini calls the Gemini model through the LLM client.
 func (s *YouTubeService) callGemini(ctx context.Context, prompt string) (string, error) {
-	// Check if API key is configured
-	if s.openRouterAPIKey == "" {
-		return "", fmt.Errorf("OPENROUTER_API_KEY environment variable is not set. Please configure it to use video analysis features")
-	}
-
-	const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
-
-	requestBody := map[string]interface{}{
-		"model": s.geminiModel,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openRouterAPIKey)
-	req.Header.Set("HTTP-Referer", "https://github.com/your-repo/vibe-engineering-playbook")
-	req.Header.Set("X-Title", "VIBE Engineering Playbook")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call OpenRouter API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	response, err := s.llm.CompleteText(ctx, llm.UserPrompt(llm.FeatureVideo, s.geminiModel, prompt))
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// Special handling for 401 authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			s.log.Error("❌ OpenRouter API 认证失败 - API密钥无效 (YouTube服务)",
-				zap.Int("status_code", resp.StatusCode),
-				zap.String("response_body", string(body)),
-				zap.String("error_type", "AUTHENTICATION_FAILED"),
-				zap.String("解决方案", "请检查 OPENROUTER_API_KEY 环境变量，访问 https://openrouter.ai/ 获取有效密钥"),
-			)
-			return "", fmt.Errorf("OpenRouter API 认证失败（401）: %s - 请检查 OPENROUTER_API_KEY 是否有效", string(body))
-		}
-		
-		s.log.Error("OpenRouter API error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("response", string(body)),
-		)
-		return "", fmt.Errorf("OpenRouter API returned status %d: %s", resp.StatusCode, string(body))
+		return "", err
 	}
-
-	// Parse response
-	var apiResponse struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return "", fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if len(apiResponse.Choices) == 0 {
-		return "", errors.New("no response from Gemini")
-	}
-
-	return apiResponse.Choices[0].Message.Content, nil
+	return response, nil
 }
 
 // TimestampToSeconds converts a timestamp string (MM:SS) to seconds.
@@ -126,8 +126,9 @@ func (s *YouTubeAPIService) GetVideoMetadata(ctx context.Context, input string)
 	return result, nil
 }
 
-// GetPlaylist fetches playlist items with caching.
-func (s *YouTubeAPIService) GetPlaylist(ctx context.Context, playlistID string, token *oauth2.Token) (*models.YouTubePlaylistResponse, error) {
+// GetPlaylist fetches playlist items with caching. tokenSource, if not nil,
+// authorizes access to private playlists.
+func (s *YouTubeAPIService) GetPlaylist(ctx context.Context, playlistID string, tokenSource oauth2.TokenSource) (*models.YouTubePlaylistResponse, error) {
 	// Check cache first (if cache is available)
 	cacheKey := fmt.Sprintf("youtube:playlist:%s", playlistID)
 	if s.cache != nil {
@@ -145,8 +146,8 @@ func (s *YouTubeAPIService) GetPlaylist(ctx context.Context, playlistID string,
 	// Create YouTube service with OAuth token if provided
 	var service *youtube.Service
 	var err error
-	if token != nil {
-		client := s.oauthService.config.Client(ctx, token)
+	if tokenSource != nil {
+		client := oauth2.NewClient(ctx, tokenSource)
 		service, err = youtube.NewService(ctx, option.WithHTTPClient(client))
 	} else {
 		service, err = youtube.NewService(ctx, option.WithAPIKey(s.apiKey))
@@ -193,8 +194,9 @@ func (s *YouTubeAPIService) GetPlaylist(ctx context.Context, playlistID string,
 	return result, nil
 }
 
-// GetCaptions fetches caption tracks for a video.
-func (s *YouTubeAPIService) GetCaptions(ctx context.Context, videoID string, token *oauth2.Token) (*models.YouTubeCaptionsResponse, error) {
+// GetCaptions fetches caption tracks for a video. The Captions API requires
+// OAuth, so tokenSource must not be nil.
+func (s *YouTubeAPIService) GetCaptions(ctx context.Context, videoID string, tokenSource oauth2.TokenSource) (*models.YouTubeCaptionsResponse, error) {
 	// Check cache first (if cache is available)
 	cacheKey := fmt.Sprintf("youtube:captions:%s", videoID)
 	if s.cache != nil {
@@ -209,19 +211,15 @@ func (s *YouTubeAPIService) GetCaptions(ctx context.Context, videoID string, tok
 	}
 
 	// Captions API requires OAuth
-	if token == nil || token.AccessToken == "" {
+	if tokenSource == nil {
 		return nil, fmt.Errorf("UNAUTHORIZED: OAuth authorization required to access captions")
 	}
 
 	s.log.Debug("Creating YouTube service with OAuth token",
 		zap.String("video_id", videoID),
-		zap.Bool("has_token", token.AccessToken != ""),
 	)
 
-	// Create YouTube service with OAuth token
-	// Use oauth2.StaticTokenSource to create a client that just uses the access token
-	// This works even without GOOGLE_CLIENT_ID/SECRET configured
-	tokenSource := oauth2.StaticTokenSource(token)
+	// Create YouTube service with the user's OAuth token (refreshed server-side)
 	client := oauth2.NewClient(ctx, tokenSource)
 	service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
@@ -16,6 +16,8 @@ export default function ClearAuthPage() {
   const handleClear = () => {
     // Clear all auth-related data
     localStorage.removeItem('auth_token');
+    localStorage.removeItem('auth_refresh_token');
+    localStorage.removeItem('auth_token_expiry');
     localStorage.removeItem('user_info');
     localStorage.removeItem('google_oauth_token');
     localStorage.removeItem('google_access_token');
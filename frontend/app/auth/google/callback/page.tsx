@@ -5,6 +5,7 @@ import { useRouter, useSearchParams } from 'next/navigation';
 import { Loader2, CheckCircle2, XCircle } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { apiClient } from "@/lib/api/client";
+import { ApiError } from "@/lib/api/types";
 import { toast } from "@/lib/utils/toast";
 
 export default function GoogleCallbackPage() {
@@ -39,41 +40,47 @@ export default function GoogleCallbackPage() {
         }
 
         // Exchange code for token
+        // The Google token stays on the server; only system session tokens are returned
         const response = await apiClient.post<{
-          accessToken: string;
-          refreshToken: string;
-          tokenType: string;
-          expiry: string;
-          tokenJSON: string;
           user?: {
             id: number;
             email: string;
             name: string;
             created_at: string;
           };
-          apiKey?: string;
+          session?: {
+            access_token: string;
+            refresh_token: string;
+            token_type: string;
+            expires_in: number;
+          };
+          google?: {
+            linked: boolean;
+            email?: string;
+          };
         }>('/v1/auth/google/callback', {
           code,
           state: searchParams.get('state'),
-        });
+        }, { credentials: 'include' });
 
-        // Clear any old auth data first to avoid format conflicts
+        // Clear any old auth data first (including Google tokens kept by older versions)
         localStorage.removeItem('auth_token');
+        localStorage.removeItem('auth_refresh_token');
+        localStorage.removeItem('auth_token_expiry');
         localStorage.removeItem('user_info');
         localStorage.removeItem('google_oauth_token');
         localStorage.removeItem('google_access_token');
         localStorage.removeItem('google_refresh_token');
         localStorage.removeItem('google_token_expiry');
 
-        // Store Google OAuth token (for YouTube API access)
-        localStorage.setItem('google_oauth_token', response.tokenJSON);
-        localStorage.setItem('google_access_token', response.accessToken);
-        localStorage.setItem('google_refresh_token', response.refreshToken);
-        localStorage.setItem('google_token_expiry', response.expiry);
-
-        // Store system API key and user info (for backend API authentication)
-        if (response.apiKey) {
-          localStorage.setItem('auth_token', response.apiKey);
+        // Store system session tokens and user info (for backend API authentication)
+        if (response.session) {
+          localStorage.setItem('auth_token', response.session.access_token);
+          localStorage.setItem('auth_refresh_token', response.session.refresh_token);
+          localStorage.setItem(
+            'auth_token_expiry',
+            new Date(Date.now() + response.session.expires_in * 1000).toISOString()
+          );
         }
         if (response.user) {
           localStorage.setItem('user_info', JSON.stringify(response.user));
@@ -86,7 +93,8 @@ export default function GoogleCallbackPage() {
       } catch (error) {
         console.error('OAuth callback error:', error);
         setStatus('error');
-        setMessage('Failed to complete authorization');
+        // e.g. the email belongs to a password account that must link Google explicitly
+        setMessage(error instanceof ApiError && error.message ? error.message : 'Failed to complete authorization');
         toast.error('Authorization failed');
         setTimeout(() => router.push('/auth'), 3000);
       }
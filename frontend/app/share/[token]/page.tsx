@@ -24,6 +24,9 @@ import {
 import { toast } from "sonner";
 import { cn } from "@/lib/utils";
 
+// 受密码保护的分享的访问令牌，按分享 token 存于 sessionStorage
+const shareAccessKey = (token: string) => `share_access:${token}`;
+
 interface SharePageProps {
   params: Promise<{ token: string }>;
 }
@@ -48,17 +51,20 @@ export default function SharePage({ params }: SharePageProps) {
     params.then((p) => setToken(p.token));
   }, [params]);
 
-  const loadSharedInsight = async (token: string, pwd?: string) => {
+  const loadSharedInsight = async (token: string) => {
     try {
       setLoading(true);
       setError(null);
-      const response = await insightApi.getSharedInsight(token, pwd);
+      const accessToken =
+        sessionStorage.getItem(shareAccessKey(token)) ?? undefined;
+      const response = await insightApi.getSharedInsight(token, accessToken);
       setInsight(response);
       setRequiresPassword(false);
     } catch (err: any) {
       console.error("Failed to load shared insight:", err);
 
       if (err.status === 401 && err.data?.requires_auth) {
+        sessionStorage.removeItem(shareAccessKey(token));
         setRequiresPassword(true);
         setError("请输入访问密码");
       } else if (err.status === 404) {
@@ -88,7 +94,22 @@ export default function SharePage({ params }: SharePageProps) {
     if (!token) return;
 
     setPasswordLoading(true);
-    await loadSharedInsight(token, password);
+    try {
+      // 密码只提交一次，换取短期访问令牌
+      const access = await insightApi.unlockSharedInsight(token, password);
+      sessionStorage.setItem(shareAccessKey(token), access.access_token);
+    } catch (err: any) {
+      setPasswordLoading(false);
+      if (err.status === 429) {
+        toast.error("密码错误次数过多，请稍后再试");
+      } else if (err.status === 401) {
+        toast.error("密码错误");
+      } else {
+        toast.error(err.message || "验证密码失败");
+      }
+      return;
+    }
+    await loadSharedInsight(token);
   };
 
   if (loading) {
@@ -232,6 +253,52 @@ export default function SharePage({ params }: SharePageProps) {
       )}
 
       <div className="grid gap-8">
+        {/* Clip */}
+        {insight.content.clip && (
+          <Card>
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2">
+                <div className="w-6 h-6 bg-red-500 rounded flex items-center justify-center">
+                  <Youtube className="w-4 h-4 text-white" />
+                </div>
+                片段 {insight.content.clip.start_timestamp} – {insight.content.clip.end_timestamp}
+              </CardTitle>
+            </CardHeader>
+            <CardContent className="space-y-4">
+              {insight.content.clip.comment && (
+                <p className="text-gray-700 italic">{insight.content.clip.comment}</p>
+              )}
+              <div className="space-y-3 border-l-4 border-red-300 pl-4">
+                {insight.content.clip.lines.map((line) => (
+                  <div key={line.seconds} className="flex gap-3">
+                    <span className="flex-shrink-0 text-sm font-mono text-red-600">
+                      {line.timestamp}
+                    </span>
+                    <div>
+                      <p className="text-gray-800">{line.text}</p>
+                      {line.translated_text && (
+                        <p className="text-gray-500">{line.translated_text}</p>
+                      )}
+                    </div>
+                  </div>
+                ))}
+              </div>
+              {insight.content.clip.url && (
+                <Button variant="outline" asChild>
+                  <a
+                    href={insight.content.clip.url}
+                    target="_blank"
+                    rel="noopener noreferrer"
+                  >
+                    <ExternalLink className="w-4 h-4 mr-2" />
+                    在 YouTube 上从 {insight.content.clip.start_timestamp} 开始观看
+                  </a>
+                </Button>
+              )}
+            </CardContent>
+          </Card>
+        )}
+
         {/* Summary */}
         {insight.content.summary && (
           <Card>
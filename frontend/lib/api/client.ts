@@ -24,70 +24,70 @@ function buildQueryString(
 }
 
 /**
- * 检查 Google OAuth token 是否过期
+ * 检查系统 access token 是否即将过期
  */
-function isGoogleTokenExpired(): boolean {
-  // 检查是否在浏览器环境中
+function isSessionTokenExpired(): boolean {
   if (typeof window === "undefined") return false;
-  
-  const expiry = localStorage.getItem("google_token_expiry");
+
+  const expiry = localStorage.getItem("auth_token_expiry");
   if (!expiry) return false;
 
-  try {
-    const expiryDate = new Date(expiry);
-    const now = new Date();
-    // 提前 5 分钟刷新 token，避免在请求过程中过期
-    const bufferTime = 5 * 60 * 1000; // 5 minutes in milliseconds
-    return expiryDate.getTime() - now.getTime() < bufferTime;
-  } catch {
-    return false;
-  }
+  const expiryTime = new Date(expiry).getTime();
+  if (Number.isNaN(expiryTime)) return false;
+  // 提前 30 秒刷新，避免在请求过程中过期
+  return expiryTime - Date.now() < 30 * 1000;
 }
 
+// 进行中的会话刷新，并发请求共享同一次刷新
+let sessionRefresh: Promise<boolean> | null = null;
+
 /**
- * 刷新 Google OAuth token
- * @returns 是否成功刷新 token，如果没有 refresh token 则返回 false
+ * 使用 refresh token 刷新系统会话
+ * refresh token 只能使用一次，每次刷新都会返回新的 refresh token
+ * @returns 是否成功刷新，如果没有 refresh token 则返回 false
  */
-async function refreshGoogleToken(): Promise<boolean> {
-  // 检查是否在浏览器环境中
+async function refreshSessionToken(): Promise<boolean> {
   if (typeof window === "undefined") return false;
-  
-  const refreshToken = localStorage.getItem("google_refresh_token");
-  if (!refreshToken) {
-    // 没有 refresh token，静默返回 false，不抛出错误
-    return false;
-  }
 
-  try {
-    const response = await fetch(`${API_BASE_PATH}/v1/auth/google/refresh`, {
-      method: "POST",
-      headers: {
-        "Content-Type": "application/json",
-      },
-      body: JSON.stringify({ refreshToken }),
-    });
-
-    if (!response.ok) {
-      throw new Error("Failed to refresh token");
-    }
+  const refreshToken = localStorage.getItem("auth_refresh_token");
+  if (!refreshToken) return false;
+
+  // 并发请求共享同一次刷新，避免重复使用同一个 refresh token 导致会话被撤销
+  if (!sessionRefresh) {
+    sessionRefresh = (async () => {
+      try {
+        const response = await fetch(`${API_BASE_PATH}/v1/auth/refresh`, {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        });
+
+        if (!response.ok) {
+          throw new Error("Failed to refresh session");
+        }
 
-    const data = await response.json();
-
-    // 更新存储的 token
-    localStorage.setItem("google_oauth_token", data.tokenJSON);
-    localStorage.setItem("google_access_token", data.accessToken);
-    localStorage.setItem("google_refresh_token", data.refreshToken);
-    localStorage.setItem("google_token_expiry", data.expiry);
-    return true;
-  } catch (error) {
-    console.error("Token refresh failed:", error);
-    // 清除过期的 token
-    localStorage.removeItem("google_oauth_token");
-    localStorage.removeItem("google_access_token");
-    localStorage.removeItem("google_refresh_token");
-    localStorage.removeItem("google_token_expiry");
-    return false;
+        const data = await response.json();
+        localStorage.setItem("auth_token", data.access_token);
+        localStorage.setItem("auth_refresh_token", data.refresh_token);
+        localStorage.setItem(
+          "auth_token_expiry",
+          new Date(Date.now() + data.expires_in * 1000).toISOString()
+        );
+        return true;
+      } catch (error) {
+        console.error("Session refresh failed:", error);
+        localStorage.removeItem("auth_token");
+        localStorage.removeItem("auth_refresh_token");
+        localStorage.removeItem("auth_token_expiry");
+        return false;
+      } finally {
+        sessionRefresh = null;
+      }
+    })();
   }
+  return sessionRefresh;
 }
 
 /**
@@ -223,22 +223,16 @@ class ApiClient {
       headers: customHeaders,
       timeout = API_TIMEOUT,
       signal,
+      credentials,
     } = options;
 
-    // 检查并刷新 Google OAuth token（如果需要且不是刷新 token 请求本身）
-    // 只在浏览器环境中执行 token 刷新
+    // 检查并刷新系统 access token（如果即将过期且不是刷新请求本身）
     if (
       typeof window !== "undefined" &&
-      endpoint !== "/v1/auth/google/refresh" &&
-      localStorage.getItem("google_access_token") &&
-      isGoogleTokenExpired()
+      endpoint !== "/v1/auth/refresh" &&
+      isSessionTokenExpired()
     ) {
-      // 尝试刷新 token，如果失败则清除过期的 token
-      const refreshed = await refreshGoogleToken();
-      if (!refreshed) {
-        // 刷新失败，清除 Google token，后续请求将使用其他认证方式（如果有）
-        console.warn("Token refresh failed, cleared Google OAuth tokens");
-      }
+      await refreshSessionToken();
     }
 
     // 构建 URL
@@ -256,6 +250,7 @@ class ApiClient {
       method,
       headers: buildHeaders(customHeaders),
       signal,
+      credentials,
     };
 
     // 添加请求体
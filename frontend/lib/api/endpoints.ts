@@ -67,21 +67,33 @@ export const youtubeApi = {
 
   getQuota: () => apiClient.get<QuotaStatus>("/v1/system/quota"),
 
-  getAuthUrl: async () => {
+  getAuthUrl: async (intent?: "link") => {
     const response = await apiClient.get<{ authUrl: string; url?: string }>(
-      "/v1/auth/google/url"
+      "/v1/auth/google/url",
+      { params: { intent }, credentials: "include" }
     );
     return { url: response.authUrl || response.url || "" };
   },
 
   handleCallback: (code: string, state?: string) =>
     apiClient.post<{
-      accessToken: string;
-      refreshToken: string;
-      tokenType: string;
-      expiry: string;
-      tokenJSON: string;
-    }>("/v1/auth/google/callback", { code, state }),
+      user?: { id: number; email: string; name: string; created_at: string };
+      session?: {
+        access_token: string;
+        refresh_token: string;
+        token_type: string;
+        expires_in: number;
+      };
+      google?: { linked: boolean; email?: string };
+    }>("/v1/auth/google/callback", { code, state }, { credentials: "include" }),
+
+  getGoogleAccount: () =>
+    apiClient.get<{ linked: boolean; email?: string; linked_at?: string }>(
+      "/v1/auth/google"
+    ),
+
+  unlinkGoogleAccount: () =>
+    apiClient.delete<{ message: string }>("/v1/auth/google"),
 };
 
 export const contentApi = {
@@ -197,7 +209,37 @@ export const insightApi = {
     ),
 
   /**
-   * Unshare an insight
+   * List the share links of an insight, including revoked ones
+   * @param insightId - Insight ID
+   */
+  listShares: (insightId: number) =>
+    apiClient.get<import("./types").ShareInsightResponse[]>(
+      `/v1/insights/${insightId}/shares`
+    ),
+
+  /**
+   * Revoke one share link of an insight
+   * @param insightId - Insight ID
+   * @param shareId - Share link ID
+   */
+  revokeShare: (insightId: number, shareId: number) =>
+    apiClient.delete<{ message: string }>(
+      `/v1/insights/${insightId}/shares/${shareId}`
+    ),
+
+  /**
+   * Get view analytics of the share links of an insight
+   * @param insightId - Insight ID
+   * @param days - Length of the daily series (default 30)
+   */
+  getShareStats: (insightId: number, days?: number) =>
+    apiClient.get<import("./types").ShareStatsResponse>(
+      `/v1/insights/${insightId}/share/stats`,
+      { params: days ? { days } : undefined }
+    ),
+
+  /**
+   * Unshare an insight (revokes every share link)
    * @param insightId - Insight ID
    */
   unshareInsight: (insightId: number) =>
@@ -206,12 +248,27 @@ export const insightApi = {
   /**
    * Get shared insight by token (public access)
    * @param token - Share token
-   * @param password - Optional password for protected shares
+   * @param accessToken - Access token from unlockSharedInsight for protected shares
    */
-  getSharedInsight: (token: string, password?: string) =>
+  getSharedInsight: (token: string, accessToken?: string) =>
     apiClient.get<import("./types").SharedInsightResponse>(
       `/v1/shared/${token}`,
-      { params: password ? { password } : undefined }
+      {
+        headers: accessToken ? { "X-Share-Token": accessToken } : undefined,
+        credentials: "include",
+      }
+    ),
+
+  /**
+   * Unlock a password protected share; the returned access token opens it until it expires
+   * @param token - Share token
+   * @param password - Share password
+   */
+  unlockSharedInsight: (token: string, password: string) =>
+    apiClient.post<import("./types").ShareAccessResponse>(
+      `/v1/shared/${token}/unlock`,
+      { password },
+      { credentials: "include" }
     ),
 };
 
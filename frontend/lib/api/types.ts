@@ -55,6 +55,8 @@ export interface RequestOptions {
   headers?: Record<string, string>;
   timeout?: number;
   signal?: AbortSignal;
+  /** 跨域请求是否携带 cookie（OAuth state 绑定 cookie 需要） */
+  credentials?: RequestCredentials;
 }
 
 /**
@@ -259,22 +261,65 @@ export interface AnalyzeEntitiesResponse {
 /**
  * 分享请求
  */
+export interface ShareClip {
+  start_seconds: number;
+  end_seconds: number; // at most 30 minutes after start_seconds
+  comment?: string;
+}
+
 export interface ShareInsightRequest {
+  label?: string;
   include_summary: boolean;
   include_key_points: boolean;
   include_highlights: boolean;
   include_chat: boolean;
+  clip?: ShareClip;
   is_public: boolean;
   password?: string;
+  expires_at?: string;
+  max_views?: number;
 }
 
 /**
- * 分享响应
+ * 分享链接（每个 Insight 可以有多个）
  */
 export interface ShareInsightResponse {
+  id: number;
+  label?: string;
   share_token: string;
   share_url: string;
+  page_url: string;
+  config: {
+    include_summary: boolean;
+    include_key_points: boolean;
+    include_highlights: boolean;
+    include_chat: boolean;
+    clip?: ShareClip;
+  };
+  has_password: boolean;
   expires_at?: string;
+  max_views: number;
+  view_count: number;
+  last_viewed_at?: string;
+  revoked_at?: string;
+  active: boolean;
+  created_at: string;
+}
+
+export interface ShareCount {
+  key: string;
+  views: number;
+}
+
+export interface ShareStatsResponse {
+  total_views: number;
+  unique_visitors: number;
+  failed_password_attempts: number;
+  days: number;
+  daily: { date: string; views: number; unique_visitors: number }[];
+  links: { share_link_id: number; label?: string; views: number; unique_visitors: number }[];
+  user_agent_classes: ShareCount[];
+  referrers: ShareCount[];
 }
 
 /**
@@ -285,6 +330,29 @@ export interface SharedContent {
   key_points?: string[];
   highlights?: Highlight[];
   chat?: ChatMessage[];
+  clip?: SharedClip;
+}
+
+/**
+ * 分享的字幕片段
+ */
+export interface SharedClip {
+  start_seconds: number;
+  end_seconds: number;
+  start_timestamp: string;
+  end_timestamp: string;
+  comment?: string;
+  url?: string; // YouTube 视频从片段开始处播放
+  lines: TranscriptItem[];
+}
+
+/**
+ * 分享访问令牌响应
+ */
+export interface ShareAccessResponse {
+  access_token: string;
+  expires_in: number;
+  expires_at: string;
 }
 
 /**
@@ -24,6 +24,8 @@ export const ROUTES = {
  */
 export const STORAGE_KEYS = {
   AUTH_TOKEN: "auth_token",
+  AUTH_REFRESH_TOKEN: "auth_refresh_token",
+  AUTH_TOKEN_EXPIRY: "auth_token_expiry",
   USER_INFO: "user_info",
   THEME: "theme",
   LANGUAGE: "language",